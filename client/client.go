@@ -0,0 +1,579 @@
+// Package client is the Go SDK for go-mq's HTTP API. A Client covers the plain
+// requests, a Producer adds batching, retries and idempotency keys on top of
+// publishing, and a Consumer runs the fetch, ack and commit loop.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// IdempotencyKeyHeader carries the key a producer attaches to every attempt of the
+// same publish. On a topic with a dedup window the broker takes it as the message's
+// dedup ID, so a retry of a publish it already took is not appended twice.
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+type Client struct {
+	BaseURL    string
+	HTTPClient *http.Client
+}
+
+func New(baseURL string) *Client {
+	return &Client{
+		BaseURL: strings.TrimRight(baseURL, "/"),
+		HTTPClient: &http.Client{
+			Timeout: 10 * time.Second,
+		},
+	}
+}
+
+// Message is a message as the broker returns it from /fetch.
+type Message struct {
+	ID               string
+	Key              string
+	Partition        int
+	Offset           int
+	Body             []byte
+	Timestamp        time.Time
+	ProducerID       string
+	DeliveryAttempts int
+	Metadata         map[string]string
+}
+
+// Error is a request the broker answered with an error status.
+type Error struct {
+	StatusCode int
+	Message    string
+
+	// LowWatermark is the earliest offset still retained, set when an offset was
+	// out of range.
+	LowWatermark int
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("go-mq: %d %s: %s", e.StatusCode, http.StatusText(e.StatusCode), e.Message)
+}
+
+// IsNotFound reports whether the broker answered 404, for an unknown topic or group
+// or a message that is no longer awaiting acknowledgement.
+func IsNotFound(err error) bool {
+	var apiErr *Error
+	return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound
+}
+
+// retryable reports whether trying the same request again may succeed: transport
+// errors, 429 and 5xx answers. Client errors and cancellation are final.
+func retryable(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	var apiErr *Error
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode == http.StatusTooManyRequests || apiErr.StatusCode >= http.StatusInternalServerError
+	}
+	return true
+}
+
+type TopicConfig struct {
+	Name       string            `json:"name"`
+	Partitions int               `json:"partitions,omitempty"`
+	Retention  *RetentionPolicy  `json:"retention,omitempty"`
+	DeadLetter *DeadLetterPolicy `json:"dead_letter,omitempty"`
+	Dedup      *DedupPolicy      `json:"dedup,omitempty"`
+}
+
+type RetentionPolicy struct {
+	MaxAgeMs    int64 `json:"max_age_ms"`
+	MaxBytes    int64 `json:"max_bytes"`
+	MaxMessages int   `json:"max_messages"`
+}
+
+type DeadLetterPolicy struct {
+	MaxDeliveryAttempts int    `json:"max_delivery_attempts"`
+	Topic               string `json:"topic"`
+}
+
+// DedupPolicy makes the topic drop a publish whose Idempotency-Key, or Dedup-Id,
+// it has seen within the last WindowMs or the last MaxIDs publishes.
+type DedupPolicy struct {
+	WindowMs int64 `json:"window_ms"`
+	MaxIDs   int   `json:"max_ids"`
+}
+
+func (c *Client) CreateTopic(ctx context.Context, cfg TopicConfig) error {
+	_, err := c.do(ctx, http.MethodPost, "/topics", nil, cfg, nil)
+	return err
+}
+
+func (c *Client) ListTopics(ctx context.Context) ([]string, error) {
+	var resp struct {
+		Topics []string `json:"topics"`
+	}
+	if _, err := c.do(ctx, http.MethodGet, "/topics", nil, nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Topics, nil
+}
+
+func (c *Client) DeleteTopic(ctx context.Context, name string) error {
+	_, err := c.do(ctx, http.MethodDelete, "/topics/"+name, nil, nil, nil)
+	return err
+}
+
+// TopicDescription is a topic's settings and the offset range of each partition.
+type TopicDescription struct {
+	Topic      string           `json:"topic"`
+	Retention  RetentionPolicy  `json:"retention"`
+	DeadLetter DeadLetterPolicy `json:"dead_letter"`
+	Dedup      DedupPolicy      `json:"dedup"`
+	Partitions []PartitionState `json:"partitions"`
+}
+
+// PartitionState is one partition of a TopicDescription. CommittedOffset and Lag
+// are only set when the description was asked for a consumer or group.
+type PartitionState struct {
+	Partition       int  `json:"partition"`
+	LowWatermark    int  `json:"low_watermark"`
+	HighWatermark   int  `json:"high_watermark"`
+	CommittedOffset *int `json:"committed_offset,omitempty"`
+	Lag             *int `json:"lag,omitempty"`
+}
+
+// DescribeTopic describes a topic, including the committed offsets and lag of
+// consumerID, or of groupID when that is set, when either is given.
+func (c *Client) DescribeTopic(ctx context.Context, topic, consumerID, groupID string) (TopicDescription, error) {
+	query := url.Values{}
+	if consumerID != "" {
+		query.Set("consumer_id", consumerID)
+	}
+	if groupID != "" {
+		query.Set("group_id", groupID)
+	}
+
+	path := "/topics/" + topic
+	if len(query) > 0 {
+		path += "?" + query.Encode()
+	}
+
+	var description TopicDescription
+	_, err := c.do(ctx, http.MethodGet, path, nil, nil, &description)
+	return description, err
+}
+
+// TopicStats is what a topic holds, in total and per partition. LatestOffset is the
+// log end offset, the offset the next message will get.
+type TopicStats struct {
+	Topic             string           `json:"topic"`
+	MessageCount      int              `json:"message_count"`
+	Bytes             int64            `json:"bytes"`
+	EarliestTimestamp *time.Time       `json:"earliest_timestamp"`
+	LatestTimestamp   *time.Time       `json:"latest_timestamp"`
+	Partitions        []PartitionStats `json:"partitions"`
+}
+
+type PartitionStats struct {
+	Partition         int        `json:"partition"`
+	MessageCount      int        `json:"message_count"`
+	Bytes             int64      `json:"bytes"`
+	EarliestOffset    int        `json:"earliest_offset"`
+	LatestOffset      int        `json:"latest_offset"`
+	EarliestTimestamp *time.Time `json:"earliest_timestamp"`
+	LatestTimestamp   *time.Time `json:"latest_timestamp"`
+}
+
+// TopicStats reads a topic's message count, size and offset and timestamp ranges.
+func (c *Client) TopicStats(ctx context.Context, topic string) (TopicStats, error) {
+	var stats TopicStats
+	_, err := c.do(ctx, http.MethodGet, "/topics/"+topic+"/stats", nil, nil, &stats)
+	return stats, err
+}
+
+// ConsumerState is a consumer, or a group when Group is set, of a topic. Unacked
+// counts messages delivered to it and not acknowledged yet.
+type ConsumerState struct {
+	ID                string                   `json:"id"`
+	Group             bool                     `json:"group"`
+	Lag               int                      `json:"lag"`
+	LastFetch         *time.Time               `json:"last_fetch"`
+	Unacked           int                      `json:"unacked"`
+	PendingRedelivery int                      `json:"pending_redelivery"`
+	Filter            string                   `json:"filter"`
+	Partitions        []ConsumerPartitionState `json:"partitions"`
+}
+
+type ConsumerPartitionState struct {
+	Partition       int `json:"partition"`
+	CommittedOffset int `json:"committed_offset"`
+	Lag             int `json:"lag"`
+}
+
+// ListConsumers lists the consumers and groups of a topic with their lag.
+func (c *Client) ListConsumers(ctx context.Context, topic string) ([]ConsumerState, error) {
+	var resp struct {
+		Consumers []ConsumerState `json:"consumers"`
+	}
+	_, err := c.do(ctx, http.MethodGet, "/topics/"+topic+"/consumers", nil, nil, &resp)
+	return resp.Consumers, err
+}
+
+// PartitionOffset is the offset a partition holds for a timestamp, or was reset to.
+type PartitionOffset struct {
+	Partition int `json:"partition"`
+	Offset    int `json:"offset"`
+}
+
+// OffsetsForTime looks up, on every partition of a topic, the offset of the first
+// message appended at or after t. A partition with nothing that recent gives its
+// log end offset.
+func (c *Client) OffsetsForTime(ctx context.Context, topic string, t time.Time) ([]PartitionOffset, error) {
+	query := url.Values{}
+	query.Set("timestamp", t.Format(time.RFC3339Nano))
+
+	var resp struct {
+		Offsets []PartitionOffset `json:"offsets"`
+	}
+	_, err := c.do(ctx, http.MethodGet, "/topics/"+topic+"/offsets?"+query.Encode(), nil, nil, &resp)
+	return resp.Offsets, err
+}
+
+// ProducerMessage is a message to publish. DeliverAt or Delay schedule it for later
+// delivery. IdempotencyKey is sent along when set, a Producer fills it in itself.
+// Sequence, with the Epoch of a session from RegisterProducer, makes the publish
+// idempotent, an idempotent Producer sets both. TransactionID publishes the message
+// inside an open transaction, see Transaction.
+type ProducerMessage struct {
+	Key            string
+	Body           []byte
+	DeliverAt      time.Time
+	Delay          time.Duration
+	IdempotencyKey string
+	Epoch          int
+	Sequence       *int64
+	TransactionID  string
+}
+
+// PublishResult is where a message was appended, or for a scheduled message when it
+// will be. Duplicate is set when the message had been appended before, by sequence
+// number or by the topic's dedup window, the result is then that of the original.
+type PublishResult struct {
+	MessageID string    `json:"message_id"`
+	Partition int       `json:"partition"`
+	Offset    int       `json:"offset"`
+	DeliverAt time.Time `json:"deliver_at"`
+	Duplicate bool      `json:"duplicate"`
+}
+
+// Scheduled reports whether the message was held back for later delivery rather
+// than appended right away.
+func (r PublishResult) Scheduled() bool {
+	return !r.DeliverAt.IsZero()
+}
+
+// Publish sends one message in a single attempt, see Producer for retries.
+func (c *Client) Publish(ctx context.Context, topic, producerID string, msg ProducerMessage) (PublishResult, error) {
+	req := struct {
+		Body          string     `json:"body"`
+		ProducerID    string     `json:"producer_id"`
+		Key           string     `json:"key,omitempty"`
+		DeliverAt     *time.Time `json:"deliver_at,omitempty"`
+		DelayMs       int64      `json:"delay_ms,omitempty"`
+		Epoch         *int       `json:"epoch,omitempty"`
+		Sequence      *int64     `json:"sequence,omitempty"`
+		TransactionID string     `json:"transaction_id,omitempty"`
+	}{
+		Body:          string(msg.Body),
+		ProducerID:    producerID,
+		Key:           msg.Key,
+		DelayMs:       msg.Delay.Milliseconds(),
+		Sequence:      msg.Sequence,
+		TransactionID: msg.TransactionID,
+	}
+	if !msg.DeliverAt.IsZero() {
+		req.DeliverAt = &msg.DeliverAt
+	}
+	if msg.Sequence != nil {
+		req.Epoch = &msg.Epoch
+	}
+
+	header := http.Header{}
+	if msg.IdempotencyKey != "" {
+		header.Set(IdempotencyKeyHeader, msg.IdempotencyKey)
+	}
+
+	var result PublishResult
+	_, err := c.do(ctx, http.MethodPost, "/publish/"+topic, header, req, &result)
+	return result, err
+}
+
+// RegisterProducer starts a new idempotent session for producerID and returns its
+// epoch. Sequence numbers of the session start at 0, and registering again fences
+// off the previous session.
+func (c *Client) RegisterProducer(ctx context.Context, producerID string) (int, error) {
+	req := map[string]string{"producer_id": producerID}
+	var resp struct {
+		Epoch int `json:"epoch"`
+	}
+	_, err := c.do(ctx, http.MethodPost, "/producers", nil, req, &resp)
+	return resp.Epoch, err
+}
+
+// BatchResult is the outcome of one message of a batch, Error is set when it was
+// not appended.
+type BatchResult struct {
+	PublishResult
+	Error string `json:"error"`
+}
+
+// PublishBatch sends msgs to topic in one request. An atomic batch is appended as a
+// whole or not at all, otherwise the results report each message on its own, in the
+// order of msgs. Batched messages are appended right away, their DeliverAt, Delay
+// and sequence are not sent.
+func (c *Client) PublishBatch(ctx context.Context, topic, producerID string, msgs []ProducerMessage, atomic bool) ([]BatchResult, error) {
+	type entry struct {
+		Body       string `json:"body"`
+		ProducerID string `json:"producer_id"`
+		Key        string `json:"key,omitempty"`
+		DedupID    string `json:"dedup_id,omitempty"`
+	}
+	req := make([]entry, len(msgs))
+	for i, msg := range msgs {
+		req[i] = entry{Body: string(msg.Body), ProducerID: producerID, Key: msg.Key, DedupID: msg.IdempotencyKey}
+	}
+
+	path := "/publish/" + topic + "/batch"
+	if atomic {
+		path += "?atomic=true"
+	}
+
+	var resp struct {
+		Results []BatchResult `json:"results"`
+	}
+	if _, err := c.do(ctx, http.MethodPost, path, nil, req, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Results, nil
+}
+
+// FetchRequest reads messages for a consumer, it mirrors the X- headers /fetch
+// takes. Partition is only sent when set, a group member leaving it out reads all
+// of its assigned partitions. ReadCommitted leaves out messages of transactions
+// that are still open or were aborted. MaxWait makes the fetch a long poll, the
+// server holds it until MinMessages, 1 by default, are ready or MaxWait passes.
+// Keep MaxWait below the HTTPClient's timeout. StartTime, when set, first moves the
+// committed offset to the first message appended at or after it. Filter registers
+// a filter expression for the consumer, or group, messages that do not pass it are
+// skipped and still move the committed offset.
+type FetchRequest struct {
+	Topic         string
+	ConsumerID    string
+	GroupID       string
+	Partition     *int
+	Limit         int
+	Commit        bool
+	ReadCommitted bool
+	MaxWait       time.Duration
+	MinMessages   int
+	StartTime     time.Time
+	Filter        string
+}
+
+func (c *Client) Fetch(ctx context.Context, req FetchRequest) ([]Message, error) {
+	header := http.Header{}
+	header.Set("X-Topic", req.Topic)
+	header.Set("X-Consumer-ID", req.ConsumerID)
+	if req.GroupID != "" {
+		header.Set("X-Group-ID", req.GroupID)
+	}
+	if req.Partition != nil {
+		header.Set("X-Partition", strconv.Itoa(*req.Partition))
+	}
+	if req.Limit > 0 {
+		header.Set("X-Limit", strconv.Itoa(req.Limit))
+	}
+	if req.Commit {
+		header.Set("X-Commit", "true")
+	}
+	if req.ReadCommitted {
+		header.Set("X-Isolation-Level", "read_committed")
+	}
+	if req.MaxWait > 0 {
+		header.Set("X-Max-Wait-Ms", strconv.FormatInt(req.MaxWait.Milliseconds(), 10))
+	}
+	if req.MinMessages > 0 {
+		header.Set("X-Min-Messages", strconv.Itoa(req.MinMessages))
+	}
+	if !req.StartTime.IsZero() {
+		header.Set("X-Start-Time", req.StartTime.Format(time.RFC3339Nano))
+	}
+	if req.Filter != "" {
+		header.Set("X-Filter", req.Filter)
+	}
+
+	var messages []Message
+	if _, err := c.do(ctx, http.MethodGet, "/fetch", header, nil, &messages); err != nil {
+		return nil, err
+	}
+	return messages, nil
+}
+
+// CommitOffset moves the consumer's committed offset, or the group's when groupID is
+// set, on one partition.
+func (c *Client) CommitOffset(ctx context.Context, topic, consumerID, groupID string, partition, offset int) error {
+	req := map[string]any{
+		"topic":       topic,
+		"consumer_id": consumerID,
+		"group_id":    groupID,
+		"partition":   partition,
+		"offset":      offset,
+	}
+	_, err := c.do(ctx, http.MethodPost, "/commit", nil, req, nil)
+	return err
+}
+
+// OffsetReset moves a consumer's committed offsets, or a group's when GroupID is
+// set. To is earliest, latest, offset (to Offset), shift (by Shift messages, negative
+// to go back) or timestamp (to the first message at or after Timestamp). Partition
+// limits the reset to one partition. The broker refuses to reset a consumer that is
+// still active with a 409 unless Force is set.
+type OffsetReset struct {
+	ConsumerID string     `json:"consumer_id,omitempty"`
+	GroupID    string     `json:"group_id,omitempty"`
+	To         string     `json:"to"`
+	Offset     *int       `json:"offset,omitempty"`
+	Shift      int        `json:"shift,omitempty"`
+	Timestamp  *time.Time `json:"timestamp,omitempty"`
+	Partition  *int       `json:"partition,omitempty"`
+	Force      bool       `json:"force,omitempty"`
+}
+
+// ResetOffsets commits the offsets the reset asks for and returns them.
+func (c *Client) ResetOffsets(ctx context.Context, topic string, reset OffsetReset) ([]PartitionOffset, error) {
+	var resp struct {
+		Offsets []PartitionOffset `json:"offsets"`
+	}
+	_, err := c.do(ctx, http.MethodPost, "/topics/"+topic+"/offsets/reset", nil, reset, &resp)
+	return resp.Offsets, err
+}
+
+// DeleteConsumer has the broker forget a consumer, or group, on the topic, its
+// committed offsets included. A consumer that is still active is refused with a
+// 409 unless force is set.
+func (c *Client) DeleteConsumer(ctx context.Context, topic, target string, force bool) error {
+	path := "/topics/" + topic + "/consumers/" + url.PathEscape(target)
+	if force {
+		path += "?force=true"
+	}
+	_, err := c.do(ctx, http.MethodDelete, path, nil, nil, nil)
+	return err
+}
+
+func (c *Client) Ack(ctx context.Context, topic, consumerID, messageID string) error {
+	req := map[string]string{
+		"topic":       topic,
+		"consumer_id": consumerID,
+		"message_id":  messageID,
+	}
+	_, err := c.do(ctx, http.MethodPost, "/ack", nil, req, nil)
+	return err
+}
+
+// Nack reports a failed delivery, see the broker's /nack. It returns whether the
+// message was dead-lettered.
+func (c *Client) Nack(ctx context.Context, topic, consumerID, messageID string, requeue bool, delay time.Duration, reason string) (bool, error) {
+	req := map[string]any{
+		"topic":       topic,
+		"consumer_id": consumerID,
+		"message_id":  messageID,
+		"requeue":     requeue,
+		"delay_ms":    delay.Milliseconds(),
+		"reason":      reason,
+	}
+	var resp struct {
+		DeadLettered bool `json:"dead_lettered"`
+	}
+	if _, err := c.do(ctx, http.MethodPost, "/nack", nil, req, &resp); err != nil {
+		return false, err
+	}
+	return resp.DeadLettered, nil
+}
+
+// Assignment is the set of partitions a group member currently owns.
+type Assignment struct {
+	GroupID    string `json:"group_id"`
+	Topic      string `json:"topic"`
+	MemberID   string `json:"member_id"`
+	Generation int    `json:"generation"`
+	Partitions []int  `json:"partitions"`
+}
+
+func (c *Client) JoinGroup(ctx context.Context, groupID, topic, memberID string) (Assignment, error) {
+	var assignment Assignment
+	_, err := c.do(ctx, http.MethodPost, "/groups/"+groupID+"/join", nil, map[string]string{"topic": topic, "member_id": memberID}, &assignment)
+	return assignment, err
+}
+
+func (c *Client) Heartbeat(ctx context.Context, groupID, memberID string) (Assignment, error) {
+	var assignment Assignment
+	_, err := c.do(ctx, http.MethodPost, "/groups/"+groupID+"/heartbeat", nil, map[string]string{"member_id": memberID}, &assignment)
+	return assignment, err
+}
+
+func (c *Client) LeaveGroup(ctx context.Context, groupID, memberID string) error {
+	_, err := c.do(ctx, http.MethodPost, "/groups/"+groupID+"/leave", nil, map[string]string{"member_id": memberID}, nil)
+	return err
+}
+
+// do sends a request with in as its JSON body and decodes a successful response
+// into out. Error statuses come back as *Error carrying the broker's message.
+func (c *Client) do(ctx context.Context, method, path string, header http.Header, in, out any) (http.Header, error) {
+	var body io.Reader
+	if in != nil {
+		payload, err := json.Marshal(in)
+		if err != nil {
+			return nil, err
+		}
+		body = bytes.NewReader(payload)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.BaseURL+path, body)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		apiErr := &Error{StatusCode: resp.StatusCode, Message: strings.TrimSpace(string(msg))}
+		if lowWatermark, err := strconv.Atoi(resp.Header.Get("X-Low-Watermark")); err == nil {
+			apiErr.LowWatermark = lowWatermark
+		}
+		return resp.Header, apiErr
+	}
+
+	if out != nil && resp.StatusCode != http.StatusNoContent {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			return resp.Header, fmt.Errorf("go-mq: decoding %s %s response: %w", method, path, err)
+		}
+	}
+	return resp.Header, nil
+}
@@ -0,0 +1,463 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/codytheroux96/go-mq/internal/api"
+	"github.com/codytheroux96/go-mq/internal/app"
+)
+
+func setupTestServer(t *testing.T, wrap func(http.Handler) http.Handler) *Client {
+	t.Helper()
+
+	a := app.NewApplication()
+	handler := api.Routes(a)
+	if wrap != nil {
+		handler = wrap(handler)
+	}
+	ts := httptest.NewServer(handler)
+	t.Cleanup(func() {
+		ts.Close()
+		a.Close()
+	})
+
+	return New(ts.URL)
+}
+
+func TestProducer(t *testing.T) {
+	ctx := context.Background()
+
+	// the first two publish attempts fail, recording the idempotency key each time
+	var mu sync.Mutex
+	var keys []string
+	failures := 2
+	requests := make(map[string]int)
+	c := setupTestServer(t, func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			mu.Lock()
+			requests[r.URL.Path]++
+			mu.Unlock()
+			if r.URL.Path == "/publish/flaky" {
+				mu.Lock()
+				keys = append(keys, r.Header.Get(IdempotencyKeyHeader))
+				fail := failures > 0
+				failures--
+				mu.Unlock()
+				if fail {
+					http.Error(w, "unavailable", http.StatusServiceUnavailable)
+					return
+				}
+			}
+			next.ServeHTTP(w, r)
+		})
+	})
+
+	for _, topic := range []string{"orders", "flaky"} {
+		if err := c.CreateTopic(ctx, TopicConfig{Name: topic}); err != nil {
+			t.Fatalf("failed to create topic %s: %v", topic, err)
+		}
+	}
+
+	producer := c.NewProducer(ProducerConfig{ProducerID: "p1", BatchSize: 2, RetryBackoff: time.Millisecond})
+
+	t.Run("Send retries with the same idempotency key", func(t *testing.T) {
+		result, err := producer.Send(ctx, "flaky", ProducerMessage{Body: []byte("retried")})
+		if err != nil {
+			t.Fatalf("expected publish to succeed after retries, got %v", err)
+		}
+		if result.MessageID == "" || result.Scheduled() {
+			t.Fatalf("expected an appended message, got %+v", result)
+		}
+		if len(keys) != 3 || keys[0] == "" || keys[0] != keys[1] || keys[1] != keys[2] {
+			t.Fatalf("expected 3 attempts with one idempotency key, got %q", keys)
+		}
+	})
+
+	t.Run("Send does not retry client errors", func(t *testing.T) {
+		_, err := producer.Send(ctx, "missing", ProducerMessage{Body: []byte("lost")})
+		if !IsNotFound(err) {
+			t.Fatalf("expected not found, got %v", err)
+		}
+	})
+
+	t.Run("SendAsync keeps order across batches", func(t *testing.T) {
+		mu.Lock()
+		clear(requests)
+		mu.Unlock()
+
+		var offsets []int
+		for _, body := range []string{"a", "b", "c", "d", "e"} {
+			err := producer.SendAsync("orders", ProducerMessage{Body: []byte(body)}, func(result PublishResult, err error) {
+				if err != nil {
+					t.Errorf("expected async publish to succeed, got %v", err)
+				}
+				offsets = append(offsets, result.Offset)
+			})
+			if err != nil {
+				t.Fatalf("failed to queue message: %v", err)
+			}
+		}
+
+		if err := producer.Flush(ctx); err != nil {
+			t.Fatalf("failed to flush: %v", err)
+		}
+		if len(offsets) != 5 {
+			t.Fatalf("expected 5 results after flush, got %d", len(offsets))
+		}
+		for i, offset := range offsets {
+			if offset != i {
+				t.Fatalf("expected offsets in send order, got %v", offsets)
+			}
+		}
+
+		// two full batches go out as one request each, the last message on its own
+		mu.Lock()
+		defer mu.Unlock()
+		if requests["/publish/orders/batch"] != 2 || requests["/publish/orders"] != 1 {
+			t.Fatalf("expected 2 batch publishes and 1 single publish, got %v", requests)
+		}
+	})
+
+	t.Run("Scheduled publish", func(t *testing.T) {
+		result, err := producer.Send(ctx, "orders", ProducerMessage{Body: []byte("later"), Delay: time.Hour})
+		if err != nil || !result.Scheduled() {
+			t.Fatalf("expected a scheduled message, got %+v %v", result, err)
+		}
+	})
+
+	if err := producer.Close(ctx); err != nil {
+		t.Fatalf("failed to close producer: %v", err)
+	}
+	if err := producer.SendAsync("orders", ProducerMessage{Body: []byte("late")}, nil); err != ErrProducerClosed {
+		t.Fatalf("expected ErrProducerClosed, got %v", err)
+	}
+}
+
+func TestIdempotentProducer(t *testing.T) {
+	ctx := context.Background()
+
+	// the first publish is appended but its response is lost, as on a timeout
+	lose := true
+	c := setupTestServer(t, func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/publish/orders" && lose {
+				lose = false
+				next.ServeHTTP(httptest.NewRecorder(), r)
+				http.Error(w, "gateway timeout", http.StatusGatewayTimeout)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	})
+	if err := c.CreateTopic(ctx, TopicConfig{Name: "orders"}); err != nil {
+		t.Fatalf("failed to create topic: %v", err)
+	}
+
+	producer := c.NewProducer(ProducerConfig{ProducerID: "p1", RetryBackoff: time.Millisecond, Idempotent: true})
+	defer producer.Close(ctx)
+
+	tests := []struct {
+		name            string
+		msg             ProducerMessage
+		expectErr       error
+		expectOffset    int
+		expectDuplicate bool
+	}{
+		{"Retry after a lost response", ProducerMessage{Body: []byte("first")}, nil, 0, true},
+		{"Next message", ProducerMessage{Body: []byte("second")}, nil, 1, false},
+		{"Scheduled message", ProducerMessage{Body: []byte("later"), Delay: time.Minute}, ErrScheduledIdempotent, 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := producer.Send(ctx, "orders", tt.msg)
+			if err != tt.expectErr {
+				t.Fatalf("expected %v, got %v", tt.expectErr, err)
+			}
+			if err == nil && (result.Offset != tt.expectOffset || result.Duplicate != tt.expectDuplicate) {
+				t.Fatalf("expected offset %d duplicate %v, got %+v", tt.expectOffset, tt.expectDuplicate, result)
+			}
+		})
+	}
+
+	description, err := c.DescribeTopic(ctx, "orders", "", "")
+	if err != nil || description.Partitions[0].HighWatermark != 2 {
+		t.Fatalf("expected each message appended once, got %+v %v", description, err)
+	}
+}
+
+func TestTransaction(t *testing.T) {
+	ctx := context.Background()
+	c := setupTestServer(t, nil)
+
+	for _, topic := range []string{"orders", "invoices"} {
+		if err := c.CreateTopic(ctx, TopicConfig{Name: topic}); err != nil {
+			t.Fatalf("failed to create topic %s: %v", topic, err)
+		}
+	}
+	if _, err := c.Publish(ctx, "orders", "shop", ProducerMessage{Body: []byte("order")}); err != nil {
+		t.Fatalf("failed to publish: %v", err)
+	}
+
+	tests := []struct {
+		name         string
+		commit       bool
+		expectOffset int
+	}{
+		{"Aborted transaction", false, 0},
+		{"Committed transaction", true, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			txn, err := c.BeginTransaction(ctx, time.Minute)
+			if err != nil {
+				t.Fatalf("failed to begin transaction: %v", err)
+			}
+			if _, err := txn.Publish(ctx, "invoices", "billing", ProducerMessage{Body: []byte(tt.name)}); err != nil {
+				t.Fatalf("failed to publish in transaction: %v", err)
+			}
+			if err := txn.AddOffsets(ctx, "orders", "billing", "", 0, 1); err != nil {
+				t.Fatalf("failed to add offsets: %v", err)
+			}
+
+			end := txn.Abort
+			if tt.commit {
+				end = txn.Commit
+			}
+			if err := end(ctx); err != nil {
+				t.Fatalf("failed to end transaction: %v", err)
+			}
+
+			description, err := c.DescribeTopic(ctx, "orders", "billing", "")
+			if err != nil || description.Partitions[0].CommittedOffset == nil || *description.Partitions[0].CommittedOffset != tt.expectOffset {
+				t.Fatalf("expected committed offset %d, got %+v %v", tt.expectOffset, description, err)
+			}
+		})
+	}
+
+	messages, err := c.Fetch(ctx, FetchRequest{Topic: "invoices", ConsumerID: "reader", ReadCommitted: true})
+	if err != nil {
+		t.Fatalf("failed to fetch: %v", err)
+	}
+	if len(messages) != 1 || string(messages[0].Body) != "Committed transaction" {
+		t.Fatalf("expected only the committed invoice, got %+v", messages)
+	}
+}
+
+func TestLongPollFetch(t *testing.T) {
+	ctx := context.Background()
+	c := setupTestServer(t, nil)
+	if err := c.CreateTopic(ctx, TopicConfig{Name: "orders"}); err != nil {
+		t.Fatalf("failed to create topic: %v", err)
+	}
+
+	time.AfterFunc(50*time.Millisecond, func() {
+		c.Publish(ctx, "orders", "shop", ProducerMessage{Body: []byte("late")})
+	})
+
+	start := time.Now()
+	messages, err := c.Fetch(ctx, FetchRequest{Topic: "orders", ConsumerID: "c1", MaxWait: 5 * time.Second})
+	if err != nil {
+		t.Fatalf("failed to fetch: %v", err)
+	}
+	if len(messages) != 1 || time.Since(start) >= 5*time.Second {
+		t.Fatalf("expected the fetch to return with the late message, got %d messages after %v", len(messages), time.Since(start))
+	}
+}
+
+func TestReplayFromTime(t *testing.T) {
+	ctx := context.Background()
+	c := setupTestServer(t, nil)
+	if err := c.CreateTopic(ctx, TopicConfig{Name: "orders"}); err != nil {
+		t.Fatalf("failed to create topic: %v", err)
+	}
+
+	publish := func(body string) {
+		if _, err := c.Publish(ctx, "orders", "shop", ProducerMessage{Body: []byte(body)}); err != nil {
+			t.Fatalf("failed to publish: %v", err)
+		}
+	}
+	publish("old")
+	time.Sleep(5 * time.Millisecond)
+	since := time.Now()
+	time.Sleep(5 * time.Millisecond)
+	publish("new")
+
+	offsets, err := c.OffsetsForTime(ctx, "orders", since)
+	if err != nil {
+		t.Fatalf("failed to look up offsets: %v", err)
+	}
+	if len(offsets) != 1 || offsets[0].Offset != 1 {
+		t.Fatalf("expected offset 1 on partition 0, got %+v", offsets)
+	}
+
+	messages, err := c.Fetch(ctx, FetchRequest{Topic: "orders", ConsumerID: "c1", StartTime: since})
+	if err != nil {
+		t.Fatalf("failed to fetch: %v", err)
+	}
+	if len(messages) != 1 || string(messages[0].Body) != "new" {
+		t.Fatalf("expected only the message after the start time, got %+v", messages)
+	}
+}
+
+func TestResetOffsets(t *testing.T) {
+	ctx := context.Background()
+	c := setupTestServer(t, nil)
+	if err := c.CreateTopic(ctx, TopicConfig{Name: "orders"}); err != nil {
+		t.Fatalf("failed to create topic: %v", err)
+	}
+	for range 3 {
+		if _, err := c.Publish(ctx, "orders", "shop", ProducerMessage{Body: []byte("order")}); err != nil {
+			t.Fatalf("failed to publish: %v", err)
+		}
+	}
+
+	offsets, err := c.ResetOffsets(ctx, "orders", OffsetReset{ConsumerID: "c1", To: "latest"})
+	if err != nil {
+		t.Fatalf("failed to reset offsets: %v", err)
+	}
+	if len(offsets) != 1 || offsets[0].Offset != 3 {
+		t.Fatalf("expected offset 3, got %+v", offsets)
+	}
+
+	if _, err := c.Fetch(ctx, FetchRequest{Topic: "orders", ConsumerID: "c1"}); err != nil {
+		t.Fatalf("failed to fetch: %v", err)
+	}
+	var apiErr *Error
+	_, err = c.ResetOffsets(ctx, "orders", OffsetReset{ConsumerID: "c1", To: "shift", Shift: -1})
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusConflict {
+		t.Fatalf("expected a 409 for a consumer that just fetched, got %v", err)
+	}
+
+	offsets, err = c.ResetOffsets(ctx, "orders", OffsetReset{ConsumerID: "c1", To: "shift", Shift: -1, Force: true})
+	if err != nil || len(offsets) != 1 || offsets[0].Offset != 2 {
+		t.Fatalf("expected a forced shift back to offset 2, got %+v %v", offsets, err)
+	}
+}
+
+func TestFetchWithFilter(t *testing.T) {
+	ctx := context.Background()
+	c := setupTestServer(t, nil)
+	if err := c.CreateTopic(ctx, TopicConfig{Name: "orders"}); err != nil {
+		t.Fatalf("failed to create topic: %v", err)
+	}
+	for _, body := range []string{`{"region":"us"}`, `{"region":"eu"}`, `{"region":"us"}`} {
+		if _, err := c.Publish(ctx, "orders", "shop", ProducerMessage{Body: []byte(body)}); err != nil {
+			t.Fatalf("failed to publish: %v", err)
+		}
+	}
+
+	messages, err := c.Fetch(ctx, FetchRequest{Topic: "orders", ConsumerID: "c1", Commit: true, Filter: `region == "eu"`})
+	if err != nil {
+		t.Fatalf("failed to fetch: %v", err)
+	}
+	if len(messages) != 1 || string(messages[0].Body) != `{"region":"eu"}` {
+		t.Fatalf("expected only the eu order, got %+v", messages)
+	}
+
+	consumers, err := c.ListConsumers(ctx, "orders")
+	if err != nil || len(consumers) != 1 || consumers[0].Filter != `region == "eu"` || consumers[0].Lag != 0 {
+		t.Fatalf("expected c1 with its filter and no lag, got %+v %v", consumers, err)
+	}
+}
+
+func TestConsumer(t *testing.T) {
+	ctx := context.Background()
+	c := setupTestServer(t, nil)
+
+	if err := c.CreateTopic(ctx, TopicConfig{Name: "events", Partitions: 2}); err != nil {
+		t.Fatalf("failed to create topic: %v", err)
+	}
+	var msgs []ProducerMessage
+	for _, key := range []string{"a", "b", "c", "d"} {
+		msgs = append(msgs, ProducerMessage{Key: key, Body: []byte(key)})
+	}
+	results, err := c.PublishBatch(ctx, "events", "p1", msgs, true)
+	if err != nil || len(results) != len(msgs) {
+		t.Fatalf("failed to publish batch: %v", err)
+	}
+	for _, result := range results {
+		if result.Error != "" || result.MessageID == "" {
+			t.Fatalf("expected every message to be published, got %+v", result)
+		}
+	}
+
+	tests := []struct {
+		name       string
+		cfg        ConsumerConfig
+		consumerID string
+	}{
+		{"Manual commit", ConsumerConfig{Topic: "events", ConsumerID: "c1", Partitions: []int{0, 1}}, "c1"},
+		{"Auto commit", ConsumerConfig{Topic: "events", ConsumerID: "c2", Partitions: []int{0, 1}, AutoCommit: true}, "c2"},
+		{"Group member", ConsumerConfig{Topic: "events", ConsumerID: "m1", GroupID: "g1"}, "m1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.cfg.PollInterval = 10 * time.Millisecond
+			consumer, err := c.NewConsumer(ctx, tt.cfg)
+			if err != nil {
+				t.Fatalf("failed to create consumer: %v", err)
+			}
+
+			// the first delivery of "b" fails and is redelivered
+			var mu sync.Mutex
+			handled := map[string]int{}
+			done := make(chan struct{})
+			handler := func(ctx context.Context, msg Message) error {
+				mu.Lock()
+				defer mu.Unlock()
+
+				handled[string(msg.Body)]++
+				if string(msg.Body) == "b" && handled["b"] == 1 {
+					return errTest
+				}
+				if len(handled) == 4 && handled["b"] == 2 {
+					close(done)
+				}
+				return nil
+			}
+
+			runErr := make(chan error, 1)
+			go func() { runErr <- consumer.Run(ctx, handler) }()
+
+			select {
+			case <-done:
+			case <-time.After(5 * time.Second):
+				t.Fatalf("timed out waiting for messages, handled %v", handled)
+			}
+
+			if err := consumer.Close(ctx); err != nil {
+				t.Fatalf("failed to close consumer: %v", err)
+			}
+			if err := <-runErr; err != nil {
+				t.Fatalf("expected Run to stop cleanly, got %v", err)
+			}
+
+			for _, partition := range []int{0, 1} {
+				messages, err := c.Fetch(ctx, FetchRequest{Topic: "events", ConsumerID: tt.consumerID, GroupID: tt.cfg.GroupID, Partition: &partition})
+				if tt.cfg.GroupID != "" {
+					// the member left, so the group no longer has anyone to fetch as
+					if !IsNotFound(err) {
+						t.Fatalf("expected the member to have left the group, got %v", err)
+					}
+					continue
+				}
+				if err != nil || len(messages) != 0 {
+					t.Fatalf("expected everything committed on partition %d, got %d messages %v", partition, len(messages), err)
+				}
+			}
+		})
+	}
+}
+
+type testError string
+
+func (e testError) Error() string { return string(e) }
+
+const errTest = testError("handler failed")
@@ -0,0 +1,398 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+)
+
+var ErrConsumerClosed = errors.New("go-mq: consumer is closed")
+
+type ConsumerConfig struct {
+	Topic      string
+	ConsumerID string
+
+	// GroupID makes the consumer a member of a consumer group, reading the
+	// partitions the group assigns it and sharing the group's offsets.
+	GroupID string
+
+	// Partitions are the partitions a consumer outside a group reads, partition 0
+	// when empty.
+	Partitions []int
+
+	// MaxMessages is the X-Limit of every fetch.
+	MaxMessages int
+
+	// AutoCommit moves the committed offset as messages are fetched. Otherwise the
+	// consumer commits what it acked or nacked, after every batch in Run and on
+	// Commit and Close.
+	AutoCommit bool
+
+	// ReadCommitted only fetches messages of committed transactions, and stops at
+	// the first message of a transaction that is still open.
+	ReadCommitted bool
+
+	// PollInterval is how long Run waits after a fetch came back empty, and the
+	// first wait after a failed one.
+	PollInterval time.Duration
+
+	// MaxWait makes Poll long-poll a consumer that reads a single partition, the
+	// fetch waits on the server for up to MaxWait until a message arrives and Run
+	// does not wait after it came back empty. Keep it below the HTTPClient's
+	// timeout.
+	MaxWait time.Duration
+
+	// HeartbeatInterval is how often a group member heartbeats while it is open.
+	HeartbeatInterval time.Duration
+
+	// NackDelay is how long a message whose handler failed in Run waits before it
+	// is redelivered.
+	NackDelay time.Duration
+
+	// Filter only fetches messages that pass the filter expression, for example
+	// region == "eu" && priority > 3. The others are skipped on the server.
+	Filter string
+}
+
+const (
+	defaultMaxMessages       = 10
+	defaultPollInterval      = 500 * time.Millisecond
+	defaultHeartbeatInterval = 3 * time.Second
+	maxPollBackoff           = 10 * time.Second
+)
+
+// Handler processes one message in Consumer.Run. Returning nil acks it, an error
+// nacks it for redelivery.
+type Handler func(ctx context.Context, msg Message) error
+
+// Consumer pulls messages from one topic over /fetch.
+type Consumer struct {
+	client *Client
+	cfg    ConsumerConfig
+
+	mu         sync.Mutex
+	partitions []int
+	positions  map[int]int
+	committed  map[int]int
+
+	running   sync.Mutex
+	closed    chan struct{}
+	closeOnce sync.Once
+	heartbeat chan struct{}
+}
+
+// NewConsumer checks the config and, for a group member, joins the group.
+func (c *Client) NewConsumer(ctx context.Context, cfg ConsumerConfig) (*Consumer, error) {
+	if cfg.Topic == "" || cfg.ConsumerID == "" {
+		return nil, errors.New("go-mq: a consumer needs a topic and a consumer id")
+	}
+	if cfg.MaxMessages <= 0 {
+		cfg.MaxMessages = defaultMaxMessages
+	}
+	if cfg.PollInterval <= 0 {
+		cfg.PollInterval = defaultPollInterval
+	}
+	if cfg.HeartbeatInterval <= 0 {
+		cfg.HeartbeatInterval = defaultHeartbeatInterval
+	}
+
+	consumer := &Consumer{
+		client:     c,
+		cfg:        cfg,
+		partitions: cfg.Partitions,
+		positions:  make(map[int]int),
+		committed:  make(map[int]int),
+		closed:     make(chan struct{}),
+	}
+	if len(consumer.partitions) == 0 {
+		consumer.partitions = []int{0}
+	}
+
+	if cfg.GroupID != "" {
+		assignment, err := c.JoinGroup(ctx, cfg.GroupID, cfg.Topic, cfg.ConsumerID)
+		if err != nil {
+			return nil, err
+		}
+		consumer.partitions = assignment.Partitions
+		consumer.heartbeat = make(chan struct{})
+		go consumer.heartbeatLoop()
+	}
+
+	return consumer, nil
+}
+
+// Partitions returns the partitions the consumer currently reads.
+func (c *Consumer) Partitions() []int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return append([]int(nil), c.partitions...)
+}
+
+// Poll fetches up to MaxMessages across the consumer's partitions. A partition
+// whose committed offset fell behind retention is moved to its low watermark, and
+// a partition the group has since given away is dropped until the next heartbeat.
+// Without AutoCommit, commit what Poll returned before polling again or the same
+// messages come back.
+func (c *Consumer) Poll(ctx context.Context) ([]Message, error) {
+	select {
+	case <-c.closed:
+		return nil, ErrConsumerClosed
+	default:
+	}
+
+	// a long poll on one partition would hold up the others
+	partitions := c.Partitions()
+	var maxWait time.Duration
+	if len(partitions) == 1 {
+		maxWait = c.cfg.MaxWait
+	}
+
+	messages := []Message{}
+	for _, partition := range partitions {
+		if len(messages) >= c.cfg.MaxMessages {
+			break
+		}
+
+		batch, err := c.client.Fetch(ctx, FetchRequest{
+			Topic:         c.cfg.Topic,
+			ConsumerID:    c.cfg.ConsumerID,
+			GroupID:       c.cfg.GroupID,
+			Partition:     &partition,
+			Limit:         c.cfg.MaxMessages - len(messages),
+			Commit:        c.cfg.AutoCommit,
+			ReadCommitted: c.cfg.ReadCommitted,
+			MaxWait:       maxWait,
+			Filter:        c.cfg.Filter,
+		})
+		if err != nil {
+			var apiErr *Error
+			switch {
+			case errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusRequestedRangeNotSatisfiable:
+				if err := c.client.CommitOffset(ctx, c.cfg.Topic, c.cfg.ConsumerID, c.cfg.GroupID, partition, apiErr.LowWatermark); err != nil {
+					return messages, err
+				}
+				continue
+			case errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusConflict && c.cfg.GroupID != "":
+				c.refreshAssignment(ctx)
+				continue
+			}
+			return messages, err
+		}
+		messages = append(messages, batch...)
+	}
+
+	return messages, nil
+}
+
+// Ack settles a message. An ack that arrives after the visibility timeout has
+// already put the message up again fails with a 404, see IsNotFound.
+func (c *Consumer) Ack(ctx context.Context, msg Message) error {
+	if err := c.client.Ack(ctx, c.cfg.Topic, c.cfg.ConsumerID, msg.ID); err != nil {
+		return err
+	}
+	c.processed(msg)
+	return nil
+}
+
+// Nack reports a failed delivery, see Client.Nack.
+func (c *Consumer) Nack(ctx context.Context, msg Message, requeue bool, delay time.Duration, reason string) (bool, error) {
+	deadLettered, err := c.client.Nack(ctx, c.cfg.Topic, c.cfg.ConsumerID, msg.ID, requeue, delay, reason)
+	if err != nil {
+		return false, err
+	}
+	c.processed(msg)
+	return deadLettered, nil
+}
+
+// Commit commits, per partition, the offset after the furthest message acked or
+// nacked so far. It does nothing with AutoCommit, where fetching commits already.
+func (c *Consumer) Commit(ctx context.Context) error {
+	c.mu.Lock()
+	positions := make(map[int]int)
+	for partition, offset := range c.positions {
+		if offset > c.committed[partition] {
+			positions[partition] = offset
+		}
+	}
+	c.mu.Unlock()
+
+	var errs []error
+	for partition, offset := range positions {
+		if err := c.client.CommitOffset(ctx, c.cfg.Topic, c.cfg.ConsumerID, c.cfg.GroupID, partition, offset); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		c.mu.Lock()
+		c.committed[partition] = max(c.committed[partition], offset)
+		c.mu.Unlock()
+	}
+	return errors.Join(errs...)
+}
+
+// Run polls and hands every message to handler until ctx is done or the consumer is
+// closed. Fetch errors that a retry may fix are retried with backoff, others end Run.
+// A message whose ack fails because it is no longer awaiting acknowledgement is left
+// to be redelivered.
+func (c *Consumer) Run(ctx context.Context, handler Handler) error {
+	if !c.running.TryLock() {
+		return errors.New("go-mq: consumer is already running")
+	}
+	defer c.running.Unlock()
+
+	// closing only interrupts polling and waiting, the message being handled is
+	// finished with ctx
+	pollCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go func() {
+		select {
+		case <-c.closed:
+			cancel()
+		case <-pollCtx.Done():
+		}
+	}()
+
+	backoff := c.cfg.PollInterval
+	for {
+		messages, err := c.Poll(pollCtx)
+		if err != nil && !retryable(err) {
+			if errors.Is(err, ErrConsumerClosed) || pollCtx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+
+		for i, msg := range messages {
+			if pollCtx.Err() != nil {
+				c.requeue(ctx, messages[i:])
+				return nil
+			}
+			if err := c.handle(ctx, handler, msg); err != nil {
+				return err
+			}
+		}
+		if !c.cfg.AutoCommit {
+			if err := c.Commit(ctx); err != nil && !retryable(err) {
+				return err
+			}
+		}
+
+		wait := time.Duration(0)
+		switch {
+		case err != nil:
+			wait = backoff
+			backoff = min(2*backoff, maxPollBackoff)
+		case len(messages) == 0 && (c.cfg.MaxWait == 0 || len(c.Partitions()) > 1):
+			wait = c.cfg.PollInterval
+			backoff = c.cfg.PollInterval
+		default:
+			backoff = c.cfg.PollInterval
+		}
+		if err := sleep(pollCtx, wait); err != nil {
+			return nil
+		}
+	}
+}
+
+// requeue hands back messages Run fetched but will not handle, so they are
+// redelivered right away instead of after the visibility timeout.
+func (c *Consumer) requeue(ctx context.Context, messages []Message) {
+	for _, msg := range messages {
+		c.client.Nack(ctx, c.cfg.Topic, c.cfg.ConsumerID, msg.ID, true, 0, "consumer closed")
+	}
+}
+
+func (c *Consumer) handle(ctx context.Context, handler Handler, msg Message) error {
+	if handlerErr := handler(ctx, msg); handlerErr != nil {
+		if _, err := c.Nack(ctx, msg, true, c.cfg.NackDelay, handlerErr.Error()); err != nil && !IsNotFound(err) {
+			return err
+		}
+		return nil
+	}
+
+	if err := c.Ack(ctx, msg); err != nil && !IsNotFound(err) {
+		return err
+	}
+	return nil
+}
+
+// Close stops Run once the message it is handling is done, commits what was
+// processed and leaves the group. ctx bounds how long that may take.
+func (c *Consumer) Close(ctx context.Context) error {
+	first := false
+	c.closeOnce.Do(func() {
+		first = true
+		close(c.closed)
+	})
+	if !first {
+		return ErrConsumerClosed
+	}
+
+	// Run holds running until it returns
+	waited := make(chan struct{})
+	go func() {
+		c.running.Lock()
+		c.running.Unlock()
+		close(waited)
+	}()
+	select {
+	case <-waited:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	var errs []error
+	if !c.cfg.AutoCommit {
+		errs = append(errs, c.Commit(ctx))
+	}
+	if c.heartbeat != nil {
+		<-c.heartbeat
+		errs = append(errs, c.client.LeaveGroup(ctx, c.cfg.GroupID, c.cfg.ConsumerID))
+	}
+	return errors.Join(errs...)
+}
+
+// processed records msg as done for the next Commit.
+func (c *Consumer) processed(msg Message) {
+	if c.cfg.AutoCommit {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.positions[msg.Partition] = max(c.positions[msg.Partition], msg.Offset+1)
+}
+
+func (c *Consumer) refreshAssignment(ctx context.Context) {
+	assignment, err := c.client.Heartbeat(ctx, c.cfg.GroupID, c.cfg.ConsumerID)
+	if err != nil {
+		return
+	}
+
+	c.mu.Lock()
+	c.partitions = assignment.Partitions
+	c.mu.Unlock()
+}
+
+// heartbeatLoop keeps the group membership alive, and the assignment current,
+// until the consumer is closed.
+func (c *Consumer) heartbeatLoop() {
+	defer close(c.heartbeat)
+
+	ticker := time.NewTicker(c.cfg.HeartbeatInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.closed:
+			return
+		case <-ticker.C:
+			ctx, cancel := context.WithTimeout(context.Background(), c.cfg.HeartbeatInterval)
+			c.refreshAssignment(ctx)
+			cancel()
+		}
+	}
+}
@@ -0,0 +1,381 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrProducerClosed      = errors.New("go-mq: producer is closed")
+	ErrScheduledIdempotent = errors.New("go-mq: an idempotent producer cannot schedule messages")
+)
+
+type ProducerConfig struct {
+	// ProducerID is sent as producer_id with every message.
+	ProducerID string
+
+	// BatchSize is how many SendAsync messages are collected before they are sent,
+	// Linger how long a smaller batch may wait for more. Each batch goes out as one
+	// batch publish per topic, scheduled and transactional messages on their own.
+	BatchSize int
+	Linger    time.Duration
+
+	// MaxRetries is how often a publish is retried after a transport error, 429 or
+	// 5xx, zero means the default and a negative value turns retries off. The wait
+	// starts at RetryBackoff and doubles up to MaxRetryBackoff.
+	MaxRetries      int
+	RetryBackoff    time.Duration
+	MaxRetryBackoff time.Duration
+
+	// Idempotent registers the producer with the broker and numbers its messages, so
+	// a retry of a message that was already appended is not appended again. The
+	// messages are then sent one at a time, batches included, and scheduled messages
+	// are not allowed.
+	Idempotent bool
+}
+
+const (
+	defaultBatchSize       = 100
+	defaultLinger          = 10 * time.Millisecond
+	defaultMaxRetries      = 3
+	defaultRetryBackoff    = 100 * time.Millisecond
+	defaultMaxRetryBackoff = 5 * time.Second
+
+	// maxBatchMessages is the most messages the broker takes in one batch publish.
+	maxBatchMessages = 10000
+)
+
+// Producer publishes with retries. Each message gets an idempotency key that stays
+// the same across its retries, which a topic with a dedup window uses to drop a
+// retry of a message it already appended. Messages handed to SendAsync are batched
+// and sent in the order they were handed over.
+type Producer struct {
+	client *Client
+	cfg    ProducerConfig
+
+	mu      sync.Mutex
+	batch   []*pendingMessage
+	linger  *time.Timer
+	closed  bool
+	batches chan batch
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	done   chan struct{}
+
+	// session is the idempotent producer's current registration, held for the whole
+	// of a sequenced publish so sequence numbers reach the broker in order
+	session struct {
+		sync.Mutex
+		registered bool
+		epoch      int
+		sequence   int64
+	}
+}
+
+type pendingMessage struct {
+	topic    string
+	msg      ProducerMessage
+	callback func(PublishResult, error)
+}
+
+// batch is a run of messages for the sender. A batch with flushed set is a marker
+// that is closed once everything handed over before it has been sent.
+type batch struct {
+	messages []*pendingMessage
+	flushed  chan struct{}
+}
+
+func (c *Client) NewProducer(cfg ProducerConfig) *Producer {
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = defaultBatchSize
+	}
+	if cfg.Linger <= 0 {
+		cfg.Linger = defaultLinger
+	}
+	if cfg.MaxRetries < 0 {
+		cfg.MaxRetries = 0
+	} else if cfg.MaxRetries == 0 {
+		cfg.MaxRetries = defaultMaxRetries
+	}
+	if cfg.RetryBackoff <= 0 {
+		cfg.RetryBackoff = defaultRetryBackoff
+	}
+	if cfg.MaxRetryBackoff <= 0 {
+		cfg.MaxRetryBackoff = defaultMaxRetryBackoff
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	p := &Producer{
+		client:  c,
+		cfg:     cfg,
+		batches: make(chan batch, 16),
+		ctx:     ctx,
+		cancel:  cancel,
+		done:    make(chan struct{}),
+	}
+	go p.run()
+
+	return p
+}
+
+// Send publishes one message right away, retrying until it is accepted, a retry
+// cannot help or ctx is done. It does not wait for earlier SendAsync messages.
+func (p *Producer) Send(ctx context.Context, topic string, msg ProducerMessage) (PublishResult, error) {
+	if msg.IdempotencyKey == "" {
+		msg.IdempotencyKey = uuid.NewString()
+	}
+	return p.publish(ctx, topic, msg)
+}
+
+// SendAsync adds a message to the current batch. callback, which may be nil, is
+// called from the producer's sender once the message was published or given up on,
+// so it must not call SendAsync or Flush itself.
+func (p *Producer) SendAsync(topic string, msg ProducerMessage, callback func(PublishResult, error)) error {
+	if msg.IdempotencyKey == "" {
+		msg.IdempotencyKey = uuid.NewString()
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.closed {
+		return ErrProducerClosed
+	}
+
+	p.batch = append(p.batch, &pendingMessage{topic: topic, msg: msg, callback: callback})
+	if len(p.batch) >= p.cfg.BatchSize {
+		p.handOff(nil)
+	} else if p.linger == nil {
+		p.linger = time.AfterFunc(p.cfg.Linger, p.lingerExpired)
+	}
+
+	return nil
+}
+
+// Flush sends the current batch and waits until every message handed to SendAsync
+// so far has been published or given up on.
+func (p *Producer) Flush(ctx context.Context) error {
+	flushed := make(chan struct{})
+
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		return ErrProducerClosed
+	}
+	p.handOff(flushed)
+	p.mu.Unlock()
+
+	select {
+	case <-flushed:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Close sends what is still batched and stops the producer. Messages that are not
+// out by the time ctx is done are failed with context.Canceled.
+func (p *Producer) Close(ctx context.Context) error {
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		return ErrProducerClosed
+	}
+	p.closed = true
+	p.handOff(nil)
+	close(p.batches)
+	p.mu.Unlock()
+
+	select {
+	case <-p.done:
+		return nil
+	case <-ctx.Done():
+		p.cancel()
+		<-p.done
+		return ctx.Err()
+	}
+}
+
+func (p *Producer) lingerExpired() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.linger = nil
+	if !p.closed {
+		p.handOff(nil)
+	}
+}
+
+// handOff passes the current batch to the sender, blocking while the sender is
+// behind so SendAsync callers slow down with it. The caller must hold p.mu.
+func (p *Producer) handOff(flushed chan struct{}) {
+	if p.linger != nil {
+		p.linger.Stop()
+		p.linger = nil
+	}
+	if len(p.batch) == 0 && flushed == nil {
+		return
+	}
+
+	p.batches <- batch{messages: p.batch, flushed: flushed}
+	p.batch = nil
+}
+
+func (p *Producer) run() {
+	defer close(p.done)
+	defer p.cancel()
+
+	for b := range p.batches {
+		p.send(b.messages)
+		if b.flushed != nil {
+			close(b.flushed)
+		}
+	}
+}
+
+// send publishes a batch in order. Runs of messages for the same topic go out as
+// one batch publish each, messages that a batch cannot carry are sent on their own.
+func (p *Producer) send(messages []*pendingMessage) {
+	for len(messages) > 0 {
+		n := 1
+		for n < len(messages) && n < maxBatchMessages && messages[n].topic == messages[0].topic && p.batchable(messages[0]) && p.batchable(messages[n]) {
+			n++
+		}
+		run := messages[:n]
+		messages = messages[n:]
+
+		if len(run) == 1 {
+			result, err := p.publish(p.ctx, run[0].topic, run[0].msg)
+			if run[0].callback != nil {
+				run[0].callback(result, err)
+			}
+			continue
+		}
+
+		msgs := make([]ProducerMessage, len(run))
+		for i, pending := range run {
+			msgs[i] = pending.msg
+		}
+		results, err := p.publishBatchWithRetries(p.ctx, run[0].topic, msgs)
+		for i, pending := range run {
+			var result PublishResult
+			sendErr := err
+			if err == nil {
+				result = results[i].PublishResult
+				// the message on its own gets the broker's error as an *Error
+				if results[i].Error != "" {
+					result, sendErr = p.publishWithRetries(p.ctx, pending.topic, pending.msg)
+				}
+			}
+			if pending.callback != nil {
+				pending.callback(result, sendErr)
+			}
+		}
+	}
+}
+
+// batchable reports whether pending can go out in a batch publish, which appends
+// right away and carries no sequence or transaction.
+func (p *Producer) batchable(pending *pendingMessage) bool {
+	return !p.cfg.Idempotent && pending.msg.DeliverAt.IsZero() && pending.msg.Delay <= 0 && pending.msg.TransactionID == ""
+}
+
+func (p *Producer) publish(ctx context.Context, topic string, msg ProducerMessage) (PublishResult, error) {
+	if p.cfg.Idempotent {
+		return p.publishSequenced(ctx, topic, msg)
+	}
+	return p.publishWithRetries(ctx, topic, msg)
+}
+
+// publishSequenced sends msg with the session's next sequence number. A session
+// the broker no longer knows is registered again, and after a publish whose outcome
+// is unknown the next one starts a fresh session, as the broker may or may not have
+// taken the sequence.
+func (p *Producer) publishSequenced(ctx context.Context, topic string, msg ProducerMessage) (PublishResult, error) {
+	if !msg.DeliverAt.IsZero() || msg.Delay > 0 {
+		return PublishResult{}, ErrScheduledIdempotent
+	}
+
+	p.session.Lock()
+	defer p.session.Unlock()
+
+	for registrations := 0; ; registrations++ {
+		if !p.session.registered {
+			epoch, err := p.client.RegisterProducer(ctx, p.cfg.ProducerID)
+			if err != nil {
+				return PublishResult{}, err
+			}
+			p.session.registered, p.session.epoch, p.session.sequence = true, epoch, 0
+		}
+
+		sequence := p.session.sequence
+		msg.Epoch, msg.Sequence = p.session.epoch, &sequence
+		result, err := p.publishWithRetries(ctx, topic, msg)
+
+		var apiErr *Error
+		switch {
+		case err == nil:
+			p.session.sequence++
+			return result, nil
+		case errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusPreconditionFailed && registrations == 0:
+			p.session.registered = false
+			continue
+		case !errors.As(err, &apiErr) || retryable(err):
+			p.session.registered = false
+		}
+		return result, err
+	}
+}
+
+func (p *Producer) publishWithRetries(ctx context.Context, topic string, msg ProducerMessage) (PublishResult, error) {
+	backoff := p.cfg.RetryBackoff
+	for attempt := 0; ; attempt++ {
+		result, err := p.client.Publish(ctx, topic, p.cfg.ProducerID, msg)
+		if err == nil || attempt >= p.cfg.MaxRetries || !retryable(err) {
+			return result, err
+		}
+
+		if err := sleep(ctx, backoff); err != nil {
+			return PublishResult{}, err
+		}
+		backoff = min(2*backoff, p.cfg.MaxRetryBackoff)
+	}
+}
+
+func (p *Producer) publishBatchWithRetries(ctx context.Context, topic string, msgs []ProducerMessage) ([]BatchResult, error) {
+	backoff := p.cfg.RetryBackoff
+	for attempt := 0; ; attempt++ {
+		results, err := p.client.PublishBatch(ctx, topic, p.cfg.ProducerID, msgs, false)
+		if err == nil && len(results) != len(msgs) {
+			return nil, fmt.Errorf("go-mq: batch publish returned %d results for %d messages", len(results), len(msgs))
+		}
+		if err == nil || attempt >= p.cfg.MaxRetries || !retryable(err) {
+			return results, err
+		}
+
+		if err := sleep(ctx, backoff); err != nil {
+			return nil, err
+		}
+		backoff = min(2*backoff, p.cfg.MaxRetryBackoff)
+	}
+}
+
+// sleep waits for d, returning early with ctx's error when ctx is done first.
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
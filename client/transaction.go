@@ -0,0 +1,59 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// Transaction is an open transaction on the server. Messages published through it
+// and offsets added to it take effect together on Commit, or not at all on Abort.
+// Consumers fetching with ReadCommitted only see its messages once it commits.
+type Transaction struct {
+	ID     string
+	client *Client
+}
+
+// BeginTransaction opens a transaction. The server aborts it if it is still open
+// after timeout, or after its own default when timeout is 0.
+func (c *Client) BeginTransaction(ctx context.Context, timeout time.Duration) (*Transaction, error) {
+	req := map[string]int64{"timeout_ms": timeout.Milliseconds()}
+	var resp struct {
+		TransactionID string `json:"transaction_id"`
+	}
+	if _, err := c.do(ctx, http.MethodPost, "/transactions", nil, req, &resp); err != nil {
+		return nil, err
+	}
+	return &Transaction{ID: resp.TransactionID, client: c}, nil
+}
+
+// Publish sends msg to topic as part of the transaction, in a single attempt. It
+// cannot be scheduled or sequenced.
+func (t *Transaction) Publish(ctx context.Context, topic, producerID string, msg ProducerMessage) (PublishResult, error) {
+	msg.TransactionID = t.ID
+	return t.client.Publish(ctx, topic, producerID, msg)
+}
+
+// AddOffsets commits offset for consumerID, or for groupID when that is set, on the
+// partition when the transaction commits.
+func (t *Transaction) AddOffsets(ctx context.Context, topic, consumerID, groupID string, partition, offset int) error {
+	req := map[string]any{
+		"topic":       topic,
+		"consumer_id": consumerID,
+		"group_id":    groupID,
+		"partition":   partition,
+		"offset":      offset,
+	}
+	_, err := t.client.do(ctx, http.MethodPost, "/transactions/"+t.ID+"/offsets", nil, req, nil)
+	return err
+}
+
+func (t *Transaction) Commit(ctx context.Context) error {
+	_, err := t.client.do(ctx, http.MethodPost, "/transactions/"+t.ID+"/commit", nil, nil, nil)
+	return err
+}
+
+func (t *Transaction) Abort(ctx context.Context) error {
+	_, err := t.client.do(ctx, http.MethodPost, "/transactions/"+t.ID+"/abort", nil, nil, nil)
+	return err
+}
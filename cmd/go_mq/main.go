@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
@@ -10,6 +11,8 @@ import (
 
 	"github.com/codytheroux96/go-mq/internal/api"
 	"github.com/codytheroux96/go-mq/internal/app"
+	"github.com/codytheroux96/go-mq/internal/rpc"
+	"github.com/codytheroux96/go-mq/internal/wire"
 )
 
 // func main() {
@@ -98,6 +101,36 @@ func main() {
 		}
 	}()
 
+	grpcServer := rpc.NewServer(app)
+
+	go func() {
+		listener, err := net.Listen("tcp", app.Config.GRPCAddr)
+		if err != nil {
+			app.Logger.Error("gRPC listen error", "addr", app.Config.GRPCAddr, "error", err)
+			os.Exit(1)
+		}
+		app.Logger.Info("gRPC server starting on " + app.Config.GRPCAddr)
+		if err := grpcServer.Serve(listener); err != nil {
+			app.Logger.Error("gRPC server error", "error", err)
+			os.Exit(1)
+		}
+	}()
+
+	wireServer := wire.NewServer(app.Broker, app.Logger)
+
+	go func() {
+		listener, err := net.Listen("tcp", app.Config.WireAddr)
+		if err != nil {
+			app.Logger.Error("wire protocol listen error", "addr", app.Config.WireAddr, "error", err)
+			os.Exit(1)
+		}
+		app.Logger.Info("wire protocol server starting on " + app.Config.WireAddr)
+		if err := wireServer.Serve(listener); err != nil {
+			app.Logger.Error("wire protocol server error", "error", err)
+			os.Exit(1)
+		}
+	}()
+
 	shutdownChan := make(chan os.Signal, 1)
 	signal.Notify(shutdownChan, os.Interrupt, syscall.SIGTERM)
 
@@ -112,4 +145,26 @@ func main() {
 	} else {
 		app.Logger.Info("Server shut down cleanly")
 	}
+
+	// open consume streams would hold a graceful stop forever, cut them off at the deadline
+	stopped := make(chan struct{})
+	go func() {
+		grpcServer.GracefulStop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+		app.Logger.Info("gRPC server shut down cleanly")
+	case <-ctx.Done():
+		grpcServer.Stop()
+		app.Logger.Warn("gRPC server stopped after the shutdown deadline")
+	}
+
+	if err := wireServer.Close(); err != nil {
+		app.Logger.Error("Failed to close wire protocol server", "error", err)
+	}
+
+	if err := app.Close(); err != nil {
+		app.Logger.Error("Failed to close repository", "error", err)
+	}
 }
@@ -0,0 +1,193 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/codytheroux96/go-mq/client"
+	"github.com/google/uuid"
+)
+
+type messageOutput struct {
+	ID        string            `json:"message_id"`
+	Key       string            `json:"key"`
+	Partition int               `json:"partition"`
+	Offset    int               `json:"offset"`
+	Timestamp time.Time         `json:"timestamp"`
+	Attempts  int               `json:"attempts"`
+	Body      string            `json:"body"`
+	Metadata  map[string]string `json:"metadata,omitempty"`
+}
+
+func newMessageOutput(msg client.Message) messageOutput {
+	return messageOutput{
+		ID:        msg.ID,
+		Key:       msg.Key,
+		Partition: msg.Partition,
+		Offset:    msg.Offset,
+		Timestamp: msg.Timestamp,
+		Attempts:  msg.DeliveryAttempts,
+		Body:      string(msg.Body),
+		Metadata:  msg.Metadata,
+	}
+}
+
+// tail follows a topic until interrupted, printing a line, or with -o json an
+// object, per message. Without a group it reads as a throwaway consumer that
+// starts at the end of every partition, or at the start with -from-beginning, and
+// is deleted again on exit so its offsets do not pile up on the broker.
+func (c *cli) tail(ctx context.Context, args []string) (err error) {
+	flags := flag.NewFlagSet("tail", flag.ContinueOnError)
+	consumerID, groupID := consumerFlags(flags)
+	fromBeginning := flags.Bool("from-beginning", false, "start at the earliest retained message")
+	maxMessages := flags.Int("max", 0, "exit after this many messages")
+	filter := flags.String("filter", "", `only show messages that pass a filter such as region == "eu"`)
+
+	positional, err := parse(flags, args, 1)
+	if err != nil {
+		return err
+	}
+	topic := positional[0]
+
+	if *groupID != "" && *consumerID == "" {
+		return usageError("-group needs a -consumer to join as")
+	}
+	throwaway := *consumerID == ""
+	if throwaway {
+		*consumerID = "gomqctl-tail-" + uuid.NewString()
+	}
+
+	cfg := client.ConsumerConfig{
+		Topic:        topic,
+		ConsumerID:   *consumerID,
+		GroupID:      *groupID,
+		AutoCommit:   true,
+		PollInterval: 200 * time.Millisecond,
+		Filter:       *filter,
+	}
+	if *groupID == "" {
+		var description client.TopicDescription
+		if description, err = c.client.DescribeTopic(ctx, topic, "", ""); err != nil {
+			return err
+		}
+		if throwaway {
+			defer func() {
+				deleteCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+				defer cancel()
+				// it fetched a moment ago, so the broker still counts it as active
+				err = errors.Join(err, c.client.DeleteConsumer(deleteCtx, topic, *consumerID, true))
+			}()
+		}
+		for _, p := range description.Partitions {
+			cfg.Partitions = append(cfg.Partitions, p.Partition)
+			start := p.HighWatermark
+			if *fromBeginning {
+				start = p.LowWatermark
+			}
+			if err := c.client.CommitOffset(ctx, topic, *consumerID, "", p.Partition, start); err != nil {
+				return err
+			}
+		}
+	}
+
+	consumer, err := c.client.NewConsumer(ctx, cfg)
+	if err != nil {
+		return err
+	}
+
+	// the consumer is closed exactly once, from the handler when -max is reached or
+	// once Run returns after an interrupt
+	closed := make(chan error, 1)
+	seen := 0
+	encoder := json.NewEncoder(c.stdout)
+	runErr := consumer.Run(ctx, func(ctx context.Context, msg client.Message) error {
+		// anything handled between reaching -max and the close taking effect goes back
+		if *maxMessages > 0 && seen >= *maxMessages {
+			return errors.New("tail reached -max")
+		}
+
+		var err error
+		if c.json {
+			err = encoder.Encode(newMessageOutput(msg))
+		} else {
+			_, err = fmt.Fprintf(c.stdout, "%d:%d\t%s\t%s\n", msg.Partition, msg.Offset, msg.Key, msg.Body)
+		}
+		if seen++; seen == *maxMessages {
+			go func() { closed <- consumer.Close(context.Background()) }()
+		}
+		return err
+	})
+
+	if *maxMessages <= 0 || seen < *maxMessages {
+		closeCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		closed <- consumer.Close(closeCtx)
+	}
+	if err := <-closed; err != nil {
+		return err
+	}
+	return runErr
+}
+
+// fetch runs a single fetch. -offset moves the consumer's committed offset on the
+// partition there first, -commit moves it past what was fetched and -ack settles
+// the fetched messages so they are not redelivered. -max-wait long-polls.
+func (c *cli) fetch(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("fetch", flag.ContinueOnError)
+	consumerID, groupID := consumerFlags(flags)
+	partition := flags.Int("partition", -1, "partition to fetch from, every assigned partition for a group member when unset")
+	offset := flags.Int("offset", -1, "commit this offset on the partition before fetching")
+	limit := flags.Int("limit", 10, "most messages to fetch")
+	commit := flags.Bool("commit", false, "commit the offset past the fetched messages")
+	ack := flags.Bool("ack", false, "acknowledge the fetched messages")
+	readCommitted := flags.Bool("read-committed", false, "leave out messages of open or aborted transactions")
+	maxWait := flags.Duration("max-wait", 0, "wait up to this long for -min messages to arrive")
+	minMessages := flags.Int("min", 0, "messages to wait for with -max-wait, 1 when unset")
+	filter := flags.String("filter", "", `only fetch messages that pass a filter such as region == "eu"`)
+
+	positional, err := parse(flags, args, 1)
+	if err != nil {
+		return err
+	}
+	topic := positional[0]
+
+	if *consumerID == "" {
+		return usageError("fetch needs a -consumer")
+	}
+	if *offset >= 0 && *partition < 0 {
+		return usageError("-offset needs a -partition")
+	}
+
+	req := client.FetchRequest{Topic: topic, ConsumerID: *consumerID, GroupID: *groupID, Limit: *limit, Commit: *commit, ReadCommitted: *readCommitted, MaxWait: *maxWait, MinMessages: *minMessages, Filter: *filter}
+	if *partition >= 0 {
+		req.Partition = partition
+	}
+	if *offset >= 0 {
+		if err := c.client.CommitOffset(ctx, topic, *consumerID, *groupID, *partition, *offset); err != nil {
+			return err
+		}
+	}
+
+	messages, err := c.client.Fetch(ctx, req)
+	if err != nil {
+		return err
+	}
+
+	output := make([]messageOutput, 0, len(messages))
+	rows := make([][]string, 0, len(messages))
+	for _, msg := range messages {
+		if *ack {
+			if err := c.client.Ack(ctx, topic, *consumerID, msg.ID); err != nil {
+				return err
+			}
+		}
+		output = append(output, newMessageOutput(msg))
+		rows = append(rows, []string{strconv.Itoa(msg.Partition), strconv.Itoa(msg.Offset), msg.Key, strconv.Itoa(msg.DeliveryAttempts), string(msg.Body)})
+	}
+	return c.print(output, []string{"PARTITION", "OFFSET", "KEY", "ATTEMPTS", "BODY"}, rows)
+}
@@ -0,0 +1,202 @@
+// Command gomqctl administers a go-mq broker over its HTTP API.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"text/tabwriter"
+
+	"github.com/codytheroux96/go-mq/client"
+)
+
+const usage = `usage: gomqctl [-server url] [-o table|json] <command> [arguments]
+
+commands:
+  topics list
+  topics create <topic> [-partitions n] [-max-age d] [-max-bytes n] [-max-messages n]
+                        [-dead-letter topic -max-attempts n] [-dedup-window d] [-dedup-max-ids n]
+  topics delete <topic>
+  topics describe <topic>
+  topics stats <topic>
+  topics consumers <topic>
+  publish <topic> [-producer id] [-key key] [-file path] [-lines]
+  tail <topic> [-consumer id | -group id -consumer id] [-from-beginning] [-max n] [-filter expr]
+  fetch <topic> -consumer id [-group id] [-partition n] [-offset n] [-limit n] [-commit] [-ack] [-read-committed]
+                        [-max-wait d] [-min n] [-filter expr]
+  offsets commit <topic> (-consumer id | -group id) -partition n -offset n
+  offsets reset <topic> (-consumer id | -group id) (-to earliest|latest|<rfc3339 time> | -shift n)
+                        [-partition n] [-force]
+  lag <topic> (-consumer id | -group id)
+
+The server defaults to $GOMQ_SERVER, or http://localhost:8080 when it is unset.
+`
+
+// usageError is a mistake in the command line rather than a failed request.
+type usageError string
+
+func (e usageError) Error() string { return string(e) }
+
+type cli struct {
+	client *client.Client
+	json   bool
+	stdin  io.Reader
+	stdout io.Writer
+}
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	os.Exit(run(ctx, os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+func run(ctx context.Context, args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	server := os.Getenv("GOMQ_SERVER")
+	if server == "" {
+		server = "http://localhost:8080"
+	}
+
+	flags := flag.NewFlagSet("gomqctl", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.Usage = func() { fmt.Fprint(stderr, usage) }
+	flags.StringVar(&server, "server", server, "broker HTTP address")
+	output := flags.String("o", "table", "output format, table or json")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	c := &cli{
+		client: client.New(server),
+		json:   *output == "json",
+		stdin:  stdin,
+		stdout: stdout,
+	}
+
+	var err error
+	if *output != "table" && *output != "json" {
+		err = usageError("-o must be table or json")
+	} else {
+		err = c.dispatch(ctx, flags.Args())
+	}
+
+	var usageErr usageError
+	switch {
+	case errors.As(err, &usageErr):
+		fmt.Fprintf(stderr, "gomqctl: %s\n\n%s", err, usage)
+		return 2
+	case err != nil:
+		fmt.Fprintf(stderr, "gomqctl: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+func (c *cli) dispatch(ctx context.Context, args []string) error {
+	if len(args) == 0 {
+		return usageError("a command is required")
+	}
+
+	command, args := args[0], args[1:]
+	if command == "topics" || command == "offsets" {
+		if len(args) == 0 {
+			return usageError(command + " needs a subcommand")
+		}
+		command, args = command+" "+args[0], args[1:]
+	}
+
+	switch command {
+	case "topics list":
+		return c.listTopics(ctx, args)
+	case "topics create":
+		return c.createTopic(ctx, args)
+	case "topics delete":
+		return c.deleteTopic(ctx, args)
+	case "topics describe":
+		return c.describeTopic(ctx, args)
+	case "topics stats":
+		return c.topicStats(ctx, args)
+	case "topics consumers":
+		return c.topicConsumers(ctx, args)
+	case "publish":
+		return c.publish(ctx, args)
+	case "tail":
+		return c.tail(ctx, args)
+	case "fetch":
+		return c.fetch(ctx, args)
+	case "offsets commit":
+		return c.commitOffset(ctx, args)
+	case "offsets reset":
+		return c.resetOffsets(ctx, args)
+	case "lag":
+		return c.lag(ctx, args)
+	default:
+		return usageError("unknown command " + command)
+	}
+}
+
+// parse parses flags that may come before or after the positional arguments and
+// checks that exactly want positional arguments were given.
+func parse(flags *flag.FlagSet, args []string, want int) ([]string, error) {
+	flags.SetOutput(io.Discard)
+
+	var positional []string
+	for {
+		if err := flags.Parse(args); err != nil {
+			return nil, usageError(fmt.Sprintf("%s: %v", flags.Name(), err))
+		}
+		if flags.NArg() == 0 {
+			break
+		}
+		positional = append(positional, flags.Arg(0))
+		args = flags.Args()[1:]
+	}
+
+	if len(positional) != want {
+		return nil, usageError(fmt.Sprintf("%s takes %d argument(s), got %d", flags.Name(), want, len(positional)))
+	}
+	return positional, nil
+}
+
+// consumerFlags adds the -consumer and -group flags most offset commands share.
+func consumerFlags(flags *flag.FlagSet) (consumerID, groupID *string) {
+	consumerID = flags.String("consumer", "", "consumer id")
+	groupID = flags.String("group", "", "consumer group id")
+	return consumerID, groupID
+}
+
+// print writes value as indented JSON with -o json, and otherwise as a table of
+// rows under header.
+func (c *cli) print(value any, header []string, rows [][]string) error {
+	if c.json {
+		encoder := json.NewEncoder(c.stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(value)
+	}
+
+	w := tabwriter.NewWriter(c.stdout, 0, 4, 2, ' ', 0)
+	if header != nil {
+		fmt.Fprintln(w, strings.Join(header, "\t"))
+	}
+	for _, row := range rows {
+		fmt.Fprintln(w, strings.Join(row, "\t"))
+	}
+	return w.Flush()
+}
+
+// printStatus reports a command that changed something, as {"topic", "status"} with
+// -o json and as a sentence otherwise.
+func (c *cli) printStatus(topic, status string) error {
+	if c.json {
+		return c.print(map[string]string{"topic": topic, "status": status}, nil, nil)
+	}
+	_, err := fmt.Fprintf(c.stdout, "topic %s %s\n", topic, status)
+	return err
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/codytheroux96/go-mq/internal/api"
+	"github.com/codytheroux96/go-mq/internal/app"
+)
+
+func TestCommands(t *testing.T) {
+	a := app.NewApplication()
+	ts := httptest.NewServer(api.Routes(a))
+	defer func() {
+		ts.Close()
+		a.Close()
+	}()
+
+	tests := []struct {
+		name       string
+		args       []string
+		stdin      string
+		expectCode int
+		expectOut  []string
+	}{
+		{"Create topic", []string{"topics", "create", "orders", "-partitions", "2", "-max-messages", "100", "-dedup-window", "1m"}, "", 0, []string{"topic orders created"}},
+		{"Create topic again", []string{"topics", "create", "orders"}, "", 1, nil},
+		{"List topics as JSON", []string{"-o", "json", "topics", "list"}, "", 0, []string{`"orders"`}},
+		{"Publish lines", []string{"publish", "orders", "-key", "k1", "-lines"}, "first\nsecond\n\nthird\n", 0, []string{"MESSAGE ID", "PARTITION"}},
+		{"Publish to missing topic", []string{"publish", "missing"}, "lost", 1, []string{"404"}},
+		{"Describe topic", []string{"topics", "describe", "orders"}, "", 0, []string{"Partitions:", "max messages 100", "window 1m0s", "PARTITION"}},
+		{"Fetch and commit", []string{"fetch", "orders", "-consumer", "c1", "-partition", "0", "-commit", "-ack", "-limit", "1"}, "", 0, []string{"PARTITION", "OFFSET"}},
+		{"Fetch with a filter", []string{"-o", "json", "fetch", "orders", "-consumer", "c2", "-filter", `region == "eu"`}, "", 0, []string{"[]"}},
+		{"Fetch with a bad filter", []string{"fetch", "orders", "-consumer", "c2", "-filter", "region =="}, "", 1, []string{"400"}},
+		{"Lag", []string{"lag", "orders", "-consumer", "c1"}, "", 0, []string{"COMMITTED", "total"}},
+		{"Topic stats", []string{"topics", "stats", "orders"}, "", 0, []string{"MESSAGES", "BYTES", "OLDEST", "total"}},
+		{"Topic consumers", []string{"topics", "consumers", "orders"}, "", 0, []string{"LAST FETCH", "c1"}},
+		{"Topic consumers as JSON", []string{"-o", "json", "topics", "consumers", "orders"}, "", 0, []string{`"id": "c1"`, `"unacked": 0`}},
+		{"Reset an active consumer", []string{"offsets", "reset", "orders", "-consumer", "c1", "-to", "earliest"}, "", 1, []string{"409"}},
+		{"Reset to earliest", []string{"-o", "json", "offsets", "reset", "orders", "-consumer", "c1", "-to", "earliest", "-force"}, "", 0, []string{`"offset": 0`}},
+		{"Reset to a time", []string{"-o", "json", "offsets", "reset", "orders", "-consumer", "c1", "-to", "2000-01-01T00:00:00Z", "-force"}, "", 0, []string{`"offset": 0`}},
+		{"Shift forward", []string{"-o", "json", "offsets", "reset", "orders", "-consumer", "c1", "-shift", "1", "-force"}, "", 0, []string{`"offset": 1`}},
+		{"Reset with both -to and -shift", []string{"offsets", "reset", "orders", "-consumer", "c1", "-to", "latest", "-shift", "1"}, "", 2, nil},
+		{"Reset to something else", []string{"offsets", "reset", "orders", "-consumer", "c1", "-to", "yesterday"}, "", 2, nil},
+		{"Commit offset", []string{"offsets", "commit", "orders", "-group", "g1", "-partition", "1", "-offset", "0"}, "", 0, []string{"PARTITION"}},
+		{"Tail from the beginning", []string{"tail", "orders", "-from-beginning", "-max", "3"}, "", 0, []string{"first", "second", "third"}},
+		{"Lag needs a consumer or group", []string{"lag", "orders"}, "", 2, nil},
+		{"Unknown command", []string{"frobnicate"}, "", 2, nil},
+		{"Bad output format", []string{"-o", "yaml", "topics", "list"}, "", 2, nil},
+		{"Delete topic", []string{"-o", "json", "topics", "delete", "orders"}, "", 0, []string{`"status": "deleted"`}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			args := append([]string{"-server", ts.URL}, tt.args...)
+
+			code := run(context.Background(), args, strings.NewReader(tt.stdin), &stdout, &stderr)
+			if code != tt.expectCode {
+				t.Fatalf("expected exit code %d, got %d: %s", tt.expectCode, code, stderr.String())
+			}
+			output := stdout.String() + stderr.String()
+			for _, expected := range tt.expectOut {
+				if !strings.Contains(output, expected) {
+					t.Fatalf("expected output to contain %q, got:\n%s", expected, output)
+				}
+			}
+		})
+	}
+}
+
+func TestPublishJSONOutput(t *testing.T) {
+	a := app.NewApplication()
+	ts := httptest.NewServer(api.Routes(a))
+	defer func() {
+		ts.Close()
+		a.Close()
+	}()
+
+	var stdout, stderr bytes.Buffer
+	if code := run(context.Background(), []string{"-server", ts.URL, "topics", "create", "events"}, nil, &stdout, &stderr); code != 0 {
+		t.Fatalf("failed to create topic: %s", stderr.String())
+	}
+
+	stdout.Reset()
+	code := run(context.Background(), []string{"-server", ts.URL, "-o", "json", "publish", "events", "-lines"}, strings.NewReader("a\nb\n"), &stdout, &stderr)
+	if code != 0 {
+		t.Fatalf("failed to publish: %s", stderr.String())
+	}
+
+	var results []publishResult
+	if err := json.Unmarshal(stdout.Bytes(), &results); err != nil {
+		t.Fatalf("expected a JSON array of results, got %q: %v", stdout.String(), err)
+	}
+	if len(results) != 2 || results[0].Offset != 0 || results[1].Offset != 1 || results[0].MessageID == "" {
+		t.Fatalf("expected two results in publish order, got %+v", results)
+	}
+}
+
+func TestTailDeletesItsConsumer(t *testing.T) {
+	a := app.NewApplication()
+	ts := httptest.NewServer(api.Routes(a))
+	defer func() {
+		ts.Close()
+		a.Close()
+	}()
+
+	var stdout, stderr bytes.Buffer
+	for _, args := range [][]string{
+		{"topics", "create", "events"},
+		{"publish", "events", "-lines"},
+		{"tail", "events", "-from-beginning", "-max", "2"},
+	} {
+		if code := run(context.Background(), append([]string{"-server", ts.URL}, args...), strings.NewReader("a\nb\n"), &stdout, &stderr); code != 0 {
+			t.Fatalf("failed to run %v: %s", args, stderr.String())
+		}
+	}
+
+	stdout.Reset()
+	if code := run(context.Background(), []string{"-server", ts.URL, "-o", "json", "topics", "consumers", "events"}, nil, &stdout, &stderr); code != 0 {
+		t.Fatalf("failed to list consumers: %s", stderr.String())
+	}
+	if strings.Contains(stdout.String(), "gomqctl-tail-") {
+		t.Fatalf("expected tail to delete its consumer, got %s", stdout.String())
+	}
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"strconv"
+	"time"
+
+	"github.com/codytheroux96/go-mq/client"
+)
+
+type offsetOutput struct {
+	Partition int `json:"partition"`
+	Offset    int `json:"offset"`
+}
+
+// checkConsumer requires exactly one of -consumer and -group, offsets belong to
+// either a consumer or a group.
+func checkConsumer(consumerID, groupID string) error {
+	if (consumerID == "") == (groupID == "") {
+		return usageError("give either -consumer or -group")
+	}
+	return nil
+}
+
+func (c *cli) commitOffset(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("offsets commit", flag.ContinueOnError)
+	consumerID, groupID := consumerFlags(flags)
+	partition := flags.Int("partition", 0, "partition")
+	offset := flags.Int("offset", -1, "offset to commit")
+
+	positional, err := parse(flags, args, 1)
+	if err != nil {
+		return err
+	}
+	if err := checkConsumer(*consumerID, *groupID); err != nil {
+		return err
+	}
+	if *offset < 0 {
+		return usageError("offsets commit needs a non-negative -offset")
+	}
+
+	if err := c.client.CommitOffset(ctx, positional[0], *consumerID, *groupID, *partition, *offset); err != nil {
+		return err
+	}
+
+	committed := []offsetOutput{{Partition: *partition, Offset: *offset}}
+	return c.print(committed, []string{"PARTITION", "OFFSET"}, [][]string{{strconv.Itoa(*partition), strconv.Itoa(*offset)}})
+}
+
+// resetOffsets has the broker move the offsets of every partition, or of -partition
+// only, to the earliest or latest offset, to the first offset at or after an RFC
+// 3339 time, or by -shift messages. The broker refuses a consumer that is still
+// active unless -force is given.
+func (c *cli) resetOffsets(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("offsets reset", flag.ContinueOnError)
+	consumerID, groupID := consumerFlags(flags)
+	to := flags.String("to", "", "earliest, latest or an RFC 3339 time")
+	shift := flags.Int("shift", 0, "move the committed offset by n messages, negative to go back")
+	partition := flags.Int("partition", -1, "only reset this partition")
+	force := flags.Bool("force", false, "reset even if the consumer is active")
+
+	positional, err := parse(flags, args, 1)
+	if err != nil {
+		return err
+	}
+	if err := checkConsumer(*consumerID, *groupID); err != nil {
+		return err
+	}
+	if (*to == "") == (*shift == 0) {
+		return usageError("give either -to or -shift")
+	}
+
+	reset := client.OffsetReset{ConsumerID: *consumerID, GroupID: *groupID, To: *to, Force: *force}
+	switch *to {
+	case "earliest", "latest":
+	case "":
+		reset.To, reset.Shift = "shift", *shift
+	default:
+		since, err := time.Parse(time.RFC3339Nano, *to)
+		if err != nil {
+			return usageError("-to must be earliest, latest or an RFC 3339 time")
+		}
+		reset.To, reset.Timestamp = "timestamp", &since
+	}
+	if *partition >= 0 {
+		reset.Partition = partition
+	}
+
+	offsets, err := c.client.ResetOffsets(ctx, positional[0], reset)
+	if err != nil {
+		return err
+	}
+
+	committed := make([]offsetOutput, 0, len(offsets))
+	rows := make([][]string, 0, len(offsets))
+	for _, o := range offsets {
+		committed = append(committed, offsetOutput{Partition: o.Partition, Offset: o.Offset})
+		rows = append(rows, []string{strconv.Itoa(o.Partition), strconv.Itoa(o.Offset)})
+	}
+
+	return c.print(committed, []string{"PARTITION", "OFFSET"}, rows)
+}
+
+func (c *cli) lag(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("lag", flag.ContinueOnError)
+	consumerID, groupID := consumerFlags(flags)
+
+	positional, err := parse(flags, args, 1)
+	if err != nil {
+		return err
+	}
+	if err := checkConsumer(*consumerID, *groupID); err != nil {
+		return err
+	}
+
+	description, err := c.client.DescribeTopic(ctx, positional[0], *consumerID, *groupID)
+	if err != nil {
+		return err
+	}
+
+	total := 0
+	rows := make([][]string, 0, len(description.Partitions)+1)
+	for _, p := range description.Partitions {
+		total += *p.Lag
+		rows = append(rows, []string{strconv.Itoa(p.Partition), strconv.Itoa(p.LowWatermark), strconv.Itoa(p.HighWatermark), strconv.Itoa(*p.CommittedOffset), strconv.Itoa(*p.Lag)})
+	}
+	rows = append(rows, []string{"total", "", "", "", strconv.Itoa(total)})
+
+	return c.print(description.Partitions, []string{"PARTITION", "LOW", "HIGH", "COMMITTED", "LAG"}, rows)
+}
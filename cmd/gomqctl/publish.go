@@ -0,0 +1,102 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+
+	"github.com/codytheroux96/go-mq/client"
+)
+
+type publishResult struct {
+	MessageID string `json:"message_id,omitempty"`
+	Partition int    `json:"partition"`
+	Offset    int    `json:"offset"`
+	Error     string `json:"error,omitempty"`
+}
+
+// publish sends stdin, or -file, as one message, or with -lines every non-empty line
+// as a message of its own.
+func (c *cli) publish(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("publish", flag.ContinueOnError)
+	producerID := flags.String("producer", "gomqctl", "producer id")
+	key := flags.String("key", "", "partition key")
+	file := flags.String("file", "", "read from this file instead of stdin")
+	lines := flags.Bool("lines", false, "publish every line as a separate message")
+
+	positional, err := parse(flags, args, 1)
+	if err != nil {
+		return err
+	}
+	topic := positional[0]
+
+	input := c.stdin
+	if *file != "" {
+		f, err := os.Open(*file)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		input = f
+	}
+
+	var bodies [][]byte
+	if *lines {
+		scanner := bufio.NewScanner(input)
+		scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+		for scanner.Scan() {
+			if len(scanner.Bytes()) > 0 {
+				bodies = append(bodies, append([]byte(nil), scanner.Bytes()...))
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			return err
+		}
+	} else {
+		body, err := io.ReadAll(input)
+		if err != nil {
+			return err
+		}
+		bodies = append(bodies, body)
+	}
+
+	producer := c.client.NewProducer(client.ProducerConfig{ProducerID: *producerID})
+	results := make([]publishResult, len(bodies))
+	for i, body := range bodies {
+		err := producer.SendAsync(topic, client.ProducerMessage{Key: *key, Body: body}, func(result client.PublishResult, err error) {
+			results[i] = publishResult{MessageID: result.MessageID, Partition: result.Partition, Offset: result.Offset}
+			if err != nil {
+				results[i].Error = err.Error()
+			}
+		})
+		if err != nil {
+			return err
+		}
+	}
+	if err := producer.Close(ctx); err != nil {
+		return err
+	}
+
+	failed := 0
+	rows := make([][]string, 0, len(results))
+	for _, result := range results {
+		if result.Error != "" {
+			failed++
+			rows = append(rows, []string{"-", "-", "-", result.Error})
+			continue
+		}
+		rows = append(rows, []string{result.MessageID, strconv.Itoa(result.Partition), strconv.Itoa(result.Offset), ""})
+	}
+	if err := c.print(results, []string{"MESSAGE ID", "PARTITION", "OFFSET", "ERROR"}, rows); err != nil {
+		return err
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d messages failed to publish", failed, len(results))
+	}
+	return nil
+}
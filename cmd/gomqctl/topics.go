@@ -0,0 +1,183 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"strconv"
+	"time"
+
+	"github.com/codytheroux96/go-mq/client"
+)
+
+func (c *cli) listTopics(ctx context.Context, args []string) error {
+	if _, err := parse(flag.NewFlagSet("topics list", flag.ContinueOnError), args, 0); err != nil {
+		return err
+	}
+
+	topics, err := c.client.ListTopics(ctx)
+	if err != nil {
+		return err
+	}
+
+	rows := make([][]string, 0, len(topics))
+	for _, topic := range topics {
+		rows = append(rows, []string{topic})
+	}
+	return c.print(topics, []string{"TOPIC"}, rows)
+}
+
+func (c *cli) createTopic(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("topics create", flag.ContinueOnError)
+	partitions := flags.Int("partitions", 0, "number of partitions")
+	maxAge := flags.Duration("max-age", 0, "drop messages older than this")
+	maxBytes := flags.Int64("max-bytes", 0, "keep at most this many bytes per partition")
+	maxMessages := flags.Int("max-messages", 0, "keep at most this many messages per partition")
+	deadLetter := flags.String("dead-letter", "", "topic that takes messages out of delivery attempts")
+	maxAttempts := flags.Int("max-attempts", 0, "delivery attempts before a message is dead-lettered")
+	dedupWindow := flags.Duration("dedup-window", 0, "drop a publish whose dedup id was seen within this long")
+	dedupMaxIDs := flags.Int("dedup-max-ids", 0, "remember at most this many dedup ids")
+
+	positional, err := parse(flags, args, 1)
+	if err != nil {
+		return err
+	}
+	if (*deadLetter == "") != (*maxAttempts == 0) {
+		return usageError("-dead-letter and -max-attempts go together")
+	}
+
+	cfg := client.TopicConfig{Name: positional[0], Partitions: *partitions}
+	if *maxAge > 0 || *maxBytes > 0 || *maxMessages > 0 {
+		cfg.Retention = &client.RetentionPolicy{
+			MaxAgeMs:    maxAge.Milliseconds(),
+			MaxBytes:    *maxBytes,
+			MaxMessages: *maxMessages,
+		}
+	}
+	if *deadLetter != "" {
+		cfg.DeadLetter = &client.DeadLetterPolicy{MaxDeliveryAttempts: *maxAttempts, Topic: *deadLetter}
+	}
+	if *dedupWindow > 0 || *dedupMaxIDs > 0 {
+		cfg.Dedup = &client.DedupPolicy{WindowMs: dedupWindow.Milliseconds(), MaxIDs: *dedupMaxIDs}
+	}
+
+	if err := c.client.CreateTopic(ctx, cfg); err != nil {
+		return err
+	}
+	return c.printStatus(cfg.Name, "created")
+}
+
+func (c *cli) deleteTopic(ctx context.Context, args []string) error {
+	positional, err := parse(flag.NewFlagSet("topics delete", flag.ContinueOnError), args, 1)
+	if err != nil {
+		return err
+	}
+
+	if err := c.client.DeleteTopic(ctx, positional[0]); err != nil {
+		return err
+	}
+	return c.printStatus(positional[0], "deleted")
+}
+
+func (c *cli) describeTopic(ctx context.Context, args []string) error {
+	positional, err := parse(flag.NewFlagSet("topics describe", flag.ContinueOnError), args, 1)
+	if err != nil {
+		return err
+	}
+
+	description, err := c.client.DescribeTopic(ctx, positional[0], "", "")
+	if err != nil {
+		return err
+	}
+	if c.json {
+		return c.print(description, nil, nil)
+	}
+
+	retention := description.Retention
+	deadLetter := "none"
+	if description.DeadLetter.Topic != "" {
+		deadLetter = description.DeadLetter.Topic + " after " + strconv.Itoa(description.DeadLetter.MaxDeliveryAttempts) + " attempts"
+	}
+	dedup := "off"
+	if d := description.Dedup; d.WindowMs > 0 || d.MaxIDs > 0 {
+		dedup = "window " + limit(time.Duration(d.WindowMs)*time.Millisecond) + ", max ids " + limit(d.MaxIDs)
+	}
+	if err := c.print(nil, nil, [][]string{
+		{"Topic:", description.Topic},
+		{"Partitions:", strconv.Itoa(len(description.Partitions))},
+		{"Retention:", "max age " + limit(time.Duration(retention.MaxAgeMs)*time.Millisecond) + ", max bytes " + limit(retention.MaxBytes) + ", max messages " + limit(retention.MaxMessages)},
+		{"Dead letter:", deadLetter},
+		{"Dedup:", dedup},
+		{"", ""},
+	}); err != nil {
+		return err
+	}
+
+	rows := make([][]string, 0, len(description.Partitions))
+	for _, p := range description.Partitions {
+		rows = append(rows, []string{strconv.Itoa(p.Partition), strconv.Itoa(p.LowWatermark), strconv.Itoa(p.HighWatermark), strconv.Itoa(p.HighWatermark - p.LowWatermark)})
+	}
+	return c.print(nil, []string{"PARTITION", "LOW", "HIGH", "MESSAGES"}, rows)
+}
+
+func (c *cli) topicStats(ctx context.Context, args []string) error {
+	positional, err := parse(flag.NewFlagSet("topics stats", flag.ContinueOnError), args, 1)
+	if err != nil {
+		return err
+	}
+
+	stats, err := c.client.TopicStats(ctx, positional[0])
+	if err != nil {
+		return err
+	}
+	if c.json {
+		return c.print(stats, nil, nil)
+	}
+
+	rows := make([][]string, 0, len(stats.Partitions)+1)
+	for _, p := range stats.Partitions {
+		rows = append(rows, []string{strconv.Itoa(p.Partition), strconv.Itoa(p.MessageCount), strconv.FormatInt(p.Bytes, 10), strconv.Itoa(p.EarliestOffset), strconv.Itoa(p.LatestOffset), timestamp(p.EarliestTimestamp), timestamp(p.LatestTimestamp)})
+	}
+	rows = append(rows, []string{"total", strconv.Itoa(stats.MessageCount), strconv.FormatInt(stats.Bytes, 10), "", "", timestamp(stats.EarliestTimestamp), timestamp(stats.LatestTimestamp)})
+	return c.print(nil, []string{"PARTITION", "MESSAGES", "BYTES", "EARLIEST", "LATEST", "OLDEST", "NEWEST"}, rows)
+}
+
+func (c *cli) topicConsumers(ctx context.Context, args []string) error {
+	positional, err := parse(flag.NewFlagSet("topics consumers", flag.ContinueOnError), args, 1)
+	if err != nil {
+		return err
+	}
+
+	consumers, err := c.client.ListConsumers(ctx, positional[0])
+	if err != nil {
+		return err
+	}
+
+	rows := make([][]string, 0, len(consumers))
+	for _, consumer := range consumers {
+		kind := "consumer"
+		if consumer.Group {
+			kind = "group"
+		}
+		rows = append(rows, []string{consumer.ID, kind, strconv.Itoa(consumer.Lag), strconv.Itoa(consumer.Unacked), strconv.Itoa(consumer.PendingRedelivery), timestamp(consumer.LastFetch)})
+	}
+	return c.print(consumers, []string{"ID", "KIND", "LAG", "UNACKED", "PENDING", "LAST FETCH"}, rows)
+}
+
+// timestamp renders an optional time, a dash when there is none.
+func timestamp(t *time.Time) string {
+	if t == nil {
+		return "-"
+	}
+	return t.Format(time.RFC3339)
+}
+
+// limit renders a retention limit, where zero means there is none.
+func limit[T int | int64 | time.Duration](v T) string {
+	if v == 0 {
+		return "unlimited"
+	}
+	if d, ok := any(v).(time.Duration); ok {
+		return d.String()
+	}
+	return strconv.FormatInt(int64(v), 10)
+}
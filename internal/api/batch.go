@@ -0,0 +1,168 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/codytheroux96/go-mq/internal/broker"
+	"github.com/codytheroux96/go-mq/internal/core"
+)
+
+// maxBatchSize is the most messages a single batch publish may carry.
+const maxBatchSize = 10000
+
+type batchEntry struct {
+	Body       string `json:"body"`
+	ProducerID string `json:"producer_id"`
+	Key        string `json:"key"`
+	ID         string `json:"id"`
+	DedupID    string `json:"dedup_id"`
+}
+
+type batchResult struct {
+	MessageID string `json:"message_id,omitempty"`
+	Partition *int   `json:"partition,omitempty"`
+	Offset    *int   `json:"offset,omitempty"`
+	Duplicate bool   `json:"duplicate,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+// HandlePublishBatch publishes many messages to one topic in a single request. The
+// body is either a JSON array of messages or, with Content-Type application/x-ndjson,
+// one message per line. With ?atomic=true every message is appended or none is,
+// otherwise each message succeeds or fails on its own. The response holds one result
+// per message, in request order, and counts the messages published, those the
+// topic's dedup window dropped as duplicates and those that failed.
+func (h *Handler) HandlePublishBatch(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		h.App.Logger.Warn("http method not allowed for batch publishing to a topic", "method", r.Method)
+		http.Error(w, "http method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	topicName := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/publish/"), "/batch")
+	if topicName == "" {
+		h.App.Logger.Warn("missing topic name in request to batch publish to a topic")
+		http.Error(w, "topic name is required to publish to a topic", http.StatusBadRequest)
+		return
+	}
+
+	atomic := false
+	if raw := r.URL.Query().Get("atomic"); raw != "" {
+		parsed, err := strconv.ParseBool(raw)
+		if err != nil {
+			h.App.Logger.Warn("invalid atomic parameter in batch publish request", "atomic", raw)
+			http.Error(w, "atomic must be true or false", http.StatusBadRequest)
+			return
+		}
+		atomic = parsed
+	}
+
+	var entries []batchEntry
+	var err error
+	switch r.Header.Get("Content-Type") {
+	case "application/json":
+		err = json.NewDecoder(r.Body).Decode(&entries)
+	case "application/x-ndjson":
+		entries, err = decodeNDJSON(r.Body)
+	default:
+		h.App.Logger.Warn("invalid content-type received", "received", r.Header.Get("Content-Type"))
+		http.Error(w, "Content-Type must be application/json or application/x-ndjson", http.StatusUnsupportedMediaType)
+		return
+	}
+	if err != nil || len(entries) == 0 {
+		h.App.Logger.Error("failed to decode batch publish request", "topic", topicName, "error", err)
+		http.Error(w, "invalid payload in request", http.StatusBadRequest)
+		return
+	}
+	if len(entries) > maxBatchSize {
+		h.App.Logger.Warn("batch publish request too large", "topic", topicName, "messages", len(entries))
+		http.Error(w, "a batch holds at most "+strconv.Itoa(maxBatchSize)+" messages", http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	results := make([]batchResult, len(entries))
+	msgs := make([]*core.Message, 0, len(entries))
+	positions := make([]int, 0, len(entries))
+	for i, entry := range entries {
+		if entry.Body == "" || entry.ProducerID == "" {
+			if atomic {
+				h.App.Logger.Warn("invalid message in atomic batch", "topic", topicName, "index", i)
+				http.Error(w, "message "+strconv.Itoa(i)+" needs a body and a producer_id", http.StatusBadRequest)
+				return
+			}
+			results[i].Error = "body and producer_id are required"
+			continue
+		}
+
+		msg := core.NewMessage([]byte(entry.Body), entry.ProducerID)
+		msg.Key = entry.Key
+		msg.ID = entry.ID
+		if entry.DedupID != "" {
+			msg.Metadata[broker.MetaDedupID] = entry.DedupID
+		}
+		msgs = append(msgs, msg)
+		positions = append(positions, i)
+	}
+
+	errs, err := h.App.Broker.PublishBatch(topicName, msgs, atomic)
+	if err != nil {
+		if strings.Contains(err.Error(), "does not exist") {
+			h.App.Logger.Error("attempting to publish to a topic that does not exist", "topic", topicName)
+			http.Error(w, "topic requested to publish to does not exist", http.StatusNotFound)
+			return
+		}
+		h.App.Logger.Error("failed to publish batch to topic", "topic", topicName, "error", err)
+		http.Error(w, "failed to publish batch to topic", http.StatusInternalServerError)
+		return
+	}
+
+	failed, duplicates := len(entries)-len(msgs), 0
+	for j, msg := range msgs {
+		result := &results[positions[j]]
+		if errors.Is(errs[j], broker.ErrDuplicateMessage) {
+			result.Duplicate, errs[j] = true, nil
+			duplicates++
+		}
+		if errs[j] != nil {
+			failed++
+			result.Error = errs[j].Error()
+			continue
+		}
+		result.MessageID = msg.ID
+		result.Partition = &msg.Partition
+		result.Offset = &msg.Offset
+	}
+
+	h.App.Logger.Info("batch published to topic", "topic", topicName, "messages", len(entries), "failed", failed, "duplicates", duplicates, "atomic", atomic)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(map[string]any{
+		"published":  len(entries) - failed - duplicates,
+		"duplicates": duplicates,
+		"failed":     failed,
+		"results":    results,
+	})
+}
+
+// decodeNDJSON reads one message per line, blank lines are skipped.
+func decodeNDJSON(r io.Reader) ([]batchEntry, error) {
+	decoder := json.NewDecoder(r)
+	var entries []batchEntry
+	for {
+		var entry batchEntry
+		err := decoder.Decode(&entry)
+		if errors.Is(err, io.EOF) {
+			return entries, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+}
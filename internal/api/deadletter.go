@@ -0,0 +1,146 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/codytheroux96/go-mq/internal/core"
+)
+
+const defaultRedriveLimit = 100
+
+type deadLetterPayload struct {
+	MaxDeliveryAttempts int    `json:"max_delivery_attempts"`
+	Topic               string `json:"topic"`
+}
+
+func (p deadLetterPayload) policy() core.DeadLetterPolicy {
+	return core.DeadLetterPolicy{
+		MaxDeliveryAttempts: p.MaxDeliveryAttempts,
+		Topic:               p.Topic,
+	}
+}
+
+// valid accepts an empty payload, which turns dead-lettering off, or an attempt
+// count together with a dead-letter topic other than the topic itself.
+func (p deadLetterPayload) valid(topic string) bool {
+	if p.MaxDeliveryAttempts == 0 && p.Topic == "" {
+		return true
+	}
+	return p.MaxDeliveryAttempts > 0 && p.Topic != "" && p.Topic != topic
+}
+
+func (h *Handler) HandleTopicDeadLetter(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodPut {
+		h.App.Logger.Warn("http method not allowed for topic dead-letter settings", "method", r.Method)
+		http.Error(w, "http method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	topicName := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/topics/"), "/dead-letter")
+	if topicName == "" {
+		h.App.Logger.Warn("missing topic name in dead-letter request")
+		http.Error(w, "topic name is required for dead-letter request", http.StatusBadRequest)
+		return
+	}
+
+	cfg, err := h.App.Repo.GetTopicConfig(topicName)
+	if err != nil {
+		if strings.Contains(err.Error(), "does not exist") {
+			h.App.Logger.Warn("dead-letter settings requested for topic that does not exist", "topic", topicName)
+			http.Error(w, "topic does not exist", http.StatusNotFound)
+			return
+		}
+		h.App.Logger.Error("failed to get topic config", "topic", topicName, "error", err)
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	if r.Method == http.MethodPut {
+		if r.Header.Get("Content-Type") != "application/json" {
+			h.App.Logger.Warn("invalid content-type received", "received", r.Header.Get("Content-Type"))
+			http.Error(w, "Content-Type must be application/json", http.StatusUnsupportedMediaType)
+			return
+		}
+
+		var req deadLetterPayload
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || !req.valid(topicName) {
+			h.App.Logger.Error("invalid dead-letter request payload", "topic", topicName, "error", err)
+			http.Error(w, "invalid payload in request", http.StatusBadRequest)
+			return
+		}
+
+		cfg, err = h.App.Broker.UpdateTopicConfig(topicName, func(cfg *core.TopicConfig) {
+			cfg.DeadLetter = req.policy()
+		})
+		if err != nil {
+			if strings.Contains(err.Error(), "does not exist") {
+				h.App.Logger.Warn("dead-letter settings update for topic that no longer exists", "topic", topicName)
+				http.Error(w, "topic does not exist", http.StatusNotFound)
+				return
+			}
+			h.App.Logger.Error("failed to update topic dead-letter settings", "topic", topicName, "error", err)
+			http.Error(w, "failed to update topic dead-letter settings", http.StatusInternalServerError)
+			return
+		}
+
+		h.App.Logger.Info("topic dead-letter settings updated", "topic", topicName, "dead_letter_topic", cfg.DeadLetter.Topic)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]any{
+		"topic":       topicName,
+		"dead_letter": deadLetterPayload(cfg.DeadLetter),
+	})
+}
+
+// HandleRedrive sends the messages of a dead-letter topic back to the topics they
+// were dead-lettered from. An optional JSON body {"limit": n} bounds the batch.
+func (h *Handler) HandleRedrive(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		h.App.Logger.Warn("http method not allowed for redriving a dead-letter topic", "method", r.Method)
+		http.Error(w, "http method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	topicName := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/topics/"), "/redrive")
+	if topicName == "" {
+		h.App.Logger.Warn("missing topic name in redrive request")
+		http.Error(w, "topic name is required for redrive request", http.StatusBadRequest)
+		return
+	}
+
+	req := struct {
+		Limit int `json:"limit"`
+	}{Limit: defaultRedriveLimit}
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Limit <= 0 {
+			h.App.Logger.Error("invalid redrive request payload", "topic", topicName, "error", err)
+			http.Error(w, "invalid payload in request", http.StatusBadRequest)
+			return
+		}
+	}
+
+	redriven, err := h.App.Broker.Redrive(topicName, req.Limit)
+	if err != nil {
+		if strings.Contains(err.Error(), "does not exist") {
+			h.App.Logger.Warn("redrive requested for topic that does not exist", "topic", topicName, "error", err)
+			http.Error(w, "topic does not exist", http.StatusNotFound)
+			return
+		}
+		h.App.Logger.Error("failed to redrive dead-letter topic", "topic", topicName, "redriven", redriven, "error", err)
+		http.Error(w, "failed to redrive dead-letter topic", http.StatusInternalServerError)
+		return
+	}
+
+	h.App.Logger.Info("dead-letter topic redriven", "topic", topicName, "redriven", redriven)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]any{
+		"topic":    topicName,
+		"redriven": redriven,
+	})
+}
@@ -0,0 +1,118 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/codytheroux96/go-mq/internal/broker"
+	"github.com/codytheroux96/go-mq/internal/core"
+)
+
+// DedupIDHeader names a published message for the topic's dedup window, the
+// Idempotency-Key header the Go client sends is taken the same way.
+const (
+	DedupIDHeader        = "Dedup-Id"
+	IdempotencyKeyHeader = "Idempotency-Key"
+)
+
+type dedupPayload struct {
+	WindowMs int64 `json:"window_ms"`
+	MaxIDs   int   `json:"max_ids"`
+}
+
+func (p dedupPayload) policy() core.DedupPolicy {
+	return core.DedupPolicy{
+		Window: time.Duration(p.WindowMs) * time.Millisecond,
+		MaxIDs: p.MaxIDs,
+	}
+}
+
+func (p dedupPayload) valid() bool {
+	return p.WindowMs >= 0 && p.MaxIDs >= 0
+}
+
+func newDedupPayload(policy core.DedupPolicy) dedupPayload {
+	return dedupPayload{
+		WindowMs: policy.Window.Milliseconds(),
+		MaxIDs:   policy.MaxIDs,
+	}
+}
+
+// setDedupID records the ID a published message is deduplicated on, from the
+// Dedup-Id header or else the Idempotency-Key header.
+func setDedupID(msg *core.Message, r *http.Request) {
+	id := r.Header.Get(DedupIDHeader)
+	if id == "" {
+		id = r.Header.Get(IdempotencyKeyHeader)
+	}
+	if id != "" {
+		msg.Metadata[broker.MetaDedupID] = id
+	}
+}
+
+func (h *Handler) HandleTopicDedup(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodPut {
+		h.App.Logger.Warn("http method not allowed for topic dedup settings", "method", r.Method)
+		http.Error(w, "http method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	topicName := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/topics/"), "/dedup")
+	if topicName == "" {
+		h.App.Logger.Warn("missing topic name in dedup request")
+		http.Error(w, "topic name is required for dedup request", http.StatusBadRequest)
+		return
+	}
+
+	cfg, err := h.App.Repo.GetTopicConfig(topicName)
+	if err != nil {
+		if strings.Contains(err.Error(), "does not exist") {
+			h.App.Logger.Warn("dedup settings requested for topic that does not exist", "topic", topicName)
+			http.Error(w, "topic does not exist", http.StatusNotFound)
+			return
+		}
+		h.App.Logger.Error("failed to get topic config", "topic", topicName, "error", err)
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	if r.Method == http.MethodPut {
+		if r.Header.Get("Content-Type") != "application/json" {
+			h.App.Logger.Warn("invalid content-type received", "received", r.Header.Get("Content-Type"))
+			http.Error(w, "Content-Type must be application/json", http.StatusUnsupportedMediaType)
+			return
+		}
+
+		var req dedupPayload
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || !req.valid() {
+			h.App.Logger.Error("invalid dedup request payload", "topic", topicName, "error", err)
+			http.Error(w, "invalid payload in request", http.StatusBadRequest)
+			return
+		}
+
+		cfg, err = h.App.Broker.UpdateTopicConfig(topicName, func(cfg *core.TopicConfig) {
+			cfg.Dedup = req.policy()
+		})
+		if err != nil {
+			if strings.Contains(err.Error(), "does not exist") {
+				h.App.Logger.Warn("dedup settings update for topic that no longer exists", "topic", topicName)
+				http.Error(w, "topic does not exist", http.StatusNotFound)
+				return
+			}
+			h.App.Logger.Error("failed to update topic dedup settings", "topic", topicName, "error", err)
+			http.Error(w, "failed to update topic dedup settings", http.StatusInternalServerError)
+			return
+		}
+
+		h.App.Logger.Info("topic dedup settings updated", "topic", topicName, "window", cfg.Dedup.Window, "max_ids", cfg.Dedup.MaxIDs)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]any{
+		"topic": topicName,
+		"dedup": newDedupPayload(cfg.Dedup),
+	})
+}
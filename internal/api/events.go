@@ -0,0 +1,276 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/codytheroux96/go-mq/internal/broker"
+	"github.com/codytheroux96/go-mq/internal/core"
+	"github.com/codytheroux96/go-mq/internal/repository"
+)
+
+const (
+	eventKeepAliveInterval = 15 * time.Second
+	eventCatchUpBatch      = 100
+)
+
+// streamEvents is the Server-Sent Events variant of HandleSubscribe. Every message is
+// sent as a "message" event whose id is "<message id>:<partition>:<offset>", and
+// acked once it is flushed so the event is not redelivered. The consumer's, or
+// group's, committed offsets follow the events sent, so a reconnect carrying
+// Last-Event-ID moves past that event and catches up on every partition the
+// consumer reads from its committed offset, then continues with live messages.
+func (h *Handler) streamEvents(w http.ResponseWriter, r *http.Request, topicName, consumerID, groupID string) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		h.App.Logger.Error("response writer does not support streaming events")
+		http.Error(w, "streaming is not supported", http.StatusInternalServerError)
+		return
+	}
+
+	var resume *eventID
+	if raw := r.Header.Get("Last-Event-ID"); raw != "" {
+		id, err := parseEventID(raw)
+		if err != nil {
+			h.App.Logger.Warn("invalid Last-Event-ID in subscribe request", "last_event_id", raw, "error", err)
+			http.Error(w, "invalid Last-Event-ID", http.StatusBadRequest)
+			return
+		}
+		resume = &id
+	}
+
+	inbox, ok := h.subscribe(w, r, topicName, consumerID, groupID)
+	if !ok {
+		return
+	}
+	defer h.unsubscribe(topicName, consumerID, groupID, inbox)
+
+	offsetKey := consumerID
+	if groupID != "" {
+		offsetKey = groupID
+	}
+
+	var partitions []int
+	if resume != nil {
+		err := h.resumeEvents(topicName, offsetKey, *resume)
+		if err == nil {
+			partitions, err = h.eventPartitions(topicName, consumerID, groupID)
+		}
+		if err != nil {
+			if strings.Contains(err.Error(), "does not exist") || strings.Contains(err.Error(), "beyond the partition length") {
+				h.App.Logger.Warn("Last-Event-ID does not match the topic", "topic", topicName, "consumer", consumerID, "error", err)
+				http.Error(w, "Last-Event-ID does not match the topic", http.StatusBadRequest)
+				return
+			}
+			h.App.Logger.Error("failed to catch up from Last-Event-ID", "topic", topicName, "consumer", consumerID, "error", err)
+			http.Error(w, "internal server error", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	// offset committed per partition, so live messages that were already sent
+	// while catching up are not sent twice
+	committed := map[int]int{}
+
+	caughtUp := 0
+	for _, partition := range partitions {
+		n, err := h.catchUpEvents(w, flusher, topicName, partition, consumerID, groupID, committed)
+		caughtUp += n
+		if err != nil {
+			h.App.Logger.Warn("failed to catch up event stream", "topic", topicName, "consumer", consumerID, "partition", partition, "error", err)
+			return
+		}
+	}
+
+	h.App.Logger.Info("event stream opened", "topic", topicName, "consumer", consumerID, "group", groupID, "catch_up", caughtUp)
+
+	interval := eventKeepAliveInterval
+	if groupID != "" && h.App.Broker.SessionTimeout/3 < interval {
+		interval = h.App.Broker.SessionTimeout / 3
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			h.App.Logger.Info("event stream closed", "topic", topicName, "consumer", consumerID)
+			return
+		case msg, ok := <-inbox:
+			if !ok {
+				h.App.Logger.Warn("slow consumer disconnected from event stream", "topic", topicName, "consumer", consumerID)
+				fmt.Fprint(w, "event: error\ndata: consumer was disconnected for falling behind\n\n")
+				flusher.Flush()
+				return
+			}
+			fresh := msg.DeliveryAttempts == 1
+			if last, ok := committed[msg.Partition]; ok && msg.Offset < last && fresh {
+				h.settleEvent(topicName, consumerID, msg)
+				continue
+			}
+			if err := writeEvent(w, msg); err != nil {
+				h.App.Logger.Warn("failed to write event", "topic", topicName, "consumer", consumerID, "error", err)
+				return
+			}
+			flusher.Flush()
+			h.settleEvent(topicName, consumerID, msg)
+			if fresh && msg.Offset >= committed[msg.Partition] {
+				if err := h.App.Repo.CommitOffset(topicName, msg.Partition, offsetKey, msg.Offset+1); err != nil {
+					h.App.Logger.Warn("failed to commit event stream offset", "topic", topicName, "partition", msg.Partition, "consumer", offsetKey, "error", err)
+				} else {
+					committed[msg.Partition] = msg.Offset + 1
+				}
+			}
+		case <-ticker.C:
+			if groupID != "" {
+				if _, err := h.App.Broker.Heartbeat(groupID, consumerID); err != nil {
+					h.App.Logger.Warn("event stream member lost its group", "group", groupID, "consumer", consumerID, "error", err)
+					return
+				}
+			}
+			if _, err := fmt.Fprint(w, ": keep-alive\n\n"); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
+
+// resumeEvents moves the committed offset past the last event the client saw, or
+// to the earliest retained offset when retention has passed it.
+func (h *Handler) resumeEvents(topicName, offsetKey string, resume eventID) error {
+	err := h.App.Repo.CommitOffset(topicName, resume.Partition, offsetKey, resume.Offset+1)
+	var outOfRange *repository.OffsetOutOfRangeError
+	if errors.As(err, &outOfRange) {
+		err = h.App.Repo.CommitOffset(topicName, resume.Partition, offsetKey, outOfRange.LowWatermark)
+	}
+	return err
+}
+
+// eventPartitions returns the partitions a resumed stream catches up on, the
+// member's assigned ones in a group and all of the topic's otherwise.
+func (h *Handler) eventPartitions(topicName, consumerID, groupID string) ([]int, error) {
+	if groupID == "" {
+		return h.topicPartitions(topicName)
+	}
+	assignment, err := h.App.Broker.Heartbeat(groupID, consumerID)
+	if err != nil {
+		return nil, err
+	}
+	return assignment.Partitions, nil
+}
+
+// catchUpEvents sends everything after the committed offset on one partition, in
+// pages of eventCatchUpBatch, committing each page once it is written. It records
+// the offset it committed in committed and returns how many events it sent. Live
+// events only carry committed transactional messages, so the catch-up reads
+// committed messages too.
+func (h *Handler) catchUpEvents(w http.ResponseWriter, flusher http.Flusher, topicName string, partition int, consumerID, groupID string, committed map[int]int) (int, error) {
+	offsetKey := consumerID
+	if groupID != "" {
+		offsetKey = groupID
+	}
+
+	sent := 0
+	for {
+		offset, err := h.App.Repo.GetOffset(topicName, partition, offsetKey)
+		if err != nil {
+			return sent, err
+		}
+		committed[partition] = offset
+
+		batch, fresh, err := h.App.Broker.Fetch(topicName, partition, groupID, consumerID, eventCatchUpBatch, broker.ReadCommitted)
+		if err != nil {
+			return sent, err
+		}
+		if len(batch) == 0 && fresh == 0 {
+			return sent, nil
+		}
+		for _, msg := range batch {
+			if err := writeEvent(w, msg); err != nil {
+				return sent, err
+			}
+			sent++
+		}
+		flusher.Flush()
+		for _, msg := range batch {
+			h.settleEvent(topicName, consumerID, msg)
+		}
+
+		if err := h.App.Repo.CommitOffset(topicName, partition, offsetKey, offset+fresh); err != nil {
+			return sent, err
+		}
+	}
+}
+
+// settleEvent acks a message once its event is flushed, or skipped as already
+// sent, so it is not redelivered when the visibility timeout passes.
+func (h *Handler) settleEvent(topicName, consumerID string, msg *core.Message) {
+	if _, err := h.App.Broker.Ack(topicName, consumerID, msg.ID); err != nil {
+		h.App.Logger.Warn("failed to ack event", "topic", topicName, "consumer", consumerID, "message_id", msg.ID, "error", err)
+	}
+}
+
+type eventID struct {
+	MessageID string
+	Partition int
+	Offset    int
+}
+
+func (id eventID) String() string {
+	return fmt.Sprintf("%s:%d:%d", id.MessageID, id.Partition, id.Offset)
+}
+
+// parseEventID reads the partition and offset from the right, so message IDs that
+// contain colons still parse.
+func parseEventID(raw string) (eventID, error) {
+	rest, offsetPart, ok := cutLast(raw, ":")
+	if !ok {
+		return eventID{}, fmt.Errorf("event id %q has no offset", raw)
+	}
+	messageID, partitionPart, ok := cutLast(rest, ":")
+	if !ok {
+		return eventID{}, fmt.Errorf("event id %q has no partition", raw)
+	}
+
+	partition, err := strconv.Atoi(partitionPart)
+	if err != nil || partition < 0 {
+		return eventID{}, fmt.Errorf("event id %q has an invalid partition", raw)
+	}
+	offset, err := strconv.Atoi(offsetPart)
+	if err != nil || offset < 0 {
+		return eventID{}, fmt.Errorf("event id %q has an invalid offset", raw)
+	}
+
+	return eventID{MessageID: messageID, Partition: partition, Offset: offset}, nil
+}
+
+func cutLast(s, sep string) (string, string, bool) {
+	i := strings.LastIndex(s, sep)
+	if i < 0 {
+		return s, "", false
+	}
+	return s[:i], s[i+len(sep):], true
+}
+
+func writeEvent(w http.ResponseWriter, msg *core.Message) error {
+	data, err := json.Marshal(messagePayload(msg))
+	if err != nil {
+		return err
+	}
+
+	id := eventID{MessageID: msg.ID, Partition: msg.Partition, Offset: msg.Offset}
+	_, err = fmt.Fprintf(w, "id: %s\nevent: message\ndata: %s\n\n", id, data)
+	return err
+}
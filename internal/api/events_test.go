@@ -0,0 +1,297 @@
+package api
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/codytheroux96/go-mq/internal/app"
+)
+
+func TestParseEventID(t *testing.T) {
+	tests := []struct {
+		name      string
+		raw       string
+		expectErr bool
+		expect    eventID
+	}{
+		{"Valid id", "abc:1:42", false, eventID{MessageID: "abc", Partition: 1, Offset: 42}},
+		{"Message id containing colons", "a:b:0:7", false, eventID{MessageID: "a:b", Partition: 0, Offset: 7}},
+		{"Missing partition", "abc:42", true, eventID{}},
+		{"Negative offset", "abc:0:-1", true, eventID{}},
+		{"Not a number", "abc:x:1", true, eventID{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseEventID(tt.raw)
+			if tt.expectErr != (err != nil) {
+				t.Fatalf("expected error %v, got %v", tt.expectErr, err)
+			}
+			if got != tt.expect {
+				t.Fatalf("expected %+v, got %+v", tt.expect, got)
+			}
+		})
+	}
+}
+
+func TestSubscribeEvents(t *testing.T) {
+	server := httptest.NewServer(setupTestServer())
+	defer server.Close()
+
+	resp, err := http.Post(server.URL+"/topics", "application/json", strings.NewReader(`{"name":"events-topic"}`))
+	if err != nil {
+		t.Fatalf("failed to create topic: %v", err)
+	}
+	resp.Body.Close()
+
+	publish := func(body string) string {
+		t.Helper()
+		resp, err := http.Post(server.URL+"/publish/events-topic", "application/json", strings.NewReader(`{"body":"`+body+`","producer_id":"p1"}`))
+		if err != nil {
+			t.Fatalf("failed to publish: %v", err)
+		}
+		defer resp.Body.Close()
+		var published struct {
+			MessageID string `json:"message_id"`
+		}
+		json.NewDecoder(resp.Body).Decode(&published)
+		return published.MessageID
+	}
+
+	firstID := publish("zero")
+	publish("one")
+	publish("two")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+"/subscribe/events-topic?consumer_id=c1", nil)
+	req.Header.Set("Accept", "text/event-stream")
+	req.Header.Set("Last-Event-ID", firstID+":0:0")
+	resp, err = http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("failed to open event stream: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Content-Type") != "text/event-stream" {
+		t.Fatalf("expected an event stream, got %d %s", resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
+
+	reader := bufio.NewReader(resp.Body)
+	readEvent := func() (string, map[string]any) {
+		t.Helper()
+		var id string
+		var data map[string]any
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				t.Fatalf("failed to read event: %v", err)
+			}
+			line = strings.TrimRight(line, "\n")
+			switch {
+			case line == "" && id != "":
+				return id, data
+			case strings.HasPrefix(line, "id: "):
+				id = strings.TrimPrefix(line, "id: ")
+			case strings.HasPrefix(line, "data: "):
+				json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &data)
+			}
+		}
+	}
+
+	for offset, expected := range []string{"one", "two"} {
+		id, data := readEvent()
+		if data["body"] != expected {
+			t.Fatalf("expected caught up event %q, got %v", expected, data)
+		}
+		if want := fmt.Sprintf("%s:0:%d", data["message_id"], offset+1); id != want {
+			t.Fatalf("expected event id %q, got %q", want, id)
+		}
+	}
+
+	publish("three")
+	id, data := readEvent()
+	if data["body"] != "three" || !strings.HasSuffix(id, ":0:3") {
+		t.Fatalf("expected live event at offset 3, got %q %v", id, data)
+	}
+
+	req, _ = http.NewRequest(http.MethodGet, server.URL+"/subscribe/events-topic?consumer_id=c2", nil)
+	req.Header.Set("Accept", "text/event-stream")
+	req.Header.Set("Last-Event-ID", "garbage")
+	resp, err = http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("failed to send request: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("expected 400 for invalid Last-Event-ID, got %d", resp.StatusCode)
+	}
+}
+
+func TestSubscribeEventsCatchesUpEveryPartition(t *testing.T) {
+	server := httptest.NewServer(setupTestServer())
+	defer server.Close()
+
+	resp, err := http.Post(server.URL+"/topics", "application/json", strings.NewReader(`{"name":"paged-events","partitions":2}`))
+	if err != nil {
+		t.Fatalf("failed to create topic: %v", err)
+	}
+	resp.Body.Close()
+
+	// unkeyed messages alternate between the partitions, so each gets more than a
+	// page of them
+	var firstID string
+	total := 2*eventCatchUpBatch + 2
+	for i := range total {
+		resp, err := http.Post(server.URL+"/publish/paged-events", "application/json", strings.NewReader(fmt.Sprintf(`{"body":"%d","producer_id":"p1"}`, i)))
+		if err != nil {
+			t.Fatalf("failed to publish: %v", err)
+		}
+		if i == 0 {
+			var published struct {
+				MessageID string `json:"message_id"`
+				Partition int    `json:"partition"`
+			}
+			json.NewDecoder(resp.Body).Decode(&published)
+			if published.Partition != 0 {
+				t.Fatalf("expected the first message on partition 0, got %d", published.Partition)
+			}
+			firstID = published.MessageID
+		}
+		resp.Body.Close()
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+"/subscribe/paged-events?consumer_id=c1", nil)
+	req.Header.Set("Accept", "text/event-stream")
+	req.Header.Set("Last-Event-ID", firstID+":0:0")
+	resp, err = http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("failed to open event stream: %v", err)
+	}
+	defer resp.Body.Close()
+
+	seen := map[string]bool{}
+	reader := bufio.NewReader(resp.Body)
+	for len(seen) < total-1 {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			t.Fatalf("failed to read event after %d events: %v", len(seen), err)
+		}
+		if id, ok := strings.CutPrefix(strings.TrimRight(line, "\n"), "id: "); ok {
+			if seen[id] {
+				t.Fatalf("expected every event once, got %s again", id)
+			}
+			seen[id] = true
+		}
+	}
+	if seen[firstID+":0:0"] {
+		t.Fatalf("expected the event named by Last-Event-ID not to be sent again")
+	}
+	for _, id := range []string{":0:1", fmt.Sprintf(":0:%d", total/2-1), ":1:0", fmt.Sprintf(":1:%d", total/2-1)} {
+		found := false
+		for seenID := range seen {
+			found = found || strings.HasSuffix(seenID, id)
+		}
+		if !found {
+			t.Fatalf("expected an event ending in %s among the catch-up", id)
+		}
+	}
+}
+
+func TestSubscribeEventsAreNotRedelivered(t *testing.T) {
+	a := app.NewApplication()
+	server := httptest.NewServer(Routes(a))
+	defer func() {
+		server.Close()
+		a.Close()
+	}()
+
+	resp, err := http.Post(server.URL+"/topics", "application/json", strings.NewReader(`{"name":"acked-events"}`))
+	if err != nil {
+		t.Fatalf("failed to create topic: %v", err)
+	}
+	resp.Body.Close()
+	publish := func(body string) string {
+		t.Helper()
+		resp, err := http.Post(server.URL+"/publish/acked-events", "application/json", strings.NewReader(`{"body":"`+body+`","producer_id":"p1"}`))
+		if err != nil {
+			t.Fatalf("failed to publish: %v", err)
+		}
+		defer resp.Body.Close()
+		var published struct {
+			MessageID string `json:"message_id"`
+		}
+		json.NewDecoder(resp.Body).Decode(&published)
+		return published.MessageID
+	}
+
+	firstID := publish("zero")
+	publish("one")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+"/subscribe/acked-events?consumer_id=c1", nil)
+	req.Header.Set("Accept", "text/event-stream")
+	req.Header.Set("Last-Event-ID", firstID+":0:0")
+	resp, err = http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("failed to open event stream: %v", err)
+	}
+	defer resp.Body.Close()
+
+	ids := make(chan string, 10)
+	go func() {
+		reader := bufio.NewReader(resp.Body)
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				close(ids)
+				return
+			}
+			if id, ok := strings.CutPrefix(strings.TrimRight(line, "\n"), "id: "); ok {
+				ids <- id
+			}
+		}
+	}()
+
+	// one event from the catch-up, one live
+	if id := <-ids; !strings.HasSuffix(id, ":0:1") {
+		t.Fatalf("expected the caught up event at offset 1, got %q", id)
+	}
+	publish("two")
+	if id := <-ids; !strings.HasSuffix(id, ":0:2") {
+		t.Fatalf("expected the live event at offset 2, got %q", id)
+	}
+
+	for deadline := time.Now().Add(time.Second); ; time.Sleep(5 * time.Millisecond) {
+		consumers, err := a.Broker.DescribeConsumers("acked-events")
+		if err != nil {
+			t.Fatalf("failed to describe consumers: %v", err)
+		}
+		if len(consumers) == 1 && consumers[0].Unacked == 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected the sent events to be acked, got %+v", consumers)
+		}
+	}
+
+	if expired := a.Broker.RedeliverExpired(time.Now().Add(2 * a.Broker.VisibilityTimeout)); expired != 0 {
+		t.Fatalf("expected no events to expire, got %d", expired)
+	}
+	select {
+	case id := <-ids:
+		t.Fatalf("expected no event to be sent again, got %q", id)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
@@ -0,0 +1,42 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/codytheroux96/go-mq/internal/filter"
+)
+
+// FilterHeader registers a filter expression, such as region == "eu" && priority > 3,
+// for the fetching consumer or its group. It stays in place for later fetches and
+// pushes until another filter replaces it.
+const FilterHeader = "X-Filter"
+
+// setFilter parses expr and registers it for target, a consumer or group, on the
+// topic. An empty expr removes the target's filter. On failure the error response
+// has been written and ok is false.
+func (h *Handler) setFilter(w http.ResponseWriter, topic, target, expr string) bool {
+	var f *filter.Filter
+	if expr != "" {
+		var err error
+		if f, err = filter.Parse(expr); err != nil {
+			h.App.Logger.Warn("invalid filter", "topic", topic, "consumer", target, "filter", expr, "error", err)
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return false
+		}
+	}
+
+	if err := h.App.Broker.SetFilter(topic, target, f); err != nil {
+		if strings.Contains(err.Error(), "does not exist") {
+			h.App.Logger.Warn("filter set on a non-existent topic", "topic", topic)
+			http.Error(w, "topic does not exist", http.StatusNotFound)
+			return false
+		}
+		h.App.Logger.Error("failed to set filter", "topic", topic, "consumer", target, "error", err)
+		http.Error(w, "failed to set filter", http.StatusInternalServerError)
+		return false
+	}
+
+	h.App.Logger.Info("filter set", "topic", topic, "consumer", target, "filter", expr)
+	return true
+}
@@ -0,0 +1,61 @@
+package api
+
+import (
+	"errors"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/codytheroux96/go-mq/internal/core"
+)
+
+const (
+	// maxInboxSize is the largest inbox a subscription may ask for.
+	maxInboxSize = 10000
+	// maxBlockTimeout is the longest a block policy may hold a publisher.
+	maxBlockTimeout = 10 * time.Second
+)
+
+type flowPayload struct {
+	InboxSize          int    `json:"inbox_size"`
+	SlowConsumerPolicy string `json:"slow_consumer_policy"`
+	BlockTimeoutMs     int64  `json:"block_timeout_ms"`
+}
+
+func (p flowPayload) flowControl() (core.FlowControl, error) {
+	flow := core.FlowControl{
+		InboxSize:    p.InboxSize,
+		Policy:       core.SlowConsumerPolicy(p.SlowConsumerPolicy),
+		BlockTimeout: time.Duration(p.BlockTimeoutMs) * time.Millisecond,
+	}
+	if err := flow.Validate(); err != nil {
+		return core.FlowControl{}, err
+	}
+	if flow.InboxSize > maxInboxSize {
+		return core.FlowControl{}, errors.New("inbox_size can be at most " + strconv.Itoa(maxInboxSize))
+	}
+	if flow.BlockTimeout > maxBlockTimeout {
+		return core.FlowControl{}, errors.New("block_timeout_ms can be at most " + strconv.FormatInt(maxBlockTimeout.Milliseconds(), 10))
+	}
+	return flow, nil
+}
+
+// flowFromQuery reads a subscription's inbox_size, slow_consumer_policy and
+// block_timeout_ms query parameters.
+func flowFromQuery(query url.Values) (core.FlowControl, error) {
+	var p flowPayload
+	var err error
+	if raw := query.Get("inbox_size"); raw != "" {
+		if p.InboxSize, err = strconv.Atoi(raw); err != nil {
+			return core.FlowControl{}, errors.New("inbox_size must be an integer")
+		}
+	}
+	if raw := query.Get("block_timeout_ms"); raw != "" {
+		if p.BlockTimeoutMs, err = strconv.ParseInt(raw, 10, 64); err != nil {
+			return core.FlowControl{}, errors.New("block_timeout_ms must be an integer")
+		}
+	}
+	p.SlowConsumerPolicy = query.Get("slow_consumer_policy")
+
+	return p.flowControl()
+}
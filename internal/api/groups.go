@@ -0,0 +1,139 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+func (h *Handler) HandleListGroups(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		h.App.Logger.Warn("http method not allowed for listing groups", "method", r.Method)
+		http.Error(w, "http method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	groups := h.App.Broker.ListGroups()
+
+	h.App.Logger.Info("listing all consumer groups", "count", len(groups))
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]any{"groups": groups})
+}
+
+func (h *Handler) HandleGroup(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimPrefix(r.URL.Path, "/groups/")
+	groupID, action, _ := strings.Cut(path, "/")
+	if groupID == "" {
+		h.App.Logger.Warn("missing group id in group request")
+		http.Error(w, "group id is required", http.StatusBadRequest)
+		return
+	}
+
+	switch action {
+	case "":
+		h.describeGroup(w, r, groupID)
+	case "join", "heartbeat", "leave":
+		h.updateGroupMembership(w, r, groupID, action)
+	default:
+		http.Error(w, "not found", http.StatusNotFound)
+	}
+}
+
+func (h *Handler) describeGroup(w http.ResponseWriter, r *http.Request, groupID string) {
+	if r.Method != http.MethodGet {
+		h.App.Logger.Warn("http method not allowed for describing a group", "method", r.Method)
+		http.Error(w, "http method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	group, err := h.App.Broker.DescribeGroup(groupID)
+	if err != nil {
+		h.App.Logger.Warn("describe requested for group that does not exist", "group", groupID)
+		http.Error(w, "group does not exist", http.StatusNotFound)
+		return
+	}
+
+	// lag is reported per partition against the group's shared committed offsets
+	type partitionState struct {
+		Partition       int `json:"partition"`
+		CommittedOffset int `json:"committed_offset"`
+	}
+	partitions := []partitionState{}
+	if cfg, err := h.App.Repo.GetTopicConfig(group.Topic); err == nil {
+		for p := 0; p < cfg.PartitionCount(); p++ {
+			offset, err := h.App.Repo.GetOffset(group.Topic, p, groupID)
+			if err != nil {
+				continue
+			}
+			partitions = append(partitions, partitionState{Partition: p, CommittedOffset: offset})
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]any{
+		"group":      group,
+		"partitions": partitions,
+	})
+}
+
+func (h *Handler) updateGroupMembership(w http.ResponseWriter, r *http.Request, groupID, action string) {
+	if r.Method != http.MethodPost {
+		h.App.Logger.Warn("http method not allowed for group membership", "method", r.Method, "action", action)
+		http.Error(w, "http method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if r.Header.Get("Content-Type") != "application/json" {
+		h.App.Logger.Warn("invalid content-type received", "received", r.Header.Get("Content-Type"))
+		http.Error(w, "Content-Type must be application/json", http.StatusUnsupportedMediaType)
+		return
+	}
+
+	var req struct {
+		Topic    string `json:"topic"`
+		MemberID string `json:"member_id"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.MemberID == "" || (action == "join" && req.Topic == "") {
+		h.App.Logger.Error("invalid group membership request", "group", groupID, "action", action, "error", err)
+		http.Error(w, "invalid payload in request", http.StatusBadRequest)
+		return
+	}
+
+	var resp any
+	var err error
+	switch action {
+	case "join":
+		resp, err = h.App.Broker.JoinGroupForFetch(groupID, req.Topic, req.MemberID)
+	case "heartbeat":
+		resp, err = h.App.Broker.Heartbeat(groupID, req.MemberID)
+	case "leave":
+		err = h.App.Broker.LeaveGroup(groupID, req.MemberID)
+		resp = map[string]string{"message": "member left group successfully"}
+	}
+
+	if err != nil {
+		if strings.Contains(err.Error(), "does not exist") {
+			h.App.Logger.Warn("group membership request for unknown topic, group or member", "group", groupID, "member", req.MemberID, "action", action, "error", err)
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		if strings.Contains(err.Error(), "already consumes") {
+			h.App.Logger.Warn("join with a group bound to another topic", "group", groupID, "topic", req.Topic)
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		h.App.Logger.Error("group membership request failed", "group", groupID, "member", req.MemberID, "action", action, "error", err)
+		http.Error(w, "group membership request failed", http.StatusInternalServerError)
+		return
+	}
+
+	h.App.Logger.Info("group membership updated", "group", groupID, "member", req.MemberID, "action", action)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(resp)
+}
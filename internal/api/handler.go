@@ -1,19 +1,51 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/codytheroux96/go-mq/internal/app"
+	"github.com/codytheroux96/go-mq/internal/broker"
 	"github.com/codytheroux96/go-mq/internal/core"
+	"github.com/codytheroux96/go-mq/internal/metrics"
 	"github.com/codytheroux96/go-mq/internal/repository"
 )
 
 type Handler struct {
-	App *app.Application
+	App     *app.Application
+	Metrics *metrics.Registry
+}
+
+type retentionPayload struct {
+	MaxAgeMs    int64 `json:"max_age_ms"`
+	MaxBytes    int64 `json:"max_bytes"`
+	MaxMessages int   `json:"max_messages"`
+}
+
+func (p retentionPayload) policy() core.RetentionPolicy {
+	return core.RetentionPolicy{
+		MaxAge:      time.Duration(p.MaxAgeMs) * time.Millisecond,
+		MaxBytes:    p.MaxBytes,
+		MaxMessages: p.MaxMessages,
+	}
+}
+
+func (p retentionPayload) valid() bool {
+	return p.MaxAgeMs >= 0 && p.MaxBytes >= 0 && p.MaxMessages >= 0
+}
+
+func newRetentionPayload(policy core.RetentionPolicy) retentionPayload {
+	return retentionPayload{
+		MaxAgeMs:    policy.MaxAge.Milliseconds(),
+		MaxBytes:    policy.MaxBytes,
+		MaxMessages: policy.MaxMessages,
+	}
 }
 
 func (h *Handler) HandleTopics(w http.ResponseWriter, r *http.Request) {
@@ -35,7 +67,11 @@ func (h *Handler) HandleCreateTopic(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var req struct {
-		Name string `json:"name"`
+		Name       string            `json:"name"`
+		Partitions int               `json:"partitions"`
+		Retention  retentionPayload  `json:"retention"`
+		DeadLetter deadLetterPayload `json:"dead_letter"`
+		Dedup      dedupPayload      `json:"dedup"`
 	}
 
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Name == "" {
@@ -44,17 +80,36 @@ func (h *Handler) HandleCreateTopic(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := h.App.Repo.CreateTopic(req.Name); err != nil {
+	if req.Partitions < 0 || !req.Retention.valid() || !req.Dedup.valid() {
+		h.App.Logger.Warn("negative partition count, retention or dedup settings in create topic request", "topic", req.Name)
+		http.Error(w, "partitions, retention and dedup settings cannot be negative", http.StatusBadRequest)
+		return
+	}
+
+	if !req.DeadLetter.valid(req.Name) {
+		h.App.Logger.Warn("invalid dead-letter settings in create topic request", "topic", req.Name)
+		http.Error(w, "dead-letter settings need a positive attempt count and a different topic", http.StatusBadRequest)
+		return
+	}
+
+	cfg := core.TopicConfig{
+		Partitions: req.Partitions,
+		Retention:  req.Retention.policy(),
+		DeadLetter: req.DeadLetter.policy(),
+		Dedup:      req.Dedup.policy(),
+	}
+	if err := h.App.Repo.CreateTopic(req.Name, cfg); err != nil {
 		if strings.Contains(err.Error(), "already exists") {
 			h.App.Logger.Warn("attempt to create duplicate topic was made", "topic", req.Name)
 			http.Error(w, "cannot create topic - topic already exists", http.StatusConflict)
 			return
 		}
-		h.App.Logger.Error("failed to create toic,", "topic", req.Name)
+		h.App.Logger.Error("failed to create topic", "topic", req.Name, "error", err)
 		http.Error(w, "internal server error", http.StatusInternalServerError)
+		return
 	}
 
-	h.App.Logger.Info("topic created", "topic", req.Name)
+	h.App.Logger.Info("topic created", "topic", req.Name, "partitions", cfg.PartitionCount())
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
@@ -76,6 +131,195 @@ func (h *Handler) HandleListTopics(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(map[string][]string{"topics": topics})
 }
 
+func (h *Handler) HandleTopic(w http.ResponseWriter, r *http.Request) {
+	if strings.HasSuffix(r.URL.Path, "/retention") {
+		h.HandleTopicRetention(w, r)
+		return
+	}
+	if strings.HasSuffix(r.URL.Path, "/dead-letter") {
+		h.HandleTopicDeadLetter(w, r)
+		return
+	}
+	if strings.HasSuffix(r.URL.Path, "/redrive") {
+		h.HandleRedrive(w, r)
+		return
+	}
+	if strings.HasSuffix(r.URL.Path, "/dedup") {
+		h.HandleTopicDedup(w, r)
+		return
+	}
+	if strings.HasSuffix(r.URL.Path, "/stats") {
+		h.HandleTopicStats(w, r)
+		return
+	}
+	if strings.Contains(r.URL.Path, "/consumers/") {
+		h.HandleDeleteConsumer(w, r)
+		return
+	}
+	if strings.HasSuffix(r.URL.Path, "/consumers") {
+		h.HandleTopicConsumers(w, r)
+		return
+	}
+	if strings.HasSuffix(r.URL.Path, "/offsets/reset") {
+		h.HandleResetOffsets(w, r)
+		return
+	}
+	if strings.HasSuffix(r.URL.Path, "/offsets") {
+		h.HandleOffsetsForTime(w, r)
+		return
+	}
+	if r.Method == http.MethodGet {
+		h.HandleDescribeTopic(w, r)
+		return
+	}
+
+	h.HandleDeleteTopic(w, r)
+}
+
+// HandleDescribeTopic reports a topic's settings and the offset range of each
+// partition. With a consumer_id or group_id query parameter it adds that
+// consumer's committed offsets and its lag behind the end of each partition.
+func (h *Handler) HandleDescribeTopic(w http.ResponseWriter, r *http.Request) {
+	topicName := strings.TrimPrefix(r.URL.Path, "/topics/")
+	if topicName == "" {
+		h.App.Logger.Warn("missing topic name in describe request")
+		http.Error(w, "topic name is required for describe request", http.StatusBadRequest)
+		return
+	}
+
+	offsetKey := r.URL.Query().Get("consumer_id")
+	if groupID := r.URL.Query().Get("group_id"); groupID != "" {
+		offsetKey = groupID
+	}
+
+	cfg, err := h.App.Repo.GetTopicConfig(topicName)
+	if err != nil {
+		if strings.Contains(err.Error(), "does not exist") {
+			h.App.Logger.Warn("describe requested for topic that does not exist", "topic", topicName)
+			http.Error(w, "topic does not exist", http.StatusNotFound)
+			return
+		}
+		h.App.Logger.Error("failed to get topic config", "topic", topicName, "error", err)
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	type partitionState struct {
+		Partition       int  `json:"partition"`
+		LowWatermark    int  `json:"low_watermark"`
+		HighWatermark   int  `json:"high_watermark"`
+		CommittedOffset *int `json:"committed_offset,omitempty"`
+		Lag             *int `json:"lag,omitempty"`
+	}
+	partitions := make([]partitionState, cfg.PartitionCount())
+	for p := range partitions {
+		state := partitionState{Partition: p}
+		state.LowWatermark, err = h.App.Repo.GetEarliestOffset(topicName, p)
+		if err == nil {
+			state.HighWatermark, err = h.App.Repo.GetLatestOffset(topicName, p)
+		}
+		if err == nil && offsetKey != "" {
+			var committed int
+			committed, err = h.App.Repo.GetOffset(topicName, p, offsetKey)
+			// an offset retention has passed has nothing left to consume before the low watermark
+			lag := state.HighWatermark - max(committed, state.LowWatermark)
+			state.CommittedOffset, state.Lag = &committed, &lag
+		}
+		if err != nil {
+			h.App.Logger.Error("failed to read partition offsets", "topic", topicName, "partition", p, "error", err)
+			http.Error(w, "internal server error", http.StatusInternalServerError)
+			return
+		}
+		partitions[p] = state
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]any{
+		"topic":       topicName,
+		"retention":   newRetentionPayload(cfg.Retention),
+		"dead_letter": deadLetterPayload(cfg.DeadLetter),
+		"dedup":       newDedupPayload(cfg.Dedup),
+		"partitions":  partitions,
+	})
+}
+
+func (h *Handler) HandleTopicRetention(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodPut {
+		h.App.Logger.Warn("http method not allowed for topic retention", "method", r.Method)
+		http.Error(w, "http method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	topicName := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/topics/"), "/retention")
+	if topicName == "" {
+		h.App.Logger.Warn("missing topic name in retention request")
+		http.Error(w, "topic name is required for retention request", http.StatusBadRequest)
+		return
+	}
+
+	cfg, err := h.App.Repo.GetTopicConfig(topicName)
+	if err != nil {
+		if strings.Contains(err.Error(), "does not exist") {
+			h.App.Logger.Warn("retention requested for topic that does not exist", "topic", topicName)
+			http.Error(w, "topic does not exist", http.StatusNotFound)
+			return
+		}
+		h.App.Logger.Error("failed to get topic config", "topic", topicName, "error", err)
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	if r.Method == http.MethodPut {
+		if r.Header.Get("Content-Type") != "application/json" {
+			h.App.Logger.Warn("invalid content-type received", "received", r.Header.Get("Content-Type"))
+			http.Error(w, "Content-Type must be application/json", http.StatusUnsupportedMediaType)
+			return
+		}
+
+		var req retentionPayload
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || !req.valid() {
+			h.App.Logger.Error("invalid retention request payload", "topic", topicName, "error", err)
+			http.Error(w, "invalid payload in request", http.StatusBadRequest)
+			return
+		}
+
+		cfg, err = h.App.Broker.UpdateTopicConfig(topicName, func(cfg *core.TopicConfig) {
+			cfg.Retention = req.policy()
+		})
+		if err != nil {
+			if strings.Contains(err.Error(), "does not exist") {
+				h.App.Logger.Warn("retention update for topic that no longer exists", "topic", topicName)
+				http.Error(w, "topic does not exist", http.StatusNotFound)
+				return
+			}
+			h.App.Logger.Error("failed to update topic retention", "topic", topicName, "error", err)
+			http.Error(w, "failed to update topic retention", http.StatusInternalServerError)
+			return
+		}
+
+		h.App.Logger.Info("topic retention updated", "topic", topicName)
+	}
+
+	lowWatermarks := make([]int, cfg.PartitionCount())
+	for partition := range lowWatermarks {
+		lowWatermarks[partition], err = h.App.Repo.GetEarliestOffset(topicName, partition)
+		if err != nil {
+			h.App.Logger.Error("failed to get earliest offset", "topic", topicName, "partition", partition, "error", err)
+			http.Error(w, "internal server error", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]any{
+		"topic":          topicName,
+		"retention":      newRetentionPayload(cfg.Retention),
+		"low_watermarks": lowWatermarks,
+	})
+}
+
 func (h *Handler) HandleDeleteTopic(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodDelete {
 		h.App.Logger.Warn("http method not allowed for deleting topic", "method", r.Method)
@@ -90,7 +334,7 @@ func (h *Handler) HandleDeleteTopic(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := h.App.Repo.DeleteTopic(topicName); err != nil {
+	if err := h.App.Broker.DeleteTopic(topicName); err != nil {
 		if strings.Contains(err.Error(), "does not exist") {
 			h.App.Logger.Error("attempt to delete topic that does not exist", "topic", topicName)
 			http.Error(w, "topic requested to be deleted does not exist", http.StatusNotFound)
@@ -109,6 +353,10 @@ func (h *Handler) HandleDeleteTopic(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *Handler) HandlePublish(w http.ResponseWriter, r *http.Request) {
+	if strings.HasSuffix(r.URL.Path, "/batch") {
+		h.HandlePublishBatch(w, r)
+		return
+	}
 	if r.Method != http.MethodPost {
 		h.App.Logger.Warn("http method not allowed for publishing a message to a topic", "method", r.Method)
 		http.Error(w, "http method not allowed", http.StatusMethodNotAllowed)
@@ -129,8 +377,16 @@ func (h *Handler) HandlePublish(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var req struct {
-		Body       string `json:"body"`
-		ProducerID string `json:"producer_id"`
+		Body       string     `json:"body"`
+		ProducerID string     `json:"producer_id"`
+		Key        string     `json:"key"`
+		DeliverAt  *time.Time `json:"deliver_at"`
+		DelayMs    int64      `json:"delay_ms"`
+		Epoch      *int       `json:"epoch"`
+		Sequence   *int64     `json:"sequence"`
+		ID         string     `json:"id"`
+		// TransactionID publishes the message inside an open transaction
+		TransactionID string `json:"transaction_id"`
 	}
 
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Body == "" || req.ProducerID == "" {
@@ -139,8 +395,72 @@ func (h *Handler) HandlePublish(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if req.DelayMs < 0 || (req.DeliverAt != nil && req.DelayMs > 0) {
+		h.App.Logger.Warn("invalid delivery schedule in publish request", "topic", topicName, "delay_ms", req.DelayMs)
+		http.Error(w, "use either deliver_at or a non-negative delay_ms", http.StatusBadRequest)
+		return
+	}
+
+	// an idempotent publish carries the epoch of its producer's session and its
+	// sequence number in that session
+	if (req.Epoch == nil) != (req.Sequence == nil) || (req.Sequence != nil && (*req.Sequence < 0 || req.DeliverAt != nil || req.DelayMs > 0)) {
+		h.App.Logger.Warn("invalid sequence in publish request", "topic", topicName, "producer_id", req.ProducerID)
+		http.Error(w, "epoch and a non-negative sequence go together and cannot be scheduled", http.StatusBadRequest)
+		return
+	}
+
+	if req.TransactionID != "" && (req.Sequence != nil || req.DeliverAt != nil || req.DelayMs > 0) {
+		h.App.Logger.Warn("invalid transactional publish request", "topic", topicName, "transaction_id", req.TransactionID)
+		http.Error(w, "a transactional publish cannot be sequenced or scheduled", http.StatusBadRequest)
+		return
+	}
+
 	msg := core.NewMessage([]byte(req.Body), req.ProducerID)
-	if err := h.App.Broker.Publish(topicName, msg); err != nil {
+	msg.Key = req.Key
+	msg.ID = req.ID
+	setDedupID(msg, r)
+
+	var deliverAt time.Time
+	switch {
+	case req.DeliverAt != nil:
+		deliverAt = *req.DeliverAt
+	case req.DelayMs > 0:
+		deliverAt = time.Now().Add(time.Duration(req.DelayMs) * time.Millisecond)
+	}
+
+	scheduled := deliverAt.After(time.Now())
+
+	var duplicate bool
+	var err error
+	switch {
+	case scheduled:
+		err = h.App.Broker.Schedule(topicName, msg, deliverAt)
+	case req.Sequence != nil:
+		duplicate, err = h.App.Broker.PublishIdempotent(topicName, msg, *req.Epoch, *req.Sequence)
+	case req.TransactionID != "":
+		err = h.App.Broker.PublishInTransaction(req.TransactionID, topicName, msg)
+	default:
+		err = h.App.Broker.Publish(topicName, msg)
+	}
+	if errors.Is(err, broker.ErrDuplicateMessage) {
+		duplicate, err = true, nil
+	}
+	if err != nil {
+		if errors.Is(err, broker.ErrUnknownProducer) {
+			h.App.Logger.Warn("sequenced publish from an unregistered producer", "topic", topicName, "producer_id", req.ProducerID)
+			http.Error(w, err.Error(), http.StatusPreconditionFailed)
+			return
+		}
+		if errors.Is(err, broker.ErrProducerFenced) || errors.Is(err, broker.ErrOutOfOrderSequence) {
+			h.App.Logger.Warn("sequenced publish rejected", "topic", topicName, "producer_id", req.ProducerID, "error", err)
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		if errors.Is(err, broker.ErrUnknownTransaction) || errors.Is(err, broker.ErrTransactionAborted) {
+			h.App.Logger.Warn("transactional publish to a transaction that is not open", "topic", topicName, "transaction_id", req.TransactionID)
+			http.Error(w, err.Error(), transactionStatus(err))
+			return
+		}
 		if strings.Contains(err.Error(), "does not exist") {
 			h.App.Logger.Error("attempting to publish to a topic that does not exist", "topic", topicName)
 			http.Error(w, "topic requested to publish to does not exist", http.StatusNotFound)
@@ -151,11 +471,44 @@ func (h *Handler) HandlePublish(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	h.App.Logger.Info("message publish successfully to topic", "topic", topicName, "producer_id", req.ProducerID)
+	if scheduled {
+		h.App.Logger.Info("message scheduled for delivery to topic", "topic", topicName, "producer_id", req.ProducerID, "deliver_at", deliverAt)
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusAccepted)
+		json.NewEncoder(w).Encode(map[string]any{
+			"message":    "message scheduled successfully",
+			"message_id": msg.ID,
+			"deliver_at": deliverAt,
+		})
+		return
+	}
+
+	if duplicate {
+		h.App.Logger.Info("duplicate publish dropped", "topic", topicName, "producer_id", req.ProducerID, "message_id", msg.ID)
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(map[string]any{
+			"message":    "duplicate message already published",
+			"message_id": msg.ID,
+			"partition":  msg.Partition,
+			"offset":     msg.Offset,
+			"duplicate":  true,
+		})
+		return
+	}
+
+	h.App.Logger.Info("message publish successfully to topic", "topic", topicName, "producer_id", req.ProducerID, "partition", msg.Partition, "offset", msg.Offset, "transaction_id", req.TransactionID)
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusAccepted)
-	json.NewEncoder(w).Encode(map[string]string{"message": "message published successfully"})
+	json.NewEncoder(w).Encode(map[string]any{
+		"message":    "message published successfully",
+		"message_id": msg.ID,
+		"partition":  msg.Partition,
+		"offset":     msg.Offset,
+	})
 }
 
 func (h *Handler) HandleSubscribe(w http.ResponseWriter, r *http.Request) {
@@ -179,35 +532,97 @@ func (h *Handler) HandleSubscribe(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	inbox, err := h.App.Broker.Subscribe(topicName, consumerID)
-	if err != nil {
-		if strings.Contains(err.Error(), "does not exist") {
-			h.App.Logger.Warn("subscribe was attempted on a non-existent topic", "topic", topicName)
-			http.Error(w, "topic does not exist", http.StatusNotFound)
-			return
-		}
-		h.App.Logger.Error("failed to subscribe to topic", "topic", topicName, "error", err)
-		http.Error(w, "failed to subscribe to topic", http.StatusInternalServerError)
+	if strings.Contains(r.Header.Get("Accept"), "text/event-stream") {
+		h.streamEvents(w, r, topicName, consumerID, r.URL.Query().Get("group_id"))
 		return
 	}
 
+	groupID := r.URL.Query().Get("group_id")
+	inbox, ok := h.subscribe(w, r, topicName, consumerID, groupID)
+	if !ok {
+		return
+	}
+	defer h.unsubscribe(topicName, consumerID, groupID, inbox)
+
 	select {
-	case msg := <-inbox:
+	case msg, ok := <-inbox:
+		if !ok {
+			h.App.Logger.Warn("slow consumer disconnected", "topic", topicName, "consumer", consumerID)
+			http.Error(w, "consumer was disconnected for falling behind", http.StatusServiceUnavailable)
+			return
+		}
 		h.App.Logger.Info("delivered message to consumer", "topic", topicName, "consumer", consumerID, "message_id", msg.ID)
 
 		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(map[string]any{
-			"body":        string(msg.Body),
-			"producer_id": msg.ProducerID,
-			"timestamp":   msg.Timestamp,
-			"message_id":  msg.ID,
-		})
+		json.NewEncoder(w).Encode(messagePayload(msg))
 	case <-time.After(10 * time.Second):
 		h.App.Logger.Info("subscribe timeout: no messages", "topic", topicName, "consumer", consumerID)
 		w.WriteHeader(http.StatusNoContent)
 	}
 }
 
+// subscribe registers a live consumer, joining the group when groupID is set so it
+// only receives the group's partitions. The inbox_size, slow_consumer_policy and
+// block_timeout_ms query parameters set its flow control, and filter registers a
+// filter for the consumer, or the group. On failure the error response has been
+// written and ok is false.
+func (h *Handler) subscribe(w http.ResponseWriter, r *http.Request, topicName, consumerID, groupID string) (<-chan *core.Message, bool) {
+	flow, err := flowFromQuery(r.URL.Query())
+	if err != nil {
+		h.App.Logger.Warn("invalid flow control in subscribe request", "topic", topicName, "consumer", consumerID, "error", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return nil, false
+	}
+
+	// the filter goes in first so nothing it rejects is pushed in between
+	if expr := r.URL.Query().Get("filter"); expr != "" {
+		target := consumerID
+		if groupID != "" {
+			target = groupID
+		}
+		if !h.setFilter(w, topicName, target, expr) {
+			return nil, false
+		}
+	}
+
+	var inbox <-chan *core.Message
+	if groupID != "" {
+		inbox, _, err = h.App.Broker.JoinGroup(groupID, topicName, consumerID, flow)
+	} else {
+		inbox, err = h.App.Broker.Subscribe(topicName, consumerID, flow)
+	}
+	if err != nil {
+		if strings.Contains(err.Error(), "does not exist") {
+			h.App.Logger.Warn("subscribe was attempted on a non-existent topic", "topic", topicName)
+			http.Error(w, "topic does not exist", http.StatusNotFound)
+			return nil, false
+		}
+		if strings.Contains(err.Error(), "already consumes") {
+			h.App.Logger.Warn("subscribe with a group bound to another topic", "topic", topicName, "error", err)
+			http.Error(w, err.Error(), http.StatusConflict)
+			return nil, false
+		}
+		h.App.Logger.Error("failed to subscribe to topic", "topic", topicName, "error", err)
+		http.Error(w, "failed to subscribe to topic", http.StatusInternalServerError)
+		return nil, false
+	}
+
+	return inbox, true
+}
+
+func messagePayload(msg *core.Message) map[string]any {
+	return map[string]any{
+		"body":        string(msg.Body),
+		"producer_id": msg.ProducerID,
+		"timestamp":   msg.Timestamp,
+		"message_id":  msg.ID,
+		"key":         msg.Key,
+		"partition":   msg.Partition,
+		"offset":      msg.Offset,
+		"attempts":    msg.DeliveryAttempts,
+	}
+}
+
 func (h *Handler) HandleRegisterConsumer(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		h.App.Logger.Warn("http method not allowed for registering a consumer", "method", r.Method)
@@ -222,19 +637,38 @@ func (h *Handler) HandleRegisterConsumer(w http.ResponseWriter, r *http.Request)
 	}
 
 	var req struct {
-		Topic      string `json:"topic"`
-		ConsumerID string `json:"consumer_id"`
-		Offset     *int   `json:"offset"`
+		Topic      string     `json:"topic"`
+		ConsumerID string     `json:"consumer_id"`
+		Partition  int        `json:"partition"`
+		Offset     *int       `json:"offset"`
+		StartTime  *time.Time `json:"start_time"`
+		// AutoOffsetReset is where the consumer starts on partitions it has not
+		// committed an offset on yet, earliest by default.
+		AutoOffsetReset broker.ResetTo `json:"auto_offset_reset"`
+		// Filter replaces the consumer's filter, an empty one removes it.
+		Filter *string `json:"filter"`
+		flowPayload
 	}
 
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Topic == "" || req.ConsumerID == "" {
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Topic == "" || req.ConsumerID == "" || (req.Offset != nil && req.StartTime != nil) ||
+		(req.AutoOffsetReset != "" && !broker.ValidAutoOffsetReset(req.AutoOffsetReset)) {
 		h.App.Logger.Error("invalid consumer registration request", "error", err)
 		http.Error(w, "invalid payload in request", http.StatusBadRequest)
 		return
 	}
 
-	_, err := h.App.Broker.Subscribe(req.Topic, req.ConsumerID)
+	flow, err := req.flowControl()
 	if err != nil {
+		h.App.Logger.Warn("invalid flow control in consumer registration", "topic", req.Topic, "consumer", req.ConsumerID, "error", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if req.Filter != nil && !h.setFilter(w, req.Topic, req.ConsumerID, *req.Filter) {
+		return
+	}
+
+	if err := h.App.Broker.Register(req.Topic, req.ConsumerID, flow); err != nil {
 		if strings.Contains(err.Error(), "does not exist") {
 			h.App.Logger.Warn("subscribe attempted on non-existent topic", "topic", req.Topic)
 			http.Error(w, "topic does not exist", http.StatusNotFound)
@@ -245,13 +679,35 @@ func (h *Handler) HandleRegisterConsumer(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	if req.AutoOffsetReset == "" {
+		req.AutoOffsetReset = broker.ResetEarliest
+	}
+	if err := h.App.Broker.AutoOffsetReset(req.Topic, req.ConsumerID, req.AutoOffsetReset); err != nil {
+		h.App.Logger.Error("failed to apply auto offset reset after consumer registration", "topic", req.Topic, "consumer", req.ConsumerID, "auto_offset_reset", req.AutoOffsetReset, "error", err)
+		http.Error(w, "consumer registered but failed to apply auto offset reset", http.StatusInternalServerError)
+		return
+	}
+
 	if req.Offset != nil {
-		if err := h.App.Repo.CommitOffset(req.Topic, req.ConsumerID, *req.Offset); err != nil {
-			h.App.Logger.Warn("failed to set custom offset after consumer registration", "topic", req.Topic, "consumer", req.ConsumerID, "offset", *req.Offset, "error", err)
+		if err := h.App.Repo.CommitOffset(req.Topic, req.Partition, req.ConsumerID, *req.Offset); err != nil {
+			h.App.Logger.Warn("failed to set custom offset after consumer registration", "topic", req.Topic, "partition", req.Partition, "consumer", req.ConsumerID, "offset", *req.Offset, "error", err)
 			http.Error(w, "consumer registered but failed to set offset", http.StatusBadRequest)
 			return
 		}
-		h.App.Logger.Info("custom offset successfully sete during consumer registration", "topic", req.Topic, "consumer", req.ConsumerID, "offset", *req.Offset)
+		h.App.Logger.Info("custom offset successfully sete during consumer registration", "topic", req.Topic, "partition", req.Partition, "consumer", req.ConsumerID, "offset", *req.Offset)
+	}
+
+	// a start time replays every partition from that point
+	if req.StartTime != nil {
+		partitions, err := h.topicPartitions(req.Topic)
+		if err == nil {
+			err = h.seekToTime(req.Topic, partitions, req.ConsumerID, *req.StartTime)
+		}
+		if err != nil {
+			h.App.Logger.Warn("failed to set start time after consumer registration", "topic", req.Topic, "consumer", req.ConsumerID, "start_time", *req.StartTime, "error", err)
+			http.Error(w, "consumer registered but failed to set start time", http.StatusInternalServerError)
+			return
+		}
 	}
 
 	h.App.Logger.Info("consumer subscribed successfully", "topic", req.Topic, "consumer", req.ConsumerID)
@@ -288,56 +744,78 @@ func (h *Handler) HandleAck(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	repo := h.App.Repo
-
-	memoryRepo, ok := repo.(*repository.InMemoryRepo)
-	if !ok {
-		h.App.Logger.Error("ack only supported in an in-memory repo")
-		http.Error(w, "ack not supported in current backend", http.StatusInternalServerError)
+	duplicate, err := h.App.Broker.Ack(req.Topic, req.ConsumerID, req.MessageID)
+	if err != nil {
+		h.App.Logger.Warn("ack failed: message not awaiting acknowledgement", "topic", req.Topic, "message_id", req.MessageID, "consumer", req.ConsumerID)
+		http.Error(w, "message not found or not awaiting acknowledgement from this consumer", http.StatusNotFound)
 		return
 	}
 
-	memoryRepo.Mu.Lock()
-	defer memoryRepo.Mu.Unlock()
+	if duplicate {
+		h.App.Logger.Info("duplicate ack received", "message_id", req.MessageID, "consumer", req.ConsumerID)
+	} else {
+		h.App.Logger.Info("message acknowledged", "message_id", req.MessageID, "consumer", req.ConsumerID)
+	}
 
-	topicEntry, exists := memoryRepo.Topics[req.Topic]
-	if !exists {
-		h.App.Logger.Warn("ack failed: topic not found", "topic", req.Topic)
-		http.Error(w, "topic not found", http.StatusNotFound)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{
+		"message": "message acknowledged successfully",
+	})
+}
+
+// HandleNack reports a failed delivery. Requeue defaults to true, in which case the
+// message is redelivered after the optional delay.
+func (h *Handler) HandleNack(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		h.App.Logger.Warn("http method is not allowed for negative acknowledging", "method", r.Method)
+		http.Error(w, "http method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
 
-	var targetMsg *core.Message
-	for _, msg := range topicEntry.Messages {
-		if msg.ID == req.MessageID {
-			targetMsg = msg
-			break
-		}
+	if r.Header.Get("Content-Type") != "application/json" {
+		h.App.Logger.Warn("invalid content-type for negative acknowledging", "received", r.Header.Get("Content-Type"))
+		http.Error(w, "Content-Type must be application/json", http.StatusUnsupportedMediaType)
+		return
 	}
 
-	if targetMsg == nil {
-		h.App.Logger.Warn("ack failed: message not found", "message_id", req.MessageID)
-		http.Error(w, "message not found", http.StatusNotFound)
-		return
+	var req struct {
+		Topic      string `json:"topic"`
+		ConsumerID string `json:"consumer_id"`
+		MessageID  string `json:"message_id"`
+		Requeue    *bool  `json:"requeue"`
+		DelayMs    int64  `json:"delay_ms"`
+		Reason     string `json:"reason"`
 	}
 
-	if !targetMsg.DeliveredTo[req.ConsumerID] {
-		h.App.Logger.Warn("ack rejected: message not delivered to consumer", "message_id", req.MessageID, "consumer", req.ConsumerID)
-		http.Error(w, "message not delivered to this consumer", http.StatusForbidden)
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Topic == "" || req.ConsumerID == "" || req.MessageID == "" || req.DelayMs < 0 {
+		h.App.Logger.Error("invalid nack request payload", "error", err)
+		http.Error(w, "invalid payload in request", http.StatusBadRequest)
 		return
 	}
 
-	if targetMsg.AckedBy[req.ConsumerID] {
-		h.App.Logger.Info("duplicate ack received", "message_id", req.MessageID, "consumer", req.ConsumerID)
-	} else {
-		targetMsg.AckedBy[req.ConsumerID] = true
-		h.App.Logger.Info("message acknowledged", "message_id", req.MessageID, "consumer", req.ConsumerID)
+	requeue := req.Requeue == nil || *req.Requeue
+	delay := time.Duration(req.DelayMs) * time.Millisecond
+
+	deadLettered, err := h.App.Broker.Nack(req.Topic, req.ConsumerID, req.MessageID, requeue, delay, req.Reason)
+	if err != nil {
+		if strings.Contains(err.Error(), "not awaiting acknowledgement") {
+			h.App.Logger.Warn("nack failed: message not awaiting acknowledgement", "topic", req.Topic, "message_id", req.MessageID, "consumer", req.ConsumerID)
+			http.Error(w, "message not found or not awaiting acknowledgement from this consumer", http.StatusNotFound)
+			return
+		}
+		h.App.Logger.Error("failed to nack message", "topic", req.Topic, "message_id", req.MessageID, "consumer", req.ConsumerID, "error", err)
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+		return
 	}
 
+	h.App.Logger.Info("message negatively acknowledged", "message_id", req.MessageID, "consumer", req.ConsumerID, "requeue", requeue, "dead_lettered", deadLettered)
+
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(map[string]string{
-		"message": "message acknowledged successfully",
+	json.NewEncoder(w).Encode(map[string]any{
+		"message":       "message negatively acknowledged",
+		"dead_lettered": deadLettered,
 	})
 }
 
@@ -364,16 +842,36 @@ func (h *Handler) HandleFetchMessages(w http.ResponseWriter, r *http.Request) {
 
 	topic := r.Header.Get("X-Topic")
 	consumerID := r.Header.Get("X-Consumer-ID")
+	groupID := r.Header.Get("X-Group-ID")
+	partitionStr := r.Header.Get("X-Partition")
 	limitStr := r.Header.Get("X-Limit")
 	offsetStr := r.Header.Get("X-Offset")
 	commit := strings.ToLower(r.Header.Get("X-Commit")) == "true"
 
+	isolation, ok := parseIsolationLevel(r.Header.Get(IsolationLevelHeader))
+	if !ok {
+		h.App.Logger.Warn("invalid isolation level in fetch request", "isolation_level", r.Header.Get(IsolationLevelHeader))
+		http.Error(w, "X-Isolation-Level must be read_committed or read_uncommitted", http.StatusBadRequest)
+		return
+	}
+
 	if topic == "" || consumerID == "" {
 		h.App.Logger.Warn("missing required fetch headers:", "topic", topic, "consumerID", consumerID)
 		http.Error(w, "X-Topic and X-Consumer-ID headers are required", http.StatusBadRequest)
 		return
 	}
 
+	partition := 0
+	if partitionStr != "" {
+		p, err := strconv.Atoi(partitionStr)
+		if err != nil || p < 0 {
+			h.App.Logger.Warn("invalid partition in fetch request", "partition", partitionStr)
+			http.Error(w, "X-Partition must be a non-negative integer", http.StatusBadRequest)
+			return
+		}
+		partition = p
+	}
+
 	limit := 10
 	if limitStr != "" {
 		if l, err := strconv.Atoi(limitStr); err == nil && l > 0 {
@@ -383,41 +881,238 @@ func (h *Handler) HandleFetchMessages(w http.ResponseWriter, r *http.Request) {
 
 	offset := -1
 	if offsetStr != "" {
-		if o, err := strconv.Atoi(offsetStr); err == nil && o >= 0 {
-			offset = o
+		o, err := strconv.Atoi(offsetStr)
+		if err != nil || o < 0 {
+			h.App.Logger.Warn("invalid offset in fetch request", "offset", offsetStr)
+			http.Error(w, "X-Offset must be a non-negative integer", http.StatusBadRequest)
+			return
 		}
+		offset = o
 	}
 
-	var startOffset int
-	var err error
-	if offset >= 0 {
-		startOffset = offset
-	} else {
-		startOffset, err = h.App.Repo.GetOffset(topic, consumerID)
-		if err != nil {
-			h.App.Logger.Error("failed to get offset", "topic", topic, "consumer", consumerID, "error", err)
-			http.Error(w, "failed to retrieve offset", http.StatusInternalServerError)
+	var startTime time.Time
+	if raw := r.Header.Get(StartTimeHeader); raw != "" {
+		var err error
+		if startTime, err = parseStartTime(raw); err != nil || offset >= 0 {
+			h.App.Logger.Warn("invalid start time in fetch request", "start_time", raw, "offset", offsetStr, "error", err)
+			http.Error(w, "X-Start-Time must be an RFC 3339 time and cannot be combined with X-Offset", http.StatusBadRequest)
 			return
 		}
 	}
 
-	messages, err := h.App.Repo.Fetch(topic, consumerID, limit)
+	// a long poll holds the request until min_messages are ready or max_wait passes
+	maxWait, minMessages, err := parseLongPoll(r.Header.Get(MaxWaitHeader), r.Header.Get(MinMessagesHeader))
 	if err != nil {
-		h.App.Logger.Error("failed to fetch messages", "topic", topic, "consumer", consumerID, "error", err)
-		http.Error(w, "failed to fetch messages", http.StatusInternalServerError)
+		h.App.Logger.Warn("invalid long poll parameters in fetch request", "max_wait", r.Header.Get(MaxWaitHeader), "min_messages", r.Header.Get(MinMessagesHeader), "error", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
+	minMessages = min(minMessages, limit)
+
+	// group members read and commit the group's shared offsets, and only for the
+	// partitions currently assigned to them
+	offsetKey := consumerID
+	partitions := []int{partition}
+	if groupID != "" {
+		assignment, err := h.App.Broker.Heartbeat(groupID, consumerID)
+		if err != nil {
+			h.App.Logger.Warn("group fetch from unknown group or member", "group", groupID, "member", consumerID, "error", err)
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		if assignment.Topic != topic {
+			h.App.Logger.Warn("group fetch for a topic the group does not consume", "group", groupID, "topic", topic)
+			http.Error(w, "group does not consume this topic", http.StatusConflict)
+			return
+		}
+
+		if partitionStr == "" {
+			partitions = assignment.Partitions
+		} else if !slices.Contains(assignment.Partitions, partition) {
+			h.App.Logger.Warn("group fetch for a partition not assigned to member", "group", groupID, "member", consumerID, "partition", partition)
+			http.Error(w, "partition is not assigned to this group member", http.StatusConflict)
+			return
+		}
+		offsetKey = groupID
+	}
+
+	if expr := r.Header.Get(FilterHeader); expr != "" && !h.setFilter(w, topic, offsetKey, expr) {
+		return
+	}
+
+	if !startTime.IsZero() {
+		if err := h.seekToTime(topic, partitions, offsetKey, startTime); err != nil {
+			if strings.Contains(err.Error(), "does not exist") {
+				h.App.Logger.Warn("fetch attempted on non-existent topic or partition", "topic", topic, "partitions", partitions)
+				http.Error(w, err.Error(), http.StatusNotFound)
+				return
+			}
+			h.App.Logger.Error("failed to move offset to start time", "topic", topic, "consumer", offsetKey, "start_time", startTime, "error", err)
+			http.Error(w, "failed to move offset to start time", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	// like a start time, an offset moves the committed offset and the fetch reads on from there
+	if offset >= 0 {
+		for _, partition := range partitions {
+			err := h.App.Repo.CommitOffset(topic, partition, offsetKey, offset)
+			if err == nil {
+				continue
+			}
+			if strings.Contains(err.Error(), "does not exist") {
+				h.App.Logger.Warn("fetch attempted on non-existent topic or partition", "topic", topic, "partition", partition)
+				http.Error(w, err.Error(), http.StatusNotFound)
+				return
+			}
+			var rangeErr *repository.OffsetOutOfRangeError
+			if errors.As(err, &rangeErr) {
+				h.App.Logger.Warn("fetch offset is out of range", "topic", topic, "partition", partition, "consumer", offsetKey, "offset", offset, "low_watermark", rangeErr.LowWatermark)
+				w.Header().Set("X-Low-Watermark", strconv.Itoa(rangeErr.LowWatermark))
+				http.Error(w, "offset out of range: "+err.Error(), http.StatusRequestedRangeNotSatisfiable)
+				return
+			}
+			if strings.Contains(err.Error(), "beyond the partition length") {
+				h.App.Logger.Warn("fetch offset is past the end of the partition", "topic", topic, "partition", partition, "offset", offset)
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			h.App.Logger.Error("failed to move offset for fetch", "topic", topic, "partition", partition, "consumer", offsetKey, "offset", offset, "error", err)
+			http.Error(w, "failed to move offset", http.StatusInternalServerError)
+			return
+		}
+	}
 
-	if commit {
-		if err := h.App.Repo.CommitOffset(topic, consumerID, startOffset+len(messages)); err != nil {
-			h.App.Logger.Warn("failed to auto-commit offset", "topic", topic, "consumer", consumerID, "error", err)
+	if maxWait > 0 {
+		ctx, cancel := context.WithTimeout(r.Context(), maxWait)
+		err := h.App.Broker.WaitForMessages(ctx, topic, partitions, groupID, consumerID, minMessages, isolation)
+		cancel()
+		// an expired wait still fetches whatever is there, a client that went away gets nothing
+		if err != nil && r.Context().Err() != nil {
+			h.App.Logger.Info("client went away during long poll fetch", "topic", topic, "consumer", offsetKey)
+			return
+		}
+	}
+
+	messages := []*core.Message{}
+	for _, partition := range partitions {
+		if len(messages) >= limit {
+			break
+		}
+
+		startOffset, err := h.App.Repo.GetOffset(topic, partition, offsetKey)
+		if err != nil {
+			if strings.Contains(err.Error(), "does not exist") {
+				h.App.Logger.Warn("fetch attempted on non-existent topic or partition", "topic", topic, "partition", partition)
+				http.Error(w, err.Error(), http.StatusNotFound)
+				return
+			}
+			h.App.Logger.Error("failed to get offset", "topic", topic, "partition", partition, "consumer", offsetKey, "error", err)
+			http.Error(w, "failed to retrieve offset", http.StatusInternalServerError)
+			return
+		}
+
+		batch, fresh, err := h.App.Broker.Fetch(topic, partition, groupID, consumerID, limit-len(messages), isolation)
+		if err != nil {
+			var rangeErr *repository.OffsetOutOfRangeError
+			if errors.As(err, &rangeErr) {
+				h.App.Logger.Warn("fetch offset is out of range", "topic", topic, "partition", partition, "consumer", offsetKey, "offset", rangeErr.Offset, "low_watermark", rangeErr.LowWatermark)
+				w.Header().Set("X-Low-Watermark", strconv.Itoa(rangeErr.LowWatermark))
+				http.Error(w, "offset out of range: "+err.Error(), http.StatusRequestedRangeNotSatisfiable)
+				return
+			}
+			h.App.Logger.Error("failed to fetch messages", "topic", topic, "consumer", offsetKey, "error", err)
+			http.Error(w, "failed to fetch messages", http.StatusInternalServerError)
+			return
+		}
+		messages = append(messages, batch...)
+
+		// redelivered messages sit before the committed offset already, only fresh ones move it
+		if commit {
+			if err := h.App.Repo.CommitOffset(topic, partition, offsetKey, startOffset+fresh); err != nil {
+				h.App.Logger.Warn("failed to auto-commit offset", "topic", topic, "partition", partition, "consumer", offsetKey, "error", err)
+			} else {
+				h.App.Logger.Info("fetched and committed messages", "topic", topic, "partition", partition, "consumer", offsetKey, "new_offset", startOffset+fresh)
+			}
 		} else {
-			h.App.Logger.Info("fetched and committed messages", "topic", topic, "consumer", consumerID, "new_offset", startOffset+len(messages))
+			h.App.Logger.Info("fetched messages without committing", "topic", topic, "partition", partition, "consumer", offsetKey)
+		}
+	}
+
+	if len(partitions) == 1 {
+		if lowWatermark, err := h.App.Repo.GetEarliestOffset(topic, partitions[0]); err == nil {
+			w.Header().Set("X-Low-Watermark", strconv.Itoa(lowWatermark))
 		}
-	} else {
-		h.App.Logger.Info("fetched messages without committing", "topic", topic, "consumer", consumerID)
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(messages)
 }
+
+// HandleCommitOffset moves a consumer's, or with group_id a group's, committed
+// offset on one partition. Consumers that fetch without X-Commit use it once they
+// have processed what they fetched.
+func (h *Handler) HandleCommitOffset(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		h.App.Logger.Warn("http method not allowed for committing an offset", "method", r.Method)
+		http.Error(w, "http method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if r.Header.Get("Content-Type") != "application/json" {
+		h.App.Logger.Warn("invalid content-type received", "received", r.Header.Get("Content-Type"))
+		http.Error(w, "Content-Type must be application/json", http.StatusUnsupportedMediaType)
+		return
+	}
+
+	var req struct {
+		Topic      string `json:"topic"`
+		ConsumerID string `json:"consumer_id"`
+		GroupID    string `json:"group_id"`
+		Partition  int    `json:"partition"`
+		Offset     int    `json:"offset"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Topic == "" || (req.ConsumerID == "" && req.GroupID == "") || req.Partition < 0 || req.Offset < 0 {
+		h.App.Logger.Error("invalid commit request payload", "error", err)
+		http.Error(w, "invalid payload in request", http.StatusBadRequest)
+		return
+	}
+
+	offsetKey := req.ConsumerID
+	if req.GroupID != "" {
+		offsetKey = req.GroupID
+	}
+
+	if err := h.App.Repo.CommitOffset(req.Topic, req.Partition, offsetKey, req.Offset); err != nil {
+		if strings.Contains(err.Error(), "does not exist") {
+			h.App.Logger.Warn("commit attempted on non-existent topic or partition", "topic", req.Topic, "partition", req.Partition)
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		var rangeErr *repository.OffsetOutOfRangeError
+		if errors.As(err, &rangeErr) {
+			h.App.Logger.Warn("commit offset is out of range", "topic", req.Topic, "partition", req.Partition, "consumer", offsetKey, "offset", req.Offset, "low_watermark", rangeErr.LowWatermark)
+			w.Header().Set("X-Low-Watermark", strconv.Itoa(rangeErr.LowWatermark))
+			http.Error(w, "offset out of range: "+err.Error(), http.StatusRequestedRangeNotSatisfiable)
+			return
+		}
+		if strings.Contains(err.Error(), "beyond the partition length") {
+			h.App.Logger.Warn("commit offset is past the end of the partition", "topic", req.Topic, "partition", req.Partition, "offset", req.Offset)
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		h.App.Logger.Error("failed to commit offset", "topic", req.Topic, "partition", req.Partition, "consumer", offsetKey, "error", err)
+		http.Error(w, "failed to commit offset", http.StatusInternalServerError)
+		return
+	}
+
+	h.App.Logger.Info("offset committed", "topic", req.Topic, "partition", req.Partition, "consumer", offsetKey, "offset", req.Offset)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]any{
+		"message": "offset committed successfully",
+		"offset":  req.Offset,
+	})
+}
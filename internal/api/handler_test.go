@@ -2,6 +2,7 @@ package api
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"io"
 	"net/http"
@@ -9,6 +10,7 @@ import (
 	"strconv"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/codytheroux96/go-mq/internal/app"
 )
@@ -53,6 +55,66 @@ func TestHandlers(t *testing.T) {
 		}
 	})
 
+	t.Run("PUT /topics/{topic}/retention", func(t *testing.T) {
+		_ = makeRequest(ts, http.MethodPost, "/topics", strings.NewReader(`{"name":"retained-topic","retention":{"max_messages":5}}`), map[string]string{"Content-Type": "application/json"})
+
+		rr := makeRequest(ts, http.MethodGet, "/topics/retained-topic/retention", nil, nil)
+		if rr.Code != http.StatusOK {
+			t.Errorf("expected 200, got %d", rr.Code)
+		}
+		if !strings.Contains(rr.Body.String(), `"max_messages":5`) {
+			t.Errorf("expected retention from topic creation, got %s", rr.Body.String())
+		}
+
+		rr = makeRequest(ts, http.MethodPut, "/topics/retained-topic/retention", strings.NewReader(`{"max_age_ms":60000}`), map[string]string{"Content-Type": "application/json"})
+		if rr.Code != http.StatusOK {
+			t.Errorf("expected 200, got %d", rr.Code)
+		}
+
+		rr = makeRequest(ts, http.MethodPut, "/topics/retained-topic/retention", strings.NewReader(`{"max_bytes":-1}`), map[string]string{"Content-Type": "application/json"})
+		if rr.Code != http.StatusBadRequest {
+			t.Errorf("expected 400, got %d", rr.Code)
+		}
+
+		rr = makeRequest(ts, http.MethodGet, "/topics/unknown/retention", nil, nil)
+		if rr.Code != http.StatusNotFound {
+			t.Errorf("expected 404, got %d", rr.Code)
+		}
+	})
+
+	t.Run("PUT /topics/{topic}/dead-letter", func(t *testing.T) {
+		_ = makeRequest(ts, http.MethodPost, "/topics", strings.NewReader(`{"name":"orders-dlq"}`), map[string]string{"Content-Type": "application/json"})
+		rr := makeRequest(ts, http.MethodPost, "/topics", strings.NewReader(`{"name":"orders","dead_letter":{"max_delivery_attempts":3,"topic":"orders-dlq"}}`), map[string]string{"Content-Type": "application/json"})
+		if rr.Code != http.StatusCreated {
+			t.Errorf("expected 201, got %d", rr.Code)
+		}
+
+		rr = makeRequest(ts, http.MethodGet, "/topics/orders/dead-letter", nil, nil)
+		if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), `"max_delivery_attempts":3`) {
+			t.Errorf("expected dead-letter settings from topic creation, got %d %s", rr.Code, rr.Body.String())
+		}
+
+		rr = makeRequest(ts, http.MethodPut, "/topics/orders/dead-letter", strings.NewReader(`{"max_delivery_attempts":5,"topic":"orders-dlq"}`), map[string]string{"Content-Type": "application/json"})
+		if rr.Code != http.StatusOK {
+			t.Errorf("expected 200, got %d", rr.Code)
+		}
+
+		rr = makeRequest(ts, http.MethodPut, "/topics/orders/dead-letter", strings.NewReader(`{"max_delivery_attempts":5,"topic":"orders"}`), map[string]string{"Content-Type": "application/json"})
+		if rr.Code != http.StatusBadRequest {
+			t.Errorf("expected 400 for a topic dead-lettering to itself, got %d", rr.Code)
+		}
+
+		rr = makeRequest(ts, http.MethodPost, "/topics/orders-dlq/redrive", nil, nil)
+		if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), `"redriven":0`) {
+			t.Errorf("expected empty redrive, got %d %s", rr.Code, rr.Body.String())
+		}
+
+		rr = makeRequest(ts, http.MethodPost, "/topics/unknown/redrive", nil, nil)
+		if rr.Code != http.StatusNotFound {
+			t.Errorf("expected 404, got %d", rr.Code)
+		}
+	})
+
 	t.Run("GET /topics", func(t *testing.T) {
 		rr := makeRequest(ts, http.MethodGet, "/topics", nil, nil)
 		if rr.Code != http.StatusOK {
@@ -60,6 +122,359 @@ func TestHandlers(t *testing.T) {
 		}
 	})
 
+	t.Run("GET /topics/{topic}", func(t *testing.T) {
+		_ = makeRequest(ts, http.MethodPost, "/topics", strings.NewReader(`{"name":"described-topic","partitions":2}`), map[string]string{"Content-Type": "application/json"})
+		for i := 0; i < 3; i++ {
+			_ = makeRequest(ts, http.MethodPost, "/publish/described-topic", strings.NewReader(`{"body":"hello","producer_id":"p1","key":"k"}`), map[string]string{"Content-Type": "application/json"})
+		}
+		// every message shares a key, so only one partition has anything to fetch
+		for _, partition := range []string{"0", "1"} {
+			_ = makeRequest(ts, http.MethodGet, "/fetch", nil, map[string]string{"X-Topic": "described-topic", "X-Consumer-ID": "c1", "X-Partition": partition, "X-Limit": "1", "X-Commit": "true"})
+		}
+
+		rr := makeRequest(ts, http.MethodGet, "/topics/described-topic?consumer_id=c1", nil, nil)
+		if rr.Code != http.StatusOK {
+			t.Fatalf("expected 200, got %d", rr.Code)
+		}
+
+		var resp struct {
+			Partitions []struct {
+				Partition       int  `json:"partition"`
+				HighWatermark   int  `json:"high_watermark"`
+				CommittedOffset *int `json:"committed_offset"`
+				Lag             *int `json:"lag"`
+			} `json:"partitions"`
+		}
+		if err := json.NewDecoder(rr.Body).Decode(&resp); err != nil || len(resp.Partitions) != 2 {
+			t.Fatalf("expected 2 partitions, got %+v %v", resp, err)
+		}
+
+		lag := 0
+		for _, p := range resp.Partitions {
+			if p.CommittedOffset == nil || p.Lag == nil {
+				t.Fatalf("expected committed offset and lag for partition %d", p.Partition)
+			}
+			lag += *p.Lag
+		}
+		if lag != 2 {
+			t.Errorf("expected a total lag of 2 after consuming 1 of 3 messages, got %d", lag)
+		}
+
+		rr = makeRequest(ts, http.MethodGet, "/topics/unknown", nil, nil)
+		if rr.Code != http.StatusNotFound {
+			t.Errorf("expected 404, got %d", rr.Code)
+		}
+	})
+
+	t.Run("GET /topics/{topic}/stats", func(t *testing.T) {
+		rr := makeRequest(ts, http.MethodGet, "/topics/described-topic/stats", nil, nil)
+		if rr.Code != http.StatusOK {
+			t.Fatalf("expected 200, got %d", rr.Code)
+		}
+
+		var stats struct {
+			MessageCount      int        `json:"message_count"`
+			Bytes             int64      `json:"bytes"`
+			EarliestTimestamp *time.Time `json:"earliest_timestamp"`
+			LatestTimestamp   *time.Time `json:"latest_timestamp"`
+			Partitions        []struct {
+				EarliestOffset int `json:"earliest_offset"`
+				LatestOffset   int `json:"latest_offset"`
+			} `json:"partitions"`
+		}
+		if err := json.NewDecoder(rr.Body).Decode(&stats); err != nil {
+			t.Fatalf("failed to decode stats: %v", err)
+		}
+		if stats.MessageCount != 3 || stats.Bytes != 15 || len(stats.Partitions) != 2 {
+			t.Errorf("expected 3 messages of 15 bytes over 2 partitions, got %+v", stats)
+		}
+		if stats.EarliestTimestamp == nil || stats.LatestTimestamp == nil || stats.LatestTimestamp.Before(*stats.EarliestTimestamp) {
+			t.Errorf("expected an ordered timestamp range, got %v to %v", stats.EarliestTimestamp, stats.LatestTimestamp)
+		}
+
+		rr = makeRequest(ts, http.MethodGet, "/topics/unknown/stats", nil, nil)
+		if rr.Code != http.StatusNotFound {
+			t.Errorf("expected 404, got %d", rr.Code)
+		}
+	})
+
+	t.Run("GET /topics/{topic}/consumers", func(t *testing.T) {
+		rr := makeRequest(ts, http.MethodGet, "/topics/described-topic/consumers", nil, nil)
+		if rr.Code != http.StatusOK {
+			t.Fatalf("expected 200, got %d", rr.Code)
+		}
+
+		var resp struct {
+			Consumers []struct {
+				ID        string     `json:"id"`
+				Group     bool       `json:"group"`
+				Lag       int        `json:"lag"`
+				LastFetch *time.Time `json:"last_fetch"`
+				Unacked   int        `json:"unacked"`
+			} `json:"consumers"`
+		}
+		if err := json.NewDecoder(rr.Body).Decode(&resp); err != nil || len(resp.Consumers) != 1 {
+			t.Fatalf("expected one consumer, got %+v %v", resp, err)
+		}
+		c1 := resp.Consumers[0]
+		if c1.ID != "c1" || c1.Group || c1.Lag != 2 || c1.LastFetch == nil || c1.Unacked != 1 {
+			t.Errorf("expected c1 with lag 2, a last fetch and 1 unacked, got %+v", c1)
+		}
+
+		rr = makeRequest(ts, http.MethodGet, "/topics/unknown/consumers", nil, nil)
+		if rr.Code != http.StatusNotFound {
+			t.Errorf("expected 404, got %d", rr.Code)
+		}
+	})
+
+	t.Run("GET /topics/{topic}/offsets", func(t *testing.T) {
+		jsonHeader := map[string]string{"Content-Type": "application/json"}
+		_ = makeRequest(ts, http.MethodPost, "/topics", strings.NewReader(`{"name":"replay-topic"}`), jsonHeader)
+		publish := func() {
+			_ = makeRequest(ts, http.MethodPost, "/publish/replay-topic", strings.NewReader(`{"body":"hello","producer_id":"p1"}`), jsonHeader)
+		}
+		publish()
+		publish()
+		time.Sleep(5 * time.Millisecond)
+		since := time.Now().UTC().Format(time.RFC3339Nano)
+		time.Sleep(5 * time.Millisecond)
+		publish()
+		publish()
+
+		rr := makeRequest(ts, http.MethodGet, "/topics/replay-topic/offsets?timestamp="+since, nil, nil)
+		if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), `"offsets":[{"partition":0,"offset":2}]`) {
+			t.Errorf("expected offset 2 for the start time, got %d %s", rr.Code, rr.Body.String())
+		}
+
+		tests := []struct {
+			name   string
+			path   string
+			expect int
+		}{
+			{"Missing timestamp", "/topics/replay-topic/offsets", http.StatusBadRequest},
+			{"Unknown partition", "/topics/replay-topic/offsets?partition=3&timestamp=" + since, http.StatusNotFound},
+			{"Unknown topic", "/topics/unknown/offsets?timestamp=" + since, http.StatusNotFound},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				rr := makeRequest(ts, http.MethodGet, tt.path, nil, nil)
+				if rr.Code != tt.expect {
+					t.Errorf("expected %d, got %d", tt.expect, rr.Code)
+				}
+			})
+		}
+
+		fetch := func(consumerID string, headers map[string]string) []map[string]any {
+			h := map[string]string{"X-Topic": "replay-topic", "X-Consumer-ID": consumerID, "X-Commit": "true"}
+			for k, v := range headers {
+				h[k] = v
+			}
+			rr := makeRequest(ts, http.MethodGet, "/fetch", nil, h)
+			if rr.Code != http.StatusOK {
+				t.Fatalf("expected 200, got %d %s", rr.Code, rr.Body.String())
+			}
+			var messages []map[string]any
+			json.NewDecoder(rr.Body).Decode(&messages)
+			return messages
+		}
+
+		if messages := fetch("replayer", map[string]string{"X-Start-Time": since}); len(messages) != 2 {
+			t.Errorf("expected a fetch from the start time to replay 2 messages, got %d", len(messages))
+		}
+		// going back further than before moves the committed offset backwards
+		if messages := fetch("replayer", map[string]string{"X-Start-Time": "2000-01-01T00:00:00Z"}); len(messages) != 4 {
+			t.Errorf("expected a fetch from long ago to replay all 4 messages, got %d", len(messages))
+		}
+		// an offset is read from rather than only committed past
+		if messages := fetch("offset-reader", map[string]string{"X-Offset": "1"}); len(messages) != 3 || messages[0]["Offset"] != float64(1) {
+			t.Errorf("expected a fetch from offset 1 to return the 3 messages from there, got %v", messages)
+		}
+		if messages := fetch("offset-reader", map[string]string{"X-Offset": "3"}); len(messages) != 1 || messages[0]["Offset"] != float64(3) {
+			t.Errorf("expected a fetch from offset 3 to return the last message, got %v", messages)
+		}
+
+		rr = makeRequest(ts, http.MethodGet, "/fetch", nil, map[string]string{"X-Topic": "replay-topic", "X-Consumer-ID": "replayer", "X-Start-Time": "02:00"})
+		if rr.Code != http.StatusBadRequest {
+			t.Errorf("expected 400 for a start time that is not RFC 3339, got %d", rr.Code)
+		}
+		rr = makeRequest(ts, http.MethodGet, "/fetch", nil, map[string]string{"X-Topic": "replay-topic", "X-Consumer-ID": "replayer", "X-Start-Time": since, "X-Offset": "0"})
+		if rr.Code != http.StatusBadRequest {
+			t.Errorf("expected 400 for a start time with an offset, got %d", rr.Code)
+		}
+		for _, offset := range []string{"-1", "first", "9"} {
+			rr = makeRequest(ts, http.MethodGet, "/fetch", nil, map[string]string{"X-Topic": "replay-topic", "X-Consumer-ID": "replayer", "X-Offset": offset})
+			if rr.Code != http.StatusBadRequest {
+				t.Errorf("expected 400 for offset %s, got %d", offset, rr.Code)
+			}
+		}
+
+		rr = makeRequest(ts, http.MethodPost, "/subscribe", strings.NewReader(`{"topic":"replay-topic","consumer_id":"registered","start_time":"`+since+`"}`), jsonHeader)
+		if rr.Code != http.StatusCreated {
+			t.Fatalf("expected 201, got %d %s", rr.Code, rr.Body.String())
+		}
+		if messages := fetch("registered", nil); len(messages) != 2 {
+			t.Errorf("expected a consumer registered with a start time to fetch 2 messages, got %d", len(messages))
+		}
+
+		rr = makeRequest(ts, http.MethodPost, "/subscribe", strings.NewReader(`{"topic":"replay-topic","consumer_id":"registered","start_time":"`+since+`","offset":0}`), jsonHeader)
+		if rr.Code != http.StatusBadRequest {
+			t.Errorf("expected 400 for a start time with an offset, got %d", rr.Code)
+		}
+	})
+
+	t.Run("POST /topics/{topic}/offsets/reset", func(t *testing.T) {
+		jsonHeader := map[string]string{"Content-Type": "application/json"}
+		_ = makeRequest(ts, http.MethodPost, "/topics", strings.NewReader(`{"name":"reset-topic"}`), jsonHeader)
+		for range 3 {
+			_ = makeRequest(ts, http.MethodPost, "/publish/reset-topic", strings.NewReader(`{"body":"hello","producer_id":"p1"}`), jsonHeader)
+		}
+
+		rr := makeRequest(ts, http.MethodPost, "/subscribe", strings.NewReader(`{"topic":"reset-topic","consumer_id":"tail","auto_offset_reset":"latest"}`), jsonHeader)
+		if rr.Code != http.StatusCreated {
+			t.Fatalf("expected 201, got %d %s", rr.Code, rr.Body.String())
+		}
+		rr = makeRequest(ts, http.MethodGet, "/topics/reset-topic?consumer_id=tail", nil, nil)
+		if !strings.Contains(rr.Body.String(), `"committed_offset":3`) {
+			t.Errorf("expected a consumer registered at latest to start at offset 3, got %s", rr.Body.String())
+		}
+		rr = makeRequest(ts, http.MethodPost, "/subscribe", strings.NewReader(`{"topic":"reset-topic","consumer_id":"tail","auto_offset_reset":"middle"}`), jsonHeader)
+		if rr.Code != http.StatusBadRequest {
+			t.Errorf("expected 400 for an unknown auto offset reset, got %d", rr.Code)
+		}
+		// registering opens no session, fetching does
+		_ = makeRequest(ts, http.MethodPost, "/subscribe", strings.NewReader(`{"topic":"reset-topic","consumer_id":"parked"}`), jsonHeader)
+		_ = makeRequest(ts, http.MethodGet, "/fetch", nil, map[string]string{"X-Topic": "reset-topic", "X-Consumer-ID": "tail"})
+
+		tests := []struct {
+			name         string
+			path         string
+			body         string
+			expect       int
+			expectOffset string
+		}{
+			{"Earliest for an idle consumer", "/topics/reset-topic/offsets/reset", `{"consumer_id":"idle","to":"earliest"}`, http.StatusOK, `"offset":0`},
+			{"Offset for a group", "/topics/reset-topic/offsets/reset", `{"group_id":"idle-group","to":"offset","offset":2}`, http.StatusOK, `"offset":2`},
+			{"Shift back from the log end", "/topics/reset-topic/offsets/reset", `{"consumer_id":"tail","to":"shift","shift":-2,"force":true}`, http.StatusOK, `"offset":1`},
+			{"Active consumer without force", "/topics/reset-topic/offsets/reset", `{"consumer_id":"tail","to":"earliest"}`, http.StatusConflict, ""},
+			{"Registered consumer without a session", "/topics/reset-topic/offsets/reset", `{"consumer_id":"parked","to":"latest"}`, http.StatusOK, `"offset":3`},
+			{"Both a consumer and a group", "/topics/reset-topic/offsets/reset", `{"consumer_id":"idle","group_id":"idle-group","to":"earliest"}`, http.StatusBadRequest, ""},
+			{"Offset missing", "/topics/reset-topic/offsets/reset", `{"consumer_id":"idle","to":"offset"}`, http.StatusBadRequest, ""},
+			{"Unknown reset", "/topics/reset-topic/offsets/reset", `{"consumer_id":"idle","to":"sideways"}`, http.StatusBadRequest, ""},
+			{"Unknown partition", "/topics/reset-topic/offsets/reset", `{"consumer_id":"idle","to":"earliest","partition":4}`, http.StatusNotFound, ""},
+			{"Unknown topic", "/topics/unknown/offsets/reset", `{"consumer_id":"idle","to":"earliest"}`, http.StatusNotFound, ""},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				rr := makeRequest(ts, http.MethodPost, tt.path, strings.NewReader(tt.body), jsonHeader)
+				if rr.Code != tt.expect {
+					t.Fatalf("expected %d, got %d %s", tt.expect, rr.Code, rr.Body.String())
+				}
+				if !strings.Contains(rr.Body.String(), tt.expectOffset) {
+					t.Errorf("expected %s in %s", tt.expectOffset, rr.Body.String())
+				}
+			})
+		}
+	})
+
+	t.Run("DELETE /topics/{topic}/consumers/{consumer}", func(t *testing.T) {
+		tests := []struct {
+			name   string
+			path   string
+			expect int
+		}{
+			{"Active consumer without force", "/topics/reset-topic/consumers/tail", http.StatusConflict},
+			{"Active consumer with force", "/topics/reset-topic/consumers/tail?force=true", http.StatusOK},
+			{"Idle consumer", "/topics/reset-topic/consumers/idle", http.StatusOK},
+			{"Invalid force", "/topics/reset-topic/consumers/idle?force=maybe", http.StatusBadRequest},
+			{"Missing consumer", "/topics/reset-topic/consumers/", http.StatusBadRequest},
+			{"Unknown topic", "/topics/unknown/consumers/idle", http.StatusNotFound},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				rr := makeRequest(ts, http.MethodDelete, tt.path, nil, nil)
+				if rr.Code != tt.expect {
+					t.Fatalf("expected %d, got %d %s", tt.expect, rr.Code, rr.Body.String())
+				}
+			})
+		}
+
+		rr := makeRequest(ts, http.MethodGet, "/topics/reset-topic/consumers", nil, nil)
+		if strings.Contains(rr.Body.String(), `"id":"tail"`) || strings.Contains(rr.Body.String(), `"id":"idle"`) {
+			t.Errorf("expected deleted consumers to be gone, got %s", rr.Body.String())
+		}
+	})
+
+	t.Run("Subscription filters", func(t *testing.T) {
+		jsonHeader := map[string]string{"Content-Type": "application/json"}
+		_ = makeRequest(ts, http.MethodPost, "/topics", strings.NewReader(`{"name":"filtered-topic"}`), jsonHeader)
+
+		rr := makeRequest(ts, http.MethodPost, "/subscribe", strings.NewReader(`{"topic":"filtered-topic","consumer_id":"eu-only","filter":"region == \"eu\" && priority > 3"}`), jsonHeader)
+		if rr.Code != http.StatusCreated {
+			t.Fatalf("expected 201, got %d %s", rr.Code, rr.Body.String())
+		}
+		for _, body := range []string{
+			`{\"region\":\"eu\",\"priority\":5}`,
+			`{\"region\":\"us\",\"priority\":5}`,
+			`{\"region\":\"eu\",\"priority\":1}`,
+			`{\"region\":\"eu\",\"priority\":4}`,
+		} {
+			_ = makeRequest(ts, http.MethodPost, "/publish/filtered-topic", strings.NewReader(`{"body":"`+body+`","producer_id":"p1"}`), jsonHeader)
+		}
+
+		rr = makeRequest(ts, http.MethodGet, "/fetch", nil, map[string]string{"X-Topic": "filtered-topic", "X-Consumer-ID": "eu-only", "X-Commit": "true"})
+		var messages []struct {
+			Offset int `json:"offset"`
+		}
+		json.NewDecoder(rr.Body).Decode(&messages)
+		if len(messages) != 2 || messages[0].Offset != 0 || messages[1].Offset != 3 {
+			t.Errorf("expected the messages at offsets 0 and 3, got %+v", messages)
+		}
+		rr = makeRequest(ts, http.MethodGet, "/topics/filtered-topic?consumer_id=eu-only", nil, nil)
+		if !strings.Contains(rr.Body.String(), `"committed_offset":4`) {
+			t.Errorf("expected skipped messages to be committed past, got %s", rr.Body.String())
+		}
+		rr = makeRequest(ts, http.MethodGet, "/topics/filtered-topic/consumers", nil, nil)
+		if !strings.Contains(rr.Body.String(), `"filter":"region == \"eu\" \u0026\u0026 priority \u003e 3"`) {
+			t.Errorf("expected the consumer's filter in the listing, got %s", rr.Body.String())
+		}
+
+		// a filter on the fetch replaces the registered one
+		rr = makeRequest(ts, http.MethodGet, "/fetch", nil, map[string]string{"X-Topic": "filtered-topic", "X-Consumer-ID": "us-only", "X-Filter": `region == "us"`})
+		messages = nil
+		json.NewDecoder(rr.Body).Decode(&messages)
+		if len(messages) != 1 || messages[0].Offset != 1 {
+			t.Errorf("expected the message at offset 1, got %+v", messages)
+		}
+
+		tests := []struct {
+			name    string
+			method  string
+			path    string
+			body    string
+			headers map[string]string
+			expect  int
+		}{
+			{"Invalid filter on fetch", http.MethodGet, "/fetch", "", map[string]string{"X-Topic": "filtered-topic", "X-Consumer-ID": "c1", "X-Filter": `region = "eu"`}, http.StatusBadRequest},
+			{"Invalid filter on registration", http.MethodPost, "/subscribe", `{"topic":"filtered-topic","consumer_id":"c1","filter":"priority >"}`, jsonHeader, http.StatusBadRequest},
+			{"Filter on a missing topic", http.MethodPost, "/subscribe", `{"topic":"unknown","consumer_id":"c1","filter":"priority > 1"}`, jsonHeader, http.StatusNotFound},
+			{"Invalid filter on subscribe", http.MethodGet, "/subscribe/filtered-topic?consumer_id=c1&filter=%28", "", nil, http.StatusBadRequest},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				var body io.Reader
+				if tt.body != "" {
+					body = strings.NewReader(tt.body)
+				}
+				rr := makeRequest(ts, tt.method, tt.path, body, tt.headers)
+				if rr.Code != tt.expect {
+					t.Errorf("expected %d, got %d %s", tt.expect, rr.Code, rr.Body.String())
+				}
+			})
+		}
+	})
+
 	t.Run("DELETE /topics/{topic}", func(t *testing.T) {
 		rr := makeRequest(ts, http.MethodDelete, "/topics/t1", nil, nil)
 		if rr.Code != http.StatusOK {
@@ -80,6 +495,238 @@ func TestHandlers(t *testing.T) {
 		}
 	})
 
+	t.Run("POST /publish/{topic} with a delay", func(t *testing.T) {
+		headers := map[string]string{"Content-Type": "application/json"}
+		_ = makeRequest(ts, http.MethodPost, "/topics", strings.NewReader(`{"name":"delayed-topic"}`), headers)
+
+		rr := makeRequest(ts, http.MethodPost, "/publish/delayed-topic", strings.NewReader(`{"body":"later","producer_id":"p1","delay_ms":60000}`), headers)
+		if rr.Code != http.StatusAccepted || !strings.Contains(rr.Body.String(), "deliver_at") {
+			t.Errorf("expected scheduled publish, got %d %s", rr.Code, rr.Body.String())
+		}
+
+		rr = makeRequest(ts, http.MethodGet, "/fetch", nil, map[string]string{"X-Topic": "delayed-topic", "X-Consumer-ID": "c1"})
+		if rr.Code != http.StatusOK || strings.TrimSpace(rr.Body.String()) != "[]" {
+			t.Errorf("expected no messages before the delay passes, got %d %s", rr.Code, rr.Body.String())
+		}
+
+		rr = makeRequest(ts, http.MethodPost, "/publish/delayed-topic", strings.NewReader(`{"body":"later","producer_id":"p1","delay_ms":-1}`), headers)
+		if rr.Code != http.StatusBadRequest {
+			t.Errorf("expected 400 for negative delay, got %d", rr.Code)
+		}
+
+		rr = makeRequest(ts, http.MethodPost, "/publish/delayed-topic", strings.NewReader(`{"body":"later","producer_id":"p1","delay_ms":5,"deliver_at":"2030-01-01T00:00:00Z"}`), headers)
+		if rr.Code != http.StatusBadRequest {
+			t.Errorf("expected 400 for both deliver_at and delay_ms, got %d", rr.Code)
+		}
+	})
+
+	t.Run("POST /publish/{topic} with key", func(t *testing.T) {
+		_ = makeRequest(ts, http.MethodPost, "/topics", strings.NewReader(`{"name":"keyed-topic","partitions":4}`), map[string]string{"Content-Type": "application/json"})
+
+		partition := -1
+		for i := 0; i < 3; i++ {
+			rr := makeRequest(ts, http.MethodPost, "/publish/keyed-topic", strings.NewReader(`{"body":"update","producer_id":"p1","key":"customer-7"}`), map[string]string{"Content-Type": "application/json"})
+			if rr.Code != http.StatusAccepted {
+				t.Fatalf("expected 202, got %d", rr.Code)
+			}
+
+			var resp struct {
+				Partition int `json:"partition"`
+				Offset    int `json:"offset"`
+			}
+			json.NewDecoder(rr.Body).Decode(&resp)
+			if partition != -1 && resp.Partition != partition {
+				t.Fatalf("expected key to stay on partition %d, got %d", partition, resp.Partition)
+			}
+			if resp.Offset != i {
+				t.Fatalf("expected offset %d, got %d", i, resp.Offset)
+			}
+			partition = resp.Partition
+		}
+
+		rr := makeRequest(ts, http.MethodGet, "/fetch", nil, map[string]string{
+			"X-Topic":       "keyed-topic",
+			"X-Consumer-ID": "c1",
+			"X-Partition":   strconv.Itoa(partition),
+		})
+		var messages []map[string]any
+		json.NewDecoder(rr.Body).Decode(&messages)
+		if rr.Code != http.StatusOK || len(messages) != 3 {
+			t.Errorf("expected 200 with 3 messages, got %d with %d", rr.Code, len(messages))
+		}
+
+		rr = makeRequest(ts, http.MethodGet, "/fetch", nil, map[string]string{
+			"X-Topic":       "keyed-topic",
+			"X-Consumer-ID": "c1",
+			"X-Partition":   "4",
+		})
+		if rr.Code != http.StatusNotFound {
+			t.Errorf("expected 404 for missing partition, got %d", rr.Code)
+		}
+	})
+
+	t.Run("POST /publish/{topic} with a sequence", func(t *testing.T) {
+		headers := map[string]string{"Content-Type": "application/json"}
+		_ = makeRequest(ts, http.MethodPost, "/topics", strings.NewReader(`{"name":"sequenced-topic"}`), headers)
+
+		rr := makeRequest(ts, http.MethodPost, "/producers", strings.NewReader(`{"producer_id":"seq-producer"}`), headers)
+		if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), `"epoch":0`) {
+			t.Fatalf("expected registration with epoch 0, got %d %s", rr.Code, rr.Body.String())
+		}
+
+		tests := []struct {
+			name       string
+			payload    string
+			statusCode int
+			expectBody string
+		}{
+			{"First sequence", `{"body":"a","producer_id":"seq-producer","epoch":0,"sequence":0}`, http.StatusAccepted, `"offset":0`},
+			{"Retried sequence", `{"body":"a","producer_id":"seq-producer","epoch":0,"sequence":0}`, http.StatusOK, `"duplicate":true`},
+			{"Gap in the sequence", `{"body":"b","producer_id":"seq-producer","epoch":0,"sequence":2}`, http.StatusConflict, "out of order"},
+			{"Stale epoch", `{"body":"b","producer_id":"seq-producer","epoch":5,"sequence":1}`, http.StatusConflict, "epoch"},
+			{"Unregistered producer", `{"body":"b","producer_id":"other","epoch":0,"sequence":0}`, http.StatusPreconditionFailed, "not registered"},
+			{"Sequence without epoch", `{"body":"b","producer_id":"seq-producer","sequence":1}`, http.StatusBadRequest, ""},
+			{"Scheduled with a sequence", `{"body":"b","producer_id":"seq-producer","epoch":0,"sequence":1,"delay_ms":1000}`, http.StatusBadRequest, ""},
+			{"Next sequence", `{"body":"b","producer_id":"seq-producer","epoch":0,"sequence":1}`, http.StatusAccepted, `"offset":1`},
+		}
+		for _, tc := range tests {
+			t.Run(tc.name, func(t *testing.T) {
+				rr := makeRequest(ts, http.MethodPost, "/publish/sequenced-topic", strings.NewReader(tc.payload), headers)
+				if rr.Code != tc.statusCode || !strings.Contains(rr.Body.String(), tc.expectBody) {
+					t.Errorf("expected %d containing %q, got %d %s", tc.statusCode, tc.expectBody, rr.Code, rr.Body.String())
+				}
+			})
+		}
+	})
+
+	t.Run("PUT /topics/{topic}/dedup", func(t *testing.T) {
+		headers := map[string]string{"Content-Type": "application/json"}
+		_ = makeRequest(ts, http.MethodPost, "/topics", strings.NewReader(`{"name":"dedup-topic"}`), headers)
+
+		tests := []struct {
+			name       string
+			method     string
+			path       string
+			payload    string
+			statusCode int
+		}{
+			{"Set dedup window", http.MethodPut, "/topics/dedup-topic/dedup", `{"window_ms":60000,"max_ids":1000}`, http.StatusOK},
+			{"Negative window", http.MethodPut, "/topics/dedup-topic/dedup", `{"window_ms":-1}`, http.StatusBadRequest},
+			{"Get dedup settings", http.MethodGet, "/topics/dedup-topic/dedup", "", http.StatusOK},
+			{"Unknown topic", http.MethodGet, "/topics/missing-topic/dedup", "", http.StatusNotFound},
+		}
+		for _, tc := range tests {
+			t.Run(tc.name, func(t *testing.T) {
+				rr := makeRequest(ts, tc.method, tc.path, strings.NewReader(tc.payload), headers)
+				if rr.Code != tc.statusCode {
+					t.Errorf("expected %d, got %d", tc.statusCode, rr.Code)
+				}
+			})
+		}
+
+		publish := []struct {
+			name            string
+			headers         map[string]string
+			expectDuplicate bool
+		}{
+			{"First publish of a dedup id", map[string]string{"Dedup-Id": "order-1"}, false},
+			{"Repeat of the dedup id", map[string]string{"Dedup-Id": "order-1"}, true},
+			{"Idempotency key", map[string]string{"Idempotency-Key": "order-2"}, false},
+			{"Repeat of the idempotency key", map[string]string{"Idempotency-Key": "order-2"}, true},
+		}
+		for _, tc := range publish {
+			t.Run(tc.name, func(t *testing.T) {
+				tc.headers["Content-Type"] = "application/json"
+				rr := makeRequest(ts, http.MethodPost, "/publish/dedup-topic", strings.NewReader(`{"body":"order","producer_id":"p1"}`), tc.headers)
+				if duplicate := strings.Contains(rr.Body.String(), `"duplicate":true`); duplicate != tc.expectDuplicate || rr.Code >= 300 {
+					t.Errorf("expected duplicate %v, got %d %s", tc.expectDuplicate, rr.Code, rr.Body.String())
+				}
+			})
+		}
+
+		rr := makeRequest(ts, http.MethodGet, "/topics/dedup-topic", nil, nil)
+		if !strings.Contains(rr.Body.String(), `"high_watermark":2`) || !strings.Contains(rr.Body.String(), `"max_ids":1000`) {
+			t.Errorf("expected two messages and the dedup settings, got %s", rr.Body.String())
+		}
+
+		rr = makeRequest(ts, http.MethodPost, "/publish/dedup-topic/batch", strings.NewReader(`[{"body":"order","producer_id":"p1","dedup_id":"order-1"},{"body":"order","producer_id":"p1","dedup_id":"order-3"}]`), headers)
+		if body := rr.Body.String(); !strings.Contains(body, `"published":1`) || !strings.Contains(body, `"duplicates":1`) || !strings.Contains(body, `"failed":0`) {
+			t.Errorf("expected one published message and one duplicate, got %d %s", rr.Code, body)
+		}
+
+		// a topic created again under the same name does not remember the old dedup ids
+		if rr := makeRequest(ts, http.MethodDelete, "/topics/dedup-topic", nil, nil); rr.Code != http.StatusOK {
+			t.Fatalf("expected the topic to be deleted, got %d", rr.Code)
+		}
+		_ = makeRequest(ts, http.MethodPost, "/topics", strings.NewReader(`{"name":"dedup-topic"}`), headers)
+		_ = makeRequest(ts, http.MethodPut, "/topics/dedup-topic/dedup", strings.NewReader(`{"window_ms":60000,"max_ids":1000}`), headers)
+		rr = makeRequest(ts, http.MethodPost, "/publish/dedup-topic", strings.NewReader(`{"body":"order","producer_id":"p1"}`), map[string]string{"Content-Type": "application/json", "Dedup-Id": "order-1"})
+		if strings.Contains(rr.Body.String(), `"duplicate":true`) || rr.Code >= 300 {
+			t.Errorf("expected the dedup id to be new on the recreated topic, got %d %s", rr.Code, rr.Body.String())
+		}
+	})
+
+	t.Run("POST /publish/{topic}/batch", func(t *testing.T) {
+		_ = makeRequest(ts, http.MethodPost, "/topics", strings.NewReader(`{"name":"batch-topic"}`), map[string]string{"Content-Type": "application/json"})
+
+		tests := []struct {
+			name         string
+			path         string
+			contentType  string
+			payload      string
+			statusCode   int
+			expectErrors []bool
+		}{
+			{"JSON array", "/publish/batch-topic/batch", "application/json", `[{"body":"a","producer_id":"p1"},{"body":"b","producer_id":"p1"}]`, http.StatusAccepted, []bool{false, false}},
+			{"NDJSON", "/publish/batch-topic/batch", "application/x-ndjson", "{\"body\":\"c\",\"producer_id\":\"p1\"}\n\n{\"body\":\"d\",\"producer_id\":\"p1\"}\n", http.StatusAccepted, []bool{false, false}},
+			{"Invalid message fails alone", "/publish/batch-topic/batch", "application/json", `[{"body":"e","producer_id":"p1"},{"body":"","producer_id":"p1"}]`, http.StatusAccepted, []bool{false, true}},
+			{"Invalid message fails an atomic batch", "/publish/batch-topic/batch?atomic=true", "application/json", `[{"body":"f","producer_id":"p1"},{"body":"g"}]`, http.StatusBadRequest, nil},
+			{"Atomic batch", "/publish/batch-topic/batch?atomic=true", "application/json", `[{"body":"h","producer_id":"p1"},{"body":"i","producer_id":"p1"}]`, http.StatusAccepted, []bool{false, false}},
+			{"Empty batch", "/publish/batch-topic/batch", "application/json", `[]`, http.StatusBadRequest, nil},
+			{"Malformed NDJSON", "/publish/batch-topic/batch", "application/x-ndjson", "{\"body\":\"j\"\n", http.StatusBadRequest, nil},
+			{"Unsupported content type", "/publish/batch-topic/batch", "text/plain", `[]`, http.StatusUnsupportedMediaType, nil},
+			{"Unknown topic", "/publish/missing-topic/batch", "application/json", `[{"body":"k","producer_id":"p1"}]`, http.StatusNotFound, nil},
+		}
+		for _, tc := range tests {
+			t.Run(tc.name, func(t *testing.T) {
+				rr := makeRequest(ts, http.MethodPost, tc.path, strings.NewReader(tc.payload), map[string]string{"Content-Type": tc.contentType})
+				if rr.Code != tc.statusCode {
+					t.Fatalf("expected %d, got %d: %s", tc.statusCode, rr.Code, rr.Body.String())
+				}
+				if tc.expectErrors == nil {
+					return
+				}
+
+				var resp struct {
+					Results []struct {
+						MessageID string `json:"message_id"`
+						Offset    *int   `json:"offset"`
+						Error     string `json:"error"`
+					} `json:"results"`
+				}
+				json.NewDecoder(rr.Body).Decode(&resp)
+				if len(resp.Results) != len(tc.expectErrors) {
+					t.Fatalf("expected %d results, got %d", len(tc.expectErrors), len(resp.Results))
+				}
+				for i, result := range resp.Results {
+					if failed := result.Error != ""; failed != tc.expectErrors[i] {
+						t.Errorf("result %d: expected failure %v, got %+v", i, tc.expectErrors[i], result)
+					}
+					if result.Error == "" && (result.MessageID == "" || result.Offset == nil) {
+						t.Errorf("result %d: expected a message id and offset, got %+v", i, result)
+					}
+				}
+			})
+		}
+
+		rr := makeRequest(ts, http.MethodGet, "/fetch", nil, map[string]string{"X-Topic": "batch-topic", "X-Consumer-ID": "c1", "X-Limit": "100"})
+		var messages []map[string]any
+		json.NewDecoder(rr.Body).Decode(&messages)
+		if len(messages) != 7 {
+			t.Errorf("expected the 7 valid messages to be appended, got %d", len(messages))
+		}
+	})
+
 	t.Run("POST /subscribe", func(t *testing.T) {
 		_ = makeRequest(ts, http.MethodPost, "/topics", bytes.NewReader([]byte(`{"name":"sub-topic"}`)), map[string]string{"Content-Type": "application/json"})
 		body := `{"topic": "sub-topic", "consumer_id": "c1"}`
@@ -97,6 +744,76 @@ func TestHandlers(t *testing.T) {
 		}
 	})
 
+	t.Run("POST /subscribe with flow control", func(t *testing.T) {
+		tests := []struct {
+			name   string
+			body   string
+			expect int
+		}{
+			{"Valid policy", `{"topic":"sub-topic","consumer_id":"c3","inbox_size":50,"slow_consumer_policy":"drop_oldest"}`, http.StatusCreated},
+			{"Unknown policy", `{"topic":"sub-topic","consumer_id":"c3","slow_consumer_policy":"drop_all"}`, http.StatusBadRequest},
+			{"Inbox too large", `{"topic":"sub-topic","consumer_id":"c3","inbox_size":1000000}`, http.StatusBadRequest},
+			{"Block timeout too long", `{"topic":"sub-topic","consumer_id":"c3","slow_consumer_policy":"block","block_timeout_ms":60000}`, http.StatusBadRequest},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				rr := makeRequest(ts, http.MethodPost, "/subscribe", strings.NewReader(tt.body), map[string]string{"Content-Type": "application/json"})
+				if rr.Code != tt.expect {
+					t.Errorf("expected %d, got %d: %s", tt.expect, rr.Code, rr.Body.String())
+				}
+			})
+		}
+
+		rr := makeRequest(ts, http.MethodGet, "/subscribe/sub-topic?consumer_id=c4&inbox_size=abc", nil, nil)
+		if rr.Code != http.StatusBadRequest {
+			t.Errorf("expected 400 for a non-numeric inbox_size, got %d", rr.Code)
+		}
+	})
+
+	t.Run("POST /groups/{group}/join", func(t *testing.T) {
+		_ = makeRequest(ts, http.MethodPost, "/topics", strings.NewReader(`{"name":"group-topic","partitions":2}`), map[string]string{"Content-Type": "application/json"})
+
+		rr := makeRequest(ts, http.MethodPost, "/groups/g1/join", strings.NewReader(`{"topic":"group-topic","member_id":"m1"}`), map[string]string{"Content-Type": "application/json"})
+		if rr.Code != http.StatusOK {
+			t.Fatalf("expected 200, got %d", rr.Code)
+		}
+		rr = makeRequest(ts, http.MethodPost, "/groups/g1/join", strings.NewReader(`{"topic":"group-topic","member_id":"m2"}`), map[string]string{"Content-Type": "application/json"})
+		if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), `"partitions":[1]`) {
+			t.Fatalf("expected m2 to be assigned partition 1, got %d %s", rr.Code, rr.Body.String())
+		}
+
+		rr = makeRequest(ts, http.MethodPost, "/groups/g1/heartbeat", strings.NewReader(`{"member_id":"ghost"}`), map[string]string{"Content-Type": "application/json"})
+		if rr.Code != http.StatusNotFound {
+			t.Errorf("expected 404 for unknown member, got %d", rr.Code)
+		}
+
+		rr = makeRequest(ts, http.MethodGet, "/fetch", nil, map[string]string{
+			"X-Topic":       "group-topic",
+			"X-Consumer-ID": "m1",
+			"X-Group-ID":    "g1",
+			"X-Partition":   "1",
+		})
+		if rr.Code != http.StatusConflict {
+			t.Errorf("expected 409 fetching a partition owned by another member, got %d", rr.Code)
+		}
+
+		rr = makeRequest(ts, http.MethodGet, "/groups/g1", nil, nil)
+		if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), `"member_id":"m2"`) {
+			t.Errorf("expected group description listing m2, got %d %s", rr.Code, rr.Body.String())
+		}
+
+		rr = makeRequest(ts, http.MethodPost, "/groups/g1/leave", strings.NewReader(`{"member_id":"m2"}`), map[string]string{"Content-Type": "application/json"})
+		if rr.Code != http.StatusOK {
+			t.Errorf("expected 200, got %d", rr.Code)
+		}
+
+		rr = makeRequest(ts, http.MethodGet, "/groups", nil, nil)
+		if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), `"group_id":"g1"`) {
+			t.Errorf("expected group list containing g1, got %d %s", rr.Code, rr.Body.String())
+		}
+	})
+
 	t.Run("POST /ack", func(t *testing.T) {
 		rr := makeRequest(ts, http.MethodPost, "/ack", strings.NewReader(`{"topic":"sub-topic","consumer_id":"c1","message_id":"nonexistent"}`), map[string]string{"Content-Type": "application/json"})
 		if rr.Code != http.StatusNotFound && rr.Code != http.StatusForbidden {
@@ -104,6 +821,17 @@ func TestHandlers(t *testing.T) {
 		}
 	})
 
+	t.Run("POST /nack", func(t *testing.T) {
+		rr := makeRequest(ts, http.MethodPost, "/nack", strings.NewReader(`{"topic":"sub-topic","consumer_id":"c1","message_id":"nonexistent","requeue":false}`), map[string]string{"Content-Type": "application/json"})
+		if rr.Code != http.StatusNotFound {
+			t.Errorf("expected 404, got %d", rr.Code)
+		}
+		rr = makeRequest(ts, http.MethodPost, "/nack", strings.NewReader(`{"topic":"sub-topic","consumer_id":"c1","message_id":"m1","delay_ms":-5}`), map[string]string{"Content-Type": "application/json"})
+		if rr.Code != http.StatusBadRequest {
+			t.Errorf("expected 400, got %d", rr.Code)
+		}
+	})
+
 	t.Run("GET /health", func(t *testing.T) {
 		rr := makeRequest(ts, http.MethodGet, "/health", nil, nil)
 		if rr.Code != http.StatusOK {
@@ -111,6 +839,93 @@ func TestHandlers(t *testing.T) {
 		}
 	})
 
+	t.Run("POST /transactions", func(t *testing.T) {
+		jsonHeader := map[string]string{"Content-Type": "application/json"}
+		_ = makeRequest(ts, http.MethodPost, "/topics", strings.NewReader(`{"name":"txn-in"}`), jsonHeader)
+		_ = makeRequest(ts, http.MethodPost, "/topics", strings.NewReader(`{"name":"txn-out"}`), jsonHeader)
+		_ = makeRequest(ts, http.MethodPost, "/publish/txn-in", strings.NewReader(`{"body":"in","producer_id":"p1"}`), jsonHeader)
+
+		begin := func() string {
+			rr := makeRequest(ts, http.MethodPost, "/transactions", strings.NewReader(`{"timeout_ms":60000}`), jsonHeader)
+			if rr.Code != http.StatusCreated {
+				t.Fatalf("expected 201, got %d: %s", rr.Code, rr.Body.String())
+			}
+			var resp struct {
+				TransactionID string `json:"transaction_id"`
+			}
+			json.NewDecoder(rr.Body).Decode(&resp)
+			return resp.TransactionID
+		}
+		committedID, abortedID := begin(), begin()
+
+		tests := []struct {
+			name       string
+			path       string
+			payload    string
+			statusCode int
+		}{
+			{"Publish in a transaction", "/publish/txn-out", `{"body":"out","producer_id":"p1","transaction_id":"` + committedID + `"}`, http.StatusAccepted},
+			{"Publish in the other transaction", "/publish/txn-out", `{"body":"dropped","producer_id":"p1","transaction_id":"` + abortedID + `"}`, http.StatusAccepted},
+			{"Scheduled transactional publish", "/publish/txn-out", `{"body":"out","producer_id":"p1","delay_ms":1000,"transaction_id":"` + committedID + `"}`, http.StatusBadRequest},
+			{"Publish in an unknown transaction", "/publish/txn-out", `{"body":"out","producer_id":"p1","transaction_id":"missing"}`, http.StatusNotFound},
+			{"Add group offsets", "/transactions/" + committedID + "/offsets", `{"topic":"txn-in","group_id":"processor","offset":1}`, http.StatusOK},
+			{"Offsets without consumer or group", "/transactions/" + committedID + "/offsets", `{"topic":"txn-in","offset":1}`, http.StatusBadRequest},
+			{"Offset past the end", "/transactions/" + committedID + "/offsets", `{"topic":"txn-in","consumer_id":"c1","offset":100}`, http.StatusBadRequest},
+			{"Offsets for an unknown topic", "/transactions/" + committedID + "/offsets", `{"topic":"missing-topic","consumer_id":"c1","offset":0}`, http.StatusNotFound},
+			{"Unknown action", "/transactions/" + committedID + "/pause", `{}`, http.StatusNotFound},
+		}
+		for _, tc := range tests {
+			t.Run(tc.name, func(t *testing.T) {
+				rr := makeRequest(ts, http.MethodPost, tc.path, strings.NewReader(tc.payload), jsonHeader)
+				if rr.Code != tc.statusCode {
+					t.Errorf("expected %d, got %d: %s", tc.statusCode, rr.Code, rr.Body.String())
+				}
+			})
+		}
+
+		fetch := func(consumerID, isolation string) int {
+			rr := makeRequest(ts, http.MethodGet, "/fetch", nil, map[string]string{"X-Topic": "txn-out", "X-Consumer-ID": consumerID, "X-Isolation-Level": isolation})
+			if rr.Code != http.StatusOK {
+				t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+			}
+			var messages []map[string]any
+			json.NewDecoder(rr.Body).Decode(&messages)
+			return len(messages)
+		}
+		if n := fetch("before-commit", "read_committed"); n != 0 {
+			t.Errorf("expected open transactions to be hidden, got %d messages", n)
+		}
+		if n := fetch("uncommitted", "read_uncommitted"); n != 2 {
+			t.Errorf("expected both messages read uncommitted, got %d", n)
+		}
+		rr := makeRequest(ts, http.MethodGet, "/fetch", nil, map[string]string{"X-Topic": "txn-out", "X-Consumer-ID": "c1", "X-Isolation-Level": "serializable"})
+		if rr.Code != http.StatusBadRequest {
+			t.Errorf("expected 400 for an unknown isolation level, got %d", rr.Code)
+		}
+
+		for _, path := range []string{"/transactions/" + committedID + "/commit", "/transactions/" + abortedID + "/abort"} {
+			if rr := makeRequest(ts, http.MethodPost, path, nil, nil); rr.Code != http.StatusOK {
+				t.Errorf("expected 200 from %s, got %d: %s", path, rr.Code, rr.Body.String())
+			}
+		}
+		if n := fetch("after-commit", "read_committed"); n != 1 {
+			t.Errorf("expected only the committed message, got %d", n)
+		}
+
+		rr = makeRequest(ts, http.MethodPost, "/transactions/"+committedID+"/commit", nil, nil)
+		if rr.Code != http.StatusNotFound {
+			t.Errorf("expected 404 committing twice, got %d", rr.Code)
+		}
+		rr = makeRequest(ts, http.MethodPost, "/transactions/"+abortedID+"/commit", nil, nil)
+		if rr.Code != http.StatusConflict {
+			t.Errorf("expected 409 committing an aborted transaction, got %d", rr.Code)
+		}
+		rr = makeRequest(ts, http.MethodGet, "/topics/txn-in?group_id=processor", nil, nil)
+		if !strings.Contains(rr.Body.String(), `"committed_offset":1`) {
+			t.Errorf("expected the transaction's group offset to be committed, got %s", rr.Body.String())
+		}
+	})
+
 	t.Run("GET /fetch", func(t *testing.T) {
 		rr := makeRequest(ts, http.MethodGet, "/fetch", nil, map[string]string{
 			"X-Topic":       "sub-topic",
@@ -122,4 +937,136 @@ func TestHandlers(t *testing.T) {
 			t.Errorf("expected 200, got %d", rr.Code)
 		}
 	})
+
+	t.Run("GET /fetch long poll", func(t *testing.T) {
+		jsonHeader := map[string]string{"Content-Type": "application/json"}
+		_ = makeRequest(ts, http.MethodPost, "/topics", strings.NewReader(`{"name":"poll-topic"}`), jsonHeader)
+
+		tests := []struct {
+			name          string
+			maxWait       string
+			minMessages   string
+			publishAfter  int
+			statusCode    int
+			expectCount   int
+			expectBlocked bool
+		}{
+			{"Woken by a publish", "5000", "", 1, http.StatusOK, 1, false},
+			{"Wait expires", "50", "", 0, http.StatusOK, 0, true},
+			{"Min messages not reached", "50", "3", 1, http.StatusOK, 1, true},
+			{"Negative wait", "-1", "", 0, http.StatusBadRequest, 0, false},
+			{"Wait too long", "3600000", "", 0, http.StatusBadRequest, 0, false},
+			{"Zero min messages", "50", "0", 0, http.StatusBadRequest, 0, false},
+		}
+		for _, tc := range tests {
+			t.Run(tc.name, func(t *testing.T) {
+				if tc.publishAfter > 0 {
+					time.AfterFunc(20*time.Millisecond, func() {
+						for range tc.publishAfter {
+							makeRequest(ts, http.MethodPost, "/publish/poll-topic", strings.NewReader(`{"body":"late","producer_id":"p1"}`), jsonHeader)
+						}
+					})
+				}
+
+				start := time.Now()
+				rr := makeRequest(ts, http.MethodGet, "/fetch", nil, map[string]string{
+					"X-Topic":        "poll-topic",
+					"X-Consumer-ID":  "poller",
+					"X-Commit":       "true",
+					"X-Max-Wait-Ms":  tc.maxWait,
+					"X-Min-Messages": tc.minMessages,
+				})
+				if rr.Code != tc.statusCode {
+					t.Fatalf("expected %d, got %d: %s", tc.statusCode, rr.Code, rr.Body.String())
+				}
+				if rr.Code != http.StatusOK {
+					return
+				}
+
+				var messages []map[string]any
+				json.NewDecoder(rr.Body).Decode(&messages)
+				if len(messages) != tc.expectCount {
+					t.Errorf("expected %d messages, got %d", tc.expectCount, len(messages))
+				}
+				if blocked := time.Since(start) >= 50*time.Millisecond; blocked != tc.expectBlocked {
+					t.Errorf("expected blocked until the wait expired %v, took %v", tc.expectBlocked, time.Since(start))
+				}
+			})
+		}
+	})
+
+	t.Run("GET /fetch long poll client disconnect", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		req := httptest.NewRequest(http.MethodGet, "/fetch", nil).WithContext(ctx)
+		req.Header.Set("X-Topic", "poll-topic")
+		req.Header.Set("X-Consumer-ID", "gone")
+		req.Header.Set("X-Min-Messages", "1000")
+		req.Header.Set("X-Max-Wait-Ms", "5000")
+		time.AfterFunc(20*time.Millisecond, cancel)
+
+		start := time.Now()
+		rr := httptest.NewRecorder()
+		ts.ServeHTTP(rr, req)
+		if elapsed := time.Since(start); elapsed >= time.Second {
+			t.Fatalf("expected the fetch to stop when the client went away, took %v", elapsed)
+		}
+		if rr.Body.Len() != 0 {
+			t.Errorf("expected nothing written for a client that went away, got %s", rr.Body.String())
+		}
+	})
+
+	t.Run("POST /commit", func(t *testing.T) {
+		tests := []struct {
+			name       string
+			payload    string
+			statusCode int
+		}{
+			{"Valid consumer commit", `{"topic":"sub-topic","consumer_id":"c1","offset":0}`, http.StatusOK},
+			{"Valid group commit", `{"topic":"sub-topic","group_id":"g1","offset":0}`, http.StatusOK},
+			{"Missing consumer and group", `{"topic":"sub-topic","offset":0}`, http.StatusBadRequest},
+			{"Negative offset", `{"topic":"sub-topic","consumer_id":"c1","offset":-1}`, http.StatusBadRequest},
+			{"Unknown topic", `{"topic":"missing-topic","consumer_id":"c1","offset":0}`, http.StatusNotFound},
+			{"Past the end of the partition", `{"topic":"sub-topic","consumer_id":"c1","offset":1000000}`, http.StatusBadRequest},
+		}
+		for _, tc := range tests {
+			t.Run(tc.name, func(t *testing.T) {
+				rr := makeRequest(ts, http.MethodPost, "/commit", strings.NewReader(tc.payload), map[string]string{"Content-Type": "application/json"})
+				if rr.Code != tc.statusCode {
+					t.Errorf("expected %d, got %d", tc.statusCode, rr.Code)
+				}
+			})
+		}
+	})
+
+	t.Run("GET /metrics", func(t *testing.T) {
+		jsonHeader := map[string]string{"Content-Type": "application/json"}
+		_ = makeRequest(ts, http.MethodPost, "/topics", strings.NewReader(`{"name":"metrics-topic"}`), jsonHeader)
+		for range 3 {
+			_ = makeRequest(ts, http.MethodPost, "/publish/metrics-topic", strings.NewReader(`{"body":"hello","producer_id":"p1"}`), jsonHeader)
+		}
+		_ = makeRequest(ts, http.MethodGet, "/fetch", nil, map[string]string{"X-Topic": "metrics-topic", "X-Consumer-ID": "m1", "X-Limit": "2", "X-Commit": "true"})
+		_ = makeRequest(ts, http.MethodGet, "/topics/missing-topic", nil, nil)
+
+		rr := makeRequest(ts, http.MethodGet, "/metrics", nil, nil)
+		if rr.Code != http.StatusOK {
+			t.Fatalf("expected 200, got %d", rr.Code)
+		}
+		if ct := rr.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/plain; version=0.0.4") {
+			t.Errorf("expected the Prometheus text format, got %q", ct)
+		}
+
+		for _, line := range []string{
+			`gomq_messages_published_total{topic="metrics-topic"} 3`,
+			`gomq_messages_fetched_total{topic="metrics-topic"} 2`,
+			`gomq_publish_duration_seconds_count{topic="metrics-topic"} 3`,
+			`gomq_topic_stored_bytes{topic="metrics-topic"} 15`,
+			`gomq_consumer_lag_messages{topic="metrics-topic",partition="0",consumer="m1"} 1`,
+			`gomq_http_requests_total{route="/topics/",code="404"}`,
+			`gomq_http_requests_total{route="/fetch",code="200"}`,
+		} {
+			if !strings.Contains(rr.Body.String(), line) {
+				t.Errorf("expected metrics to contain %q", line)
+			}
+		}
+	})
 }
@@ -0,0 +1,45 @@
+package api
+
+import (
+	"errors"
+	"strconv"
+	"time"
+)
+
+// MaxWaitHeader and MinMessagesHeader turn a fetch into a long poll. The request is
+// held for up to X-Max-Wait-Ms milliseconds until X-Min-Messages messages, 1 by
+// default, are ready to be fetched.
+const (
+	MaxWaitHeader     = "X-Max-Wait-Ms"
+	MinMessagesHeader = "X-Min-Messages"
+)
+
+// maxFetchWait is the longest a fetch may be held.
+const maxFetchWait = time.Minute
+
+// parseLongPoll reads the long poll headers of a fetch. A zero wait means the fetch
+// returns right away.
+func parseLongPoll(maxWaitRaw, minMessagesRaw string) (time.Duration, int, error) {
+	var maxWait time.Duration
+	if maxWaitRaw != "" {
+		ms, err := strconv.ParseInt(maxWaitRaw, 10, 64)
+		if err != nil || ms < 0 {
+			return 0, 0, errors.New("X-Max-Wait-Ms must be a non-negative integer")
+		}
+		maxWait = time.Duration(ms) * time.Millisecond
+		if maxWait > maxFetchWait {
+			return 0, 0, errors.New("X-Max-Wait-Ms can be at most " + strconv.FormatInt(maxFetchWait.Milliseconds(), 10))
+		}
+	}
+
+	minMessages := 1
+	if minMessagesRaw != "" {
+		n, err := strconv.Atoi(minMessagesRaw)
+		if err != nil || n < 1 {
+			return 0, 0, errors.New("X-Min-Messages must be a positive integer")
+		}
+		minMessages = n
+	}
+
+	return maxWait, minMessages, nil
+}
@@ -0,0 +1,109 @@
+package api
+
+import (
+	"bufio"
+	"errors"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/codytheroux96/go-mq/internal/metrics"
+)
+
+// metricsContentType is the Prometheus text exposition format.
+const metricsContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// httpMetrics counts the requests served by each route in Routes, keyed by the
+// route's pattern rather than the request path so topic names do not multiply series.
+type httpMetrics struct {
+	requests *metrics.CounterVec
+	seconds  *metrics.HistogramVec
+}
+
+func newHTTPMetrics() *httpMetrics {
+	return &httpMetrics{
+		requests: metrics.NewCounterVec("gomq_http_requests_total", "HTTP requests served, by route and status code.", "route", "code"),
+		seconds:  metrics.NewHistogramVec("gomq_http_request_duration_seconds", "Time taken to serve an HTTP request, by route.", metrics.DefaultBuckets, "route"),
+	}
+}
+
+func (m *httpMetrics) Collect() []metrics.Family {
+	return append(m.requests.Collect(), m.seconds.Collect()...)
+}
+
+// instrument records the status code and duration of every request next serves.
+// A request whose connection was hijacked, a websocket upgrade, counts as a 101.
+func (m *httpMetrics) instrument(route string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: w}
+		next(rec, r)
+
+		status := rec.status
+		if status == 0 {
+			status = http.StatusOK
+		}
+		m.requests.Inc(route, strconv.Itoa(status))
+		m.seconds.Observe(time.Since(start).Seconds(), route)
+	}
+}
+
+// statusRecorder remembers the status code written to a response. It passes
+// flushes and hijacks through so streaming and websocket handlers keep working.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	if r.status == 0 {
+		r.status = status
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *statusRecorder) Write(b []byte) (int, error) {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+	return r.ResponseWriter.Write(b)
+}
+
+func (r *statusRecorder) Flush() {
+	if flusher, ok := r.ResponseWriter.(http.Flusher); ok {
+		if r.status == 0 {
+			r.status = http.StatusOK
+		}
+		flusher.Flush()
+	}
+}
+
+func (r *statusRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := r.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("response does not support hijacking")
+	}
+	conn, rw, err := hijacker.Hijack()
+	if err == nil && r.status == 0 {
+		r.status = http.StatusSwitchingProtocols
+	}
+	return conn, rw, err
+}
+
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+// HandleMetrics serves the broker's and the HTTP layer's metrics for Prometheus to scrape.
+func (h *Handler) HandleMetrics(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", metricsContentType)
+	if err := h.Metrics.WriteText(w); err != nil {
+		h.App.Logger.Warn("failed to write metrics", "error", err)
+	}
+}
@@ -0,0 +1,154 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/codytheroux96/go-mq/internal/broker"
+)
+
+// HandleResetOffsets moves the committed offsets of a consumer, or of a group, on a
+// topic to the earliest or latest offset, to a given offset or timestamp, or by a
+// shift. A consumer that is still active is only reset when force is set.
+func (h *Handler) HandleResetOffsets(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		h.App.Logger.Warn("http method not allowed for resetting offsets", "method", r.Method)
+		http.Error(w, "http method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if r.Header.Get("Content-Type") != "application/json" {
+		h.App.Logger.Warn("invalid content-type received", "received", r.Header.Get("Content-Type"))
+		http.Error(w, "Content-Type must be application/json", http.StatusUnsupportedMediaType)
+		return
+	}
+
+	topicName := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/topics/"), "/offsets/reset")
+	if topicName == "" {
+		h.App.Logger.Warn("missing topic name in offset reset request")
+		http.Error(w, "topic name is required for offset reset request", http.StatusBadRequest)
+		return
+	}
+
+	var req struct {
+		ConsumerID string         `json:"consumer_id"`
+		GroupID    string         `json:"group_id"`
+		To         broker.ResetTo `json:"to"`
+		Offset     *int           `json:"offset"`
+		Shift      int            `json:"shift"`
+		Timestamp  *time.Time     `json:"timestamp"`
+		Partition  *int           `json:"partition"`
+		Force      bool           `json:"force"`
+	}
+
+	err := json.NewDecoder(r.Body).Decode(&req)
+	switch {
+	case err != nil:
+	case (req.ConsumerID == "") == (req.GroupID == ""):
+		err = errors.New("give either consumer_id or group_id")
+	case req.To == broker.ResetOffset && req.Offset == nil:
+		err = errors.New("offset is required to reset to an offset")
+	case req.To == broker.ResetTimestamp && req.Timestamp == nil:
+		err = errors.New("timestamp is required to reset to a timestamp")
+	}
+	if err != nil {
+		h.App.Logger.Warn("invalid offset reset request", "topic", topicName, "error", err)
+		http.Error(w, "invalid payload in request", http.StatusBadRequest)
+		return
+	}
+
+	target := req.ConsumerID
+	if req.GroupID != "" {
+		target = req.GroupID
+	}
+	reset := broker.OffsetReset{To: req.To, Shift: req.Shift, Partition: req.Partition}
+	if req.Offset != nil {
+		reset.Offset = *req.Offset
+	}
+	if req.Timestamp != nil {
+		reset.Timestamp = *req.Timestamp
+	}
+
+	offsets, err := h.App.Broker.ResetOffsets(topicName, target, reset, req.Force)
+	if err != nil {
+		switch {
+		case errors.Is(err, broker.ErrConsumerActive):
+			h.App.Logger.Warn("offset reset refused for active consumer", "topic", topicName, "consumer", target)
+			http.Error(w, err.Error(), http.StatusConflict)
+		case strings.Contains(err.Error(), "does not exist"):
+			h.App.Logger.Warn("offset reset for topic or partition that does not exist", "topic", topicName, "error", err)
+			http.Error(w, err.Error(), http.StatusNotFound)
+		case strings.Contains(err.Error(), "unknown offset reset"):
+			h.App.Logger.Warn("invalid offset reset request", "topic", topicName, "to", req.To)
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		default:
+			h.App.Logger.Error("failed to reset offsets", "topic", topicName, "consumer", target, "error", err)
+			http.Error(w, "internal server error", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	h.App.Logger.Info("offsets reset", "topic", topicName, "consumer", target, "to", req.To, "forced", req.Force)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]any{
+		"topic":   topicName,
+		"target":  target,
+		"offsets": offsets,
+	})
+}
+
+// HandleDeleteConsumer forgets a consumer, or group, on a topic: its committed
+// offsets, its filter and its unsettled deliveries. A consumer that is still active
+// is only deleted with ?force=true.
+func (h *Handler) HandleDeleteConsumer(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		h.App.Logger.Warn("http method not allowed for deleting a consumer", "method", r.Method)
+		http.Error(w, "http method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	topicName, target, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/topics/"), "/consumers/")
+	if topicName == "" || target == "" {
+		h.App.Logger.Warn("missing topic name or consumer in delete consumer request", "path", r.URL.Path)
+		http.Error(w, "topic name and consumer are required to delete a consumer", http.StatusBadRequest)
+		return
+	}
+
+	force := false
+	if raw := r.URL.Query().Get("force"); raw != "" {
+		parsed, err := strconv.ParseBool(raw)
+		if err != nil {
+			h.App.Logger.Warn("invalid force parameter in delete consumer request", "force", raw)
+			http.Error(w, "force must be true or false", http.StatusBadRequest)
+			return
+		}
+		force = parsed
+	}
+
+	if err := h.App.Broker.DeleteConsumer(topicName, target, force); err != nil {
+		switch {
+		case errors.Is(err, broker.ErrConsumerActive):
+			h.App.Logger.Warn("delete refused for active consumer", "topic", topicName, "consumer", target)
+			http.Error(w, err.Error(), http.StatusConflict)
+		case strings.Contains(err.Error(), "does not exist"):
+			h.App.Logger.Warn("delete consumer for topic that does not exist", "topic", topicName)
+			http.Error(w, "topic does not exist", http.StatusNotFound)
+		default:
+			h.App.Logger.Error("failed to delete consumer", "topic", topicName, "consumer", target, "error", err)
+			http.Error(w, "internal server error", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	h.App.Logger.Info("consumer deleted", "topic", topicName, "consumer", target, "forced", force)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{"message": "consumer deleted successfully"})
+}
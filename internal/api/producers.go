@@ -0,0 +1,43 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// HandleRegisterProducer starts a new idempotent session for a producer. The
+// returned epoch goes with every sequenced publish of the session, registering
+// again fences off the previous session.
+func (h *Handler) HandleRegisterProducer(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		h.App.Logger.Warn("http method not allowed for registering a producer", "method", r.Method)
+		http.Error(w, "http method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if r.Header.Get("Content-Type") != "application/json" {
+		h.App.Logger.Warn("invalid content-type received", "received", r.Header.Get("Content-Type"))
+		http.Error(w, "Content-Type must be application/json", http.StatusUnsupportedMediaType)
+		return
+	}
+
+	var req struct {
+		ProducerID string `json:"producer_id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.ProducerID == "" {
+		h.App.Logger.Error("failed to decode producer registration", "error", err)
+		http.Error(w, "producer_id is required", http.StatusBadRequest)
+		return
+	}
+
+	epoch := h.App.Broker.RegisterProducer(req.ProducerID)
+
+	h.App.Logger.Info("producer registered", "producer_id", req.ProducerID, "epoch", epoch)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]any{
+		"producer_id": req.ProducerID,
+		"epoch":       epoch,
+	})
+}
@@ -4,25 +4,40 @@ import (
 	"net/http"
 
 	"github.com/codytheroux96/go-mq/internal/app"
+	"github.com/codytheroux96/go-mq/internal/metrics"
 )
 
 func Routes(app *app.Application) http.Handler {
 	mux := http.NewServeMux()
-	handler := &Handler{App: app}
+	requests := newHTTPMetrics()
+	handler := &Handler{App: app, Metrics: metrics.NewRegistry(app.Broker, requests)}
+	handle := func(pattern string, fn http.HandlerFunc) {
+		mux.HandleFunc(pattern, requests.instrument(pattern, fn))
+	}
 
-	mux.HandleFunc("/topics", handler.HandleTopics)
-	mux.HandleFunc("/topics/", handler.HandleDeleteTopic)
+	handle("/topics", handler.HandleTopics)
+	handle("/topics/", handler.HandleTopic)
 
-	mux.HandleFunc("/publish/", handler.HandlePublish)
+	handle("/publish/", handler.HandlePublish)
+	handle("/producers", handler.HandleRegisterProducer)
+	handle("/transactions", handler.HandleBeginTransaction)
+	handle("/transactions/", handler.HandleTransaction)
 
-	mux.HandleFunc("/subscribe", handler.HandleRegisterConsumer)
-	mux.HandleFunc("/subscribe/", handler.HandleSubscribe)
+	handle("/subscribe", handler.HandleRegisterConsumer)
+	handle("/subscribe/", handler.HandleSubscribe)
+	handle("/stream/", handler.HandleStream)
 
-	mux.HandleFunc("/ack", handler.HandleAck)
+	handle("/groups", handler.HandleListGroups)
+	handle("/groups/", handler.HandleGroup)
 
-	mux.HandleFunc("/health", handler.HandleHealthCheck)
+	handle("/ack", handler.HandleAck)
+	handle("/nack", handler.HandleNack)
 
-	mux.HandleFunc("/fetch", handler.HandleFetchMessages)
+	handle("/health", handler.HandleHealthCheck)
+	handle("/metrics", handler.HandleMetrics)
+
+	handle("/fetch", handler.HandleFetchMessages)
+	handle("/commit", handler.HandleCommitOffset)
 
 	return mux
 }
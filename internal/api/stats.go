@@ -0,0 +1,76 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+// HandleTopicStats reports a topic's message count, size and the offset and
+// timestamp range of each partition.
+func (h *Handler) HandleTopicStats(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		h.App.Logger.Warn("http method not allowed for topic stats", "method", r.Method)
+		http.Error(w, "http method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	topicName := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/topics/"), "/stats")
+	if topicName == "" {
+		h.App.Logger.Warn("missing topic name in stats request")
+		http.Error(w, "topic name is required for stats request", http.StatusBadRequest)
+		return
+	}
+
+	stats, err := h.App.Broker.TopicStats(topicName)
+	if err != nil {
+		if strings.Contains(err.Error(), "does not exist") {
+			h.App.Logger.Warn("stats requested for topic that does not exist", "topic", topicName)
+			http.Error(w, "topic does not exist", http.StatusNotFound)
+			return
+		}
+		h.App.Logger.Error("failed to read topic stats", "topic", topicName, "error", err)
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(stats)
+}
+
+// HandleTopicConsumers lists the consumers and groups of a topic with their
+// committed offsets, lag, last fetch and unacknowledged deliveries.
+func (h *Handler) HandleTopicConsumers(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		h.App.Logger.Warn("http method not allowed for topic consumers", "method", r.Method)
+		http.Error(w, "http method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	topicName := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/topics/"), "/consumers")
+	if topicName == "" {
+		h.App.Logger.Warn("missing topic name in consumers request")
+		http.Error(w, "topic name is required for consumers request", http.StatusBadRequest)
+		return
+	}
+
+	consumers, err := h.App.Broker.DescribeConsumers(topicName)
+	if err != nil {
+		if strings.Contains(err.Error(), "does not exist") {
+			h.App.Logger.Warn("consumers requested for topic that does not exist", "topic", topicName)
+			http.Error(w, "topic does not exist", http.StatusNotFound)
+			return
+		}
+		h.App.Logger.Error("failed to describe topic consumers", "topic", topicName, "error", err)
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]any{
+		"topic":     topicName,
+		"consumers": consumers,
+	})
+}
@@ -0,0 +1,232 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/codytheroux96/go-mq/internal/core"
+	"github.com/gorilla/websocket"
+)
+
+const (
+	// defaultStreamCredit is how many messages a stream may push before the client
+	// grants more, matching the capacity of a consumer inbox.
+	defaultStreamCredit = 10
+
+	streamWriteWait    = 10 * time.Second
+	streamPongWait     = 60 * time.Second
+	streamPingInterval = 25 * time.Second
+)
+
+var streamUpgrader = websocket.Upgrader{
+	ReadBufferSize:  4096,
+	WriteBufferSize: 4096,
+}
+
+// streamFrame is a frame sent by a streaming client: "ack", "nack" or "credit".
+type streamFrame struct {
+	Type      string `json:"type"`
+	MessageID string `json:"message_id"`
+	Requeue   *bool  `json:"requeue"`
+	DelayMs   int64  `json:"delay_ms"`
+	Reason    string `json:"reason"`
+	Credit    int    `json:"credit"`
+}
+
+// HandleStream upgrades to a WebSocket session that pushes every message from the
+// consumer's inbox as a "message" frame while the client has credit left, and takes
+// ack, nack and credit frames on the same socket. Failed client frames are answered
+// with an "error" frame.
+func (h *Handler) HandleStream(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		h.App.Logger.Warn("http method not allowed for streaming a topic", "method", r.Method)
+		http.Error(w, "http method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	topicName := strings.TrimPrefix(r.URL.Path, "/stream/")
+	if topicName == "" {
+		h.App.Logger.Warn("missing topic name in stream request")
+		http.Error(w, "topic name is required in order to stream", http.StatusBadRequest)
+		return
+	}
+
+	consumerID := r.URL.Query().Get("consumer_id")
+	if consumerID == "" {
+		h.App.Logger.Warn("missing consumer ID in stream request")
+		http.Error(w, "consumer_id is required in order to stream", http.StatusBadRequest)
+		return
+	}
+
+	credit := defaultStreamCredit
+	if raw := r.URL.Query().Get("credit"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed < 0 {
+			h.App.Logger.Warn("invalid credit in stream request", "credit", raw)
+			http.Error(w, "credit must be a non-negative integer", http.StatusBadRequest)
+			return
+		}
+		credit = parsed
+	}
+
+	groupID := r.URL.Query().Get("group_id")
+	inbox, ok := h.subscribe(w, r, topicName, consumerID, groupID)
+	if !ok {
+		return
+	}
+	defer h.unsubscribe(topicName, consumerID, groupID, inbox)
+
+	conn, err := streamUpgrader.Upgrade(w, r, nil)
+	if err != nil {
+		h.App.Logger.Warn("failed to upgrade stream request", "topic", topicName, "consumer", consumerID, "error", err)
+		return
+	}
+	defer conn.Close()
+
+	h.App.Logger.Info("stream opened", "topic", topicName, "consumer", consumerID, "group", groupID, "credit", credit)
+
+	credits := make(chan int)
+	replies := make(chan map[string]any)
+	done := make(chan struct{})
+	quit := make(chan struct{})
+	defer close(quit)
+	go h.readStream(conn, topicName, consumerID, credits, replies, done, quit)
+
+	// group members keep their partitions by heartbeating from the stream
+	interval := streamPingInterval
+	if groupID != "" && h.App.Broker.SessionTimeout/3 < interval {
+		interval = h.App.Broker.SessionTimeout / 3
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		var next <-chan *core.Message
+		if credit > 0 {
+			next = inbox
+		}
+
+		select {
+		case <-done:
+			h.App.Logger.Info("stream closed", "topic", topicName, "consumer", consumerID)
+			return
+		case n := <-credits:
+			credit += n
+		case reply := <-replies:
+			if err := writeStreamFrame(conn, reply); err != nil {
+				h.App.Logger.Warn("failed to write to stream", "topic", topicName, "consumer", consumerID, "error", err)
+				return
+			}
+		case msg, ok := <-next:
+			if !ok {
+				h.App.Logger.Warn("slow consumer disconnected from stream", "topic", topicName, "consumer", consumerID)
+				writeStreamFrame(conn, map[string]any{"type": "error", "error": "consumer was disconnected for falling behind"})
+				return
+			}
+			frame := messagePayload(msg)
+			frame["type"] = "message"
+			if err := writeStreamFrame(conn, frame); err != nil {
+				h.App.Logger.Warn("failed to write to stream", "topic", topicName, "consumer", consumerID, "error", err)
+				return
+			}
+			credit--
+		case <-ticker.C:
+			if groupID != "" {
+				if _, err := h.App.Broker.Heartbeat(groupID, consumerID); err != nil {
+					h.App.Logger.Warn("stream member lost its group", "group", groupID, "consumer", consumerID, "error", err)
+					writeStreamFrame(conn, map[string]any{"type": "error", "error": err.Error()})
+					return
+				}
+			}
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(streamWriteWait)); err != nil {
+				h.App.Logger.Warn("failed to ping stream", "topic", topicName, "consumer", consumerID, "error", err)
+				return
+			}
+		}
+	}
+}
+
+// readStream applies the client's frames until the socket closes, then closes done.
+// Credit grants and replies are handed to the writing side, which owns the socket
+// for writes, until it closes quit.
+func (h *Handler) readStream(conn *websocket.Conn, topicName, consumerID string, credits chan<- int, replies chan<- map[string]any, done, quit chan struct{}) {
+	defer close(done)
+
+	conn.SetReadDeadline(time.Now().Add(streamPongWait))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(streamPongWait))
+	})
+
+	for {
+		var frame streamFrame
+		if err := conn.ReadJSON(&frame); err != nil {
+			if websocket.IsUnexpectedCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway) {
+				h.App.Logger.Warn("stream read failed", "topic", topicName, "consumer", consumerID, "error", err)
+			}
+			return
+		}
+
+		var reply map[string]any
+		switch frame.Type {
+		case "credit":
+			if frame.Credit <= 0 {
+				reply = streamError(frame, "credit must be positive")
+				break
+			}
+			select {
+			case credits <- frame.Credit:
+			case <-quit:
+				return
+			}
+		case "ack":
+			if _, err := h.App.Broker.Ack(topicName, consumerID, frame.MessageID); err != nil {
+				reply = streamError(frame, err.Error())
+			}
+		case "nack":
+			if frame.DelayMs < 0 {
+				reply = streamError(frame, "delay_ms cannot be negative")
+				break
+			}
+			requeue := frame.Requeue == nil || *frame.Requeue
+			delay := time.Duration(frame.DelayMs) * time.Millisecond
+			if _, err := h.App.Broker.Nack(topicName, consumerID, frame.MessageID, requeue, delay, frame.Reason); err != nil {
+				reply = streamError(frame, err.Error())
+			}
+		default:
+			reply = streamError(frame, "unknown frame type")
+		}
+
+		if reply != nil {
+			select {
+			case replies <- reply:
+			case <-quit:
+				return
+			}
+		}
+	}
+}
+
+// unsubscribe undoes subscribe once a session ends.
+func (h *Handler) unsubscribe(topicName, consumerID, groupID string, inbox <-chan *core.Message) {
+	if groupID != "" {
+		h.App.Broker.LeaveGroup(groupID, consumerID)
+		return
+	}
+	h.App.Broker.Unsubscribe(topicName, consumerID, inbox)
+}
+
+func streamError(frame streamFrame, reason string) map[string]any {
+	return map[string]any{
+		"type":       "error",
+		"frame_type": frame.Type,
+		"message_id": frame.MessageID,
+		"error":      reason,
+	}
+}
+
+func writeStreamFrame(conn *websocket.Conn, frame map[string]any) error {
+	conn.SetWriteDeadline(time.Now().Add(streamWriteWait))
+	return conn.WriteJSON(frame)
+}
@@ -0,0 +1,120 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+func TestStream(t *testing.T) {
+	server := httptest.NewServer(setupTestServer())
+	defer server.Close()
+
+	headers := map[string]string{"Content-Type": "application/json"}
+	publish := func(body string) {
+		t.Helper()
+		resp, err := http.Post(server.URL+"/publish/stream-topic", "application/json", strings.NewReader(`{"body":"`+body+`","producer_id":"p1"}`))
+		if err != nil || resp.StatusCode != http.StatusAccepted {
+			t.Fatalf("failed to publish: %v", err)
+		}
+		resp.Body.Close()
+	}
+
+	resp, err := http.Post(server.URL+"/topics", headers["Content-Type"], strings.NewReader(`{"name":"stream-topic"}`))
+	if err != nil {
+		t.Fatalf("failed to create topic: %v", err)
+	}
+	resp.Body.Close()
+
+	wsURL := "ws" + strings.TrimPrefix(server.URL, "http") + "/stream/stream-topic?consumer_id=c1&credit=1"
+	conn, _, err := websocket.DefaultDialer.Dial(wsURL, nil)
+	if err != nil {
+		t.Fatalf("failed to open stream: %v", err)
+	}
+	defer conn.Close()
+
+	readFrame := func(wait time.Duration) (map[string]any, error) {
+		conn.SetReadDeadline(time.Now().Add(wait))
+		var frame map[string]any
+		err := conn.ReadJSON(&frame)
+		return frame, err
+	}
+
+	publish("first")
+	publish("second")
+
+	first, err := readFrame(2 * time.Second)
+	if err != nil || first["type"] != "message" || first["body"] != "first" {
+		t.Fatalf("expected first message frame, got %v (err: %v)", first, err)
+	}
+
+	t.Run("No message is pushed without credit", func(t *testing.T) {
+		if frame, err := readFrame(200 * time.Millisecond); err == nil {
+			t.Fatalf("expected no frame while out of credit, got %v", frame)
+		}
+		// a read timeout leaves the gorilla connection unusable, so reconnect below
+	})
+
+	conn.Close()
+	conn, _, err = websocket.DefaultDialer.Dial(strings.Replace(wsURL, "credit=1", "credit=0", 1), nil)
+	if err != nil {
+		t.Fatalf("failed to reopen stream: %v", err)
+	}
+
+	t.Run("Ack and credit frames", func(t *testing.T) {
+		if err := conn.WriteJSON(map[string]any{"type": "ack", "message_id": first["message_id"]}); err != nil {
+			t.Fatalf("failed to send ack: %v", err)
+		}
+		if err := conn.WriteJSON(map[string]any{"type": "ack", "message_id": "unknown"}); err != nil {
+			t.Fatalf("failed to send ack: %v", err)
+		}
+		frame, err := readFrame(2 * time.Second)
+		if err != nil || frame["type"] != "error" || frame["message_id"] != "unknown" {
+			t.Fatalf("expected an error frame for the unknown ack, got %v (err: %v)", frame, err)
+		}
+
+		// the second message was left unread in the first stream's inbox, so it is
+		// redelivered to this one
+		publish("third")
+		if err := conn.WriteJSON(map[string]any{"type": "credit", "credit": 2}); err != nil {
+			t.Fatalf("failed to send credit: %v", err)
+		}
+		received := make(map[string]map[string]any)
+		for range 2 {
+			frame, err = readFrame(2 * time.Second)
+			if err != nil || frame["type"] != "message" {
+				t.Fatalf("expected a message frame after granting credit, got %v (err: %v)", frame, err)
+			}
+			received[frame["body"].(string)] = frame
+		}
+		if second := received["second"]; second == nil || second["attempts"] != float64(1) {
+			t.Fatalf("expected the unread second message on its first attempt, got %v", received)
+		}
+		frame = received["third"]
+		if frame == nil {
+			t.Fatalf("expected third message after granting credit, got %v", received)
+		}
+
+		if err := conn.WriteJSON(map[string]any{"type": "nack", "message_id": frame["message_id"], "requeue": true}); err != nil {
+			t.Fatalf("failed to send nack: %v", err)
+		}
+		if err := conn.WriteJSON(map[string]any{"type": "credit", "credit": 1}); err != nil {
+			t.Fatalf("failed to send credit: %v", err)
+		}
+		again, err := readFrame(2 * time.Second)
+		if err != nil || again["message_id"] != frame["message_id"] || again["attempts"] != float64(2) {
+			t.Fatalf("expected the nacked message redelivered on attempt 2, got %v (err: %v)", again, err)
+		}
+	})
+
+	t.Run("Unknown topic is rejected before upgrading", func(t *testing.T) {
+		_, resp, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"/stream/unknown?consumer_id=c1", nil)
+		if err == nil || resp == nil || resp.StatusCode != http.StatusNotFound {
+			t.Fatalf("expected 404 for unknown topic, got %v", err)
+		}
+	})
+}
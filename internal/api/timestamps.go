@@ -0,0 +1,115 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// StartTimeHeader makes a fetch replay from the first message appended at or after
+// the given RFC 3339 time, by committing that offset before fetching.
+const StartTimeHeader = "X-Start-Time"
+
+func parseStartTime(raw string) (time.Time, error) {
+	return time.Parse(time.RFC3339Nano, raw)
+}
+
+// HandleOffsetsForTime looks up, on every partition or on the one given with
+// partition, the first offset whose message was appended at or after timestamp.
+func (h *Handler) HandleOffsetsForTime(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		h.App.Logger.Warn("http method not allowed for offset lookup", "method", r.Method)
+		http.Error(w, "http method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	topicName := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/topics/"), "/offsets")
+	if topicName == "" {
+		h.App.Logger.Warn("missing topic name in offset lookup request")
+		http.Error(w, "topic name is required for offset lookup request", http.StatusBadRequest)
+		return
+	}
+
+	timestamp, err := parseStartTime(r.URL.Query().Get("timestamp"))
+	if err != nil {
+		h.App.Logger.Warn("invalid timestamp in offset lookup request", "timestamp", r.URL.Query().Get("timestamp"), "error", err)
+		http.Error(w, "timestamp must be an RFC 3339 time", http.StatusBadRequest)
+		return
+	}
+
+	partitions, err := h.topicPartitions(topicName)
+	if err != nil {
+		if strings.Contains(err.Error(), "does not exist") {
+			h.App.Logger.Warn("offset lookup for topic that does not exist", "topic", topicName)
+			http.Error(w, "topic does not exist", http.StatusNotFound)
+			return
+		}
+		h.App.Logger.Error("failed to get topic config", "topic", topicName, "error", err)
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+	if raw := r.URL.Query().Get("partition"); raw != "" {
+		p, err := strconv.Atoi(raw)
+		if err != nil || p < 0 || p >= len(partitions) {
+			h.App.Logger.Warn("invalid partition in offset lookup request", "topic", topicName, "partition", raw)
+			http.Error(w, "partition does not exist", http.StatusNotFound)
+			return
+		}
+		partitions = []int{p}
+	}
+
+	type partitionOffset struct {
+		Partition int `json:"partition"`
+		Offset    int `json:"offset"`
+	}
+	offsets := make([]partitionOffset, 0, len(partitions))
+	for _, p := range partitions {
+		offset, err := h.App.Repo.GetOffsetForTimestamp(topicName, p, timestamp)
+		if err != nil {
+			h.App.Logger.Error("failed to look up offset for timestamp", "topic", topicName, "partition", p, "timestamp", timestamp, "error", err)
+			http.Error(w, "internal server error", http.StatusInternalServerError)
+			return
+		}
+		offsets = append(offsets, partitionOffset{Partition: p, Offset: offset})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]any{
+		"topic":     topicName,
+		"timestamp": timestamp,
+		"offsets":   offsets,
+	})
+}
+
+// topicPartitions lists every partition of the topic.
+func (h *Handler) topicPartitions(topic string) ([]int, error) {
+	cfg, err := h.App.Repo.GetTopicConfig(topic)
+	if err != nil {
+		return nil, err
+	}
+
+	partitions := make([]int, cfg.PartitionCount())
+	for p := range partitions {
+		partitions[p] = p
+	}
+	return partitions, nil
+}
+
+// seekToTime commits, for the consumer or group offsetKey, the first offset at or
+// after timestamp on each of the partitions.
+func (h *Handler) seekToTime(topic string, partitions []int, offsetKey string, timestamp time.Time) error {
+	for _, p := range partitions {
+		offset, err := h.App.Repo.GetOffsetForTimestamp(topic, p, timestamp)
+		if err != nil {
+			return err
+		}
+		if err := h.App.Repo.CommitOffset(topic, p, offsetKey, offset); err != nil {
+			return err
+		}
+		h.App.Logger.Info("offset moved to start time", "topic", topic, "partition", p, "consumer", offsetKey, "start_time", timestamp, "offset", offset)
+	}
+	return nil
+}
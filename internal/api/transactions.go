@@ -0,0 +1,184 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/codytheroux96/go-mq/internal/broker"
+)
+
+// IsolationLevelHeader picks whether a fetch sees transactional messages before
+// their transaction commits.
+const IsolationLevelHeader = "X-Isolation-Level"
+
+// parseIsolationLevel reads an X-Isolation-Level value, read_uncommitted when empty.
+func parseIsolationLevel(raw string) (broker.IsolationLevel, bool) {
+	switch strings.ToLower(raw) {
+	case "", "read_uncommitted":
+		return broker.ReadUncommitted, true
+	case "read_committed":
+		return broker.ReadCommitted, true
+	default:
+		return broker.ReadUncommitted, false
+	}
+}
+
+// transactionStatus maps an error about a transaction that is not open to its
+// response status.
+func transactionStatus(err error) int {
+	if errors.Is(err, broker.ErrTransactionAborted) {
+		return http.StatusConflict
+	}
+	return http.StatusNotFound
+}
+
+// HandleBeginTransaction opens a transaction. Messages published with its
+// transaction_id and offsets added to it take effect together when it commits, it
+// is aborted if it is still open after timeout_ms.
+func (h *Handler) HandleBeginTransaction(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		h.App.Logger.Warn("http method not allowed for beginning a transaction", "method", r.Method)
+		http.Error(w, "http method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req struct {
+		TimeoutMs int64 `json:"timeout_ms"`
+	}
+	if r.ContentLength != 0 {
+		if r.Header.Get("Content-Type") != "application/json" {
+			h.App.Logger.Warn("invalid content-type received", "received", r.Header.Get("Content-Type"))
+			http.Error(w, "Content-Type must be application/json", http.StatusUnsupportedMediaType)
+			return
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.TimeoutMs < 0 {
+			h.App.Logger.Error("invalid transaction request payload", "error", err)
+			http.Error(w, "invalid payload in request", http.StatusBadRequest)
+			return
+		}
+	}
+
+	txnID := h.App.Broker.BeginTransaction(time.Duration(req.TimeoutMs) * time.Millisecond)
+
+	h.App.Logger.Info("transaction started", "transaction_id", txnID)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]any{
+		"transaction_id": txnID,
+	})
+}
+
+func (h *Handler) HandleTransaction(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimPrefix(r.URL.Path, "/transactions/")
+	txnID, action, _ := strings.Cut(path, "/")
+	if txnID == "" {
+		h.App.Logger.Warn("missing transaction id in transaction request")
+		http.Error(w, "transaction id is required", http.StatusBadRequest)
+		return
+	}
+
+	if r.Method != http.MethodPost {
+		h.App.Logger.Warn("http method not allowed for transaction request", "method", r.Method)
+		http.Error(w, "http method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	switch action {
+	case "offsets":
+		h.addTransactionOffsets(w, r, txnID)
+	case "commit", "abort":
+		h.endTransaction(w, txnID, action)
+	default:
+		http.Error(w, "not found", http.StatusNotFound)
+	}
+}
+
+// addTransactionOffsets adds consumer or group offsets to commit with the
+// transaction, in the body shape of /commit.
+func (h *Handler) addTransactionOffsets(w http.ResponseWriter, r *http.Request, txnID string) {
+	if r.Header.Get("Content-Type") != "application/json" {
+		h.App.Logger.Warn("invalid content-type received", "received", r.Header.Get("Content-Type"))
+		http.Error(w, "Content-Type must be application/json", http.StatusUnsupportedMediaType)
+		return
+	}
+
+	var req struct {
+		Topic      string `json:"topic"`
+		Partition  int    `json:"partition"`
+		ConsumerID string `json:"consumer_id"`
+		GroupID    string `json:"group_id"`
+		Offset     int    `json:"offset"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Topic == "" || (req.ConsumerID == "") == (req.GroupID == "") {
+		h.App.Logger.Error("invalid transaction offsets payload", "transaction_id", txnID, "error", err)
+		http.Error(w, "topic and one of consumer_id or group_id are required", http.StatusBadRequest)
+		return
+	}
+
+	offsetKey := req.ConsumerID
+	if req.GroupID != "" {
+		offsetKey = req.GroupID
+	}
+
+	if err := h.App.Broker.AddOffsetsToTransaction(txnID, req.Topic, req.Partition, offsetKey, req.Offset); err != nil {
+		switch {
+		case errors.Is(err, broker.ErrUnknownTransaction) || errors.Is(err, broker.ErrTransactionAborted):
+			h.App.Logger.Warn("offsets added to a transaction that is not open", "transaction_id", txnID, "error", err)
+			http.Error(w, err.Error(), transactionStatus(err))
+		case strings.Contains(err.Error(), "does not exist"):
+			h.App.Logger.Warn("transaction offsets for a topic or partition that does not exist", "topic", req.Topic, "partition", req.Partition)
+			http.Error(w, err.Error(), http.StatusNotFound)
+		case strings.Contains(err.Error(), "is outside partition"):
+			h.App.Logger.Warn("transaction offset out of range", "topic", req.Topic, "partition", req.Partition, "offset", req.Offset)
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		default:
+			h.App.Logger.Error("failed to add offsets to transaction", "transaction_id", txnID, "error", err)
+			http.Error(w, "failed to add offsets to transaction", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	h.App.Logger.Info("offsets added to transaction", "transaction_id", txnID, "topic", req.Topic, "partition", req.Partition, "consumer", offsetKey, "offset", req.Offset)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]any{
+		"transaction_id": txnID,
+		"topic":          req.Topic,
+		"partition":      req.Partition,
+		"offset":         req.Offset,
+	})
+}
+
+func (h *Handler) endTransaction(w http.ResponseWriter, txnID, action string) {
+	end := h.App.Broker.CommitTransaction
+	state := "committed"
+	if action == "abort" {
+		end = h.App.Broker.AbortTransaction
+		state = "aborted"
+	}
+
+	if err := end(txnID); err != nil {
+		if errors.Is(err, broker.ErrUnknownTransaction) || errors.Is(err, broker.ErrTransactionAborted) {
+			h.App.Logger.Warn("attempting to end a transaction that is not open", "transaction_id", txnID, "action", action, "error", err)
+			http.Error(w, err.Error(), transactionStatus(err))
+			return
+		}
+		h.App.Logger.Error("failed to end transaction", "transaction_id", txnID, "action", action, "error", err)
+		http.Error(w, "failed to "+action+" transaction", http.StatusInternalServerError)
+		return
+	}
+
+	h.App.Logger.Info("transaction ended", "transaction_id", txnID, "state", state)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]any{
+		"transaction_id": txnID,
+		"state":          state,
+	})
+}
@@ -1,6 +1,8 @@
 package app
 
 import (
+	"context"
+	"io"
 	"log/slog"
 	"net/http"
 	"os"
@@ -13,23 +15,74 @@ import (
 type Application struct {
 	Logger *slog.Logger
 	Client *http.Client
+	Config Config
 	Repo   repository.Repository
 	Broker *broker.Manager
+
+	cancel context.CancelFunc
 }
 
 func NewApplication() *Application {
 	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
-	repo := repository.NewInMemoryRepo()
+	cfg := LoadConfig()
+
+	repo, err := newRepository(cfg)
+	if err != nil {
+		logger.Error("failed to open repository", "data_dir", cfg.DataDir, "error", err)
+		os.Exit(1)
+	}
 	broker := broker.NewManager(repo)
+	broker.SessionTimeout = cfg.SessionTimeout
+	broker.VisibilityTimeout = cfg.VisibilityTimeout
+	if err := broker.RestoreScheduled(); err != nil {
+		logger.Error("failed to restore scheduled messages", "error", err)
+		os.Exit(1)
+	}
+	if err := broker.RestoreTransactions(); err != nil {
+		logger.Error("failed to restore transactions", "error", err)
+		os.Exit(1)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go repository.RunJanitor(ctx, repo, cfg.RetentionInterval, logger)
+	go broker.Run(ctx)
 
 	app := &Application{
 		Logger: logger,
 		Client: &http.Client{
 			Timeout: 10 * time.Second,
 		},
+		Config: cfg,
 		Repo:   repo,
 		Broker: broker,
+		cancel: cancel,
 	}
 
 	return app
 }
+
+func (a *Application) Close() error {
+	a.cancel()
+
+	if closer, ok := a.Repo.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+func newRepository(cfg Config) (repository.Repository, error) {
+	if cfg.DataDir == "" {
+		return repository.NewInMemoryRepo(), nil
+	}
+
+	repo, err := repository.NewFileRepo(cfg.DataDir)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.SegmentBytes > 0 {
+		repo.SegmentBytes = cfg.SegmentBytes
+	}
+	repo.SyncWrites = cfg.SyncWrites
+
+	return repo, nil
+}
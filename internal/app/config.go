@@ -0,0 +1,77 @@
+package app
+
+import (
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/codytheroux96/go-mq/internal/broker"
+	"github.com/codytheroux96/go-mq/internal/repository"
+)
+
+type Config struct {
+	// DataDir enables the durable file repository. When it is empty the broker
+	// keeps everything in memory.
+	DataDir      string
+	SegmentBytes int64
+	// SyncWrites fsyncs the file repository after every publish.
+	SyncWrites bool
+
+	// RetentionInterval is how often the janitor enforces topic retention policies.
+	RetentionInterval time.Duration
+
+	// SessionTimeout is how long a consumer group member can go without a
+	// heartbeat before its partitions are handed to the rest of the group.
+	SessionTimeout time.Duration
+
+	// VisibilityTimeout is how long a delivered message may go unacknowledged before
+	// it is delivered again.
+	VisibilityTimeout time.Duration
+
+	// GRPCAddr is the address the gRPC API listens on.
+	GRPCAddr string
+
+	// WireAddr is the address the binary TCP protocol listens on.
+	WireAddr string
+}
+
+func LoadConfig() Config {
+	cfg := Config{
+		DataDir:           os.Getenv("GO_MQ_DATA_DIR"),
+		GRPCAddr:          ":9090",
+		WireAddr:          ":9092",
+		RetentionInterval: 30 * time.Second,
+		SessionTimeout:    broker.DefaultSessionTimeout,
+		VisibilityTimeout: broker.DefaultVisibilityTimeout,
+	}
+
+	if v, err := strconv.ParseInt(os.Getenv("GO_MQ_SEGMENT_BYTES"), 10, 64); err == nil && v > 0 && v <= repository.MaxSegmentBytes {
+		cfg.SegmentBytes = v
+	}
+
+	if v, err := strconv.ParseBool(os.Getenv("GO_MQ_SYNC_WRITES")); err == nil {
+		cfg.SyncWrites = v
+	}
+
+	if v, err := time.ParseDuration(os.Getenv("GO_MQ_RETENTION_INTERVAL")); err == nil && v > 0 {
+		cfg.RetentionInterval = v
+	}
+
+	if v, err := time.ParseDuration(os.Getenv("GO_MQ_SESSION_TIMEOUT")); err == nil && v > 0 {
+		cfg.SessionTimeout = v
+	}
+
+	if v, err := time.ParseDuration(os.Getenv("GO_MQ_VISIBILITY_TIMEOUT")); err == nil && v > 0 {
+		cfg.VisibilityTimeout = v
+	}
+
+	if v := os.Getenv("GO_MQ_GRPC_ADDR"); v != "" {
+		cfg.GRPCAddr = v
+	}
+
+	if v := os.Getenv("GO_MQ_WIRE_ADDR"); v != "" {
+		cfg.WireAddr = v
+	}
+
+	return cfg
+}
@@ -0,0 +1,68 @@
+package broker
+
+import (
+	"testing"
+
+	"github.com/codytheroux96/go-mq/internal/core"
+	"github.com/codytheroux96/go-mq/internal/repository"
+)
+
+func TestPublishBatch(t *testing.T) {
+	repo := repository.NewInMemoryRepo()
+	if err := repo.CreateTopic("orders", core.TopicConfig{Partitions: 2}); err != nil {
+		t.Fatalf("failed to create topic: %v", err)
+	}
+
+	manager := NewManager(repo)
+	inbox, err := manager.Subscribe("orders", "c1", core.FlowControl{})
+	if err != nil {
+		t.Fatalf("failed to subscribe: %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		topic     string
+		atomic    bool
+		keys      []string
+		expectErr bool
+	}{
+		{"Per-message batch", "orders", false, []string{"a", "b", "a"}, false},
+		{"Atomic batch", "orders", true, []string{"c", "d"}, false},
+		{"Unknown topic", "missing", true, []string{"e"}, true},
+	}
+
+	delivered := 0
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msgs := make([]*core.Message, len(tt.keys))
+			for i, key := range tt.keys {
+				msgs[i] = core.NewMessage([]byte("order"), "p1")
+				msgs[i].Key = key
+			}
+
+			errs, err := manager.PublishBatch(tt.topic, msgs, tt.atomic)
+			if tt.expectErr {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("failed to publish batch: %v", err)
+			}
+
+			for i, msg := range msgs {
+				if errs[i] != nil {
+					t.Fatalf("expected message %d to be published, got %v", i, errs[i])
+				}
+				if msg.ID == "" || msg.Partition != core.NewTopic("orders").PartitionFor(msg.Key, 2) {
+					t.Fatalf("expected message %d to be assigned an id and its key's partition, got %+v", i, msg)
+				}
+			}
+			delivered += len(msgs)
+			if len(inbox) != delivered {
+				t.Fatalf("expected %d messages pushed to the consumer, got %d", delivered, len(inbox))
+			}
+		})
+	}
+}
@@ -0,0 +1,124 @@
+package broker
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/codytheroux96/go-mq/internal/core"
+	"github.com/codytheroux96/go-mq/internal/repository"
+)
+
+// Metadata recorded on a message when it is moved to a dead-letter topic.
+const (
+	MetaOriginalTopic     = "dlq.original_topic"
+	MetaOriginalPartition = "dlq.original_partition"
+	MetaOriginalOffset    = "dlq.original_offset"
+	MetaOriginalMessageID = "dlq.original_message_id"
+	MetaFailureCount      = "dlq.failure_count"
+	MetaLastError         = "dlq.last_error"
+
+	metaDeadLetterPrefix = "dlq."
+)
+
+// redriveConsumerID is the offset key that remembers how far a dead-letter topic
+// has been redriven, so every dead letter is sent back at most once.
+const redriveConsumerID = "__redrive"
+
+// deadLetter republishes a delivery that has used up its attempts to the topic's
+// dead-letter topic and reports whether it did. When the dead-letter topic cannot
+// take the message it stays up for redelivery rather than being lost. The caller
+// must hold b.Mu.
+func (b *Manager) deadLetter(d *delivery) bool {
+	cfg, err := b.Repo.GetTopicConfig(d.Topic)
+	if err != nil || !cfg.DeadLetter.Enabled() || d.Attempts < cfg.DeadLetter.MaxDeliveryAttempts {
+		return false
+	}
+
+	return b.deadLetterTo(cfg.DeadLetter.Topic, d) == nil
+}
+
+// deadLetterTo publishes a copy of the delivered message to dlqTopic, recording
+// where it came from and why it failed. The caller must hold b.Mu.
+func (b *Manager) deadLetterTo(dlqTopic string, d *delivery) error {
+	msg := core.NewMessage(d.Message.Body, d.Message.ProducerID)
+	msg.Key = d.Message.Key
+	for k, v := range d.Message.Metadata {
+		msg.Metadata[k] = v
+	}
+	// the copy is a message of its own, it must not be taken for a duplicate
+	delete(msg.Metadata, MetaDedupID)
+	msg.Metadata[MetaOriginalTopic] = d.Topic
+	msg.Metadata[MetaOriginalPartition] = strconv.Itoa(d.Message.Partition)
+	msg.Metadata[MetaOriginalOffset] = strconv.Itoa(d.Message.Offset)
+	msg.Metadata[MetaOriginalMessageID] = d.Message.ID
+	msg.Metadata[MetaFailureCount] = strconv.Itoa(d.Attempts)
+	msg.Metadata[MetaLastError] = d.LastError
+
+	return b.publish(dlqTopic, msg)
+}
+
+// Redrive publishes up to limit messages of a dead-letter topic back to the topics
+// they came from and returns how many were sent. Messages without a source topic
+// are skipped.
+func (b *Manager) Redrive(dlqTopic string, limit int) (int, error) {
+	b.Mu.Lock()
+	defer b.unlockAndSendBlocked()
+
+	cfg, err := b.Repo.GetTopicConfig(dlqTopic)
+	if err != nil {
+		return 0, err
+	}
+
+	redriven := 0
+	for partition := 0; partition < cfg.PartitionCount() && redriven < limit; partition++ {
+		messages, err := b.fetchDeadLetters(dlqTopic, partition, limit-redriven)
+		if err != nil {
+			return redriven, err
+		}
+
+		for _, msg := range messages {
+			if source := msg.Metadata[MetaOriginalTopic]; source != "" {
+				if err := b.publish(source, redriveMessage(msg)); err != nil {
+					return redriven, fmt.Errorf("failed to redrive message %q to topic %q: %w", msg.ID, source, err)
+				}
+				redriven++
+			}
+			if err := b.Repo.CommitOffset(dlqTopic, partition, redriveConsumerID, msg.Offset+1); err != nil {
+				return redriven, err
+			}
+		}
+	}
+
+	return redriven, nil
+}
+
+// fetchDeadLetters reads the next dead letters to redrive, skipping any that
+// retention already dropped. The caller must hold b.Mu.
+func (b *Manager) fetchDeadLetters(topic string, partition, limit int) ([]*core.Message, error) {
+	messages, err := b.Repo.Fetch(topic, partition, redriveConsumerID, limit)
+
+	var outOfRange *repository.OffsetOutOfRangeError
+	if errors.As(err, &outOfRange) {
+		if err := b.Repo.CommitOffset(topic, partition, redriveConsumerID, outOfRange.LowWatermark); err != nil {
+			return nil, err
+		}
+		return b.Repo.Fetch(topic, partition, redriveConsumerID, limit)
+	}
+
+	return messages, err
+}
+
+// redriveMessage copies a dead letter for its source topic, without the
+// dead-letter metadata or a dedup ID that would have it dropped as a duplicate.
+func redriveMessage(msg *core.Message) *core.Message {
+	redriven := core.NewMessage(msg.Body, msg.ProducerID)
+	redriven.Key = msg.Key
+	for k, v := range msg.Metadata {
+		if !strings.HasPrefix(k, metaDeadLetterPrefix) && k != MetaDedupID {
+			redriven.Metadata[k] = v
+		}
+	}
+	return redriven
+}
@@ -0,0 +1,114 @@
+package broker
+
+import (
+	"testing"
+	"time"
+
+	"github.com/codytheroux96/go-mq/internal/core"
+	"github.com/codytheroux96/go-mq/internal/repository"
+)
+
+func TestDeadLetter(t *testing.T) {
+	repo := repository.NewInMemoryRepo()
+	if err := repo.CreateTopic("jobs-dlq", core.TopicConfig{}); err != nil {
+		t.Fatalf("failed to create dead-letter topic: %v", err)
+	}
+	cfg := core.TopicConfig{DeadLetter: core.DeadLetterPolicy{MaxDeliveryAttempts: 2, Topic: "jobs-dlq"}}
+	if err := repo.CreateTopic("jobs", cfg); err != nil {
+		t.Fatalf("failed to create topic: %v", err)
+	}
+	manager := NewManager(repo)
+	manager.VisibilityTimeout = time.Minute
+
+	inbox, err := manager.Subscribe("jobs", "c1", core.FlowControl{})
+	if err != nil {
+		t.Fatalf("failed to subscribe: %v", err)
+	}
+	msg := core.NewMessage([]byte("poison"), "p1")
+	msg.Metadata["trace"] = "abc"
+	if err := manager.Publish("jobs", msg); err != nil {
+		t.Fatalf("failed to publish: %v", err)
+	}
+
+	now := time.Now()
+	for attempt := 1; attempt <= 2; attempt++ {
+		select {
+		case delivered := <-inbox:
+			if delivered.DeliveryAttempts != attempt {
+				t.Fatalf("expected delivery attempt %d, got %d", attempt, delivered.DeliveryAttempts)
+			}
+		default:
+			t.Fatalf("expected delivery attempt %d", attempt)
+		}
+		now = now.Add(2 * time.Minute)
+		manager.RedeliverExpired(now)
+	}
+
+	if len(inbox) != 0 {
+		t.Fatalf("expected no delivery after the last attempt, got %d messages", len(inbox))
+	}
+
+	dead, err := repo.Fetch("jobs-dlq", 0, "inspector", 10)
+	if err != nil {
+		t.Fatalf("failed to fetch dead letters: %v", err)
+	}
+	if len(dead) != 1 {
+		t.Fatalf("expected 1 dead letter, got %d", len(dead))
+	}
+	expected := map[string]string{
+		MetaOriginalTopic:     "jobs",
+		MetaOriginalOffset:    "0",
+		MetaOriginalMessageID: msg.ID,
+		MetaFailureCount:      "2",
+		MetaLastError:         "visibility timeout expired",
+		"trace":               "abc",
+	}
+	for k, v := range expected {
+		if dead[0].Metadata[k] != v {
+			t.Fatalf("expected dead letter metadata %s=%q, got %q", k, v, dead[0].Metadata[k])
+		}
+	}
+
+	redriven, err := manager.Redrive("jobs-dlq", 10)
+	if err != nil || redriven != 1 {
+		t.Fatalf("expected 1 message redriven, got %d (err: %v)", redriven, err)
+	}
+
+	select {
+	case again := <-inbox:
+		if string(again.Body) != "poison" || again.Offset != 1 || again.DeliveryAttempts != 1 {
+			t.Fatalf("unexpected redriven message: %+v", again)
+		}
+		if _, ok := again.Metadata[MetaOriginalTopic]; ok || again.Metadata["trace"] != "abc" {
+			t.Fatalf("expected dead-letter metadata to be stripped on redrive, got %v", again.Metadata)
+		}
+	default:
+		t.Fatalf("expected redriven message to be delivered")
+	}
+
+	redriven, err = manager.Redrive("jobs-dlq", 10)
+	if err != nil || redriven != 0 {
+		t.Fatalf("expected nothing left to redrive, got %d (err: %v)", redriven, err)
+	}
+}
+
+func TestDeadLetterTopicMissing(t *testing.T) {
+	repo := repository.NewInMemoryRepo()
+	cfg := core.TopicConfig{DeadLetter: core.DeadLetterPolicy{MaxDeliveryAttempts: 1, Topic: "missing"}}
+	if err := repo.CreateTopic("jobs", cfg); err != nil {
+		t.Fatalf("failed to create topic: %v", err)
+	}
+	manager := NewManager(repo)
+	manager.VisibilityTimeout = time.Minute
+
+	inbox, _ := manager.Subscribe("jobs", "c1", core.FlowControl{})
+	if err := manager.Publish("jobs", core.NewMessage([]byte("poison"), "p1")); err != nil {
+		t.Fatalf("failed to publish: %v", err)
+	}
+	<-inbox
+
+	manager.RedeliverExpired(time.Now().Add(2 * time.Minute))
+	if len(inbox) != 1 {
+		t.Fatalf("expected the message to stay up for redelivery when the dead-letter topic is missing")
+	}
+}
@@ -0,0 +1,99 @@
+package broker
+
+import (
+	"errors"
+	"time"
+
+	"github.com/codytheroux96/go-mq/internal/core"
+)
+
+// MetaDedupID is the metadata a publisher sets to name a message for deduplication.
+// Without it a topic with a dedup policy uses the message ID the publisher chose.
+const MetaDedupID = "dedup.id"
+
+// ErrDuplicateMessage is returned for a publish whose dedup ID was seen within the
+// topic's dedup window. Nothing is appended and the message is given the ID,
+// partition and offset of the original.
+var ErrDuplicateMessage = errors.New("message is a duplicate of one already published")
+
+// dedupWindow holds the dedup IDs a topic has seen, oldest first.
+type dedupWindow struct {
+	seen  map[string]*dedupEntry
+	order []*dedupEntry
+}
+
+type dedupEntry struct {
+	id       string
+	at       time.Time
+	original core.Message
+}
+
+// dedupID is the ID msg is deduplicated on, empty when it has none.
+func dedupID(msg *core.Message) string {
+	if id := msg.Metadata[MetaDedupID]; id != "" {
+		return id
+	}
+	return msg.ID
+}
+
+// duplicate reports whether id was seen within the window, giving msg the ID,
+// partition and offset of the original when it was.
+func (w *dedupWindow) duplicate(id string, msg *core.Message, policy core.DedupPolicy, now time.Time) bool {
+	w.evict(policy, now)
+	entry, ok := w.seen[id]
+	if !ok {
+		return false
+	}
+	msg.ID, msg.Partition, msg.Offset, msg.Timestamp = entry.original.ID, entry.original.Partition, entry.original.Offset, entry.original.Timestamp
+	return true
+}
+
+// record remembers that msg was appended under id. Only the fields a duplicate is
+// answered with are kept.
+func (w *dedupWindow) record(id string, msg *core.Message, policy core.DedupPolicy, now time.Time) {
+	entry := &dedupEntry{
+		id: id,
+		at: now,
+		original: core.Message{
+			ID:        msg.ID,
+			Partition: msg.Partition,
+			Offset:    msg.Offset,
+			Timestamp: msg.Timestamp,
+		},
+	}
+	w.seen[id] = entry
+	w.order = append(w.order, entry)
+	w.evict(policy, now)
+}
+
+func (w *dedupWindow) evict(policy core.DedupPolicy, now time.Time) {
+	for len(w.order) > 0 {
+		oldest := w.order[0]
+		expired := policy.Window > 0 && now.Sub(oldest.at) > policy.Window
+		overflow := policy.MaxIDs > 0 && len(w.order) > policy.MaxIDs
+		if !expired && !overflow {
+			return
+		}
+		w.order[0] = nil
+		w.order = w.order[1:]
+		if w.seen[oldest.id] == oldest {
+			delete(w.seen, oldest.id)
+		}
+	}
+}
+
+// dedupWindowFor returns the topic's dedup window, nil when the topic has no dedup
+// policy. The caller must hold b.Mu.
+func (b *Manager) dedupWindowFor(topic string, policy core.DedupPolicy) *dedupWindow {
+	if !policy.Enabled() {
+		delete(b.dedup, topic)
+		return nil
+	}
+
+	window, ok := b.dedup[topic]
+	if !ok {
+		window = &dedupWindow{seen: make(map[string]*dedupEntry)}
+		b.dedup[topic] = window
+	}
+	return window
+}
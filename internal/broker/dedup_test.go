@@ -0,0 +1,119 @@
+package broker
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/codytheroux96/go-mq/internal/core"
+	"github.com/codytheroux96/go-mq/internal/repository"
+)
+
+func TestDedupWindow(t *testing.T) {
+	start := time.Now()
+
+	tests := []struct {
+		name            string
+		policy          core.DedupPolicy
+		seen            []string
+		lookup          string
+		after           time.Duration
+		expectDuplicate bool
+	}{
+		{"Seen id", core.DedupPolicy{Window: time.Minute}, []string{"a", "b"}, "a", 0, true},
+		{"Unseen id", core.DedupPolicy{Window: time.Minute}, []string{"a", "b"}, "c", 0, false},
+		{"Id past the window", core.DedupPolicy{Window: time.Minute}, []string{"a"}, "a", 2 * time.Minute, false},
+		{"Id within the count", core.DedupPolicy{MaxIDs: 2}, []string{"a", "b"}, "a", 0, true},
+		{"Id pushed out by newer ids", core.DedupPolicy{MaxIDs: 2}, []string{"a", "b", "c"}, "a", 0, false},
+		{"Both bounds", core.DedupPolicy{Window: time.Minute, MaxIDs: 10}, []string{"a"}, "a", 2 * time.Minute, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			window := &dedupWindow{seen: make(map[string]*dedupEntry)}
+			for i, id := range tt.seen {
+				window.record(id, &core.Message{ID: "msg-" + id, Offset: i}, tt.policy, start)
+			}
+
+			msg := core.NewMessage([]byte("repeat"), "p1")
+			if duplicate := window.duplicate(tt.lookup, msg, tt.policy, start.Add(tt.after)); duplicate != tt.expectDuplicate {
+				t.Fatalf("expected duplicate %v, got %v", tt.expectDuplicate, duplicate)
+			}
+			if tt.expectDuplicate && msg.ID != "msg-"+tt.lookup {
+				t.Fatalf("expected the duplicate to carry the original id, got %q", msg.ID)
+			}
+		})
+	}
+}
+
+func TestPublishDedup(t *testing.T) {
+	repo := repository.NewInMemoryRepo()
+	if err := repo.CreateTopic("orders", core.TopicConfig{Dedup: core.DedupPolicy{Window: time.Minute}}); err != nil {
+		t.Fatalf("failed to create topic: %v", err)
+	}
+	if err := repo.CreateTopic("events", core.TopicConfig{}); err != nil {
+		t.Fatalf("failed to create topic: %v", err)
+	}
+	manager := NewManager(repo)
+
+	withDedupID := func(id string) *core.Message {
+		msg := core.NewMessage([]byte("order"), "p1")
+		msg.Metadata[MetaDedupID] = id
+		return msg
+	}
+	withID := func(id string) *core.Message {
+		msg := core.NewMessage([]byte("order"), "p1")
+		msg.ID = id
+		return msg
+	}
+
+	tests := []struct {
+		name            string
+		topic           string
+		msg             *core.Message
+		expectDuplicate bool
+		expectOffset    int
+	}{
+		{"First publish of a dedup id", "orders", withDedupID("d1"), false, 0},
+		{"Repeat of the dedup id", "orders", withDedupID("d1"), true, 0},
+		{"Caller supplied message id", "orders", withID("m1"), false, 1},
+		{"Repeat of the message id", "orders", withID("m1"), true, 1},
+		{"No id to deduplicate on", "orders", core.NewMessage([]byte("order"), "p1"), false, 2},
+		{"Topic without a dedup policy", "events", withDedupID("d1"), false, 0},
+		{"Repeat on a topic without a dedup policy", "events", withDedupID("d1"), false, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := manager.Publish(tt.topic, tt.msg)
+			if duplicate := errors.Is(err, ErrDuplicateMessage); duplicate != tt.expectDuplicate {
+				t.Fatalf("expected duplicate %v, got %v", tt.expectDuplicate, err)
+			}
+			if err != nil && !tt.expectDuplicate {
+				t.Fatalf("failed to publish: %v", err)
+			}
+			if tt.msg.Offset != tt.expectOffset {
+				t.Fatalf("expected offset %d, got %d", tt.expectOffset, tt.msg.Offset)
+			}
+		})
+	}
+
+	t.Run("Atomic batch", func(t *testing.T) {
+		msgs := []*core.Message{withDedupID("d1"), withDedupID("d2"), withDedupID("d2"), withDedupID("d3")}
+		errs, err := manager.PublishBatch("orders", msgs, true)
+		if err != nil {
+			t.Fatalf("failed to publish batch: %v", err)
+		}
+
+		expectDuplicate := []bool{true, false, true, false}
+		expectOffset := []int{0, 3, 3, 4}
+		for i, msg := range msgs {
+			if errors.Is(errs[i], ErrDuplicateMessage) != expectDuplicate[i] || msg.Offset != expectOffset[i] {
+				t.Fatalf("message %d: expected duplicate %v at offset %d, got %v at %d", i, expectDuplicate[i], expectOffset[i], errs[i], msg.Offset)
+			}
+		}
+		if latest, _ := repo.GetLatestOffset("orders", 0); latest != 5 {
+			t.Fatalf("expected only the new messages appended, got %d messages", latest)
+		}
+	})
+}
@@ -0,0 +1,327 @@
+package broker
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/codytheroux96/go-mq/internal/core"
+)
+
+const DefaultVisibilityTimeout = 30 * time.Second
+
+// delivery is a message handed to a consumer that has not been acknowledged yet.
+// GroupID is set when the message was delivered on behalf of a consumer group, in
+// which case a redelivery goes to whichever member owns the partition by then.
+// NotBefore holds back the redelivery of a message nacked with a delay.
+type delivery struct {
+	Topic      string
+	GroupID    string
+	ConsumerID string
+	Message    *core.Message
+	Attempts   int
+	Deadline   time.Time
+	NotBefore  time.Time
+	LastError  string
+}
+
+type deliveryKey struct {
+	Topic      string
+	ConsumerID string
+	MessageID  string
+}
+
+// pendingKey identifies who a waiting redelivery belongs to, the group when there
+// is one and the consumer otherwise.
+type pendingKey struct {
+	Topic  string
+	Target string
+}
+
+func (d *delivery) pendingKey() pendingKey {
+	if d.GroupID != "" {
+		return pendingKey{Topic: d.Topic, Target: d.GroupID}
+	}
+	return pendingKey{Topic: d.Topic, Target: d.ConsumerID}
+}
+
+// Ack settles a delivery so it is never redelivered. Acking a delivery that was
+// settled recently again is reported as a duplicate rather than an error.
+func (b *Manager) Ack(topic, consumerID, messageID string) (bool, error) {
+	b.Mu.Lock()
+	defer b.Mu.Unlock()
+
+	key := deliveryKey{Topic: topic, ConsumerID: consumerID, MessageID: messageID}
+	d, ok := b.inFlight[key]
+	if !ok {
+		if _, settled := b.settled[key]; settled {
+			return true, nil
+		}
+		return false, fmt.Errorf("message %q is not awaiting acknowledgement from consumer %q", messageID, consumerID)
+	}
+
+	delete(b.inFlight, key)
+	b.settled[key] = time.Now()
+	d.Message.AckedBy[consumerID] = true
+
+	return false, nil
+}
+
+// Nack settles a failed delivery. With requeue the message is redelivered once
+// delay has passed, or dead-lettered if that was its last attempt. Without requeue
+// it goes straight to the topic's dead-letter topic, or is dropped when the topic
+// has none. It reports whether the message was dead-lettered.
+func (b *Manager) Nack(topic, consumerID, messageID string, requeue bool, delay time.Duration, reason string) (bool, error) {
+	b.Mu.Lock()
+	defer b.unlockAndSendBlocked()
+
+	key := deliveryKey{Topic: topic, ConsumerID: consumerID, MessageID: messageID}
+	d, ok := b.inFlight[key]
+	if !ok {
+		return false, fmt.Errorf("message %q is not awaiting acknowledgement from consumer %q", messageID, consumerID)
+	}
+	if reason == "" {
+		reason = "negative acknowledgement"
+	}
+
+	if requeue {
+		delete(b.inFlight, key)
+		d.NotBefore = time.Now().Add(delay)
+		deadLettered := b.retry(d, reason)
+		if !deadLettered {
+			b.flushPending(d.pendingKey(), time.Now())
+		}
+		return deadLettered, nil
+	}
+
+	cfg, err := b.Repo.GetTopicConfig(topic)
+	if err != nil {
+		return false, err
+	}
+	d.LastError = reason
+	if cfg.DeadLetter.Enabled() {
+		if err := b.deadLetterTo(cfg.DeadLetter.Topic, d); err != nil {
+			return false, err
+		}
+	}
+
+	delete(b.inFlight, key)
+	b.settled[key] = time.Now()
+	return cfg.DeadLetter.Enabled(), nil
+}
+
+// Fetch serves a pulling consumer. Redeliveries waiting for the consumer (or its
+// group) on this partition come first, then new messages from the committed
+// offset. It also returns how many of the messages came from the log, which is
+// how far the caller may move the committed offset. That count includes the
+// positions of messages skipped by the target's filter and, at ReadCommitted, of
+// messages from aborted transactions.
+func (b *Manager) Fetch(topic string, partition int, groupID, consumerID string, limit int, isolation IsolationLevel) ([]*core.Message, int, error) {
+	b.Mu.Lock()
+	defer b.Mu.Unlock()
+
+	start := time.Now()
+	offsetKey := consumerID
+	target := pendingKey{Topic: topic, Target: consumerID}
+	if groupID != "" {
+		offsetKey = groupID
+		target.Target = groupID
+	}
+	b.lastFetch[target] = start
+
+	messages := []*core.Message{}
+
+	var waiting []*delivery
+	for _, d := range b.pending[target] {
+		if len(messages) < limit && d.Message.Partition == partition && !time.Now().Before(d.NotBefore) {
+			messages = append(messages, b.deliver(topic, groupID, consumerID, d.Message, d.Attempts+1))
+			continue
+		}
+		waiting = append(waiting, d)
+	}
+	b.setPending(target, waiting)
+
+	if len(messages) >= limit {
+		b.metrics.observeFetch(topic, len(messages), start)
+		return messages, 0, nil
+	}
+
+	var fresh []*core.Message
+	var advanced int
+	var err error
+	if f := b.filters[target]; isolation == ReadCommitted || f != nil {
+		var offset int
+		if offset, err = b.Repo.GetOffset(topic, partition, offsetKey); err == nil {
+			fresh, advanced, err = b.read(topic, partition, offset, limit-len(messages), isolation, f)
+		}
+	} else {
+		fresh, err = b.Repo.Fetch(topic, partition, offsetKey, limit-len(messages))
+		advanced = len(fresh)
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+	for _, msg := range fresh {
+		messages = append(messages, b.deliver(topic, groupID, consumerID, msg, 1))
+	}
+
+	b.metrics.observeFetch(topic, len(messages), start)
+	return messages, advanced, nil
+}
+
+// RedeliverExpired puts every delivery whose visibility timeout has passed back up
+// for delivery and retries redeliveries that are still waiting for inbox space.
+func (b *Manager) RedeliverExpired(now time.Time) int {
+	b.Mu.Lock()
+	defer b.unlockAndSendBlocked()
+
+	for key, settledAt := range b.settled {
+		if now.Sub(settledAt) > b.VisibilityTimeout {
+			delete(b.settled, key)
+		}
+	}
+
+	expired := 0
+	for key, d := range b.inFlight {
+		if now.Before(d.Deadline) {
+			continue
+		}
+		delete(b.inFlight, key)
+		b.retry(d, "visibility timeout expired")
+		expired++
+	}
+
+	for key := range b.pending {
+		b.flushPending(key, now)
+	}
+
+	return expired
+}
+
+// flushPending pushes the redeliveries that are due to live inboxes and keeps the
+// rest waiting. The caller must hold b.Mu.
+func (b *Manager) flushPending(key pendingKey, now time.Time) {
+	var waiting []*delivery
+	for _, d := range b.pending[key] {
+		if now.Before(d.NotBefore) {
+			waiting = append(waiting, d)
+			continue
+		}
+		consumer := b.liveConsumer(d)
+		if consumer == nil || !b.push(d.Topic, d.GroupID, consumer, d.Message, d.Attempts+1) {
+			waiting = append(waiting, d)
+		}
+	}
+	b.setPending(key, waiting)
+}
+
+// retry queues a failed delivery for redelivery, unless it has used up the
+// delivery attempts of its topic and goes to the dead-letter topic instead. It
+// reports whether the delivery was dead-lettered. The caller must hold b.Mu.
+func (b *Manager) retry(d *delivery, reason string) bool {
+	d.LastError = reason
+	if b.deadLetter(d) {
+		return true
+	}
+	b.pending[d.pendingKey()] = append(b.pending[d.pendingKey()], d)
+	b.signalAppend(d.Topic)
+	return false
+}
+
+// push hands msg to a consumer's inbox without blocking and starts tracking the
+// delivery. It reports false when the inbox is full. The caller must hold b.Mu.
+func (b *Manager) push(topic, groupID string, consumer *core.Consumer, msg *core.Message, attempts int) bool {
+	delivered := msg.Clone()
+	delivered.DeliveryAttempts = attempts
+
+	select {
+	case consumer.Inbox <- delivered:
+		b.track(topic, groupID, consumer.ID, msg, attempts)
+		return true
+	default:
+		return false
+	}
+}
+
+// release puts the messages left in an inbox whose session ended back up for
+// redelivery, so they are not left tracked against an inbox nobody reads. They
+// never reached the consumer, so they keep their delivery attempt, and go to a
+// session the consumer, or its group, has open by now. The caller must hold b.Mu.
+func (b *Manager) release(topic, consumerID string, inbox <-chan *core.Message) {
+	released := make(map[pendingKey]bool)
+drain:
+	for {
+		select {
+		case msg, ok := <-inbox:
+			if !ok {
+				break drain
+			}
+			key := deliveryKey{Topic: topic, ConsumerID: consumerID, MessageID: msg.ID}
+			d, ok := b.inFlight[key]
+			if !ok {
+				continue
+			}
+			delete(b.inFlight, key)
+			d.Attempts--
+			b.pending[d.pendingKey()] = append(b.pending[d.pendingKey()], d)
+			released[d.pendingKey()] = true
+		default:
+			break drain
+		}
+	}
+
+	for key := range released {
+		b.signalAppend(topic)
+		b.flushPending(key, time.Now())
+	}
+}
+
+// deliver starts tracking a delivery made by Fetch. The caller must hold b.Mu.
+func (b *Manager) deliver(topic, groupID, consumerID string, msg *core.Message, attempts int) *core.Message {
+	b.track(topic, groupID, consumerID, msg, attempts)
+
+	delivered := msg.Clone()
+	delivered.DeliveryAttempts = attempts
+	return delivered
+}
+
+func (b *Manager) track(topic, groupID, consumerID string, msg *core.Message, attempts int) {
+	msg.DeliveredTo[consumerID] = true
+
+	b.inFlight[deliveryKey{Topic: topic, ConsumerID: consumerID, MessageID: msg.ID}] = &delivery{
+		Topic:      topic,
+		GroupID:    groupID,
+		ConsumerID: consumerID,
+		Message:    msg,
+		Attempts:   attempts,
+		Deadline:   time.Now().Add(b.VisibilityTimeout),
+	}
+}
+
+// liveConsumer finds the inbox a redelivery should go to, or nil when the target
+// only pulls. The caller must hold b.Mu.
+func (b *Manager) liveConsumer(d *delivery) *core.Consumer {
+	if d.GroupID != "" {
+		group, ok := b.Groups[d.GroupID]
+		if !ok {
+			return nil
+		}
+		if member := group.Owner(d.Message.Partition); member != nil {
+			return member.Consumer
+		}
+		return nil
+	}
+
+	topic, ok := b.Topics[d.Topic]
+	if !ok {
+		return nil
+	}
+	return topic.Consumers[d.ConsumerID]
+}
+
+func (b *Manager) setPending(key pendingKey, deliveries []*delivery) {
+	if len(deliveries) == 0 {
+		delete(b.pending, key)
+		return
+	}
+	b.pending[key] = deliveries
+}
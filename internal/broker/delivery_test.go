@@ -0,0 +1,266 @@
+package broker
+
+import (
+	"testing"
+	"time"
+
+	"github.com/codytheroux96/go-mq/internal/core"
+	"github.com/codytheroux96/go-mq/internal/repository"
+)
+
+func TestRedelivery(t *testing.T) {
+	tests := []struct {
+		name           string
+		ack            bool
+		expectAttempts int // 0 means no redelivery expected
+	}{
+		{
+			name:           "Unacked message is redelivered after the visibility timeout",
+			ack:            false,
+			expectAttempts: 2,
+		},
+		{
+			name:           "Acked message is never redelivered",
+			ack:            true,
+			expectAttempts: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := repository.NewInMemoryRepo()
+			if err := repo.CreateTopic("jobs", core.TopicConfig{}); err != nil {
+				t.Fatalf("failed to create topic: %v", err)
+			}
+			manager := NewManager(repo)
+			manager.VisibilityTimeout = time.Minute
+
+			inbox, err := manager.Subscribe("jobs", "c1", core.FlowControl{})
+			if err != nil {
+				t.Fatalf("failed to subscribe: %v", err)
+			}
+			if err := manager.Publish("jobs", core.NewMessage([]byte("work"), "p1")); err != nil {
+				t.Fatalf("failed to publish: %v", err)
+			}
+
+			first := <-inbox
+			if first.DeliveryAttempts != 1 {
+				t.Fatalf("expected first delivery attempt to be 1, got %d", first.DeliveryAttempts)
+			}
+
+			if tt.ack {
+				if _, err := manager.Ack("jobs", "c1", first.ID); err != nil {
+					t.Fatalf("failed to ack: %v", err)
+				}
+				duplicate, err := manager.Ack("jobs", "c1", first.ID)
+				if err != nil || !duplicate {
+					t.Fatalf("expected second ack to be reported as duplicate, got %v (err: %v)", duplicate, err)
+				}
+			}
+
+			manager.RedeliverExpired(time.Now().Add(30 * time.Second))
+			if len(inbox) != 0 {
+				t.Fatalf("expected no redelivery before the visibility timeout")
+			}
+
+			manager.RedeliverExpired(time.Now().Add(2 * time.Minute))
+			if tt.expectAttempts == 0 {
+				if len(inbox) != 0 {
+					t.Fatalf("expected no redelivery, got %d messages", len(inbox))
+				}
+				return
+			}
+
+			select {
+			case again := <-inbox:
+				if again.ID != first.ID || again.DeliveryAttempts != tt.expectAttempts {
+					t.Fatalf("expected %s redelivered with attempt %d, got %s attempt %d", first.ID, tt.expectAttempts, again.ID, again.DeliveryAttempts)
+				}
+			default:
+				t.Fatalf("expected message to be redelivered")
+			}
+		})
+	}
+}
+
+func TestFetchRedelivery(t *testing.T) {
+	repo := repository.NewInMemoryRepo()
+	if err := repo.CreateTopic("jobs", core.TopicConfig{}); err != nil {
+		t.Fatalf("failed to create topic: %v", err)
+	}
+	manager := NewManager(repo)
+	manager.VisibilityTimeout = time.Minute
+
+	for i := 0; i < 2; i++ {
+		if err := manager.Publish("jobs", core.NewMessage([]byte("work"), "p1")); err != nil {
+			t.Fatalf("failed to publish: %v", err)
+		}
+	}
+
+	messages, fresh, err := manager.Fetch("jobs", 0, "", "c1", 10, ReadUncommitted)
+	if err != nil || len(messages) != 2 || fresh != 2 {
+		t.Fatalf("expected 2 fresh messages, got %d (%d fresh, err: %v)", len(messages), fresh, err)
+	}
+	if err := repo.CommitOffset("jobs", 0, "c1", 2); err != nil {
+		t.Fatalf("failed to commit: %v", err)
+	}
+	if _, err := manager.Ack("jobs", "c1", messages[0].ID); err != nil {
+		t.Fatalf("failed to ack: %v", err)
+	}
+
+	manager.RedeliverExpired(time.Now().Add(2 * time.Minute))
+
+	redelivered, fresh, err := manager.Fetch("jobs", 0, "", "c1", 10, ReadUncommitted)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(redelivered) != 1 || fresh != 0 {
+		t.Fatalf("expected only the unacked message back, got %d (%d fresh)", len(redelivered), fresh)
+	}
+	if redelivered[0].ID != messages[1].ID || redelivered[0].DeliveryAttempts != 2 {
+		t.Fatalf("expected %s on attempt 2, got %s on attempt %d", messages[1].ID, redelivered[0].ID, redelivered[0].DeliveryAttempts)
+	}
+}
+
+func TestNack(t *testing.T) {
+	tests := []struct {
+		name              string
+		maxAttempts       int
+		requeue           bool
+		delay             time.Duration
+		expectDeadLetter  bool
+		expectRedelivered bool
+	}{
+		{
+			name:              "Requeue redelivers right away",
+			requeue:           true,
+			expectRedelivered: true,
+		},
+		{
+			name:              "Requeue with a delay waits before redelivering",
+			requeue:           true,
+			delay:             time.Minute,
+			expectRedelivered: true,
+		},
+		{
+			name:             "Requeue on the last attempt dead-letters",
+			maxAttempts:      1,
+			requeue:          true,
+			expectDeadLetter: true,
+		},
+		{
+			name:             "No requeue dead-letters right away",
+			maxAttempts:      5,
+			requeue:          false,
+			expectDeadLetter: true,
+		},
+		{
+			name:    "No requeue without a dead-letter topic drops the message",
+			requeue: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := repository.NewInMemoryRepo()
+			if err := repo.CreateTopic("jobs-dlq", core.TopicConfig{}); err != nil {
+				t.Fatalf("failed to create dead-letter topic: %v", err)
+			}
+			cfg := core.TopicConfig{}
+			if tt.maxAttempts > 0 {
+				cfg.DeadLetter = core.DeadLetterPolicy{MaxDeliveryAttempts: tt.maxAttempts, Topic: "jobs-dlq"}
+			}
+			if err := repo.CreateTopic("jobs", cfg); err != nil {
+				t.Fatalf("failed to create topic: %v", err)
+			}
+			manager := NewManager(repo)
+			manager.VisibilityTimeout = time.Hour
+
+			inbox, _ := manager.Subscribe("jobs", "c1", core.FlowControl{})
+			if err := manager.Publish("jobs", core.NewMessage([]byte("work"), "p1")); err != nil {
+				t.Fatalf("failed to publish: %v", err)
+			}
+			first := <-inbox
+
+			deadLettered, err := manager.Nack("jobs", "c1", first.ID, tt.requeue, tt.delay, "handler failed")
+			if err != nil {
+				t.Fatalf("failed to nack: %v", err)
+			}
+			if deadLettered != tt.expectDeadLetter {
+				t.Fatalf("expected dead-lettered %v, got %v", tt.expectDeadLetter, deadLettered)
+			}
+
+			if tt.delay > 0 {
+				manager.RedeliverExpired(time.Now().Add(tt.delay / 2))
+				if len(inbox) != 0 {
+					t.Fatalf("expected no redelivery before the nack delay")
+				}
+				manager.RedeliverExpired(time.Now().Add(tt.delay * 2))
+			}
+
+			if tt.expectRedelivered {
+				select {
+				case again := <-inbox:
+					if again.ID != first.ID || again.DeliveryAttempts != 2 {
+						t.Fatalf("expected %s on attempt 2, got %s on attempt %d", first.ID, again.ID, again.DeliveryAttempts)
+					}
+				default:
+					t.Fatalf("expected message to be redelivered")
+				}
+			} else if len(inbox) != 0 {
+				t.Fatalf("expected no redelivery, got %d messages", len(inbox))
+			}
+
+			dead, _ := repo.Fetch("jobs-dlq", 0, "inspector", 10)
+			if tt.expectDeadLetter != (len(dead) == 1) {
+				t.Fatalf("expected dead letter %v, found %d", tt.expectDeadLetter, len(dead))
+			}
+			if tt.expectDeadLetter && dead[0].Metadata[MetaLastError] != "handler failed" {
+				t.Fatalf("expected nack reason on dead letter, got %q", dead[0].Metadata[MetaLastError])
+			}
+
+			if _, err := manager.Nack("jobs", "c1", "missing", true, 0, ""); err == nil {
+				t.Fatalf("expected error nacking a message that was never delivered")
+			}
+		})
+	}
+}
+
+func TestUnsubscribeReleasesInbox(t *testing.T) {
+	repo := repository.NewInMemoryRepo()
+	if err := repo.CreateTopic("jobs", core.TopicConfig{}); err != nil {
+		t.Fatalf("failed to create topic: %v", err)
+	}
+	manager := NewManager(repo)
+	manager.VisibilityTimeout = time.Minute
+
+	inbox, err := manager.Subscribe("jobs", "c1", core.FlowControl{})
+	if err != nil {
+		t.Fatalf("failed to subscribe: %v", err)
+	}
+	for _, payload := range []string{"read", "unread"} {
+		if err := manager.Publish("jobs", core.NewMessage([]byte(payload), "p1")); err != nil {
+			t.Fatalf("failed to publish: %v", err)
+		}
+	}
+	read := <-inbox
+	manager.Unsubscribe("jobs", "c1", inbox)
+
+	if err := manager.Publish("jobs", core.NewMessage([]byte("after"), "p1")); err != nil {
+		t.Fatalf("failed to publish: %v", err)
+	}
+	if len(inbox) != 0 {
+		t.Fatalf("expected nothing pushed to an inbox after unsubscribing, got %d messages", len(inbox))
+	}
+
+	messages, _, err := manager.Fetch("jobs", 0, "", "c1", 1, ReadUncommitted)
+	if err != nil {
+		t.Fatalf("failed to fetch: %v", err)
+	}
+	if len(messages) != 1 || string(messages[0].Body) != "unread" || messages[0].DeliveryAttempts != 1 {
+		t.Fatalf("expected the unread message back on its first attempt, got %v", messages)
+	}
+	if _, err := manager.Ack("jobs", "c1", read.ID); err != nil {
+		t.Fatalf("expected the message read before unsubscribing to stay ackable: %v", err)
+	}
+}
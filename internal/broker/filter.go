@@ -0,0 +1,86 @@
+package broker
+
+import (
+	"github.com/codytheroux96/go-mq/internal/core"
+	"github.com/codytheroux96/go-mq/internal/filter"
+)
+
+// SetFilter has the broker deliver to target, a consumer ID or a group ID, only
+// the messages of topic that pass f, both when pushing to its inbox and in Fetch.
+// Messages that do not pass are skipped as if consumed, they still move the
+// committed offset. A nil f removes the filter.
+func (b *Manager) SetFilter(topic, target string, f *filter.Filter) error {
+	b.Mu.Lock()
+	defer b.Mu.Unlock()
+
+	if _, _, err := b.topic(topic); err != nil {
+		return err
+	}
+
+	key := pendingKey{Topic: topic, Target: target}
+	if f == nil {
+		delete(b.filters, key)
+		return nil
+	}
+	b.filters[key] = f
+	return nil
+}
+
+// Filter returns the filter target has on topic, or nil when it has none.
+func (b *Manager) Filter(topic, target string) *filter.Filter {
+	b.Mu.RLock()
+	defer b.Mu.RUnlock()
+
+	return b.filters[pendingKey{Topic: topic, Target: target}]
+}
+
+// passes reports whether msg passes target's filter on topic. The caller must hold
+// b.Mu.
+func (b *Manager) passes(topic, target string, msg *core.Message) bool {
+	f, ok := b.filters[pendingKey{Topic: topic, Target: target}]
+	return !ok || f.Match(msg)
+}
+
+// maxReadScan is the most log positions one read moves past, so a filter that
+// rejects a long run of messages does not hold b.Mu for the whole partition.
+const maxReadScan = 1000
+
+// read reads up to limit messages from offset that pass f, when f is set. At
+// ReadCommitted it also skips messages of aborted transactions and stops before
+// those of open ones. It returns how many log positions it moved past, skipped
+// messages included, which is at most maxReadScan, so the caller can move the
+// committed offset even when nothing passed. The caller must hold b.Mu.
+func (b *Manager) read(topic string, partition int, offset int, limit int, isolation IsolationLevel, f *filter.Filter) ([]*core.Message, int, error) {
+	stable := -1
+	if isolation == ReadCommitted {
+		stable = b.stableOffset(topic, partition)
+	}
+	messages := []*core.Message{}
+	next := offset
+
+	for len(messages) < limit && next-offset < maxReadScan {
+		batch, err := b.Repo.Read(topic, partition, next, min(limit, maxReadScan-(next-offset)))
+		if err != nil {
+			return nil, 0, err
+		}
+		if len(batch) == 0 {
+			break
+		}
+
+		for _, msg := range batch {
+			if (stable >= 0 && msg.Offset >= stable) || len(messages) == limit {
+				return messages, next - offset, nil
+			}
+			next = msg.Offset + 1
+			if _, aborted := b.aborted[msg.Metadata[MetaTransactionID]]; aborted && isolation == ReadCommitted {
+				continue
+			}
+			if f != nil && !f.Match(msg) {
+				continue
+			}
+			messages = append(messages, msg)
+		}
+	}
+
+	return messages, next - offset, nil
+}
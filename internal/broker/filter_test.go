@@ -0,0 +1,199 @@
+package broker
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/codytheroux96/go-mq/internal/core"
+	"github.com/codytheroux96/go-mq/internal/filter"
+	"github.com/codytheroux96/go-mq/internal/repository"
+)
+
+func mustParse(t *testing.T, expr string) *filter.Filter {
+	t.Helper()
+	f, err := filter.Parse(expr)
+	if err != nil {
+		t.Fatalf("failed to parse filter %s: %v", expr, err)
+	}
+	return f
+}
+
+func publishRegions(t *testing.T, manager *Manager, topic string, regions ...string) {
+	t.Helper()
+	for _, region := range regions {
+		msg := core.NewMessage([]byte(`{"region":"`+region+`"}`), "p1")
+		if err := manager.Publish(topic, msg); err != nil {
+			t.Fatalf("failed to publish: %v", err)
+		}
+	}
+}
+
+func TestFetchWithFilter(t *testing.T) {
+	repo := repository.NewInMemoryRepo()
+	if err := repo.CreateTopic("orders", core.TopicConfig{}); err != nil {
+		t.Fatalf("failed to create topic: %v", err)
+	}
+	manager := NewManager(repo)
+	if err := manager.SetFilter("orders", "c1", mustParse(t, `region == "eu"`)); err != nil {
+		t.Fatalf("failed to set filter: %v", err)
+	}
+	publishRegions(t, manager, "orders", "us", "eu", "us", "us", "eu", "us")
+
+	tests := []struct {
+		name           string
+		limit          int
+		isolation      IsolationLevel
+		expectOffsets  []int
+		expectAdvanced int
+	}{
+		{"Everything that passes", 10, ReadUncommitted, []int{1, 4}, 6},
+		{"Stops after the limit", 1, ReadUncommitted, []int{1}, 2},
+		{"Read committed", 10, ReadCommitted, []int{1, 4}, 6},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			messages, advanced, err := manager.Fetch("orders", 0, "", "c1", tt.limit, tt.isolation)
+			if err != nil {
+				t.Fatalf("failed to fetch: %v", err)
+			}
+			if len(messages) != len(tt.expectOffsets) || advanced != tt.expectAdvanced {
+				t.Fatalf("expected offsets %v advancing %d, got %d messages advancing %d", tt.expectOffsets, tt.expectAdvanced, len(messages), advanced)
+			}
+			for i, msg := range messages {
+				if msg.Offset != tt.expectOffsets[i] {
+					t.Fatalf("expected offsets %v, got offset %d at %d", tt.expectOffsets, msg.Offset, i)
+				}
+			}
+		})
+	}
+
+	// without a filter every message comes back
+	if err := manager.SetFilter("orders", "c1", nil); err != nil {
+		t.Fatalf("failed to remove filter: %v", err)
+	}
+	if messages, _, _ := manager.Fetch("orders", 0, "", "c1", 10, ReadUncommitted); len(messages) != 6 {
+		t.Fatalf("expected 6 messages without a filter, got %d", len(messages))
+	}
+
+	if err := manager.SetFilter("missing", "c1", nil); err == nil {
+		t.Fatalf("expected an error for a missing topic")
+	}
+}
+
+func TestPushWithFilter(t *testing.T) {
+	repo := repository.NewInMemoryRepo()
+	if err := repo.CreateTopic("orders", core.TopicConfig{}); err != nil {
+		t.Fatalf("failed to create topic: %v", err)
+	}
+	manager := NewManager(repo)
+
+	inbox, err := manager.Subscribe("orders", "c1", core.FlowControl{})
+	if err != nil {
+		t.Fatalf("failed to subscribe: %v", err)
+	}
+	groupInbox, _, err := manager.JoinGroup("billing", "orders", "m1", core.FlowControl{})
+	if err != nil {
+		t.Fatalf("failed to join group: %v", err)
+	}
+	if err := manager.SetFilter("orders", "c1", mustParse(t, `region == "eu"`)); err != nil {
+		t.Fatalf("failed to set filter: %v", err)
+	}
+	if err := manager.SetFilter("orders", "billing", mustParse(t, `region != "eu"`)); err != nil {
+		t.Fatalf("failed to set filter: %v", err)
+	}
+	publishRegions(t, manager, "orders", "us", "eu", "apac")
+
+	tests := []struct {
+		name          string
+		inbox         <-chan *core.Message
+		expectOffsets []int
+	}{
+		{"Consumer", inbox, []int{1}},
+		{"Group", groupInbox, []int{0, 2}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if len(tt.inbox) != len(tt.expectOffsets) {
+				t.Fatalf("expected %d messages in the inbox, got %d", len(tt.expectOffsets), len(tt.inbox))
+			}
+			for _, offset := range tt.expectOffsets {
+				if msg := <-tt.inbox; msg.Offset != offset {
+					t.Fatalf("expected offset %d, got %d", offset, msg.Offset)
+				}
+			}
+		})
+	}
+}
+
+func TestWaitForMessagesWithFilter(t *testing.T) {
+	repo := repository.NewInMemoryRepo()
+	if err := repo.CreateTopic("orders", core.TopicConfig{}); err != nil {
+		t.Fatalf("failed to create topic: %v", err)
+	}
+	manager := NewManager(repo)
+	if err := manager.SetFilter("orders", "c1", mustParse(t, `region == "eu"`)); err != nil {
+		t.Fatalf("failed to set filter: %v", err)
+	}
+	publishRegions(t, manager, "orders", "us")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := manager.WaitForMessages(ctx, "orders", []int{0}, "", "c1", 1, ReadUncommitted); err == nil {
+		t.Fatalf("expected the wait to time out when nothing passes the filter")
+	}
+
+	time.AfterFunc(20*time.Millisecond, func() {
+		manager.Publish("orders", core.NewMessage([]byte(`{"region":"us"}`), "p1"))
+		manager.Publish("orders", core.NewMessage([]byte(`{"region":"eu"}`), "p1"))
+	})
+	ctx, cancel = context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := manager.WaitForMessages(ctx, "orders", []int{0}, "", "c1", 1, ReadUncommitted); err != nil {
+		t.Fatalf("expected a message that passes the filter to end the wait, got %v", err)
+	}
+}
+
+func TestFilterScanIsCapped(t *testing.T) {
+	repo := repository.NewInMemoryRepo()
+	if err := repo.CreateTopic("orders", core.TopicConfig{}); err != nil {
+		t.Fatalf("failed to create topic: %v", err)
+	}
+	manager := NewManager(repo)
+	if err := manager.SetFilter("orders", "c1", mustParse(t, `region == "eu"`)); err != nil {
+		t.Fatalf("failed to set filter: %v", err)
+	}
+	regions := make([]string, maxReadScan+5)
+	for i := range regions {
+		regions[i] = "us"
+	}
+	publishRegions(t, manager, "orders", append(regions, "eu")...)
+
+	// a long poll wakes up for the rejected run instead of waiting on it
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := manager.WaitForMessages(ctx, "orders", []int{0}, "", "c1", 1, ReadUncommitted); err != nil {
+		t.Fatalf("expected the wait to return for a run of rejected messages, got %v", err)
+	}
+
+	messages, advanced, err := manager.Fetch("orders", 0, "", "c1", 1, ReadUncommitted)
+	if err != nil {
+		t.Fatalf("failed to fetch: %v", err)
+	}
+	if len(messages) != 0 || advanced != maxReadScan {
+		t.Fatalf("expected one capped scan to advance %d without messages, got %d messages advancing %d", maxReadScan, len(messages), advanced)
+	}
+	if err := repo.CommitOffset("orders", 0, "c1", advanced); err != nil {
+		t.Fatalf("failed to commit: %v", err)
+	}
+
+	messages, advanced, err = manager.Fetch("orders", 0, "", "c1", 1, ReadUncommitted)
+	if err != nil {
+		t.Fatalf("failed to fetch: %v", err)
+	}
+	if len(messages) != 1 || messages[0].Offset != maxReadScan+5 || advanced != 6 {
+		t.Fatalf("expected the eu message after the rejected run, got %d messages advancing %d", len(messages), advanced)
+	}
+}
@@ -0,0 +1,167 @@
+package broker
+
+import (
+	"maps"
+	"sync"
+	"time"
+
+	"github.com/codytheroux96/go-mq/internal/core"
+)
+
+// DropKey identifies a count of messages a consumer's slow consumer policy kept from
+// it. ConsumerID is the member for a group's messages.
+type DropKey struct {
+	Topic      string
+	GroupID    string
+	ConsumerID string
+	Policy     core.SlowConsumerPolicy
+}
+
+// Drops returns how many messages have been dropped for slow consumers so far.
+// Counts outlive the consumers they belong to.
+func (b *Manager) Drops() map[DropKey]uint64 {
+	b.Mu.RLock()
+	defer b.Mu.RUnlock()
+
+	return maps.Clone(b.drops)
+}
+
+// offer pushes a newly published message to a live consumer, applying its slow
+// consumer policy when the inbox is full. A message evicted by DropOldest stays
+// tracked and is redelivered once its visibility timeout passes. A block policy
+// consumer's message is queued instead of waited for, see unlockAndSendBlocked.
+// The caller must hold b.Mu.
+func (b *Manager) offer(topic, groupID string, consumer *core.Consumer, msg *core.Message) {
+	if consumer.Flow.Policy == core.Block {
+		// messages queued earlier go first so the consumer sees them in order
+		if queue, ok := b.blocked[consumer]; ok {
+			b.blocked[consumer] = append(queue, blockedOffer{topic: topic, groupID: groupID, msg: msg})
+		} else if !b.push(topic, groupID, consumer, msg, 1) {
+			b.blocked[consumer] = []blockedOffer{{topic: topic, groupID: groupID, msg: msg}}
+			b.claimed = append(b.claimed, consumer)
+		}
+		return
+	}
+
+	delivered := msg.Clone()
+	delivered.DeliveryAttempts = 1
+
+	ok, dropped := consumer.Offer(delivered)
+	if ok {
+		b.track(topic, groupID, consumer.ID, msg, 1)
+	}
+	if dropped == 0 {
+		return
+	}
+
+	b.drops[DropKey{Topic: topic, GroupID: groupID, ConsumerID: consumer.ID, Policy: consumer.Flow.Policy}] += uint64(dropped)
+	if consumer.Flow.Policy == core.Disconnect {
+		b.disconnect(topic, groupID, consumer)
+	}
+}
+
+type blockedOffer struct {
+	topic   string
+	groupID string
+	msg     *core.Message
+}
+
+// unlockAndSendBlocked releases b.Mu and then sends the messages the caller queued
+// for full block policy inboxes, waiting up to each consumer's BlockTimeout for
+// room before dropping a message. Methods that publish defer it in place of
+// b.Mu.Unlock, so a slow consumer holds back the publishers of its own messages
+// and never the broker. The publisher that started a consumer's queue sends it
+// until it is empty, including messages others queue behind it meanwhile.
+func (b *Manager) unlockAndSendBlocked() {
+	consumers := b.claimed
+	b.claimed = nil
+	b.Mu.Unlock()
+
+	var wg sync.WaitGroup
+	for _, consumer := range consumers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			b.sendQueued(consumer)
+		}()
+	}
+	wg.Wait()
+}
+
+// sendQueued sends a consumer's queued messages in order until its queue is empty.
+// A message is tracked before it is sent so an ack cannot beat the tracking.
+func (b *Manager) sendQueued(consumer *core.Consumer) {
+	for {
+		b.Mu.Lock()
+		queue := b.blocked[consumer]
+		if len(queue) == 0 {
+			delete(b.blocked, consumer)
+			b.Mu.Unlock()
+			return
+		}
+		next := queue[0]
+		b.blocked[consumer] = queue[1:]
+		if !b.subscribed(next.topic, next.groupID, consumer) {
+			b.Mu.Unlock()
+			continue
+		}
+		b.track(next.topic, next.groupID, consumer.ID, next.msg, 1)
+		b.Mu.Unlock()
+
+		delivered := next.msg.Clone()
+		delivered.DeliveryAttempts = 1
+
+		timer := time.NewTimer(consumer.Flow.BlockTimeout)
+		sent := false
+		select {
+		case consumer.Inbox <- delivered:
+			sent = true
+		case <-timer.C:
+		}
+		timer.Stop()
+		if sent {
+			continue
+		}
+
+		b.Mu.Lock()
+		key := deliveryKey{Topic: next.topic, ConsumerID: consumer.ID, MessageID: next.msg.ID}
+		if d, ok := b.inFlight[key]; ok && d.Message == next.msg {
+			delete(b.inFlight, key)
+		}
+		consumer.Dropped.Add(1)
+		b.drops[DropKey{Topic: next.topic, GroupID: next.groupID, ConsumerID: consumer.ID, Policy: core.Block}]++
+		b.Mu.Unlock()
+	}
+}
+
+// subscribed reports whether consumer still receives the messages of topic, or of
+// the group's topic. The caller must hold b.Mu.
+func (b *Manager) subscribed(topic, groupID string, consumer *core.Consumer) bool {
+	if groupID != "" {
+		group, ok := b.Groups[groupID]
+		if !ok {
+			return false
+		}
+		member, ok := group.Members[consumer.ID]
+		return ok && member.Consumer == consumer
+	}
+	topicEntry, ok := b.Topics[topic]
+	return ok && topicEntry.Consumers[consumer.ID] == consumer
+}
+
+// disconnect removes a consumer that fell behind and closes its inbox, which ends
+// its subscription. A group member leaves the group. The caller must hold b.Mu.
+func (b *Manager) disconnect(topic, groupID string, consumer *core.Consumer) {
+	if groupID != "" {
+		if group, ok := b.Groups[groupID]; ok {
+			if member, ok := group.Members[consumer.ID]; ok && member.Consumer == consumer {
+				delete(group.Members, consumer.ID)
+				group.Rebalance()
+			}
+		}
+	} else if topicEntry, ok := b.Topics[topic]; ok && topicEntry.Consumers[consumer.ID] == consumer {
+		delete(topicEntry.Consumers, consumer.ID)
+	}
+
+	close(consumer.Inbox)
+}
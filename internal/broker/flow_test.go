@@ -0,0 +1,208 @@
+package broker
+
+import (
+	"testing"
+	"time"
+
+	"github.com/codytheroux96/go-mq/internal/core"
+	"github.com/codytheroux96/go-mq/internal/repository"
+)
+
+func TestSlowConsumerPolicies(t *testing.T) {
+	tests := []struct {
+		name          string
+		policy        core.SlowConsumerPolicy
+		expectInbox   []string
+		expectDropped uint64
+		expectRemoved bool
+	}{
+		{"Drop newest", core.DropNewest, []string{"1", "2"}, 1, false},
+		{"Drop oldest", core.DropOldest, []string{"2", "3"}, 1, false},
+		{"Block", core.Block, []string{"1", "2"}, 1, false},
+		{"Disconnect", core.Disconnect, []string{"1", "2"}, 1, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := repository.NewInMemoryRepo()
+			if err := repo.CreateTopic("orders", core.TopicConfig{}); err != nil {
+				t.Fatalf("failed to create topic: %v", err)
+			}
+			manager := NewManager(repo)
+
+			inbox, err := manager.Subscribe("orders", "c1", core.FlowControl{InboxSize: 2, Policy: tt.policy})
+			if err != nil {
+				t.Fatalf("failed to subscribe: %v", err)
+			}
+			for _, payload := range []string{"1", "2", "3"} {
+				if err := manager.Publish("orders", core.NewMessage([]byte(payload), "p1")); err != nil {
+					t.Fatalf("failed to publish: %v", err)
+				}
+			}
+
+			_, subscribed := manager.Topics["orders"].Consumers["c1"]
+			if subscribed == tt.expectRemoved {
+				t.Fatalf("expected the consumer to be removed %v", tt.expectRemoved)
+			}
+
+			var got []string
+			for len(got) < len(tt.expectInbox) {
+				got = append(got, string((<-inbox).Body))
+			}
+			for i := range got {
+				if got[i] != tt.expectInbox[i] {
+					t.Fatalf("expected inbox %v, got %v", tt.expectInbox, got)
+				}
+			}
+			if tt.expectRemoved {
+				if _, ok := <-inbox; ok {
+					t.Fatalf("expected the inbox to be closed")
+				}
+			}
+
+			drops := manager.Drops()[DropKey{Topic: "orders", ConsumerID: "c1", Policy: tt.policy}]
+			if drops != tt.expectDropped {
+				t.Fatalf("expected %d drops, got %d", tt.expectDropped, drops)
+			}
+		})
+	}
+}
+
+func TestDisconnectGroupMember(t *testing.T) {
+	repo := repository.NewInMemoryRepo()
+	if err := repo.CreateTopic("orders", core.TopicConfig{}); err != nil {
+		t.Fatalf("failed to create topic: %v", err)
+	}
+	manager := NewManager(repo)
+
+	inbox, _, err := manager.JoinGroup("billing", "orders", "m1", core.FlowControl{InboxSize: 1, Policy: core.Disconnect})
+	if err != nil {
+		t.Fatalf("failed to join group: %v", err)
+	}
+	for range 2 {
+		if err := manager.Publish("orders", core.NewMessage([]byte("order"), "p1")); err != nil {
+			t.Fatalf("failed to publish: %v", err)
+		}
+	}
+
+	<-inbox
+	if _, ok := <-inbox; ok {
+		t.Fatalf("expected the inbox to be closed")
+	}
+	state, err := manager.DescribeGroup("billing")
+	if err != nil {
+		t.Fatalf("failed to describe group: %v", err)
+	}
+	if len(state.Members) != 0 {
+		t.Fatalf("expected the member to leave the group, got %v", state.Members)
+	}
+	if drops := manager.Drops()[DropKey{Topic: "orders", GroupID: "billing", ConsumerID: "m1", Policy: core.Disconnect}]; drops != 1 {
+		t.Fatalf("expected 1 drop, got %d", drops)
+	}
+}
+
+func TestBlockPolicyHoldsOnlyItsPublisher(t *testing.T) {
+	repo := repository.NewInMemoryRepo()
+	for _, topic := range []string{"orders", "payments"} {
+		if err := repo.CreateTopic(topic, core.TopicConfig{}); err != nil {
+			t.Fatalf("failed to create topic: %v", err)
+		}
+	}
+	manager := NewManager(repo)
+
+	inbox, err := manager.Subscribe("orders", "c1", core.FlowControl{InboxSize: 1, Policy: core.Block, BlockTimeout: 5 * time.Second})
+	if err != nil {
+		t.Fatalf("failed to subscribe: %v", err)
+	}
+	if err := manager.Publish("orders", core.NewMessage([]byte("1"), "p1")); err != nil {
+		t.Fatalf("failed to publish: %v", err)
+	}
+
+	blocked := make(chan error, 1)
+	go func() {
+		blocked <- manager.Publish("orders", core.NewMessage([]byte("2"), "p1"))
+	}()
+
+	// wait until the second message is queued for the full inbox
+	for {
+		manager.Mu.RLock()
+		queued := len(manager.blocked) > 0
+		manager.Mu.RUnlock()
+		if queued {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	start := time.Now()
+	if err := manager.Publish("payments", core.NewMessage([]byte("paid"), "p1")); err != nil {
+		t.Fatalf("failed to publish: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("expected a publish to another topic not to wait for the full inbox, took %v", elapsed)
+	}
+	select {
+	case err := <-blocked:
+		t.Fatalf("expected the publisher of the blocked message to wait, got %v", err)
+	default:
+	}
+
+	for _, expect := range []string{"1", "2"} {
+		if got := string((<-inbox).Body); got != expect {
+			t.Fatalf("expected %s, got %s", expect, got)
+		}
+	}
+	if err := <-blocked; err != nil {
+		t.Fatalf("failed to publish: %v", err)
+	}
+	if drops := manager.Drops()[DropKey{Topic: "orders", ConsumerID: "c1", Policy: core.Block}]; drops != 0 {
+		t.Fatalf("expected no drops, got %d", drops)
+	}
+}
+
+func TestBlockPolicyScheduleInThePast(t *testing.T) {
+	repo := repository.NewInMemoryRepo()
+	if err := repo.CreateTopic("orders", core.TopicConfig{}); err != nil {
+		t.Fatalf("failed to create topic: %v", err)
+	}
+	manager := NewManager(repo)
+
+	inbox, err := manager.Subscribe("orders", "c1", core.FlowControl{InboxSize: 1, Policy: core.Block, BlockTimeout: 5 * time.Second})
+	if err != nil {
+		t.Fatalf("failed to subscribe: %v", err)
+	}
+	if err := manager.Publish("orders", core.NewMessage([]byte("1"), "p1")); err != nil {
+		t.Fatalf("failed to publish: %v", err)
+	}
+
+	// a time that has passed publishes right away, and waits for the full inbox
+	scheduled := make(chan error, 1)
+	go func() {
+		scheduled <- manager.Schedule("orders", core.NewMessage([]byte("2"), "p1"), time.Now().Add(-time.Second))
+	}()
+
+	// wait until the second message is queued for the full inbox
+	for {
+		manager.Mu.RLock()
+		queued := len(manager.blocked) > 0
+		manager.Mu.RUnlock()
+		if queued {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	for _, expect := range []string{"1", "2"} {
+		select {
+		case msg := <-inbox:
+			if got := string(msg.Body); got != expect {
+				t.Fatalf("expected %s, got %s", expect, got)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("expected %s to be delivered", expect)
+		}
+	}
+	if err := <-scheduled; err != nil {
+		t.Fatalf("failed to schedule: %v", err)
+	}
+}
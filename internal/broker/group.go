@@ -0,0 +1,251 @@
+package broker
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/codytheroux96/go-mq/internal/core"
+)
+
+const DefaultSessionTimeout = 30 * time.Second
+
+// Assignment is the set of partitions a group member currently owns.
+type Assignment struct {
+	GroupID    string `json:"group_id"`
+	Topic      string `json:"topic"`
+	MemberID   string `json:"member_id"`
+	Generation int    `json:"generation"`
+	Partitions []int  `json:"partitions"`
+}
+
+type GroupState struct {
+	ID         string        `json:"group_id"`
+	Topic      string        `json:"topic"`
+	Generation int           `json:"generation"`
+	Members    []MemberState `json:"members"`
+}
+
+type MemberState struct {
+	ID            string    `json:"member_id"`
+	Partitions    []int     `json:"partitions"`
+	LastHeartbeat time.Time `json:"last_heartbeat"`
+	InboxDepth    int       `json:"inbox_depth"`
+	Dropped       uint64    `json:"dropped"`
+}
+
+// JoinGroup adds a member to the group, creating the group on first use, and
+// rebalances the topic's partitions across the members. Joining again as an
+// existing member just refreshes its heartbeat. A new member's inbox is sized and
+// drained as flow says.
+func (b *Manager) JoinGroup(groupID, topicName, memberID string, flow core.FlowControl) (<-chan *core.Message, Assignment, error) {
+	if err := flow.Validate(); err != nil {
+		return nil, Assignment{}, err
+	}
+
+	b.Mu.Lock()
+	defer b.Mu.Unlock()
+
+	group, member, err := b.joinGroup(groupID, topicName, memberID)
+	if err != nil {
+		return nil, Assignment{}, err
+	}
+	if member.Consumer == nil {
+		member.Consumer = core.NewConsumer(memberID, flow)
+		b.flushPending(pendingKey{Topic: topicName, Target: groupID}, time.Now())
+	}
+
+	return member.Consumer.Inbox, assignmentFor(group, member), nil
+}
+
+// JoinGroupForFetch joins a member that only pulls with Fetch. It has no inbox, so
+// the group's messages and redeliveries wait for its next fetch instead of being
+// pushed.
+func (b *Manager) JoinGroupForFetch(groupID, topicName, memberID string) (Assignment, error) {
+	b.Mu.Lock()
+	defer b.Mu.Unlock()
+
+	group, member, err := b.joinGroup(groupID, topicName, memberID)
+	if err != nil {
+		return Assignment{}, err
+	}
+
+	return assignmentFor(group, member), nil
+}
+
+// joinGroup finds or adds the member, the caller must hold b.Mu.
+func (b *Manager) joinGroup(groupID, topicName, memberID string) (*core.ConsumerGroup, *core.GroupMember, error) {
+	_, cfg, err := b.topic(topicName)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	group, ok := b.Groups[groupID]
+	if !ok {
+		group = core.NewConsumerGroup(groupID, topicName, cfg.PartitionCount())
+		b.Groups[groupID] = group
+	}
+	if group.Topic != topicName {
+		return nil, nil, fmt.Errorf("group %q already consumes topic %q", groupID, group.Topic)
+	}
+
+	member, ok := group.Members[memberID]
+	if !ok {
+		member = &core.GroupMember{ID: memberID}
+		group.Members[memberID] = member
+		group.Rebalance()
+	}
+	member.LastHeartbeat = time.Now()
+
+	return group, member, nil
+}
+
+// Heartbeat keeps a member alive and returns its current assignment, which changes
+// whenever the group rebalances.
+func (b *Manager) Heartbeat(groupID, memberID string) (Assignment, error) {
+	b.Mu.Lock()
+	defer b.Mu.Unlock()
+
+	group, member, err := b.member(groupID, memberID)
+	if err != nil {
+		return Assignment{}, err
+	}
+	member.LastHeartbeat = time.Now()
+
+	return assignmentFor(group, member), nil
+}
+
+// LeaveGroup removes a member and rebalances the group. Messages still in the
+// member's inbox are put back up for redelivery to the group.
+func (b *Manager) LeaveGroup(groupID, memberID string) error {
+	b.Mu.Lock()
+	defer b.Mu.Unlock()
+
+	group, member, err := b.member(groupID, memberID)
+	if err != nil {
+		return err
+	}
+
+	delete(group.Members, memberID)
+	group.Rebalance()
+	if member.Consumer != nil {
+		b.release(group.Topic, memberID, member.Consumer.Inbox)
+	}
+
+	return nil
+}
+
+// ExpireMembers removes members that have not sent a heartbeat within the session
+// timeout and rebalances their groups. As on LeaveGroup, messages still in their
+// inboxes are put back up for redelivery to the group. It returns how many members
+// were removed.
+func (b *Manager) ExpireMembers(now time.Time) int {
+	b.Mu.Lock()
+	defer b.Mu.Unlock()
+
+	expired := 0
+	for _, group := range b.Groups {
+		removed := make(map[string]*core.GroupMember)
+		for id, member := range group.Members {
+			if now.Sub(member.LastHeartbeat) > b.SessionTimeout {
+				delete(group.Members, id)
+				removed[id] = member
+				expired++
+			}
+		}
+		if len(removed) == 0 {
+			continue
+		}
+
+		group.Rebalance()
+		for id, member := range removed {
+			if member.Consumer != nil {
+				b.release(group.Topic, id, member.Consumer.Inbox)
+			}
+		}
+	}
+
+	return expired
+}
+
+func (b *Manager) DescribeGroup(groupID string) (GroupState, error) {
+	b.Mu.RLock()
+	defer b.Mu.RUnlock()
+
+	group, ok := b.Groups[groupID]
+	if !ok {
+		return GroupState{}, fmt.Errorf("group %q does not exist", groupID)
+	}
+
+	return groupStateFor(group), nil
+}
+
+func (b *Manager) ListGroups() []GroupState {
+	b.Mu.RLock()
+	defer b.Mu.RUnlock()
+
+	groups := make([]GroupState, 0, len(b.Groups))
+	for _, group := range b.Groups {
+		groups = append(groups, groupStateFor(group))
+	}
+	sort.Slice(groups, func(i, j int) bool {
+		return groups[i].ID < groups[j].ID
+	})
+
+	return groups
+}
+
+// member looks up a group member, the caller must hold b.Mu.
+func (b *Manager) member(groupID, memberID string) (*core.ConsumerGroup, *core.GroupMember, error) {
+	group, ok := b.Groups[groupID]
+	if !ok {
+		return nil, nil, fmt.Errorf("group %q does not exist", groupID)
+	}
+
+	member, ok := group.Members[memberID]
+	if !ok {
+		return nil, nil, fmt.Errorf("member %q does not exist in group %q", memberID, groupID)
+	}
+
+	return group, member, nil
+}
+
+func assignmentFor(group *core.ConsumerGroup, member *core.GroupMember) Assignment {
+	return Assignment{
+		GroupID:    group.ID,
+		Topic:      group.Topic,
+		MemberID:   member.ID,
+		Generation: group.Generation,
+		Partitions: append([]int{}, member.Partitions...),
+	}
+}
+
+func groupStateFor(group *core.ConsumerGroup) GroupState {
+	state := GroupState{
+		ID:         group.ID,
+		Topic:      group.Topic,
+		Generation: group.Generation,
+		Members:    make([]MemberState, 0, len(group.Members)),
+	}
+
+	for _, member := range group.Members {
+		inboxDepth := 0
+		var dropped uint64
+		if member.Consumer != nil {
+			inboxDepth = len(member.Consumer.Inbox)
+			dropped = member.Consumer.Dropped.Load()
+		}
+		state.Members = append(state.Members, MemberState{
+			ID:            member.ID,
+			Partitions:    append([]int{}, member.Partitions...),
+			LastHeartbeat: member.LastHeartbeat,
+			InboxDepth:    inboxDepth,
+			Dropped:       dropped,
+		})
+	}
+	sort.Slice(state.Members, func(i, j int) bool {
+		return state.Members[i].ID < state.Members[j].ID
+	})
+
+	return state
+}
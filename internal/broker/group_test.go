@@ -0,0 +1,224 @@
+package broker
+
+import (
+	"testing"
+	"time"
+
+	"github.com/codytheroux96/go-mq/internal/core"
+	"github.com/codytheroux96/go-mq/internal/repository"
+)
+
+func TestConsumerGroups(t *testing.T) {
+	repo := repository.NewInMemoryRepo()
+	if err := repo.CreateTopic("orders", core.TopicConfig{Partitions: 2}); err != nil {
+		t.Fatalf("failed to create topic: %v", err)
+	}
+	if err := repo.CreateTopic("payments", core.TopicConfig{}); err != nil {
+		t.Fatalf("failed to create topic: %v", err)
+	}
+
+	manager := NewManager(repo)
+	manager.SessionTimeout = time.Minute
+
+	tests := []struct {
+		name      string
+		action    func() (Assignment, error)
+		expectErr bool
+		expect    []int
+	}{
+		{
+			name: "First member owns every partition",
+			action: func() (Assignment, error) {
+				_, assignment, err := manager.JoinGroup("billing", "orders", "m1", core.FlowControl{})
+				return assignment, err
+			},
+			expect: []int{0, 1},
+		},
+		{
+			name: "Second member triggers a rebalance",
+			action: func() (Assignment, error) {
+				_, assignment, err := manager.JoinGroup("billing", "orders", "m2", core.FlowControl{})
+				return assignment, err
+			},
+			expect: []int{1},
+		},
+		{
+			name: "First member learns its new assignment on heartbeat",
+			action: func() (Assignment, error) {
+				return manager.Heartbeat("billing", "m1")
+			},
+			expect: []int{0},
+		},
+		{
+			name: "Joining a group with another topic fails",
+			action: func() (Assignment, error) {
+				_, assignment, err := manager.JoinGroup("billing", "payments", "m3", core.FlowControl{})
+				return assignment, err
+			},
+			expectErr: true,
+		},
+		{
+			name: "Heartbeat from unknown member fails",
+			action: func() (Assignment, error) {
+				return manager.Heartbeat("billing", "ghost")
+			},
+			expectErr: true,
+		},
+		{
+			name: "Member that stops heartbeating is expired",
+			action: func() (Assignment, error) {
+				manager.Mu.Lock()
+				manager.Groups["billing"].Members["m2"].LastHeartbeat = time.Now().Add(-2 * time.Minute)
+				manager.Mu.Unlock()
+
+				if expired := manager.ExpireMembers(time.Now()); expired != 1 {
+					t.Fatalf("expected 1 expired member, got %d", expired)
+				}
+				return manager.Heartbeat("billing", "m1")
+			},
+			expect: []int{0, 1},
+		},
+		{
+			name: "Leaving empties the group",
+			action: func() (Assignment, error) {
+				if err := manager.LeaveGroup("billing", "m1"); err != nil {
+					return Assignment{}, err
+				}
+				state, err := manager.DescribeGroup("billing")
+				if len(state.Members) != 0 {
+					t.Fatalf("expected no members, got %d", len(state.Members))
+				}
+				return Assignment{Partitions: []int{}}, err
+			},
+			expect: []int{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assignment, err := tt.action()
+			if tt.expectErr && err == nil {
+				t.Fatalf("expected error, got none")
+			}
+			if !tt.expectErr && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if tt.expectErr {
+				return
+			}
+
+			if len(assignment.Partitions) != len(tt.expect) {
+				t.Fatalf("expected partitions %v, got %v", tt.expect, assignment.Partitions)
+			}
+			for i := range tt.expect {
+				if assignment.Partitions[i] != tt.expect[i] {
+					t.Fatalf("expected partitions %v, got %v", tt.expect, assignment.Partitions)
+				}
+			}
+		})
+	}
+}
+
+func TestGroupPublishDeliversToPartitionOwner(t *testing.T) {
+	repo := repository.NewInMemoryRepo()
+	if err := repo.CreateTopic("orders", core.TopicConfig{Partitions: 2}); err != nil {
+		t.Fatalf("failed to create topic: %v", err)
+	}
+
+	manager := NewManager(repo)
+	inbox1, _, _ := manager.JoinGroup("billing", "orders", "m1", core.FlowControl{})
+	inbox2, _, _ := manager.JoinGroup("billing", "orders", "m2", core.FlowControl{})
+
+	for i := 0; i < 4; i++ {
+		if err := manager.Publish("orders", core.NewMessage([]byte("order"), "p1")); err != nil {
+			t.Fatalf("failed to publish: %v", err)
+		}
+	}
+
+	for name, inbox := range map[string]<-chan *core.Message{"m1": inbox1, "m2": inbox2} {
+		if len(inbox) != 2 {
+			t.Fatalf("expected %s to receive 2 messages, got %d", name, len(inbox))
+		}
+		expected := 0
+		if name == "m2" {
+			expected = 1
+		}
+		for len(inbox) > 0 {
+			if msg := <-inbox; msg.Partition != expected {
+				t.Fatalf("expected %s to only receive partition %d, got %d", name, expected, msg.Partition)
+			}
+		}
+	}
+}
+
+func TestGroupFetchOnlyMember(t *testing.T) {
+	repo := repository.NewInMemoryRepo()
+	if err := repo.CreateTopic("orders", core.TopicConfig{}); err != nil {
+		t.Fatalf("failed to create topic: %v", err)
+	}
+
+	manager := NewManager(repo)
+	assignment, err := manager.JoinGroupForFetch("billing", "orders", "m1")
+	if err != nil || len(assignment.Partitions) != 1 {
+		t.Fatalf("expected the member to own the partition, got %+v %v", assignment, err)
+	}
+	if err := manager.Publish("orders", core.NewMessage([]byte("order"), "p1")); err != nil {
+		t.Fatalf("failed to publish: %v", err)
+	}
+
+	messages, _, err := manager.Fetch("orders", 0, "billing", "m1", 10, ReadUncommitted)
+	if err != nil || len(messages) != 1 {
+		t.Fatalf("expected the message to wait for the fetch, got %d %v", len(messages), err)
+	}
+	if err := repo.CommitOffset("orders", 0, "billing", 1); err != nil {
+		t.Fatalf("failed to commit: %v", err)
+	}
+
+	// a requeued message is kept for the member's next fetch rather than pushed
+	if _, err := manager.Nack("orders", "m1", messages[0].ID, true, 0, ""); err != nil {
+		t.Fatalf("failed to nack: %v", err)
+	}
+	redelivered, _, err := manager.Fetch("orders", 0, "billing", "m1", 10, ReadUncommitted)
+	if err != nil || len(redelivered) != 1 || redelivered[0].DeliveryAttempts != 2 {
+		t.Fatalf("expected the nacked message on the next fetch, got %d %v", len(redelivered), err)
+	}
+
+	groups := manager.ListGroups()
+	if len(groups) != 1 || groups[0].Members[0].InboxDepth != 0 {
+		t.Fatalf("expected a member without an inbox, got %+v", groups)
+	}
+}
+
+func TestExpireMembersReleasesInbox(t *testing.T) {
+	repo := repository.NewInMemoryRepo()
+	if err := repo.CreateTopic("orders", core.TopicConfig{}); err != nil {
+		t.Fatalf("failed to create topic: %v", err)
+	}
+
+	manager := NewManager(repo)
+	if _, _, err := manager.JoinGroup("billing", "orders", "m1", core.FlowControl{}); err != nil {
+		t.Fatalf("failed to join: %v", err)
+	}
+	if err := manager.Publish("orders", core.NewMessage([]byte("order"), "p1")); err != nil {
+		t.Fatalf("failed to publish: %v", err)
+	}
+	inbox2, _, err := manager.JoinGroup("billing", "orders", "m2", core.FlowControl{})
+	if err != nil {
+		t.Fatalf("failed to join: %v", err)
+	}
+
+	// m1 stops sending heartbeats with the message unread in its inbox
+	manager.Groups["billing"].Members["m1"].LastHeartbeat = time.Now().Add(-2 * manager.SessionTimeout)
+	if expired := manager.ExpireMembers(time.Now()); expired != 1 {
+		t.Fatalf("expected 1 member to expire, got %d", expired)
+	}
+
+	select {
+	case msg := <-inbox2:
+		if msg.DeliveryAttempts != 1 {
+			t.Fatalf("expected the message to keep its attempt count, got %d", msg.DeliveryAttempts)
+		}
+	default:
+		t.Fatalf("expected the expired member's message to be redelivered to the group")
+	}
+}
@@ -1,106 +1,422 @@
 package broker
 
 import (
+	"context"
 	"fmt"
 	"sync"
 	"time"
 
 	"github.com/codytheroux96/go-mq/internal/core"
+	"github.com/codytheroux96/go-mq/internal/filter"
 	"github.com/codytheroux96/go-mq/internal/repository"
 	"github.com/google/uuid"
 )
 
+const housekeepingInterval = time.Second
+
 type Manager struct {
-	Repo   repository.Repository
-	Topics map[string]*core.Topic
-	Mu     sync.RWMutex
+	Repo            repository.Repository
+	Topics          map[string]*core.Topic
+	Groups          map[string]*core.ConsumerGroup
+	Producers       map[string]*core.Producer
+	SessionTimeout  time.Duration
+	ProducerTimeout time.Duration
+	// TransactionTimeout is how long a transaction may stay open before it is aborted.
+	TransactionTimeout time.Duration
+	VisibilityTimeout  time.Duration
+	Mu                 sync.RWMutex
+
+	inFlight map[deliveryKey]*delivery
+	settled  map[deliveryKey]time.Time
+	pending  map[pendingKey][]*delivery
+	// lastFetch is when each consumer, or group, last fetched from a topic
+	lastFetch map[pendingKey]time.Time
+	// polling counts the long polls each consumer, or group, has waiting on a topic
+	polling map[pendingKey]int
+
+	dedup   map[string]*dedupWindow
+	filters map[pendingKey]*filter.Filter
+	// registered is the flow control consumers registered for their push sessions
+	registered map[pendingKey]core.FlowControl
+
+	appended map[string]chan struct{} // closed on the next append to the topic, see WaitForMessages
+	drops    map[DropKey]uint64
+	blocked  map[*core.Consumer][]blockedOffer // pushes to full block policy inboxes, see unlockAndSendBlocked
+	claimed  []*core.Consumer                  // consumers whose queue the current lock holder started
+	metrics  brokerMetrics
+
+	// expiredEpochs is the last epoch of each recently expired producer session, so
+	// the next session of the producer still fences off the ones before it
+	expiredEpochs map[string]expiredEpoch
+
+	transactions map[string]*transaction     // open transactions
+	aborted      map[string]core.Transaction // aborted transactions whose messages are still retained
+
+	scheduled    scheduleQueue
+	unforgotten  []string // IDs of scheduled messages delivered but still saved in the repository
+	scheduleWake chan struct{}
 }
 
 func NewManager(repo repository.Repository) *Manager {
 	return &Manager{
-		Repo:   repo,
-		Topics: make(map[string]*core.Topic),
+		Repo:               repo,
+		Topics:             make(map[string]*core.Topic),
+		Groups:             make(map[string]*core.ConsumerGroup),
+		Producers:          make(map[string]*core.Producer),
+		expiredEpochs:      make(map[string]expiredEpoch),
+		dedup:              make(map[string]*dedupWindow),
+		filters:            make(map[pendingKey]*filter.Filter),
+		registered:         make(map[pendingKey]core.FlowControl),
+		appended:           make(map[string]chan struct{}),
+		drops:              make(map[DropKey]uint64),
+		blocked:            make(map[*core.Consumer][]blockedOffer),
+		metrics:            newBrokerMetrics(),
+		SessionTimeout:     DefaultSessionTimeout,
+		ProducerTimeout:    DefaultProducerTimeout,
+		TransactionTimeout: DefaultTransactionTimeout,
+		transactions:       make(map[string]*transaction),
+		aborted:            make(map[string]core.Transaction),
+		VisibilityTimeout:  DefaultVisibilityTimeout,
+		inFlight:           make(map[deliveryKey]*delivery),
+		settled:            make(map[deliveryKey]time.Time),
+		pending:            make(map[pendingKey][]*delivery),
+		lastFetch:          make(map[pendingKey]time.Time),
+		polling:            make(map[pendingKey]int),
+		scheduleWake:       make(chan struct{}, 1),
 	}
 }
 
-func (b *Manager) Subscribe(topicName, consumerID string) (<-chan *core.Message, error) {
+// Register records a consumer of the topic without opening a session. Its push
+// sessions use flow unless they ask for flow control of their own.
+func (b *Manager) Register(topicName, consumerID string, flow core.FlowControl) error {
+	if err := flow.Validate(); err != nil {
+		return err
+	}
+
 	b.Mu.Lock()
 	defer b.Mu.Unlock()
 
-	if _, ok := b.Topics[topicName]; !ok {
-		topics, err := b.Repo.ListTopics()
-		if err != nil {
-			return nil, fmt.Errorf("failed to verify topic existence: %w", err)
-		}
-		found := false
-		for _, t := range topics {
-			if t == topicName {
-				found = true
-				break
-			}
-		}
-		if !found {
-			return nil, fmt.Errorf("topic %q does not exist", topicName)
-		}
-		b.Topics[topicName] = core.NewTopic(topicName)
+	if _, _, err := b.topic(topicName); err != nil {
+		return err
 	}
 
-	topic := b.Topics[topicName]
+	b.registered[pendingKey{Topic: topicName, Target: consumerID}] = flow
+	return nil
+}
 
-	consumer := core.NewConsumer(consumerID)
-	topic.Consumers[consumerID] = consumer
+// Subscribe registers a live consumer and returns its inbox, sized and drained as
+// flow says, or as the consumer registered when flow is the zero value. Waiting
+// redeliveries are pushed to it right away. The inbox is closed if the consumer is
+// disconnected for falling behind. The session lasts until Unsubscribe, so callers
+// must unsubscribe once they stop reading the inbox.
+func (b *Manager) Subscribe(topicName, consumerID string, flow core.FlowControl) (<-chan *core.Message, error) {
+	if err := flow.Validate(); err != nil {
+		return nil, err
+	}
 
-	offset, err := b.Repo.GetOffset(topicName, consumerID)
+	b.Mu.Lock()
+	defer b.Mu.Unlock()
+
+	topic, _, err := b.topic(topicName)
 	if err != nil {
-		_ = b.Repo.CommitOffset(topicName, consumerID, 0)
-	} else {
-		_ = offset
+		return nil, err
 	}
 
+	if flow == (core.FlowControl{}) {
+		flow = b.registered[pendingKey{Topic: topicName, Target: consumerID}]
+	}
+	consumer := core.NewConsumer(consumerID, flow)
+	topic.Consumers[consumerID] = consumer
+	b.flushPending(pendingKey{Topic: topicName, Target: consumerID}, time.Now())
+
 	return consumer.Inbox, nil
 }
 
-func (b *Manager) Publish(topic string, msg *core.Message) error {
+// Unsubscribe stops pushing messages to a consumer. The inbox returned by Subscribe
+// is passed back so a consumer that has subscribed again since is left alone.
+// Messages still in the inbox are put back up for redelivery.
+func (b *Manager) Unsubscribe(topicName, consumerID string, inbox <-chan *core.Message) {
 	b.Mu.Lock()
 	defer b.Mu.Unlock()
 
-	if _, ok := b.Topics[topic]; !ok {
-		topics, err := b.Repo.ListTopics()
-		if err != nil {
-			return fmt.Errorf("failed to verify topic existence: %w", err)
+	topic, ok := b.Topics[topicName]
+	if !ok {
+		return
+	}
+
+	if consumer, ok := topic.Consumers[consumerID]; ok && (<-chan *core.Message)(consumer.Inbox) == inbox {
+		delete(topic.Consumers, consumerID)
+	}
+	b.release(topicName, consumerID, inbox)
+}
+
+func (b *Manager) Publish(topic string, msg *core.Message) error {
+	b.Mu.Lock()
+	defer b.unlockAndSendBlocked()
+
+	return b.publish(topic, msg)
+}
+
+// publish appends msg to the topic and pushes it to live consumers, the caller must hold b.Mu.
+func (b *Manager) publish(topic string, msg *core.Message) error {
+	topicEntry, err := b.appendMessage(topic, msg, nil)
+	if err != nil {
+		return err
+	}
+
+	b.fanOut(topicEntry, msg)
+	return nil
+}
+
+// appendMessage appends msg to the topic unless the topic's dedup window knows it.
+// A message of txn has its partition recorded on txn before it is appended. The
+// caller must hold b.Mu.
+func (b *Manager) appendMessage(topic string, msg *core.Message, txn *transaction) (*core.Topic, error) {
+	now := time.Now()
+	topicEntry, cfg, err := b.topic(topic)
+	if err != nil {
+		return nil, err
+	}
+
+	window := b.dedupWindowFor(topic, cfg.Dedup)
+	id := dedupID(msg)
+	if window != nil && id != "" && window.duplicate(id, msg, cfg.Dedup, now) {
+		return nil, fmt.Errorf("%w: %q", ErrDuplicateMessage, id)
+	}
+
+	prepare(topicEntry, cfg, msg)
+	if txn != nil {
+		if err := b.addTransactionPartition(txn, topic, msg.Partition); err != nil {
+			return nil, err
 		}
-		found := false
-		for _, t := range topics {
-			if t == topic {
-				found = true
-				break
-			}
+	}
+	if err := b.Repo.Publish(topic, msg.Partition, msg); err != nil {
+		return nil, err
+	}
+	if window != nil && id != "" {
+		window.record(id, msg, cfg.Dedup, now)
+	}
+	b.signalAppend(topic)
+	b.metrics.observePublish(topic, 1, now)
+
+	return topicEntry, nil
+}
+
+// PublishBatch publishes msgs to one topic under a single acquisition of the broker
+// lock. An atomic batch is appended as a whole or not at all, and its error is the
+// second return value. Otherwise every message is appended on its own. Either way
+// the first return value holds each message's error, nil for those that were
+// appended and ErrDuplicateMessage for duplicates.
+func (b *Manager) PublishBatch(topic string, msgs []*core.Message, atomic bool) ([]error, error) {
+	b.Mu.Lock()
+	defer b.unlockAndSendBlocked()
+
+	start := time.Now()
+	topicEntry, cfg, err := b.topic(topic)
+	if err != nil {
+		return nil, err
+	}
+
+	errs := make([]error, len(msgs))
+	if !atomic {
+		for i, msg := range msgs {
+			errs[i] = b.publish(topic, msg)
 		}
-		if !found {
-			return fmt.Errorf("topic %q does not exist", topic)
+		return errs, nil
+	}
+
+	// duplicates, of earlier publishes or of a message earlier in the batch, are
+	// left out of the append
+	now := time.Now()
+	window := b.dedupWindowFor(topic, cfg.Dedup)
+	ids := make([]string, len(msgs))
+	firstInBatch := make(map[string]int)
+	appended := make([]*core.Message, 0, len(msgs))
+	for i, msg := range msgs {
+		if window != nil {
+			ids[i] = dedupID(msg)
+		}
+		if ids[i] != "" {
+			if window.duplicate(ids[i], msg, cfg.Dedup, now) {
+				errs[i] = fmt.Errorf("%w: %q", ErrDuplicateMessage, ids[i])
+				continue
+			}
+			if _, ok := firstInBatch[ids[i]]; ok {
+				continue
+			}
+			firstInBatch[ids[i]] = i
 		}
-		b.Topics[topic] = core.NewTopic(topic)
+		prepare(topicEntry, cfg, msg)
+		appended = append(appended, msg)
 	}
 
-	topicEntry := b.Topics[topic]
+	if err := b.Repo.PublishBatch(topic, appended); err != nil {
+		return nil, err
+	}
+	b.metrics.observePublish(topic, len(appended), start)
 
+	for i, msg := range msgs {
+		if ids[i] == "" || errs[i] != nil {
+			continue
+		}
+		if first := msgs[firstInBatch[ids[i]]]; first != msg {
+			msg.ID, msg.Partition, msg.Offset, msg.Timestamp = first.ID, first.Partition, first.Offset, first.Timestamp
+			errs[i] = fmt.Errorf("%w: %q", ErrDuplicateMessage, ids[i])
+			continue
+		}
+		window.record(ids[i], msg, cfg.Dedup, now)
+	}
+	for _, msg := range appended {
+		b.fanOut(topicEntry, msg)
+	}
+	b.signalAppend(topic)
+	return errs, nil
+}
+
+// prepare gives msg its ID, timestamp and partition before it is appended.
+func prepare(topicEntry *core.Topic, cfg core.TopicConfig, msg *core.Message) {
 	if msg.ID == "" {
 		msg.ID = uuid.NewString()
 	}
 	msg.Timestamp = time.Now()
+	msg.Partition = topicEntry.PartitionFor(msg.Key, cfg.PartitionCount())
+}
 
-	if err := b.Repo.Publish(topic, msg); err != nil {
-		return err
+// fanOut pushes an appended message to the topic's live consumers, the caller must hold b.Mu.
+func (b *Manager) fanOut(topicEntry *core.Topic, msg *core.Message) {
+	// a full inbox gets the consumer's slow consumer policy
+	for _, consumer := range topicEntry.Consumers {
+		if !b.passes(topicEntry.Name, consumer.ID, msg) {
+			continue
+		}
+		b.offer(topicEntry.Name, "", consumer, msg)
 	}
 
-	for consumerID, consumer := range topicEntry.Consumers {
+	// a group gets one copy of the message, delivered to whichever member owns the partition
+	for _, group := range b.Groups {
+		if group.Topic != topicEntry.Name {
+			continue
+		}
+		member := group.Owner(msg.Partition)
+		if member == nil || member.Consumer == nil || !b.passes(topicEntry.Name, group.ID, msg) {
+			continue
+		}
+		b.offer(topicEntry.Name, group.ID, member.Consumer, msg)
+	}
+}
+
+// Run performs the broker's periodic housekeeping until ctx is cancelled.
+func (b *Manager) Run(ctx context.Context) {
+	ticker := time.NewTicker(housekeepingInterval)
+	defer ticker.Stop()
+
+	schedule := time.NewTimer(b.untilNextDue())
+	defer schedule.Stop()
+
+	for {
 		select {
-		case consumer.Inbox <- msg:
-			msg.DeliveredTo[consumerID] = true
-		default:
-			// inbox is full — skip delivery
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			b.ExpireMembers(now)
+			b.ExpireProducers(now)
+			b.ExpireTransactions(now)
+			b.RedeliverExpired(now)
+		case now := <-schedule.C:
+			b.DeliverDue(now)
+			schedule.Reset(b.untilNextDue())
+		case <-b.scheduleWake:
+			schedule.Reset(b.untilNextDue())
+		}
+	}
+}
+
+// UpdateTopicConfig applies update to the saved config of a topic and saves it
+// again, holding b.Mu throughout so concurrent updates of different settings do
+// not undo each other. It returns the config as saved.
+func (b *Manager) UpdateTopicConfig(name string, update func(*core.TopicConfig)) (core.TopicConfig, error) {
+	b.Mu.Lock()
+	defer b.Mu.Unlock()
+
+	cfg, err := b.Repo.GetTopicConfig(name)
+	if err != nil {
+		return core.TopicConfig{}, err
+	}
+	update(&cfg)
+	if err := b.Repo.UpdateTopicConfig(name, cfg); err != nil {
+		return core.TopicConfig{}, err
+	}
+
+	return cfg, nil
+}
+
+// DeleteTopic deletes the topic and forgets what the broker kept for it: its dedup
+// window, consumers, groups, filters and unsettled deliveries, so a topic created
+// later under the same name starts afresh. Live sessions on the topic stop
+// receiving messages and long polls on it return.
+func (b *Manager) DeleteTopic(name string) error {
+	b.Mu.Lock()
+	defer b.Mu.Unlock()
+
+	if err := b.Repo.DeleteTopic(name); err != nil {
+		return err
+	}
+
+	delete(b.Topics, name)
+	delete(b.dedup, name)
+	for id, group := range b.Groups {
+		if group.Topic == name {
+			delete(b.Groups, id)
+		}
+	}
+	for key := range b.pending {
+		if key.Topic == name {
+			delete(b.pending, key)
+		}
+	}
+	for key := range b.lastFetch {
+		if key.Topic == name {
+			delete(b.lastFetch, key)
 		}
 	}
+	for key := range b.filters {
+		if key.Topic == name {
+			delete(b.filters, key)
+		}
+	}
+	for key := range b.registered {
+		if key.Topic == name {
+			delete(b.registered, key)
+		}
+	}
+	for key := range b.inFlight {
+		if key.Topic == name {
+			delete(b.inFlight, key)
+		}
+	}
+	for key := range b.settled {
+		if key.Topic == name {
+			delete(b.settled, key)
+		}
+	}
+	b.signalAppend(name)
 
 	return nil
 }
+
+// topic returns the live topic for name, creating it the first time a topic that
+// exists in the repository is used. The caller must hold b.Mu.
+func (b *Manager) topic(name string) (*core.Topic, core.TopicConfig, error) {
+	cfg, err := b.Repo.GetTopicConfig(name)
+	if err != nil {
+		return nil, core.TopicConfig{}, err
+	}
+
+	topic, ok := b.Topics[name]
+	if !ok {
+		topic = core.NewTopic(name)
+		b.Topics[name] = topic
+	}
+
+	return topic, cfg, nil
+}
@@ -1,6 +1,7 @@
 package broker
 
 import (
+	"sync"
 	"testing"
 	"time"
 
@@ -60,7 +61,7 @@ func TestManager(t *testing.T) {
 			manager := NewManager(repo)
 
 			if tt.preCreateTopic {
-				if err := repo.CreateTopic(tt.topic); err != nil {
+				if err := repo.CreateTopic(tt.topic, core.TopicConfig{}); err != nil {
 					t.Fatalf("failed to pre-create topic: %v", err)
 				}
 			}
@@ -69,7 +70,7 @@ func TestManager(t *testing.T) {
 
 			if tt.preSubscribe {
 				var err error
-				inbox, err = manager.Subscribe(tt.topic, tt.consumer)
+				inbox, err = manager.Subscribe(tt.topic, tt.consumer, core.FlowControl{})
 				if err != nil {
 					t.Fatalf("failed to pre-subscribe: %v", err)
 				}
@@ -77,7 +78,7 @@ func TestManager(t *testing.T) {
 
 			switch tt.action {
 			case "Subscribe":
-				ch, err := manager.Subscribe(tt.topic, tt.consumer)
+				ch, err := manager.Subscribe(tt.topic, tt.consumer, core.FlowControl{})
 				if tt.expectErr && err == nil {
 					t.Errorf("expected error, got none")
 				}
@@ -114,4 +115,48 @@ func TestManager(t *testing.T) {
 			}
 		})
 	}
-}
\ No newline at end of file
+}
+
+// slowConfigRepo widens the window between reading and saving a topic config.
+type slowConfigRepo struct {
+	repository.Repository
+}
+
+func (r slowConfigRepo) UpdateTopicConfig(name string, cfg core.TopicConfig) error {
+	time.Sleep(time.Millisecond)
+	return r.Repository.UpdateTopicConfig(name, cfg)
+}
+
+func TestUpdateTopicConfig(t *testing.T) {
+	repo := slowConfigRepo{repository.NewInMemoryRepo()}
+	if err := repo.CreateTopic("orders", core.TopicConfig{}); err != nil {
+		t.Fatalf("failed to create topic: %v", err)
+	}
+	manager := NewManager(repo)
+
+	// each update changes one setting and must leave the others as they are
+	var wg sync.WaitGroup
+	for _, update := range []func(*core.TopicConfig){
+		func(cfg *core.TopicConfig) { cfg.Retention.MaxMessages = 7 },
+		func(cfg *core.TopicConfig) { cfg.Dedup.Window = time.Minute },
+		func(cfg *core.TopicConfig) { cfg.DeadLetter.Topic = "orders-dlq" },
+	} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := manager.UpdateTopicConfig("orders", update); err != nil {
+				t.Errorf("failed to update config: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	cfg, _ := repo.GetTopicConfig("orders")
+	if cfg.Retention.MaxMessages != 7 || cfg.Dedup.Window != time.Minute || cfg.DeadLetter.Topic != "orders-dlq" {
+		t.Fatalf("expected every update to be kept, got %+v", cfg)
+	}
+
+	if _, err := manager.UpdateTopicConfig("missing", func(*core.TopicConfig) {}); err == nil {
+		t.Fatalf("expected an error for a missing topic")
+	}
+}
@@ -0,0 +1,153 @@
+package broker
+
+import (
+	"cmp"
+	"maps"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/codytheroux96/go-mq/internal/metrics"
+)
+
+// brokerMetrics counts what the broker appends and serves. Latencies cover the
+// work done holding the broker lock, not the wait for it.
+type brokerMetrics struct {
+	published      *metrics.CounterVec
+	publishSeconds *metrics.HistogramVec
+	fetched        *metrics.CounterVec
+	fetchSeconds   *metrics.HistogramVec
+}
+
+func newBrokerMetrics() brokerMetrics {
+	return brokerMetrics{
+		published:      metrics.NewCounterVec("gomq_messages_published_total", "Messages appended to a topic.", "topic"),
+		publishSeconds: metrics.NewHistogramVec("gomq_publish_duration_seconds", "Time taken to append a message or an atomic batch to a topic.", metrics.DefaultBuckets, "topic"),
+		fetched:        metrics.NewCounterVec("gomq_messages_fetched_total", "Messages returned by fetches, redeliveries included.", "topic"),
+		fetchSeconds:   metrics.NewHistogramVec("gomq_fetch_duration_seconds", "Time taken to serve a fetch from one partition.", metrics.DefaultBuckets, "topic"),
+	}
+}
+
+func (m brokerMetrics) observePublish(topic string, count int, start time.Time) {
+	m.published.Add(float64(count), topic)
+	m.publishSeconds.Observe(time.Since(start).Seconds(), topic)
+}
+
+func (m brokerMetrics) observeFetch(topic string, count int, start time.Time) {
+	m.fetched.Add(float64(count), topic)
+	m.fetchSeconds.Observe(time.Since(start).Seconds(), topic)
+}
+
+// Collect returns the broker's counters along with what is stored per topic, the
+// depth of every live consumer's inbox, the messages dropped for slow consumers
+// and how far each consumer and group's committed offset is behind the log end.
+func (b *Manager) Collect() []metrics.Family {
+	families := slices.Concat(
+		b.metrics.published.Collect(),
+		b.metrics.publishSeconds.Collect(),
+		b.metrics.fetched.Collect(),
+		b.metrics.fetchSeconds.Collect(),
+	)
+
+	b.Mu.RLock()
+	defer b.Mu.RUnlock()
+
+	return append(families, b.storageFamilies()...)
+}
+
+// storageFamilies reads the stored bytes and consumer lag of every topic from the
+// repository, skipping a topic deleted halfway through. The caller must hold b.Mu.
+func (b *Manager) storageFamilies() []metrics.Family {
+	stored := metrics.Family{Name: "gomq_topic_stored_bytes", Help: "Bytes of message data retained by a topic.", Type: metrics.Gauge}
+	lag := metrics.Family{Name: "gomq_consumer_lag_messages", Help: "Retained messages past the committed offset of a consumer or group.", Type: metrics.Gauge}
+
+	topics, _ := b.Repo.ListTopics()
+	slices.Sort(topics)
+	for _, topic := range topics {
+		cfg, err := b.Repo.GetTopicConfig(topic)
+		if err != nil {
+			continue
+		}
+
+		var bytes int64
+		for partition := range cfg.PartitionCount() {
+			size, err := b.Repo.GetPartitionBytes(topic, partition)
+			if err != nil {
+				continue
+			}
+			bytes += size
+
+			low, err := b.Repo.GetEarliestOffset(topic, partition)
+			if err != nil {
+				continue
+			}
+			end, err := b.Repo.GetLatestOffset(topic, partition)
+			if err != nil {
+				continue
+			}
+			offsets, err := b.Repo.ListOffsets(topic, partition)
+			if err != nil {
+				continue
+			}
+			for _, consumer := range slices.Sorted(maps.Keys(offsets)) {
+				if consumer == redriveConsumerID {
+					continue
+				}
+				lag.Samples = append(lag.Samples, metrics.Sample{
+					Labels: []metrics.Label{{Name: "topic", Value: topic}, {Name: "partition", Value: strconv.Itoa(partition)}, {Name: "consumer", Value: consumer}},
+					// an offset retention has passed has nothing left to consume before the low watermark
+					Value: float64(end - max(offsets[consumer], low)),
+				})
+			}
+		}
+		stored.Samples = append(stored.Samples, metrics.Sample{Labels: []metrics.Label{{Name: "topic", Value: topic}}, Value: float64(bytes)})
+	}
+
+	return append([]metrics.Family{stored, lag}, b.consumerFamilies()...)
+}
+
+// consumerFamilies reports the inbox of every live consumer, a group's members
+// under the group, and the drops counted so far. The caller must hold b.Mu.
+func (b *Manager) consumerFamilies() []metrics.Family {
+	depth := metrics.Family{Name: "gomq_consumer_inbox_messages", Help: "Messages waiting in a live consumer's inbox.", Type: metrics.Gauge}
+	capacity := metrics.Family{Name: "gomq_consumer_inbox_capacity", Help: "Size of a live consumer's inbox.", Type: metrics.Gauge}
+	dropped := metrics.Family{Name: "gomq_consumer_dropped_messages_total", Help: "Messages a slow consumer policy kept from a consumer because its inbox was full.", Type: metrics.Counter}
+
+	inbox := func(topic, groupID, consumerID string, length, size int) {
+		labels := []metrics.Label{{Name: "topic", Value: topic}, {Name: "group", Value: groupID}, {Name: "consumer", Value: consumerID}}
+		depth.Samples = append(depth.Samples, metrics.Sample{Labels: labels, Value: float64(length)})
+		capacity.Samples = append(capacity.Samples, metrics.Sample{Labels: labels, Value: float64(size)})
+	}
+	for _, name := range slices.Sorted(maps.Keys(b.Topics)) {
+		topic := b.Topics[name]
+		for _, id := range slices.Sorted(maps.Keys(topic.Consumers)) {
+			consumer := topic.Consumers[id]
+			inbox(name, "", id, len(consumer.Inbox), cap(consumer.Inbox))
+		}
+	}
+	for _, groupID := range slices.Sorted(maps.Keys(b.Groups)) {
+		group := b.Groups[groupID]
+		for _, id := range slices.Sorted(maps.Keys(group.Members)) {
+			if consumer := group.Members[id].Consumer; consumer != nil {
+				inbox(group.Topic, groupID, id, len(consumer.Inbox), cap(consumer.Inbox))
+			}
+		}
+	}
+
+	keys := slices.SortedFunc(maps.Keys(b.drops), func(x, y DropKey) int {
+		return cmp.Or(
+			cmp.Compare(x.Topic, y.Topic),
+			cmp.Compare(x.GroupID, y.GroupID),
+			cmp.Compare(x.ConsumerID, y.ConsumerID),
+			cmp.Compare(x.Policy, y.Policy),
+		)
+	})
+	for _, key := range keys {
+		dropped.Samples = append(dropped.Samples, metrics.Sample{
+			Labels: []metrics.Label{{Name: "topic", Value: key.Topic}, {Name: "group", Value: key.GroupID}, {Name: "consumer", Value: key.ConsumerID}, {Name: "policy", Value: string(key.Policy)}},
+			Value:  float64(b.drops[key]),
+		})
+	}
+
+	return []metrics.Family{depth, capacity, dropped}
+}
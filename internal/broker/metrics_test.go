@@ -0,0 +1,95 @@
+package broker
+
+import (
+	"testing"
+	"time"
+
+	"github.com/codytheroux96/go-mq/internal/core"
+	"github.com/codytheroux96/go-mq/internal/metrics"
+	"github.com/codytheroux96/go-mq/internal/repository"
+)
+
+func TestCollect(t *testing.T) {
+	repo := repository.NewInMemoryRepo()
+	if err := repo.CreateTopic("orders", core.TopicConfig{Partitions: 2}); err != nil {
+		t.Fatalf("failed to create topic: %v", err)
+	}
+	manager := NewManager(repo)
+
+	if _, err := manager.Subscribe("orders", "c1", core.FlowControl{InboxSize: 2}); err != nil {
+		t.Fatalf("failed to subscribe: %v", err)
+	}
+	if _, _, err := manager.JoinGroup("billing", "orders", "m1", core.FlowControl{}); err != nil {
+		t.Fatalf("failed to join group: %v", err)
+	}
+	for range 3 {
+		if err := manager.Publish("orders", core.NewMessage([]byte("order"), "p1")); err != nil {
+			t.Fatalf("failed to publish: %v", err)
+		}
+	}
+	if err := repo.CommitOffset("orders", 0, "billing", 1); err != nil {
+		t.Fatalf("failed to commit: %v", err)
+	}
+
+	// retention drops two messages past the committed offset of c1
+	if err := repo.CreateTopic("retained", core.TopicConfig{Retention: core.RetentionPolicy{MaxMessages: 1}}); err != nil {
+		t.Fatalf("failed to create topic: %v", err)
+	}
+	if err := repo.CommitOffset("retained", 0, "c1", 0); err != nil {
+		t.Fatalf("failed to commit: %v", err)
+	}
+	for range 3 {
+		if err := manager.Publish("retained", core.NewMessage([]byte("order"), "p1")); err != nil {
+			t.Fatalf("failed to publish: %v", err)
+		}
+	}
+	if _, err := repo.ApplyRetention("retained", time.Now()); err != nil {
+		t.Fatalf("failed to apply retention: %v", err)
+	}
+
+	values := make(map[string]float64)
+	for _, family := range manager.Collect() {
+		for _, sample := range family.Samples {
+			values[family.Name+sample.Suffix+labelString(sample.Labels)] = sample.Value
+		}
+	}
+
+	tests := []struct {
+		name   string
+		series string
+		expect float64
+	}{
+		{"Published", `gomq_messages_published_total{topic=orders}`, 3},
+		{"Stored bytes", `gomq_topic_stored_bytes{topic=orders}`, 15},
+		{"Consumer inbox is full", `gomq_consumer_inbox_messages{topic=orders,group=,consumer=c1}`, 2},
+		{"Group member inbox", `gomq_consumer_inbox_messages{topic=orders,group=billing,consumer=m1}`, 3},
+		{"Lag counts only retained messages", `gomq_consumer_lag_messages{topic=retained,partition=0,consumer=c1}`, 1},
+		{"Drops", `gomq_consumer_dropped_messages_total{topic=orders,group=,consumer=c1,policy=drop_newest}`, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got, ok := values[tt.series]; !ok || got != tt.expect {
+				t.Fatalf("expected %s to be %v, got %v (present %v)", tt.series, tt.expect, got, ok)
+			}
+		})
+	}
+
+	// round robin put two of the three messages on partition 0
+	if got := values[`gomq_consumer_lag_messages{topic=orders,partition=0,consumer=billing}`]; got != 1 {
+		t.Fatalf("expected lag 1 on partition 0, got %v", got)
+	}
+	if _, ok := values[`gomq_consumer_lag_messages{topic=orders,partition=1,consumer=billing}`]; ok {
+		t.Fatalf("expected no lag for a partition the group never committed on")
+	}
+}
+
+func labelString(labels []metrics.Label) string {
+	s := "{"
+	for i, label := range labels {
+		if i > 0 {
+			s += ","
+		}
+		s += label.Name + "=" + label.Value
+	}
+	return s + "}"
+}
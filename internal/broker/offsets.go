@@ -0,0 +1,230 @@
+package broker
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ResetTo is where ResetOffsets moves a consumer's, or group's, committed offsets.
+type ResetTo string
+
+const (
+	// ResetEarliest moves to the earliest offset still retained.
+	ResetEarliest ResetTo = "earliest"
+	// ResetLatest moves to the log end offset, skipping everything already appended.
+	ResetLatest ResetTo = "latest"
+	// ResetOffset moves to OffsetReset.Offset.
+	ResetOffset ResetTo = "offset"
+	// ResetShift moves the committed offset by OffsetReset.Shift messages.
+	ResetShift ResetTo = "shift"
+	// ResetTimestamp moves to the first message appended at or after
+	// OffsetReset.Timestamp.
+	ResetTimestamp ResetTo = "timestamp"
+)
+
+// ErrConsumerActive is returned when resetting the offsets of a consumer or group
+// that is still consuming the topic, unless the reset is forced.
+var ErrConsumerActive = errors.New("consumer is active on the topic, stop it or force the reset")
+
+// OffsetReset describes an offset reset. Partition limits it to one partition, all
+// of the topic's partitions are reset when it is nil.
+type OffsetReset struct {
+	To        ResetTo
+	Offset    int
+	Shift     int
+	Timestamp time.Time
+	Partition *int
+}
+
+// PartitionOffset is the offset committed on a partition by a reset.
+type PartitionOffset struct {
+	Partition int `json:"partition"`
+	Offset    int `json:"offset"`
+}
+
+// ValidAutoOffsetReset reports whether to can be where a consumer without a
+// committed offset starts, which is earliest or latest.
+func ValidAutoOffsetReset(to ResetTo) bool {
+	return to == ResetEarliest || to == ResetLatest
+}
+
+// ResetOffsets commits new offsets for target, a consumer ID or a group ID, on the
+// topic. Offsets are kept between the earliest retained offset and the log end.
+// A target that is active on the topic, see active, is refused with
+// ErrConsumerActive unless force is set.
+func (b *Manager) ResetOffsets(topic, target string, reset OffsetReset, force bool) ([]PartitionOffset, error) {
+	switch reset.To {
+	case ResetEarliest, ResetLatest, ResetOffset, ResetShift, ResetTimestamp:
+	default:
+		return nil, fmt.Errorf("unknown offset reset %q", reset.To)
+	}
+
+	b.Mu.Lock()
+	defer b.Mu.Unlock()
+
+	_, cfg, err := b.topic(topic)
+	if err != nil {
+		return nil, err
+	}
+
+	partitions := make([]int, cfg.PartitionCount())
+	for p := range partitions {
+		partitions[p] = p
+	}
+	if reset.Partition != nil {
+		if *reset.Partition < 0 || *reset.Partition >= len(partitions) {
+			return nil, fmt.Errorf("partition %d does not exist for topic %q", *reset.Partition, topic)
+		}
+		partitions = []int{*reset.Partition}
+	}
+
+	if !force && b.active(topic, target, time.Now()) {
+		return nil, ErrConsumerActive
+	}
+
+	return b.resetOffsets(topic, target, partitions, reset, false)
+}
+
+// DeleteConsumer forgets target, a consumer ID or a group ID, on the topic: its
+// committed offsets, its filter and the deliveries it has not settled, which are
+// not redelivered. A target that is active on the topic is refused with
+// ErrConsumerActive unless force is set.
+func (b *Manager) DeleteConsumer(topic, target string, force bool) error {
+	b.Mu.Lock()
+	defer b.Mu.Unlock()
+
+	if _, _, err := b.topic(topic); err != nil {
+		return err
+	}
+	if !force && b.active(topic, target, time.Now()) {
+		return ErrConsumerActive
+	}
+
+	if err := b.Repo.DeleteOffsets(topic, target); err != nil {
+		return err
+	}
+
+	key := pendingKey{Topic: topic, Target: target}
+	delete(b.lastFetch, key)
+	delete(b.filters, key)
+	delete(b.registered, key)
+	delete(b.pending, key)
+	for k, d := range b.inFlight {
+		if d.pendingKey() == key {
+			delete(b.inFlight, k)
+		}
+	}
+	for k := range b.settled {
+		if k.Topic == topic && k.ConsumerID == target {
+			delete(b.settled, k)
+		}
+	}
+	if group, ok := b.Groups[target]; ok && group.Topic == topic && len(group.Members) == 0 {
+		delete(b.Groups, target)
+	}
+
+	return nil
+}
+
+// AutoOffsetReset commits the earliest or latest offset, as to says, on every
+// partition of the topic where the consumer has no committed offset yet.
+func (b *Manager) AutoOffsetReset(topic, consumerID string, to ResetTo) error {
+	if !ValidAutoOffsetReset(to) {
+		return fmt.Errorf("auto offset reset must be %s or %s, not %q", ResetEarliest, ResetLatest, to)
+	}
+
+	b.Mu.Lock()
+	defer b.Mu.Unlock()
+
+	_, cfg, err := b.topic(topic)
+	if err != nil {
+		return err
+	}
+
+	partitions := make([]int, cfg.PartitionCount())
+	for p := range partitions {
+		partitions[p] = p
+	}
+
+	_, err = b.resetOffsets(topic, consumerID, partitions, OffsetReset{To: to}, true)
+	return err
+}
+
+// active reports whether target still consumes the topic: it has a push session
+// open, group members that keep sending heartbeats, a long poll waiting, or it
+// fetched within the session timeout. Registering alone does not make a consumer
+// active. The caller must hold b.Mu.
+func (b *Manager) active(topic, target string, now time.Time) bool {
+	if topicEntry, ok := b.Topics[topic]; ok {
+		if _, ok := topicEntry.Consumers[target]; ok {
+			return true
+		}
+	}
+	if group, ok := b.Groups[target]; ok && group.Topic == topic && len(group.Members) > 0 {
+		return true
+	}
+	key := pendingKey{Topic: topic, Target: target}
+	if b.polling[key] > 0 {
+		return true
+	}
+	fetched, ok := b.lastFetch[key]
+	return ok && now.Sub(fetched) < b.SessionTimeout
+}
+
+// resetOffsets commits the reset on each partition, leaving partitions where target
+// already committed an offset alone when onlyUncommitted is set. The caller must
+// hold b.Mu.
+func (b *Manager) resetOffsets(topic, target string, partitions []int, reset OffsetReset, onlyUncommitted bool) ([]PartitionOffset, error) {
+	committed := make([]PartitionOffset, 0, len(partitions))
+	for _, p := range partitions {
+		if onlyUncommitted {
+			offsets, err := b.Repo.ListOffsets(topic, p)
+			if err != nil {
+				return nil, err
+			}
+			if _, ok := offsets[target]; ok {
+				continue
+			}
+		}
+
+		low, err := b.Repo.GetEarliestOffset(topic, p)
+		if err != nil {
+			return nil, err
+		}
+		high, err := b.Repo.GetLatestOffset(topic, p)
+		if err != nil {
+			return nil, err
+		}
+
+		var offset int
+		switch reset.To {
+		case ResetEarliest:
+			offset = low
+		case ResetLatest:
+			offset = high
+		case ResetOffset:
+			offset = reset.Offset
+		case ResetShift:
+			current, err := b.Repo.GetOffset(topic, p, target)
+			if err != nil {
+				return nil, err
+			}
+			offset = max(current, low) + reset.Shift
+		case ResetTimestamp:
+			if offset, err = b.Repo.GetOffsetForTimestamp(topic, p, reset.Timestamp); err != nil {
+				return nil, err
+			}
+		default:
+			return nil, fmt.Errorf("unknown offset reset %q", reset.To)
+		}
+		offset = min(max(offset, low), high)
+
+		if err := b.Repo.CommitOffset(topic, p, target, offset); err != nil {
+			return nil, err
+		}
+		committed = append(committed, PartitionOffset{Partition: p, Offset: offset})
+	}
+
+	return committed, nil
+}
@@ -0,0 +1,206 @@
+package broker
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/codytheroux96/go-mq/internal/core"
+	"github.com/codytheroux96/go-mq/internal/repository"
+)
+
+func TestResetOffsets(t *testing.T) {
+	repo := repository.NewInMemoryRepo()
+	if err := repo.CreateTopic("orders", core.TopicConfig{}); err != nil {
+		t.Fatalf("failed to create topic: %v", err)
+	}
+	manager := NewManager(repo)
+	for range 5 {
+		if err := manager.Publish("orders", core.NewMessage([]byte("order"), "p1")); err != nil {
+			t.Fatalf("failed to publish: %v", err)
+		}
+	}
+
+	tests := []struct {
+		name   string
+		reset  OffsetReset
+		expect int
+	}{
+		{"Earliest", OffsetReset{To: ResetEarliest}, 0},
+		{"Latest", OffsetReset{To: ResetLatest}, 5},
+		{"Offset", OffsetReset{To: ResetOffset, Offset: 2}, 2},
+		{"Offset past the log end", OffsetReset{To: ResetOffset, Offset: 99}, 5},
+		{"Shift back", OffsetReset{To: ResetShift, Shift: -2}, 1},
+		{"Shift before the start", OffsetReset{To: ResetShift, Shift: -10}, 0},
+		{"Shift forward", OffsetReset{To: ResetShift, Shift: 10}, 5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := repo.CommitOffset("orders", 0, "c1", 3); err != nil {
+				t.Fatalf("failed to commit: %v", err)
+			}
+			offsets, err := manager.ResetOffsets("orders", "c1", tt.reset, false)
+			if err != nil {
+				t.Fatalf("failed to reset: %v", err)
+			}
+			if len(offsets) != 1 || offsets[0].Offset != tt.expect {
+				t.Fatalf("expected offset %d, got %+v", tt.expect, offsets)
+			}
+			if committed, _ := repo.GetOffset("orders", 0, "c1"); committed != tt.expect {
+				t.Fatalf("expected committed offset %d, got %d", tt.expect, committed)
+			}
+		})
+	}
+
+	if _, err := manager.ResetOffsets("orders", "c1", OffsetReset{To: "sideways"}, false); err == nil {
+		t.Fatalf("expected an error for an unknown reset")
+	}
+	partition := 1
+	if _, err := manager.ResetOffsets("orders", "c1", OffsetReset{To: ResetEarliest, Partition: &partition}, false); err == nil {
+		t.Fatalf("expected an error for a missing partition")
+	}
+}
+
+func TestResetOffsetsOfActiveConsumers(t *testing.T) {
+	repo := repository.NewInMemoryRepo()
+	if err := repo.CreateTopic("orders", core.TopicConfig{}); err != nil {
+		t.Fatalf("failed to create topic: %v", err)
+	}
+	manager := NewManager(repo)
+
+	if _, err := manager.Subscribe("orders", "subscriber", core.FlowControl{}); err != nil {
+		t.Fatalf("failed to subscribe: %v", err)
+	}
+	if _, _, err := manager.JoinGroup("billing", "orders", "m1", core.FlowControl{}); err != nil {
+		t.Fatalf("failed to join group: %v", err)
+	}
+	if _, _, err := manager.Fetch("orders", 0, "", "fetcher", 1, ReadUncommitted); err != nil {
+		t.Fatalf("failed to fetch: %v", err)
+	}
+	if err := manager.Register("orders", "registered", core.FlowControl{}); err != nil {
+		t.Fatalf("failed to register: %v", err)
+	}
+	inbox, err := manager.Subscribe("orders", "gone", core.FlowControl{})
+	if err != nil {
+		t.Fatalf("failed to subscribe: %v", err)
+	}
+	manager.Unsubscribe("orders", "gone", inbox)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go manager.WaitForMessages(ctx, "orders", []int{0}, "", "poller", 1, ReadUncommitted)
+	for {
+		manager.Mu.RLock()
+		polling := manager.polling[pendingKey{Topic: "orders", Target: "poller"}]
+		manager.Mu.RUnlock()
+		if polling > 0 {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	tests := []struct {
+		name   string
+		target string
+		active bool
+	}{
+		{"Subscribed consumer", "subscriber", true},
+		{"Group with members", "billing", true},
+		{"Consumer that just fetched", "fetcher", true},
+		{"Consumer with a long poll waiting", "poller", true},
+		{"Registered consumer without a session", "registered", false},
+		{"Consumer that unsubscribed", "gone", false},
+		{"Consumer that never connected", "idle", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := manager.ResetOffsets("orders", tt.target, OffsetReset{To: ResetLatest}, false)
+			if errors.Is(err, ErrConsumerActive) != tt.active {
+				t.Fatalf("expected active %v, got %v", tt.active, err)
+			}
+			if _, err := manager.ResetOffsets("orders", tt.target, OffsetReset{To: ResetLatest}, true); err != nil {
+				t.Fatalf("expected a forced reset to succeed, got %v", err)
+			}
+		})
+	}
+
+	if err := manager.LeaveGroup("billing", "m1"); err != nil {
+		t.Fatalf("failed to leave group: %v", err)
+	}
+	if _, err := manager.ResetOffsets("orders", "billing", OffsetReset{To: ResetEarliest}, false); err != nil {
+		t.Fatalf("expected a group without members to reset, got %v", err)
+	}
+}
+
+func TestDeleteConsumer(t *testing.T) {
+	repo := repository.NewInMemoryRepo()
+	if err := repo.CreateTopic("orders", core.TopicConfig{}); err != nil {
+		t.Fatalf("failed to create topic: %v", err)
+	}
+	manager := NewManager(repo)
+	if err := manager.Publish("orders", core.NewMessage([]byte("order"), "p1")); err != nil {
+		t.Fatalf("failed to publish: %v", err)
+	}
+
+	if _, _, err := manager.Fetch("orders", 0, "", "tail", 1, ReadUncommitted); err != nil {
+		t.Fatalf("failed to fetch: %v", err)
+	}
+	if err := repo.CommitOffset("orders", 0, "tail", 1); err != nil {
+		t.Fatalf("failed to commit: %v", err)
+	}
+
+	if err := manager.DeleteConsumer("orders", "tail", false); !errors.Is(err, ErrConsumerActive) {
+		t.Fatalf("expected the consumer that just fetched to be active, got %v", err)
+	}
+	if err := manager.DeleteConsumer("orders", "tail", true); err != nil {
+		t.Fatalf("failed to delete consumer: %v", err)
+	}
+
+	consumers, err := manager.DescribeConsumers("orders")
+	if err != nil {
+		t.Fatalf("failed to describe consumers: %v", err)
+	}
+	if len(consumers) != 0 {
+		t.Fatalf("expected no consumers left, got %+v", consumers)
+	}
+	if expired := manager.RedeliverExpired(time.Now().Add(time.Hour)); expired != 0 {
+		t.Fatalf("expected the deleted consumer's delivery to be forgotten, %d expired", expired)
+	}
+
+	if err := manager.DeleteConsumer("missing", "tail", false); err == nil {
+		t.Fatalf("expected an error for a missing topic")
+	}
+}
+
+func TestAutoOffsetReset(t *testing.T) {
+	repo := repository.NewInMemoryRepo()
+	if err := repo.CreateTopic("orders", core.TopicConfig{Partitions: 2}); err != nil {
+		t.Fatalf("failed to create topic: %v", err)
+	}
+	manager := NewManager(repo)
+	for range 4 {
+		if err := manager.Publish("orders", core.NewMessage([]byte("order"), "p1")); err != nil {
+			t.Fatalf("failed to publish: %v", err)
+		}
+	}
+	if err := repo.CommitOffset("orders", 1, "c1", 1); err != nil {
+		t.Fatalf("failed to commit: %v", err)
+	}
+
+	if err := manager.AutoOffsetReset("orders", "c1", ResetLatest); err != nil {
+		t.Fatalf("failed to apply auto offset reset: %v", err)
+	}
+	// round robin put two messages on each partition, partition 1 keeps its commit
+	for partition, expect := range []int{2, 1} {
+		if committed, _ := repo.GetOffset("orders", partition, "c1"); committed != expect {
+			t.Fatalf("expected offset %d on partition %d, got %d", expect, partition, committed)
+		}
+	}
+
+	if err := manager.AutoOffsetReset("orders", "c1", ResetShift); err == nil {
+		t.Fatalf("expected an error for an auto offset reset that is not earliest or latest")
+	}
+}
@@ -0,0 +1,126 @@
+package broker
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/codytheroux96/go-mq/internal/core"
+)
+
+// DefaultProducerTimeout is how long an idle producer session is kept before it
+// has to register again.
+const DefaultProducerTimeout = 24 * time.Hour
+
+// producerDedupWindow is how many of a producer's latest appends are remembered, a
+// retry of one of them is answered with the original result.
+const producerDedupWindow = 5
+
+// expiredEpoch is the last epoch of a producer session and when it expired.
+type expiredEpoch struct {
+	epoch     int
+	expiredAt time.Time
+}
+
+var (
+	ErrUnknownProducer    = errors.New("producer is not registered")
+	ErrProducerFenced     = errors.New("producer epoch is stale, a newer session has registered")
+	ErrOutOfOrderSequence = errors.New("sequence number is out of order")
+)
+
+// RegisterProducer starts a new session for producerID and returns its epoch. The
+// session's sequence numbers start at 0 and publishes from earlier epochs, those of
+// sessions that expired within the last ProducerTimeout included, are rejected from
+// now on. Sessions and epochs are only kept in memory, after a restart every
+// producer registers again at epoch 0 and nothing fences the sessions from before.
+func (b *Manager) RegisterProducer(producerID string) int {
+	b.Mu.Lock()
+	defer b.Mu.Unlock()
+
+	producer, ok := b.Producers[producerID]
+	if !ok {
+		producer = core.NewProducer(producerID)
+		if expired, ok := b.expiredEpochs[producerID]; ok {
+			producer.Epoch = expired.epoch + 1
+			delete(b.expiredEpochs, producerID)
+		}
+		b.Producers[producerID] = producer
+	} else {
+		producer.Epoch++
+		producer.Sequence = -1
+		producer.Recent = nil
+	}
+	producer.LastActive = time.Now()
+
+	return producer.Epoch
+}
+
+// PublishIdempotent publishes msg as sequence number sequence of its producer's
+// session. A sequence that was already appended is a duplicate, nothing is appended
+// and msg is given the ID, partition and offset of the original instead. A gap in
+// the sequence, a duplicate too old to be remembered or a stale epoch is rejected.
+func (b *Manager) PublishIdempotent(topic string, msg *core.Message, epoch int, sequence int64) (bool, error) {
+	b.Mu.Lock()
+	defer b.unlockAndSendBlocked()
+
+	producer, ok := b.Producers[msg.ProducerID]
+	if !ok {
+		return false, fmt.Errorf("%w: %q", ErrUnknownProducer, msg.ProducerID)
+	}
+	if epoch != producer.Epoch {
+		return false, fmt.Errorf("%w: got epoch %d, current is %d", ErrProducerFenced, epoch, producer.Epoch)
+	}
+	producer.LastActive = time.Now()
+
+	if sequence <= producer.Sequence {
+		// Recent holds consecutive sequences ending at producer.Sequence
+		i := len(producer.Recent) - 1 - int(producer.Sequence-sequence)
+		if i < 0 {
+			return false, fmt.Errorf("%w: sequence %d is older than the last %d appended", ErrOutOfOrderSequence, sequence, len(producer.Recent))
+		}
+		original := producer.Recent[i]
+		msg.ID, msg.Partition, msg.Offset, msg.Timestamp = original.ID, original.Partition, original.Offset, original.Timestamp
+		return true, nil
+	}
+	if sequence != producer.Sequence+1 {
+		return false, fmt.Errorf("%w: expected %d, got %d", ErrOutOfOrderSequence, producer.Sequence+1, sequence)
+	}
+
+	// a message the topic's dedup window recognises still takes up the sequence
+	err := b.publish(topic, msg)
+	if err != nil && !errors.Is(err, ErrDuplicateMessage) {
+		return false, err
+	}
+
+	producer.Sequence = sequence
+	producer.Recent = append(producer.Recent, msg)
+	if len(producer.Recent) > producerDedupWindow {
+		producer.Recent = producer.Recent[1:]
+	}
+	return err != nil, nil
+}
+
+// ExpireProducers drops producer sessions idle for longer than ProducerTimeout. Only
+// the epoch of an expired session is kept, for the producer's next registration,
+// and it is forgotten too once another ProducerTimeout passes without one.
+func (b *Manager) ExpireProducers(now time.Time) int {
+	b.Mu.Lock()
+	defer b.Mu.Unlock()
+
+	for id, expired := range b.expiredEpochs {
+		if now.Sub(expired.expiredAt) > b.ProducerTimeout {
+			delete(b.expiredEpochs, id)
+		}
+	}
+
+	expired := 0
+	for id, producer := range b.Producers {
+		if now.Sub(producer.LastActive) > b.ProducerTimeout {
+			delete(b.Producers, id)
+			b.expiredEpochs[id] = expiredEpoch{epoch: producer.Epoch, expiredAt: now}
+			expired++
+		}
+	}
+
+	return expired
+}
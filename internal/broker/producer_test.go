@@ -0,0 +1,100 @@
+package broker
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/codytheroux96/go-mq/internal/core"
+	"github.com/codytheroux96/go-mq/internal/repository"
+)
+
+func TestPublishIdempotent(t *testing.T) {
+	repo := repository.NewInMemoryRepo()
+	if err := repo.CreateTopic("orders", core.TopicConfig{}); err != nil {
+		t.Fatalf("failed to create topic: %v", err)
+	}
+
+	manager := NewManager(repo)
+	if epoch := manager.RegisterProducer("p1"); epoch != 0 {
+		t.Fatalf("expected the first session to have epoch 0, got %d", epoch)
+	}
+
+	tests := []struct {
+		name            string
+		producerID      string
+		epoch           int
+		sequence        int64
+		expectErr       error
+		expectDuplicate bool
+		expectOffset    int
+	}{
+		{"First sequence", "p1", 0, 0, nil, false, 0},
+		{"Next sequence", "p1", 0, 1, nil, false, 1},
+		{"Retry of the last sequence", "p1", 0, 1, nil, true, 1},
+		{"Retry of an earlier sequence", "p1", 0, 0, nil, true, 0},
+		{"Gap in the sequence", "p1", 0, 3, ErrOutOfOrderSequence, false, 0},
+		{"Wrong epoch", "p1", 1, 2, ErrProducerFenced, false, 0},
+		{"Unregistered producer", "p2", 0, 0, ErrUnknownProducer, false, 0},
+		{"Sequence after a rejection", "p1", 0, 2, nil, false, 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg := core.NewMessage([]byte("order"), tt.producerID)
+			duplicate, err := manager.PublishIdempotent("orders", msg, tt.epoch, tt.sequence)
+			if tt.expectErr != nil {
+				if !errors.Is(err, tt.expectErr) {
+					t.Fatalf("expected %v, got %v", tt.expectErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("failed to publish: %v", err)
+			}
+			if duplicate != tt.expectDuplicate || msg.Offset != tt.expectOffset {
+				t.Fatalf("expected duplicate %v at offset %d, got %v at %d", tt.expectDuplicate, tt.expectOffset, duplicate, msg.Offset)
+			}
+		})
+	}
+
+	if latest, _ := repo.GetLatestOffset("orders", 0); latest != 3 {
+		t.Fatalf("expected 3 messages appended, got %d", latest)
+	}
+
+	// registering again fences the old session and restarts the sequence
+	if epoch := manager.RegisterProducer("p1"); epoch != 1 {
+		t.Fatalf("expected epoch 1, got %d", epoch)
+	}
+	if _, err := manager.PublishIdempotent("orders", core.NewMessage([]byte("order"), "p1"), 0, 3); !errors.Is(err, ErrProducerFenced) {
+		t.Fatalf("expected the old session to be fenced, got %v", err)
+	}
+	if _, err := manager.PublishIdempotent("orders", core.NewMessage([]byte("order"), "p1"), 1, 0); err != nil {
+		t.Fatalf("expected the new session to start at sequence 0, got %v", err)
+	}
+
+	if expired := manager.ExpireProducers(time.Now().Add(2 * manager.ProducerTimeout)); expired != 1 {
+		t.Fatalf("expected the idle producer to expire, got %d", expired)
+	}
+
+	// a session registered after expiry still fences the sessions before it
+	if epoch := manager.RegisterProducer("p1"); epoch != 2 {
+		t.Fatalf("expected epoch 2 after expiry, got %d", epoch)
+	}
+	if _, err := manager.PublishIdempotent("orders", core.NewMessage([]byte("order"), "p1"), 1, 1); !errors.Is(err, ErrProducerFenced) {
+		t.Fatalf("expected the session from before expiry to be fenced, got %v", err)
+	}
+
+	// the epoch of an expired session is only kept for another producer timeout
+	now := time.Now().Add(2 * manager.ProducerTimeout)
+	if expired := manager.ExpireProducers(now); expired != 1 {
+		t.Fatalf("expected the idle producer to expire, got %d", expired)
+	}
+	if _, ok := manager.expiredEpochs["p1"]; !ok {
+		t.Fatalf("expected the expired epoch to be kept")
+	}
+	manager.ExpireProducers(now.Add(2 * manager.ProducerTimeout))
+	if len(manager.expiredEpochs) != 0 {
+		t.Fatalf("expected old expired epochs to be forgotten, got %v", manager.expiredEpochs)
+	}
+}
@@ -0,0 +1,149 @@
+package broker
+
+import (
+	"container/heap"
+	"errors"
+	"time"
+
+	"github.com/codytheroux96/go-mq/internal/core"
+	"github.com/codytheroux96/go-mq/internal/repository"
+	"github.com/google/uuid"
+)
+
+// idleScheduleWait is how long Run sleeps on the schedule when nothing is scheduled,
+// a new message wakes it early.
+const idleScheduleWait = time.Hour
+
+// scheduleRetryDelay is how long a scheduled message that failed to publish waits
+// before it is tried again.
+const scheduleRetryDelay = time.Second
+
+// scheduleQueue is a min-heap of scheduled messages ordered by delivery time.
+type scheduleQueue []repository.ScheduledMessage
+
+func (q scheduleQueue) Len() int           { return len(q) }
+func (q scheduleQueue) Less(i, j int) bool { return q[i].DeliverAt.Before(q[j].DeliverAt) }
+func (q scheduleQueue) Swap(i, j int)      { q[i], q[j] = q[j], q[i] }
+
+func (q *scheduleQueue) Push(x any) {
+	*q = append(*q, x.(repository.ScheduledMessage))
+}
+
+func (q *scheduleQueue) Pop() any {
+	old := *q
+	last := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return last
+}
+
+// Schedule holds msg back until deliverAt and then publishes it to the topic. A
+// time that has already passed publishes right away. The message is saved to the
+// repository so a durable repository keeps it across restarts.
+func (b *Manager) Schedule(topic string, msg *core.Message, deliverAt time.Time) error {
+	b.Mu.Lock()
+	defer b.unlockAndSendBlocked()
+
+	if _, _, err := b.topic(topic); err != nil {
+		return err
+	}
+
+	if !deliverAt.After(time.Now()) {
+		return b.publish(topic, msg)
+	}
+
+	if msg.ID == "" {
+		msg.ID = uuid.NewString()
+	}
+
+	scheduled := repository.ScheduledMessage{Topic: topic, DeliverAt: deliverAt, Message: msg}
+	if err := b.Repo.SaveScheduled(scheduled); err != nil {
+		return err
+	}
+	heap.Push(&b.scheduled, scheduled)
+
+	// wake Run in case this message is due before the one it is waiting on
+	select {
+	case b.scheduleWake <- struct{}{}:
+	default:
+	}
+
+	return nil
+}
+
+// RestoreScheduled loads the messages scheduled before a restart.
+func (b *Manager) RestoreScheduled() error {
+	scheduled, err := b.Repo.ListScheduled()
+	if err != nil {
+		return err
+	}
+
+	b.Mu.Lock()
+	defer b.Mu.Unlock()
+
+	b.scheduled = scheduleQueue(scheduled)
+	heap.Init(&b.scheduled)
+
+	return nil
+}
+
+// DeliverDue publishes every scheduled message that is due by now and returns how
+// many were published. Messages for topics deleted in the meantime are dropped, a
+// message that fails to publish otherwise is tried again after scheduleRetryDelay.
+// A crash between publishing and forgetting a message delivers it again on
+// restart, and so does a restart before a failed forget is retried.
+func (b *Manager) DeliverDue(now time.Time) int {
+	b.Mu.Lock()
+	defer b.unlockAndSendBlocked()
+
+	b.forgetScheduled()
+
+	delivered := 0
+	for len(b.scheduled) > 0 && !b.scheduled[0].DeliverAt.After(now) {
+		next := heap.Pop(&b.scheduled).(repository.ScheduledMessage)
+		// a duplicate is given the original's ID, so the saved ID is kept aside
+		id := next.Message.ID
+		if err := b.publish(next.Topic, next.Message); err != nil && !errors.Is(err, ErrDuplicateMessage) {
+			if _, _, topicErr := b.topic(next.Topic); topicErr == nil {
+				next.DeliverAt = now.Add(scheduleRetryDelay)
+				heap.Push(&b.scheduled, next)
+				continue
+			}
+		} else if err == nil {
+			delivered++
+		}
+
+		b.unforgotten = append(b.unforgotten, id)
+	}
+	b.forgetScheduled()
+
+	return delivered
+}
+
+// forgetScheduled deletes the saved copies of scheduled messages that are no longer
+// scheduled, keeping those the repository failed to delete for the next try. The
+// caller must hold b.Mu.
+func (b *Manager) forgetScheduled() {
+	var failed []string
+	for _, id := range b.unforgotten {
+		if err := b.Repo.DeleteScheduled(id); err != nil {
+			failed = append(failed, id)
+		}
+	}
+	b.unforgotten = failed
+}
+
+// untilNextDue returns how long until the earliest scheduled message is due, or
+// until failed forgets are retried when that is sooner.
+func (b *Manager) untilNextDue() time.Duration {
+	b.Mu.RLock()
+	defer b.Mu.RUnlock()
+
+	wait := idleScheduleWait
+	if len(b.scheduled) > 0 {
+		wait = time.Until(b.scheduled[0].DeliverAt)
+	}
+	if len(b.unforgotten) > 0 {
+		wait = min(wait, scheduleRetryDelay)
+	}
+	return wait
+}
@@ -0,0 +1,178 @@
+package broker
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/codytheroux96/go-mq/internal/core"
+	"github.com/codytheroux96/go-mq/internal/repository"
+)
+
+func TestSchedule(t *testing.T) {
+	repo := repository.NewInMemoryRepo()
+	if err := repo.CreateTopic("reminders", core.TopicConfig{}); err != nil {
+		t.Fatalf("failed to create topic: %v", err)
+	}
+	manager := NewManager(repo)
+
+	inbox, err := manager.Subscribe("reminders", "c1", core.FlowControl{})
+	if err != nil {
+		t.Fatalf("failed to subscribe: %v", err)
+	}
+
+	now := time.Now()
+	late := core.NewMessage([]byte("late"), "p1")
+	early := core.NewMessage([]byte("early"), "p1")
+	if err := manager.Schedule("reminders", late, now.Add(2*time.Minute)); err != nil {
+		t.Fatalf("failed to schedule: %v", err)
+	}
+	if err := manager.Schedule("reminders", early, now.Add(time.Minute)); err != nil {
+		t.Fatalf("failed to schedule: %v", err)
+	}
+	if err := manager.Schedule("missing", core.NewMessage([]byte("x"), "p1"), now.Add(time.Minute)); err == nil {
+		t.Fatalf("expected error scheduling to a topic that does not exist")
+	}
+
+	if delivered := manager.DeliverDue(now); delivered != 0 || len(inbox) != 0 {
+		t.Fatalf("expected nothing delivered before it is due, got %d", delivered)
+	}
+	if messages, _ := repo.Fetch("reminders", 0, "c2", 10); len(messages) != 0 {
+		t.Fatalf("expected scheduled messages to stay out of the log, got %d", len(messages))
+	}
+
+	if delivered := manager.DeliverDue(now.Add(90 * time.Second)); delivered != 1 {
+		t.Fatalf("expected 1 message delivered, got %d", delivered)
+	}
+	if msg := <-inbox; msg.ID != early.ID || msg.Offset != 0 {
+		t.Fatalf("expected the earlier message first at offset 0, got %s at %d", msg.ID, msg.Offset)
+	}
+
+	if delivered := manager.DeliverDue(now.Add(3 * time.Minute)); delivered != 1 {
+		t.Fatalf("expected 1 message delivered, got %d", delivered)
+	}
+	if msg := <-inbox; msg.ID != late.ID || msg.Offset != 1 {
+		t.Fatalf("expected the later message at offset 1, got %s at %d", msg.ID, msg.Offset)
+	}
+
+	if scheduled, _ := repo.ListScheduled(); len(scheduled) != 0 {
+		t.Fatalf("expected delivered messages to be removed from the repository, got %d", len(scheduled))
+	}
+}
+
+func TestScheduleSurvivesRestart(t *testing.T) {
+	dir := t.TempDir()
+
+	repo, err := repository.NewFileRepo(dir)
+	if err != nil {
+		t.Fatalf("failed to open file repo: %v", err)
+	}
+	if err := repo.CreateTopic("reminders", core.TopicConfig{}); err != nil {
+		t.Fatalf("failed to create topic: %v", err)
+	}
+
+	deliverAt := time.Now().Add(time.Minute)
+	msg := core.NewMessage([]byte("wake up"), "p1")
+	msg.Key = "k1"
+	if err := NewManager(repo).Schedule("reminders", msg, deliverAt); err != nil {
+		t.Fatalf("failed to schedule: %v", err)
+	}
+	repo.Close()
+
+	repo, err = repository.NewFileRepo(dir)
+	if err != nil {
+		t.Fatalf("failed to reopen file repo: %v", err)
+	}
+	defer repo.Close()
+
+	manager := NewManager(repo)
+	if err := manager.RestoreScheduled(); err != nil {
+		t.Fatalf("failed to restore scheduled messages: %v", err)
+	}
+	if delivered := manager.DeliverDue(deliverAt.Add(time.Second)); delivered != 1 {
+		t.Fatalf("expected the restored message to be delivered, got %d", delivered)
+	}
+
+	messages, err := repo.Fetch("reminders", 0, "c1", 10)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(messages) != 1 || messages[0].ID != msg.ID || messages[0].Key != "k1" || string(messages[0].Body) != "wake up" {
+		t.Fatalf("expected the scheduled message in the log, got %+v", messages)
+	}
+}
+
+// failingRepo fails publishes, offset commits and deletes of scheduled messages
+// and transactions while the flags are set.
+type failingRepo struct {
+	repository.Repository
+	failPublish bool
+	failCommit  bool
+	failDelete  bool
+}
+
+func (r *failingRepo) Publish(topic string, partition int, msg *core.Message) error {
+	if r.failPublish {
+		return errors.New("disk full")
+	}
+	return r.Repository.Publish(topic, partition, msg)
+}
+
+func (r *failingRepo) CommitOffset(topic string, partition int, consumerID string, offset int) error {
+	if r.failCommit {
+		return errors.New("disk full")
+	}
+	return r.Repository.CommitOffset(topic, partition, consumerID, offset)
+}
+
+func (r *failingRepo) DeleteScheduled(messageID string) error {
+	if r.failDelete {
+		return errors.New("disk full")
+	}
+	return r.Repository.DeleteScheduled(messageID)
+}
+
+func (r *failingRepo) DeleteTransaction(txnID string) error {
+	if r.failDelete {
+		return errors.New("disk full")
+	}
+	return r.Repository.DeleteTransaction(txnID)
+}
+
+func TestDeliverDueRetriesFailures(t *testing.T) {
+	repo := &failingRepo{Repository: repository.NewInMemoryRepo(), failPublish: true, failDelete: true}
+	if err := repo.CreateTopic("reminders", core.TopicConfig{}); err != nil {
+		t.Fatalf("failed to create topic: %v", err)
+	}
+	manager := NewManager(repo)
+
+	now := time.Now()
+	if err := manager.Schedule("reminders", core.NewMessage([]byte("wake up"), "p1"), now.Add(time.Minute)); err != nil {
+		t.Fatalf("failed to schedule: %v", err)
+	}
+
+	due := now.Add(time.Minute)
+	if delivered := manager.DeliverDue(due); delivered != 0 {
+		t.Fatalf("expected nothing delivered while publishing fails, got %d", delivered)
+	}
+	if wait := manager.untilNextDue(); wait <= 0 {
+		t.Fatalf("expected the failed message to be retried later, it is due in %v", wait)
+	}
+	if delivered := manager.DeliverDue(due.Add(scheduleRetryDelay / 2)); delivered != 0 {
+		t.Fatalf("expected no retry before the retry delay, got %d", delivered)
+	}
+
+	repo.failPublish = false
+	if delivered := manager.DeliverDue(due.Add(scheduleRetryDelay)); delivered != 1 {
+		t.Fatalf("expected the message delivered on retry, got %d", delivered)
+	}
+	if scheduled, _ := repo.ListScheduled(); len(scheduled) != 1 {
+		t.Fatalf("expected the message kept in the repository while deleting fails, got %d", len(scheduled))
+	}
+
+	repo.failDelete = false
+	manager.DeliverDue(due.Add(scheduleRetryDelay))
+	if scheduled, _ := repo.ListScheduled(); len(scheduled) != 0 {
+		t.Fatalf("expected the failed delete to be retried, got %d scheduled", len(scheduled))
+	}
+}
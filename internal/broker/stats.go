@@ -0,0 +1,221 @@
+package broker
+
+import (
+	"maps"
+	"slices"
+	"time"
+)
+
+// TopicStats describes what a topic holds right now. MessageCount counts every
+// retained position, messages of aborted transactions included. LatestOffset is the
+// log end offset, the offset the next message will get.
+type TopicStats struct {
+	Topic             string           `json:"topic"`
+	MessageCount      int              `json:"message_count"`
+	Bytes             int64            `json:"bytes"`
+	EarliestTimestamp *time.Time       `json:"earliest_timestamp,omitempty"`
+	LatestTimestamp   *time.Time       `json:"latest_timestamp,omitempty"`
+	Partitions        []PartitionStats `json:"partitions"`
+}
+
+type PartitionStats struct {
+	Partition         int        `json:"partition"`
+	MessageCount      int        `json:"message_count"`
+	Bytes             int64      `json:"bytes"`
+	EarliestOffset    int        `json:"earliest_offset"`
+	LatestOffset      int        `json:"latest_offset"`
+	EarliestTimestamp *time.Time `json:"earliest_timestamp,omitempty"`
+	LatestTimestamp   *time.Time `json:"latest_timestamp,omitempty"`
+}
+
+// ConsumerState is how far a consumer, or a group when Group is set, has got
+// through a topic. Unacked counts messages delivered to it and not acknowledged
+// yet, PendingRedelivery those nacked or timed out and waiting to go out again.
+// Filter is the expression messages must pass to be delivered to it, if any.
+type ConsumerState struct {
+	ID                string                   `json:"id"`
+	Group             bool                     `json:"group"`
+	Lag               int                      `json:"lag"`
+	LastFetch         *time.Time               `json:"last_fetch,omitempty"`
+	Unacked           int                      `json:"unacked"`
+	PendingRedelivery int                      `json:"pending_redelivery"`
+	Filter            string                   `json:"filter,omitempty"`
+	Partitions        []ConsumerPartitionState `json:"partitions"`
+}
+
+type ConsumerPartitionState struct {
+	Partition       int `json:"partition"`
+	CommittedOffset int `json:"committed_offset"`
+	Lag             int `json:"lag"`
+}
+
+// TopicStats reads the size, offset range and timestamp range of every partition.
+func (b *Manager) TopicStats(topic string) (TopicStats, error) {
+	b.Mu.RLock()
+	defer b.Mu.RUnlock()
+
+	cfg, err := b.Repo.GetTopicConfig(topic)
+	if err != nil {
+		return TopicStats{}, err
+	}
+
+	stats := TopicStats{Topic: topic, Partitions: make([]PartitionStats, cfg.PartitionCount())}
+	for p := range stats.Partitions {
+		partition, err := b.partitionStats(topic, p)
+		if err != nil {
+			return TopicStats{}, err
+		}
+		stats.Partitions[p] = partition
+
+		stats.MessageCount += partition.MessageCount
+		stats.Bytes += partition.Bytes
+		if t := partition.EarliestTimestamp; t != nil && (stats.EarliestTimestamp == nil || t.Before(*stats.EarliestTimestamp)) {
+			stats.EarliestTimestamp = t
+		}
+		if t := partition.LatestTimestamp; t != nil && (stats.LatestTimestamp == nil || t.After(*stats.LatestTimestamp)) {
+			stats.LatestTimestamp = t
+		}
+	}
+
+	return stats, nil
+}
+
+// partitionStats takes the timestamps from the first and last retained messages.
+// The caller must hold b.Mu.
+func (b *Manager) partitionStats(topic string, partition int) (PartitionStats, error) {
+	stats := PartitionStats{Partition: partition}
+
+	var err error
+	if stats.EarliestOffset, err = b.Repo.GetEarliestOffset(topic, partition); err != nil {
+		return PartitionStats{}, err
+	}
+	if stats.LatestOffset, err = b.Repo.GetLatestOffset(topic, partition); err != nil {
+		return PartitionStats{}, err
+	}
+	if stats.Bytes, err = b.Repo.GetPartitionBytes(topic, partition); err != nil {
+		return PartitionStats{}, err
+	}
+	stats.MessageCount = stats.LatestOffset - stats.EarliestOffset
+	if stats.MessageCount == 0 {
+		return stats, nil
+	}
+
+	for _, offset := range []int{stats.EarliestOffset, stats.LatestOffset - 1} {
+		msgs, err := b.Repo.Read(topic, partition, offset, 1)
+		if err != nil {
+			return PartitionStats{}, err
+		}
+		if len(msgs) == 0 {
+			continue
+		}
+		timestamp := msgs[0].Timestamp
+		if offset == stats.EarliestOffset {
+			stats.EarliestTimestamp = &timestamp
+		} else {
+			stats.LatestTimestamp = &timestamp
+		}
+	}
+
+	return stats, nil
+}
+
+// DescribeConsumers lists everyone consuming the topic, whether they registered,
+// committed an offset, are subscribed, belong to a group on it or have fetched from
+// it. Lag is counted from the committed offset, or from the earliest retained
+// offset when retention has passed it.
+func (b *Manager) DescribeConsumers(topic string) ([]ConsumerState, error) {
+	b.Mu.RLock()
+	defer b.Mu.RUnlock()
+
+	cfg, err := b.Repo.GetTopicConfig(topic)
+	if err != nil {
+		return nil, err
+	}
+
+	// consumers and groups are told apart by the live groups, or failing that by
+	// how their messages were delivered
+	groups := make(map[string]bool)
+	add := func(id string, group bool) {
+		groups[id] = groups[id] || group
+	}
+	for id, group := range b.Groups {
+		if group.Topic == topic {
+			add(id, true)
+		}
+	}
+	if topicEntry, ok := b.Topics[topic]; ok {
+		for id := range topicEntry.Consumers {
+			add(id, false)
+		}
+	}
+	for key := range b.lastFetch {
+		if key.Topic == topic {
+			add(key.Target, false)
+		}
+	}
+	for key := range b.registered {
+		if key.Topic == topic {
+			add(key.Target, false)
+		}
+	}
+
+	unacked := make(map[string]int)
+	for _, d := range b.inFlight {
+		if d.Topic != topic {
+			continue
+		}
+		target := d.pendingKey().Target
+		unacked[target]++
+		add(target, d.GroupID != "")
+	}
+	for key, waiting := range b.pending {
+		if key.Topic == topic && len(waiting) > 0 {
+			add(key.Target, waiting[0].GroupID != "")
+		}
+	}
+
+	offsets := make([]map[string]int, cfg.PartitionCount())
+	low := make([]int, len(offsets))
+	high := make([]int, len(offsets))
+	for p := range offsets {
+		if offsets[p], err = b.Repo.ListOffsets(topic, p); err != nil {
+			return nil, err
+		}
+		if low[p], err = b.Repo.GetEarliestOffset(topic, p); err != nil {
+			return nil, err
+		}
+		if high[p], err = b.Repo.GetLatestOffset(topic, p); err != nil {
+			return nil, err
+		}
+		for id := range offsets[p] {
+			add(id, false)
+		}
+	}
+	delete(groups, redriveConsumerID)
+
+	consumers := make([]ConsumerState, 0, len(groups))
+	for _, id := range slices.Sorted(maps.Keys(groups)) {
+		state := ConsumerState{
+			ID:                id,
+			Group:             groups[id],
+			Unacked:           unacked[id],
+			PendingRedelivery: len(b.pending[pendingKey{Topic: topic, Target: id}]),
+			Partitions:        make([]ConsumerPartitionState, len(offsets)),
+		}
+		if fetched, ok := b.lastFetch[pendingKey{Topic: topic, Target: id}]; ok {
+			state.LastFetch = &fetched
+		}
+		if f, ok := b.filters[pendingKey{Topic: topic, Target: id}]; ok {
+			state.Filter = f.String()
+		}
+		for p := range offsets {
+			committed := offsets[p][id]
+			lag := high[p] - max(committed, low[p])
+			state.Partitions[p] = ConsumerPartitionState{Partition: p, CommittedOffset: committed, Lag: lag}
+			state.Lag += lag
+		}
+		consumers = append(consumers, state)
+	}
+
+	return consumers, nil
+}
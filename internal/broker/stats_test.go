@@ -0,0 +1,119 @@
+package broker
+
+import (
+	"testing"
+
+	"github.com/codytheroux96/go-mq/internal/core"
+	"github.com/codytheroux96/go-mq/internal/repository"
+)
+
+func TestTopicStats(t *testing.T) {
+	repo := repository.NewInMemoryRepo()
+	if err := repo.CreateTopic("orders", core.TopicConfig{Partitions: 2}); err != nil {
+		t.Fatalf("failed to create topic: %v", err)
+	}
+	manager := NewManager(repo)
+
+	stats, err := manager.TopicStats("orders")
+	if err != nil {
+		t.Fatalf("failed to read stats: %v", err)
+	}
+	if stats.MessageCount != 0 || stats.EarliestTimestamp != nil || stats.LatestTimestamp != nil {
+		t.Fatalf("expected an empty topic, got %+v", stats)
+	}
+
+	var published []*core.Message
+	for _, body := range []string{"a", "bb", "ccc"} {
+		msg := core.NewMessage([]byte(body), "p1")
+		if err := manager.Publish("orders", msg); err != nil {
+			t.Fatalf("failed to publish: %v", err)
+		}
+		published = append(published, msg)
+	}
+
+	stats, err = manager.TopicStats("orders")
+	if err != nil {
+		t.Fatalf("failed to read stats: %v", err)
+	}
+	if stats.MessageCount != 3 || stats.Bytes != 6 || len(stats.Partitions) != 2 {
+		t.Fatalf("expected 3 messages of 6 bytes over 2 partitions, got %+v", stats)
+	}
+	if !stats.EarliestTimestamp.Equal(published[0].Timestamp) || !stats.LatestTimestamp.Equal(published[2].Timestamp) {
+		t.Fatalf("expected the timestamps of the first and last messages, got %v and %v", stats.EarliestTimestamp, stats.LatestTimestamp)
+	}
+
+	// round robin put the first and third message on partition 0
+	p0 := stats.Partitions[0]
+	if p0.MessageCount != 2 || p0.EarliestOffset != 0 || p0.LatestOffset != 2 || p0.Bytes != 4 {
+		t.Fatalf("unexpected partition 0 stats %+v", p0)
+	}
+
+	if _, err := manager.TopicStats("missing"); err == nil {
+		t.Fatalf("expected an error for a missing topic")
+	}
+}
+
+func TestDescribeConsumers(t *testing.T) {
+	repo := repository.NewInMemoryRepo()
+	if err := repo.CreateTopic("orders", core.TopicConfig{}); err != nil {
+		t.Fatalf("failed to create topic: %v", err)
+	}
+	manager := NewManager(repo)
+
+	if _, _, err := manager.JoinGroup("billing", "orders", "m1", core.FlowControl{}); err != nil {
+		t.Fatalf("failed to join group: %v", err)
+	}
+	for range 4 {
+		if err := manager.Publish("orders", core.NewMessage([]byte("order"), "p1")); err != nil {
+			t.Fatalf("failed to publish: %v", err)
+		}
+	}
+
+	// c1 fetches two, commits them and acks one
+	messages, advanced, err := manager.Fetch("orders", 0, "", "c1", 2, ReadUncommitted)
+	if err != nil || len(messages) != 2 {
+		t.Fatalf("failed to fetch: %v", err)
+	}
+	if err := repo.CommitOffset("orders", 0, "c1", advanced); err != nil {
+		t.Fatalf("failed to commit: %v", err)
+	}
+	if _, err := manager.Ack("orders", "c1", messages[0].ID); err != nil {
+		t.Fatalf("failed to ack: %v", err)
+	}
+
+	consumers, err := manager.DescribeConsumers("orders")
+	if err != nil {
+		t.Fatalf("failed to describe consumers: %v", err)
+	}
+
+	tests := []struct {
+		name          string
+		index         int
+		expectID      string
+		expectGroup   bool
+		expectLag     int
+		expectUnacked int
+		expectFetched bool
+	}{
+		{"Group with a pushed member", 0, "billing", true, 4, 4, false},
+		{"Consumer that fetched", 1, "c1", false, 2, 1, true},
+	}
+
+	if len(consumers) != len(tests) {
+		t.Fatalf("expected %d consumers, got %+v", len(tests), consumers)
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			state := consumers[tt.index]
+			if state.ID != tt.expectID || state.Group != tt.expectGroup {
+				t.Fatalf("expected %s (group %v), got %s (group %v)", tt.expectID, tt.expectGroup, state.ID, state.Group)
+			}
+			if state.Lag != tt.expectLag || state.Unacked != tt.expectUnacked {
+				t.Fatalf("expected lag %d and %d unacked, got %d and %d", tt.expectLag, tt.expectUnacked, state.Lag, state.Unacked)
+			}
+			if (state.LastFetch != nil) != tt.expectFetched {
+				t.Fatalf("expected a last fetch time %v, got %v", tt.expectFetched, state.LastFetch)
+			}
+		})
+	}
+}
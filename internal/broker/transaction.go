@@ -0,0 +1,383 @@
+package broker
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/codytheroux96/go-mq/internal/core"
+	"github.com/codytheroux96/go-mq/internal/repository"
+	"github.com/google/uuid"
+)
+
+// DefaultTransactionTimeout is how long a transaction may stay open before it is
+// aborted.
+const DefaultTransactionTimeout = time.Minute
+
+// MetaTransactionID marks a message as published inside a transaction.
+const MetaTransactionID = "txn.id"
+
+// IsolationLevel decides whether Fetch returns transactional messages before their
+// transaction commits.
+type IsolationLevel int
+
+const (
+	// ReadUncommitted returns every appended message.
+	ReadUncommitted IsolationLevel = iota
+	// ReadCommitted stops at the first message of a transaction that is still open
+	// and skips messages of aborted transactions.
+	ReadCommitted
+)
+
+var (
+	ErrUnknownTransaction = errors.New("transaction does not exist")
+	ErrTransactionAborted = errors.New("transaction was aborted")
+)
+
+// transaction is an open transaction along with the messages it holds back from
+// push consumers until it commits.
+type transaction struct {
+	core.Transaction
+	messages []pendingPublish
+}
+
+type pendingPublish struct {
+	topic string
+	msg   *core.Message
+}
+
+// BeginTransaction opens a transaction and returns its ID. It is aborted if it is
+// still open after timeout, or the manager's TransactionTimeout when timeout is 0.
+func (b *Manager) BeginTransaction(timeout time.Duration) string {
+	b.Mu.Lock()
+	defer b.Mu.Unlock()
+
+	if timeout <= 0 {
+		timeout = b.TransactionTimeout
+	}
+
+	id := uuid.NewString()
+	b.transactions[id] = &transaction{
+		Transaction: core.Transaction{
+			ID:       id,
+			State:    core.TransactionOpen,
+			Deadline: time.Now().Add(timeout),
+		},
+	}
+
+	return id
+}
+
+// PublishInTransaction appends msg to the topic as part of transaction txnID. The
+// message is readable right away at ReadUncommitted, but push consumers only get it
+// once the transaction commits.
+func (b *Manager) PublishInTransaction(txnID, topic string, msg *core.Message) error {
+	b.Mu.Lock()
+	defer b.Mu.Unlock()
+
+	txn, err := b.openTransaction(txnID)
+	if err != nil {
+		return err
+	}
+
+	msg.Metadata[MetaTransactionID] = txnID
+	if _, err := b.appendMessage(topic, msg, txn); err != nil {
+		return err
+	}
+
+	for i := range txn.Partitions {
+		if p := &txn.Partitions[i]; p.Topic == topic && p.Partition == msg.Partition {
+			p.LastOffset = msg.Offset
+		}
+	}
+	txn.messages = append(txn.messages, pendingPublish{topic: topic, msg: msg})
+
+	return nil
+}
+
+// AddOffsetsToTransaction commits offset for consumerID, a consumer or group, on the
+// partition when transaction txnID commits. A later call for the same partition and
+// consumer replaces the earlier offset.
+func (b *Manager) AddOffsetsToTransaction(txnID, topic string, partition int, consumerID string, offset int) error {
+	b.Mu.Lock()
+	defer b.Mu.Unlock()
+
+	txn, err := b.openTransaction(txnID)
+	if err != nil {
+		return err
+	}
+
+	latest, err := b.Repo.GetLatestOffset(topic, partition)
+	if err != nil {
+		return err
+	}
+	if offset < 0 || offset > latest {
+		return fmt.Errorf("offset %d is outside partition %d of topic %q, latest is %d", offset, partition, topic, latest)
+	}
+
+	for i := range txn.Offsets {
+		if o := &txn.Offsets[i]; o.Topic == topic && o.Partition == partition && o.ConsumerID == consumerID {
+			o.Offset = offset
+			return nil
+		}
+	}
+	txn.Offsets = append(txn.Offsets, core.TransactionOffset{
+		Topic:      topic,
+		Partition:  partition,
+		ConsumerID: consumerID,
+		Offset:     offset,
+	})
+
+	return nil
+}
+
+// CommitTransaction makes the transaction's messages visible to read-committed
+// consumers, commits its offsets and pushes its messages to live consumers. An
+// offset that fails to commit is reported, the messages are committed regardless.
+func (b *Manager) CommitTransaction(txnID string) error {
+	b.Mu.Lock()
+	defer b.unlockAndSendBlocked()
+
+	txn, err := b.openTransaction(txnID)
+	if err != nil {
+		return err
+	}
+
+	// once the record says committing, a restart finishes the commit instead of
+	// aborting it and hiding its messages
+	if len(txn.Partitions) > 0 || len(txn.Offsets) > 0 {
+		txn.State = core.TransactionCommitting
+		if err := b.Repo.SaveTransaction(txn.Transaction); err != nil {
+			txn.State = core.TransactionOpen
+			return err
+		}
+	}
+	delete(b.transactions, txnID)
+
+	// the messages are committed even when an offset is not, so they are pushed
+	// either way
+	err = b.finishCommit(txn.Transaction)
+
+	for _, pending := range txn.messages {
+		if topicEntry, _, err := b.topic(pending.topic); err == nil {
+			b.fanOut(topicEntry, pending.msg)
+		}
+	}
+	b.signalTransactionEnd(txn.Transaction)
+
+	return err
+}
+
+// AbortTransaction drops the transaction's offsets and hides its messages from
+// read-committed consumers for as long as they are retained.
+func (b *Manager) AbortTransaction(txnID string) error {
+	b.Mu.Lock()
+	defer b.Mu.Unlock()
+
+	txn, err := b.openTransaction(txnID)
+	if err != nil {
+		return err
+	}
+
+	return b.abort(txn.Transaction)
+}
+
+// ExpireTransactions aborts every transaction open past its deadline and forgets
+// aborted transactions whose messages have all been removed by retention. It
+// returns how many transactions were aborted.
+func (b *Manager) ExpireTransactions(now time.Time) int {
+	b.Mu.Lock()
+	defer b.Mu.Unlock()
+
+	expired := 0
+	for _, txn := range b.transactions {
+		if now.Before(txn.Deadline) {
+			continue
+		}
+		if err := b.abort(txn.Transaction); err == nil {
+			expired++
+		}
+	}
+
+	for id, txn := range b.aborted {
+		if b.retained(txn) {
+			continue
+		}
+		if err := b.Repo.DeleteTransaction(id); err == nil {
+			delete(b.aborted, id)
+		}
+	}
+
+	return expired
+}
+
+// RestoreTransactions picks up the transactions saved in the repository. Commits
+// that were under way are finished and transactions that were still open are
+// aborted, their producers are gone.
+func (b *Manager) RestoreTransactions() error {
+	txns, err := b.Repo.ListTransactions()
+	if err != nil {
+		return err
+	}
+
+	b.Mu.Lock()
+	defer b.Mu.Unlock()
+
+	for _, txn := range txns {
+		switch txn.State {
+		case core.TransactionCommitting:
+			if err := b.finishCommit(txn); err != nil {
+				return err
+			}
+		case core.TransactionOpen:
+			for i := range txn.Partitions {
+				p := &txn.Partitions[i]
+				// anything appended after the transaction's first message may be its
+				if latest, err := b.Repo.GetLatestOffset(p.Topic, p.Partition); err == nil {
+					p.LastOffset = latest - 1
+				}
+			}
+			if err := b.abort(txn); err != nil {
+				return err
+			}
+		case core.TransactionAborted:
+			b.aborted[txn.ID] = txn
+		}
+	}
+
+	return nil
+}
+
+// openTransaction looks up an open transaction, the caller must hold b.Mu.
+func (b *Manager) openTransaction(txnID string) (*transaction, error) {
+	if txn, ok := b.transactions[txnID]; ok {
+		return txn, nil
+	}
+	if _, ok := b.aborted[txnID]; ok {
+		return nil, fmt.Errorf("%w: %q", ErrTransactionAborted, txnID)
+	}
+	return nil, fmt.Errorf("%w: %q", ErrUnknownTransaction, txnID)
+}
+
+// addTransactionPartition records the partition on txn before its first message
+// there is appended, so a restart can tell which messages belong to it. The caller
+// must hold b.Mu.
+func (b *Manager) addTransactionPartition(txn *transaction, topic string, partition int) error {
+	for _, p := range txn.Partitions {
+		if p.Topic == topic && p.Partition == partition {
+			return nil
+		}
+	}
+
+	latest, err := b.Repo.GetLatestOffset(topic, partition)
+	if err != nil {
+		return err
+	}
+	txn.Partitions = append(txn.Partitions, core.TransactionPartition{
+		Topic:       topic,
+		Partition:   partition,
+		FirstOffset: latest,
+		LastOffset:  latest - 1,
+	})
+	if err := b.Repo.SaveTransaction(txn.Transaction); err != nil {
+		txn.Partitions = txn.Partitions[:len(txn.Partitions)-1]
+		return err
+	}
+
+	return nil
+}
+
+// finishCommit commits the transaction's offsets and forgets the transaction, the
+// caller must hold b.Mu. Offsets of a topic or partition that no longer exists are
+// skipped and an offset retention has passed is committed at the low watermark.
+// When any other offset fails the saved transaction is kept, so a restart commits
+// its offsets again.
+func (b *Manager) finishCommit(txn core.Transaction) error {
+	var errs []error
+	for _, o := range txn.Offsets {
+		err := b.Repo.CommitOffset(o.Topic, o.Partition, o.ConsumerID, o.Offset)
+		var rangeErr *repository.OffsetOutOfRangeError
+		if errors.As(err, &rangeErr) {
+			err = b.Repo.CommitOffset(o.Topic, o.Partition, o.ConsumerID, rangeErr.LowWatermark)
+		}
+		if err != nil && !strings.Contains(err.Error(), "does not exist") {
+			errs = append(errs, err)
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		return err
+	}
+
+	return b.Repo.DeleteTransaction(txn.ID)
+}
+
+// abort marks txn aborted, the caller must hold b.Mu.
+func (b *Manager) abort(txn core.Transaction) error {
+	txn.State = core.TransactionAborted
+	txn.Offsets = nil
+
+	if len(txn.Partitions) == 0 {
+		// nothing was appended, there is nothing to hide
+		delete(b.transactions, txn.ID)
+		return b.Repo.DeleteTransaction(txn.ID)
+	}
+
+	if err := b.Repo.SaveTransaction(txn); err != nil {
+		return err
+	}
+	delete(b.transactions, txn.ID)
+	b.aborted[txn.ID] = txn
+	b.signalTransactionEnd(txn)
+
+	return nil
+}
+
+// signalTransactionEnd wakes read-committed fetches waiting on the topics txn wrote
+// to, the messages after its first ones may be readable now. The caller must hold
+// b.Mu.
+func (b *Manager) signalTransactionEnd(txn core.Transaction) {
+	for _, p := range txn.Partitions {
+		b.signalAppend(p.Topic)
+	}
+}
+
+// retained reports whether any of txn's messages are still in the log, the caller
+// must hold b.Mu.
+func (b *Manager) retained(txn core.Transaction) bool {
+	for _, p := range txn.Partitions {
+		earliest, err := b.Repo.GetEarliestOffset(p.Topic, p.Partition)
+		if err == nil && earliest <= p.LastOffset {
+			return true
+		}
+	}
+	return false
+}
+
+// abortedFrom reports whether an aborted transaction has messages on the partition
+// at or after offset. The caller must hold b.Mu.
+func (b *Manager) abortedFrom(topic string, partition, offset int) bool {
+	for _, txn := range b.aborted {
+		for _, p := range txn.Partitions {
+			if p.Topic == topic && p.Partition == partition && p.LastOffset >= offset {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// stableOffset returns the offset of the first message on the partition that
+// belongs to a transaction still open, or -1 when there is none. The caller must
+// hold b.Mu.
+func (b *Manager) stableOffset(topic string, partition int) int {
+	stable := -1
+	for _, txn := range b.transactions {
+		for _, p := range txn.Partitions {
+			if p.Topic == topic && p.Partition == partition && p.LastOffset >= p.FirstOffset && (stable < 0 || p.FirstOffset < stable) {
+				stable = p.FirstOffset
+			}
+		}
+	}
+	return stable
+}
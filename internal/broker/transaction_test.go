@@ -0,0 +1,335 @@
+package broker
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/codytheroux96/go-mq/internal/core"
+	"github.com/codytheroux96/go-mq/internal/repository"
+)
+
+func TestTransactionIsolation(t *testing.T) {
+	tests := []struct {
+		name            string
+		end             func(m *Manager, txnID string) error
+		expectCommitted []string
+		expectAdvanced  int
+	}{
+		{"Open transaction", nil, []string{"before"}, 1},
+		{"Committed transaction", (*Manager).CommitTransaction, []string{"before", "in txn", "after"}, 3},
+		{"Aborted transaction", (*Manager).AbortTransaction, []string{"before", "after"}, 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := repository.NewInMemoryRepo()
+			if err := repo.CreateTopic("orders", core.TopicConfig{}); err != nil {
+				t.Fatalf("failed to create topic: %v", err)
+			}
+			manager := NewManager(repo)
+
+			if err := manager.Publish("orders", core.NewMessage([]byte("before"), "p1")); err != nil {
+				t.Fatalf("failed to publish: %v", err)
+			}
+			txnID := manager.BeginTransaction(0)
+			if err := manager.PublishInTransaction(txnID, "orders", core.NewMessage([]byte("in txn"), "p1")); err != nil {
+				t.Fatalf("failed to publish in transaction: %v", err)
+			}
+			if err := manager.Publish("orders", core.NewMessage([]byte("after"), "p1")); err != nil {
+				t.Fatalf("failed to publish: %v", err)
+			}
+			if tt.end != nil {
+				if err := tt.end(manager, txnID); err != nil {
+					t.Fatalf("failed to end transaction: %v", err)
+				}
+			}
+
+			uncommitted, _, err := manager.Fetch("orders", 0, "", "c1", 10, ReadUncommitted)
+			if err != nil {
+				t.Fatalf("failed to fetch: %v", err)
+			}
+			if len(uncommitted) != 3 {
+				t.Fatalf("expected all 3 messages read uncommitted, got %d", len(uncommitted))
+			}
+
+			committed, advanced, err := manager.Fetch("orders", 0, "", "c2", 10, ReadCommitted)
+			if err != nil {
+				t.Fatalf("failed to fetch: %v", err)
+			}
+			if len(committed) != len(tt.expectCommitted) {
+				t.Fatalf("expected %d messages read committed, got %d", len(tt.expectCommitted), len(committed))
+			}
+			for i, msg := range committed {
+				if string(msg.Body) != tt.expectCommitted[i] {
+					t.Fatalf("expected message %d to be %q, got %q", i, tt.expectCommitted[i], msg.Body)
+				}
+			}
+			if advanced != tt.expectAdvanced {
+				t.Fatalf("expected to move past %d positions, got %d", tt.expectAdvanced, advanced)
+			}
+		})
+	}
+}
+
+func TestTransactionOffsets(t *testing.T) {
+	tests := []struct {
+		name         string
+		commit       bool
+		expectOffset int
+	}{
+		{"Commit applies the offsets", true, 2},
+		{"Abort drops the offsets", false, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := repository.NewInMemoryRepo()
+			for _, topic := range []string{"orders", "invoices"} {
+				if err := repo.CreateTopic(topic, core.TopicConfig{}); err != nil {
+					t.Fatalf("failed to create topic: %v", err)
+				}
+			}
+			manager := NewManager(repo)
+			for range 2 {
+				if err := manager.Publish("orders", core.NewMessage([]byte("order"), "p1")); err != nil {
+					t.Fatalf("failed to publish: %v", err)
+				}
+			}
+
+			txnID := manager.BeginTransaction(0)
+			if err := manager.PublishInTransaction(txnID, "invoices", core.NewMessage([]byte("invoice"), "billing")); err != nil {
+				t.Fatalf("failed to publish in transaction: %v", err)
+			}
+			if err := manager.AddOffsetsToTransaction(txnID, "orders", 0, "billing", 3); err == nil {
+				t.Fatalf("expected an offset past the end to be rejected")
+			}
+			if err := manager.AddOffsetsToTransaction(txnID, "orders", 0, "billing", 2); err != nil {
+				t.Fatalf("failed to add offsets: %v", err)
+			}
+
+			if offset, _ := repo.GetOffset("orders", 0, "billing"); offset != 0 {
+				t.Fatalf("expected no offset committed before the transaction ends, got %d", offset)
+			}
+
+			end := manager.AbortTransaction
+			if tt.commit {
+				end = manager.CommitTransaction
+			}
+			if err := end(txnID); err != nil {
+				t.Fatalf("failed to end transaction: %v", err)
+			}
+
+			if offset, _ := repo.GetOffset("orders", 0, "billing"); offset != tt.expectOffset {
+				t.Fatalf("expected offset %d, got %d", tt.expectOffset, offset)
+			}
+			if err := manager.CommitTransaction(txnID); err == nil {
+				t.Fatalf("expected the ended transaction to be closed")
+			}
+		})
+	}
+}
+
+func TestCommitTransactionOffsetErrors(t *testing.T) {
+	repo := &failingRepo{Repository: repository.NewInMemoryRepo()}
+	for _, topic := range []string{"orders", "returns", "invoices"} {
+		if err := repo.CreateTopic(topic, core.TopicConfig{}); err != nil {
+			t.Fatalf("failed to create topic: %v", err)
+		}
+	}
+	manager := NewManager(repo)
+
+	begin := func(offsetTopic string) string {
+		txnID := manager.BeginTransaction(0)
+		if err := manager.PublishInTransaction(txnID, "invoices", core.NewMessage([]byte("invoice"), "billing")); err != nil {
+			t.Fatalf("failed to publish in transaction: %v", err)
+		}
+		if err := manager.AddOffsetsToTransaction(txnID, offsetTopic, 0, "billing", 0); err != nil {
+			t.Fatalf("failed to add offsets: %v", err)
+		}
+		return txnID
+	}
+
+	// the offsets of a topic deleted in the meantime are skipped
+	txnID := begin("returns")
+	if err := repo.DeleteTopic("returns"); err != nil {
+		t.Fatalf("failed to delete topic: %v", err)
+	}
+	if err := manager.CommitTransaction(txnID); err != nil {
+		t.Fatalf("expected the offsets of a deleted topic to be skipped, got %v", err)
+	}
+
+	// any other failure is reported and the transaction is kept for a restart
+	txnID = begin("orders")
+	repo.failCommit = true
+	if err := manager.CommitTransaction(txnID); err == nil {
+		t.Fatalf("expected the failed offset commit to be reported")
+	}
+	saved, err := repo.ListTransactions()
+	if err != nil {
+		t.Fatalf("failed to list transactions: %v", err)
+	}
+	if len(saved) != 1 || saved[0].ID != txnID || saved[0].State != core.TransactionCommitting {
+		t.Fatalf("expected the committing transaction to stay saved, got %+v", saved)
+	}
+
+	repo.failCommit = false
+	if err := NewManager(repo).RestoreTransactions(); err != nil {
+		t.Fatalf("failed to restore transactions: %v", err)
+	}
+	if saved, _ := repo.ListTransactions(); len(saved) != 0 {
+		t.Fatalf("expected the restart to finish the commit, got %+v", saved)
+	}
+}
+
+func TestCommitTransactionWithoutOffsetsSurvivesRestart(t *testing.T) {
+	repo := &failingRepo{Repository: repository.NewInMemoryRepo()}
+	if err := repo.CreateTopic("orders", core.TopicConfig{}); err != nil {
+		t.Fatalf("failed to create topic: %v", err)
+	}
+	manager := NewManager(repo)
+
+	txnID := manager.BeginTransaction(0)
+	if err := manager.PublishInTransaction(txnID, "orders", core.NewMessage([]byte("order"), "p1")); err != nil {
+		t.Fatalf("failed to publish in transaction: %v", err)
+	}
+
+	// the record outlives a commit that could not clean it up
+	repo.failDelete = true
+	if err := manager.CommitTransaction(txnID); err == nil {
+		t.Fatalf("expected the failed delete to be reported")
+	}
+	repo.failDelete = false
+
+	restarted := NewManager(repo)
+	if err := restarted.RestoreTransactions(); err != nil {
+		t.Fatalf("failed to restore transactions: %v", err)
+	}
+	messages, _, err := restarted.Fetch("orders", 0, "", "c1", 10, ReadCommitted)
+	if err != nil {
+		t.Fatalf("failed to fetch: %v", err)
+	}
+	if len(messages) != 1 || string(messages[0].Body) != "order" {
+		t.Fatalf("expected the committed message to stay visible after a restart, got %d messages", len(messages))
+	}
+	if saved, _ := repo.ListTransactions(); len(saved) != 0 {
+		t.Fatalf("expected the restart to finish the commit, got %+v", saved)
+	}
+}
+
+func TestTransactionNotOpen(t *testing.T) {
+	repo := repository.NewInMemoryRepo()
+	if err := repo.CreateTopic("orders", core.TopicConfig{}); err != nil {
+		t.Fatalf("failed to create topic: %v", err)
+	}
+	manager := NewManager(repo)
+
+	aborted := manager.BeginTransaction(0)
+	if err := manager.PublishInTransaction(aborted, "orders", core.NewMessage([]byte("order"), "p1")); err != nil {
+		t.Fatalf("failed to publish in transaction: %v", err)
+	}
+	if err := manager.AbortTransaction(aborted); err != nil {
+		t.Fatalf("failed to abort: %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		txnID     string
+		expectErr error
+	}{
+		{"Unknown transaction", "missing", ErrUnknownTransaction},
+		{"Aborted transaction", aborted, ErrTransactionAborted},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := manager.PublishInTransaction(tt.txnID, "orders", core.NewMessage([]byte("order"), "p1"))
+			if !errors.Is(err, tt.expectErr) {
+				t.Fatalf("expected %v, got %v", tt.expectErr, err)
+			}
+		})
+	}
+}
+
+func TestExpireTransactions(t *testing.T) {
+	repo := repository.NewInMemoryRepo()
+	if err := repo.CreateTopic("orders", core.TopicConfig{}); err != nil {
+		t.Fatalf("failed to create topic: %v", err)
+	}
+	manager := NewManager(repo)
+
+	short := manager.BeginTransaction(time.Second)
+	long := manager.BeginTransaction(time.Hour)
+	for _, txnID := range []string{short, long} {
+		if err := manager.PublishInTransaction(txnID, "orders", core.NewMessage([]byte("order"), "p1")); err != nil {
+			t.Fatalf("failed to publish in transaction: %v", err)
+		}
+	}
+
+	if expired := manager.ExpireTransactions(time.Now().Add(time.Minute)); expired != 1 {
+		t.Fatalf("expected 1 transaction to expire, got %d", expired)
+	}
+	if err := manager.CommitTransaction(short); !errors.Is(err, ErrTransactionAborted) {
+		t.Fatalf("expected the expired transaction to be aborted, got %v", err)
+	}
+	if err := manager.CommitTransaction(long); err != nil {
+		t.Fatalf("failed to commit the open transaction: %v", err)
+	}
+
+	committed, _, err := manager.Fetch("orders", 0, "", "c1", 10, ReadCommitted)
+	if err != nil {
+		t.Fatalf("failed to fetch: %v", err)
+	}
+	if len(committed) != 1 || committed[0].Metadata[MetaTransactionID] != long {
+		t.Fatalf("expected only the committed transaction's message, got %d messages", len(committed))
+	}
+}
+
+func TestRestoreTransactions(t *testing.T) {
+	repo := repository.NewInMemoryRepo()
+	if err := repo.CreateTopic("orders", core.TopicConfig{}); err != nil {
+		t.Fatalf("failed to create topic: %v", err)
+	}
+
+	manager := NewManager(repo)
+	open := manager.BeginTransaction(0)
+	if err := manager.PublishInTransaction(open, "orders", core.NewMessage([]byte("open"), "p1")); err != nil {
+		t.Fatalf("failed to publish in transaction: %v", err)
+	}
+	// a commit that was cut short after its decision was saved
+	if err := repo.SaveTransaction(core.Transaction{
+		ID:      "committing",
+		State:   core.TransactionCommitting,
+		Offsets: []core.TransactionOffset{{Topic: "orders", Partition: 0, ConsumerID: "billing", Offset: 1}},
+	}); err != nil {
+		t.Fatalf("failed to save transaction: %v", err)
+	}
+
+	restarted := NewManager(repo)
+	if err := restarted.RestoreTransactions(); err != nil {
+		t.Fatalf("failed to restore transactions: %v", err)
+	}
+
+	if err := restarted.CommitTransaction(open); !errors.Is(err, ErrTransactionAborted) {
+		t.Fatalf("expected the open transaction to be aborted on restart, got %v", err)
+	}
+	if offset, _ := repo.GetOffset("orders", 0, "billing"); offset != 1 {
+		t.Fatalf("expected the interrupted commit to be finished, got offset %d", offset)
+	}
+
+	committed, advanced, err := restarted.Fetch("orders", 0, "", "c1", 10, ReadCommitted)
+	if err != nil {
+		t.Fatalf("failed to fetch: %v", err)
+	}
+	if len(committed) != 0 || advanced != 1 {
+		t.Fatalf("expected the aborted message to be skipped, got %d messages past %d positions", len(committed), advanced)
+	}
+
+	txns, err := repo.ListTransactions()
+	if err != nil {
+		t.Fatalf("failed to list transactions: %v", err)
+	}
+	if len(txns) != 1 || txns[0].State != core.TransactionAborted {
+		t.Fatalf("expected only the aborted transaction to be kept, got %+v", txns)
+	}
+}
@@ -0,0 +1,141 @@
+package broker
+
+import (
+	"context"
+	"slices"
+	"time"
+)
+
+// WaitForMessages blocks until a Fetch by the consumer, or its group, on the
+// partitions would return at least min messages, or until ctx is done. It wakes on
+// appends to the topic, ended transactions and redeliveries rather than polling.
+// When counting fails, for instance on a missing partition, it returns right away
+// and leaves the error to the Fetch that follows.
+func (b *Manager) WaitForMessages(ctx context.Context, topic string, partitions []int, groupID, consumerID string, min int, isolation IsolationLevel) error {
+	target := pendingKey{Topic: topic, Target: consumerID}
+	if groupID != "" {
+		target.Target = groupID
+	}
+	b.Mu.Lock()
+	b.polling[target]++
+	b.Mu.Unlock()
+	defer func() {
+		b.Mu.Lock()
+		if b.polling[target]--; b.polling[target] == 0 {
+			delete(b.polling, target)
+		}
+		b.Mu.Unlock()
+	}()
+
+	for {
+		b.Mu.Lock()
+		signal := b.appendSignal(topic)
+		ready, nextDue, err := b.ready(topic, partitions, groupID, consumerID, min, isolation, time.Now())
+		b.Mu.Unlock()
+		if err != nil || ready >= min {
+			return nil
+		}
+
+		// a delayed redelivery falls due without anything being appended
+		var due <-chan time.Time
+		var timer *time.Timer
+		if !nextDue.IsZero() {
+			timer = time.NewTimer(time.Until(nextDue))
+			due = timer.C
+		}
+
+		select {
+		case <-ctx.Done():
+			err = ctx.Err()
+		case <-signal:
+		case <-due:
+		}
+		if timer != nil {
+			timer.Stop()
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// appendSignal returns a channel that is closed the next time messages become
+// available on topic. The caller must hold b.Mu.
+func (b *Manager) appendSignal(topic string) <-chan struct{} {
+	signal, ok := b.appended[topic]
+	if !ok {
+		signal = make(chan struct{})
+		b.appended[topic] = signal
+	}
+	return signal
+}
+
+// signalAppend wakes every fetch waiting on topic. The caller must hold b.Mu.
+func (b *Manager) signalAppend(topic string) {
+	if signal, ok := b.appended[topic]; ok {
+		close(signal)
+		delete(b.appended, topic)
+	}
+}
+
+// ready counts the messages Fetch would return on the partitions right now, and
+// returns when the next delayed redelivery among them falls due. When the target
+// has a filter, or reads committed past messages of aborted transactions, the log
+// is read to count the messages Fetch would return, stopping at limit on each
+// partition or after maxReadScan positions. The caller must hold b.Mu.
+func (b *Manager) ready(topic string, partitions []int, groupID, consumerID string, limit int, isolation IsolationLevel, now time.Time) (int, time.Time, error) {
+	offsetKey := consumerID
+	target := pendingKey{Topic: topic, Target: consumerID}
+	if groupID != "" {
+		offsetKey = groupID
+		target.Target = groupID
+	}
+
+	ready := 0
+	var nextDue time.Time
+	for _, d := range b.pending[target] {
+		if !slices.Contains(partitions, d.Message.Partition) {
+			continue
+		}
+		if !now.Before(d.NotBefore) {
+			ready++
+		} else if nextDue.IsZero() || d.NotBefore.Before(nextDue) {
+			nextDue = d.NotBefore
+		}
+	}
+
+	f := b.filters[target]
+	for _, partition := range partitions {
+		committed, err := b.Repo.GetOffset(topic, partition, offsetKey)
+		if err != nil {
+			return 0, time.Time{}, err
+		}
+		if f != nil || (isolation == ReadCommitted && b.abortedFrom(topic, partition, committed)) {
+			passed, advanced, err := b.read(topic, partition, committed, limit, isolation, f)
+			if err != nil {
+				return 0, time.Time{}, err
+			}
+			ready += len(passed)
+			// a long run of messages the fetch skips counts as ready, so the fetch
+			// that follows moves the committed offset past it
+			if advanced >= maxReadScan {
+				ready = max(ready, limit)
+			}
+			continue
+		}
+		end, err := b.Repo.GetLatestOffset(topic, partition)
+		if err != nil {
+			return 0, time.Time{}, err
+		}
+		if isolation == ReadCommitted {
+			if stable := b.stableOffset(topic, partition); stable >= 0 && stable < end {
+				end = stable
+			}
+		}
+		if end > committed {
+			ready += end - committed
+		}
+	}
+
+	return ready, nextDue, nil
+}
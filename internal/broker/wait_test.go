@@ -0,0 +1,106 @@
+package broker
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/codytheroux96/go-mq/internal/core"
+	"github.com/codytheroux96/go-mq/internal/repository"
+)
+
+func TestWaitForMessages(t *testing.T) {
+	publish := func(m *Manager) {
+		m.Publish("orders", core.NewMessage([]byte("order"), "p1"))
+	}
+
+	tests := []struct {
+		name      string
+		ready     int
+		min       int
+		isolation IsolationLevel
+		later     func(m *Manager, txnID string)
+		cancel    bool
+		expectErr error
+	}{
+		{"Enough messages already", 2, 2, ReadUncommitted, nil, false, nil},
+		{"Woken by an append", 0, 1, ReadUncommitted, func(m *Manager, _ string) { publish(m) }, false, nil},
+		{"Too few messages arrive", 0, 2, ReadUncommitted, func(m *Manager, _ string) { publish(m) }, false, context.DeadlineExceeded},
+		{"Nothing arrives", 0, 1, ReadUncommitted, nil, false, context.DeadlineExceeded},
+		{"Client goes away", 0, 1, ReadUncommitted, nil, true, context.Canceled},
+		{"Open transaction is not ready", 0, 1, ReadCommitted, nil, false, context.DeadlineExceeded},
+		{"Woken by a commit", 0, 1, ReadCommitted, func(m *Manager, txnID string) { m.CommitTransaction(txnID) }, false, nil},
+		{"Aborted transaction is not ready", 0, 1, ReadCommitted, func(m *Manager, txnID string) { m.AbortTransaction(txnID) }, false, context.DeadlineExceeded},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := repository.NewInMemoryRepo()
+			if err := repo.CreateTopic("orders", core.TopicConfig{}); err != nil {
+				t.Fatalf("failed to create topic: %v", err)
+			}
+			manager := NewManager(repo)
+			for range tt.ready {
+				publish(manager)
+			}
+			txnID := manager.BeginTransaction(0)
+			if err := manager.PublishInTransaction(txnID, "orders", core.NewMessage([]byte("in txn"), "p1")); err != nil {
+				t.Fatalf("failed to publish in transaction: %v", err)
+			}
+			// the transactional message counts at ReadUncommitted, so wait for one more
+			min := tt.min
+			if tt.isolation == ReadUncommitted {
+				min++
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+			defer cancel()
+			if tt.later != nil {
+				time.AfterFunc(20*time.Millisecond, func() { tt.later(manager, txnID) })
+			}
+			if tt.cancel {
+				time.AfterFunc(20*time.Millisecond, cancel)
+			}
+
+			start := time.Now()
+			err := manager.WaitForMessages(ctx, "orders", []int{0}, "", "c1", min, tt.isolation)
+			if !errors.Is(err, tt.expectErr) {
+				t.Fatalf("expected %v, got %v", tt.expectErr, err)
+			}
+			if tt.expectErr == nil && time.Since(start) >= 200*time.Millisecond {
+				t.Fatalf("expected to return before the wait expired")
+			}
+		})
+	}
+}
+
+func TestWaitForDelayedRedelivery(t *testing.T) {
+	repo := repository.NewInMemoryRepo()
+	if err := repo.CreateTopic("orders", core.TopicConfig{}); err != nil {
+		t.Fatalf("failed to create topic: %v", err)
+	}
+	manager := NewManager(repo)
+	if err := manager.Publish("orders", core.NewMessage([]byte("order"), "p1")); err != nil {
+		t.Fatalf("failed to publish: %v", err)
+	}
+
+	messages, _, err := manager.Fetch("orders", 0, "", "c1", 10, ReadUncommitted)
+	if err != nil || len(messages) != 1 {
+		t.Fatalf("failed to fetch: %v", err)
+	}
+	repo.CommitOffset("orders", 0, "c1", 1)
+	if _, err := manager.Nack("orders", "c1", messages[0].ID, true, 50*time.Millisecond, "retry later"); err != nil {
+		t.Fatalf("failed to nack: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	start := time.Now()
+	if err := manager.WaitForMessages(ctx, "orders", []int{0}, "", "c1", 1, ReadUncommitted); err != nil {
+		t.Fatalf("expected the redelivery to fall due, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 40*time.Millisecond {
+		t.Fatalf("expected to wait for the nack delay, took %v", elapsed)
+	}
+}
@@ -1,15 +1,120 @@
 package core
 
+import (
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	DefaultInboxSize    = 10
+	DefaultBlockTimeout = time.Second
+)
+
+// SlowConsumerPolicy decides what happens to a message pushed to a consumer whose
+// inbox is full.
+type SlowConsumerPolicy string
+
+const (
+	// DropNewest skips the consumer for the new message.
+	DropNewest SlowConsumerPolicy = "drop_newest"
+	// DropOldest makes room by discarding the oldest message in the inbox.
+	DropOldest SlowConsumerPolicy = "drop_oldest"
+	// Block holds the publisher until the inbox has room or BlockTimeout passes,
+	// then drops the new message.
+	Block SlowConsumerPolicy = "block"
+	// Disconnect drops the new message and disconnects the consumer.
+	Disconnect SlowConsumerPolicy = "disconnect"
+)
+
+// FlowControl is how many messages a consumer's inbox holds and what happens once
+// it is full. The zero value is a DefaultInboxSize inbox that drops new messages.
+type FlowControl struct {
+	InboxSize    int
+	Policy       SlowConsumerPolicy
+	BlockTimeout time.Duration
+}
+
+func (f FlowControl) Validate() error {
+	switch f.Policy {
+	case "", DropNewest, DropOldest, Block, Disconnect:
+	default:
+		return fmt.Errorf("unknown slow consumer policy %q", f.Policy)
+	}
+	if f.InboxSize < 0 || f.BlockTimeout < 0 {
+		return fmt.Errorf("inbox size and block timeout cannot be negative")
+	}
+	return nil
+}
+
+func (f FlowControl) withDefaults() FlowControl {
+	if f.InboxSize == 0 {
+		f.InboxSize = DefaultInboxSize
+	}
+	if f.Policy == "" {
+		f.Policy = DropNewest
+	}
+	if f.Policy == Block && f.BlockTimeout == 0 {
+		f.BlockTimeout = DefaultBlockTimeout
+	}
+	return f
+}
+
 type Consumer struct {
 	ID      string
 	Inbox   chan *Message
 	Offsets map[string]int // Topic name -> index of last read message
+	Flow    FlowControl
+	Dropped atomic.Uint64 // messages its slow consumer policy kept from it
 }
 
-func NewConsumer(id string) *Consumer {
+func NewConsumer(id string, flow FlowControl) *Consumer {
+	flow = flow.withDefaults()
 	return &Consumer{
 		ID:      id,
-		Inbox:   make(chan *Message, 10),
+		Inbox:   make(chan *Message, flow.InboxSize),
 		Offsets: make(map[string]int),
+		Flow:    flow,
+	}
+}
+
+// Offer puts msg in the inbox, applying the consumer's slow consumer policy when it
+// is full. It reports whether msg was delivered and how many messages were dropped,
+// msg itself or the oldest one in the inbox. Senders must not offer concurrently.
+func (c *Consumer) Offer(msg *Message) (bool, int) {
+	select {
+	case c.Inbox <- msg:
+		return true, 0
+	default:
 	}
+
+	switch c.Flow.Policy {
+	case DropOldest:
+		dropped := 0
+		select {
+		case <-c.Inbox:
+			dropped++
+		default:
+			// the reader emptied it in the meantime
+		}
+		select {
+		case c.Inbox <- msg:
+			c.Dropped.Add(uint64(dropped))
+			return true, dropped
+		default:
+			c.Dropped.Add(uint64(dropped + 1))
+			return false, dropped + 1
+		}
+	case Block:
+		timer := time.NewTimer(c.Flow.BlockTimeout)
+		defer timer.Stop()
+		select {
+		case c.Inbox <- msg:
+			return true, 0
+		case <-timer.C:
+		}
+	}
+
+	c.Dropped.Add(1)
+	return false, 1
 }
@@ -0,0 +1,85 @@
+package core
+
+import (
+	"testing"
+	"time"
+)
+
+func TestConsumerOffer(t *testing.T) {
+	tests := []struct {
+		name            string
+		flow            FlowControl
+		drain           bool
+		expectDelivered bool
+		expectDropped   int
+		expectInbox     []string
+	}{
+		{"Room in the inbox", FlowControl{InboxSize: 3}, false, true, 0, []string{"a", "b", "new"}},
+		{"Drop newest", FlowControl{InboxSize: 2}, false, false, 1, []string{"a", "b"}},
+		{"Drop oldest", FlowControl{InboxSize: 2, Policy: DropOldest}, false, true, 1, []string{"b", "new"}},
+		{"Block times out", FlowControl{InboxSize: 2, Policy: Block, BlockTimeout: 20 * time.Millisecond}, false, false, 1, []string{"a", "b"}},
+		{"Block until the reader drains", FlowControl{InboxSize: 2, Policy: Block, BlockTimeout: time.Second}, true, true, 0, []string{"b", "new"}},
+		{"Disconnect", FlowControl{InboxSize: 2, Policy: Disconnect}, false, false, 1, []string{"a", "b"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			consumer := NewConsumer("c1", tt.flow)
+			consumer.Inbox <- &Message{ID: "a"}
+			consumer.Inbox <- &Message{ID: "b"}
+
+			drained := make(chan *Message, 1)
+			if tt.drain {
+				time.AfterFunc(20*time.Millisecond, func() { drained <- <-consumer.Inbox })
+			}
+
+			delivered, dropped := consumer.Offer(&Message{ID: "new"})
+			if delivered != tt.expectDelivered || dropped != tt.expectDropped {
+				t.Fatalf("expected delivered %v with %d dropped, got %v with %d", tt.expectDelivered, tt.expectDropped, delivered, dropped)
+			}
+			if consumer.Dropped.Load() != uint64(tt.expectDropped) {
+				t.Fatalf("expected the consumer to count %d drops, got %d", tt.expectDropped, consumer.Dropped.Load())
+			}
+			if tt.drain {
+				if msg := <-drained; msg.ID != "a" {
+					t.Fatalf("expected the reader to take the oldest message, got %q", msg.ID)
+				}
+			}
+
+			close(consumer.Inbox)
+			var inbox []string
+			for msg := range consumer.Inbox {
+				inbox = append(inbox, msg.ID)
+			}
+			if len(inbox) != len(tt.expectInbox) {
+				t.Fatalf("expected inbox %v, got %v", tt.expectInbox, inbox)
+			}
+			for i := range inbox {
+				if inbox[i] != tt.expectInbox[i] {
+					t.Fatalf("expected inbox %v, got %v", tt.expectInbox, inbox)
+				}
+			}
+		})
+	}
+}
+
+func TestFlowControlValidate(t *testing.T) {
+	tests := []struct {
+		name      string
+		flow      FlowControl
+		expectErr bool
+	}{
+		{"Defaults", FlowControl{}, false},
+		{"Known policy", FlowControl{InboxSize: 100, Policy: Block, BlockTimeout: time.Second}, false},
+		{"Unknown policy", FlowControl{Policy: "drop_all"}, true},
+		{"Negative inbox size", FlowControl{InboxSize: -1}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.flow.Validate(); (err != nil) != tt.expectErr {
+				t.Fatalf("expected error %v, got %v", tt.expectErr, err)
+			}
+		})
+	}
+}
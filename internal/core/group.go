@@ -0,0 +1,81 @@
+package core
+
+import (
+	"sort"
+	"time"
+)
+
+// GroupMember is one consumer in a group. Consumer is nil for a member that only
+// fetches and has nothing pushed to it.
+type GroupMember struct {
+	ID            string
+	Consumer      *Consumer
+	Partitions    []int
+	LastHeartbeat time.Time
+}
+
+// ConsumerGroup is a set of consumers sharing one committed offset per partition
+// of a topic. Every partition is owned by exactly one member at a time.
+type ConsumerGroup struct {
+	ID         string
+	Topic      string
+	Partitions int
+	Generation int
+	Members    map[string]*GroupMember
+}
+
+func NewConsumerGroup(id, topic string, partitions int) *ConsumerGroup {
+	return &ConsumerGroup{
+		ID:         id,
+		Topic:      topic,
+		Partitions: partitions,
+		Members:    make(map[string]*GroupMember),
+	}
+}
+
+// Rebalance hands out the partitions to the members in contiguous ranges, ordered
+// by member ID so every member sees the same assignment, and starts a new generation.
+func (g *ConsumerGroup) Rebalance() {
+	g.Generation++
+
+	ids := make([]string, 0, len(g.Members))
+	for id := range g.Members {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	if len(ids) == 0 {
+		return
+	}
+
+	per := g.Partitions / len(ids)
+	extra := g.Partitions % len(ids)
+
+	next := 0
+	for i, id := range ids {
+		count := per
+		if i < extra {
+			count++
+		}
+
+		partitions := make([]int, 0, count)
+		for p := next; p < next+count; p++ {
+			partitions = append(partitions, p)
+		}
+		g.Members[id].Partitions = partitions
+		next += count
+	}
+}
+
+// Owner returns the member currently assigned the partition, or nil when the group is empty.
+func (g *ConsumerGroup) Owner(partition int) *GroupMember {
+	for _, member := range g.Members {
+		for _, p := range member.Partitions {
+			if p == partition {
+				return member
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,65 @@
+package core
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestConsumerGroupRebalance(t *testing.T) {
+	tests := []struct {
+		name       string
+		partitions int
+		members    []string
+		expect     map[string][]int
+	}{
+		{
+			name:       "Single member owns every partition",
+			partitions: 3,
+			members:    []string{"m1"},
+			expect:     map[string][]int{"m1": {0, 1, 2}},
+		},
+		{
+			name:       "Partitions split evenly in member order",
+			partitions: 4,
+			members:    []string{"m2", "m1"},
+			expect:     map[string][]int{"m1": {0, 1}, "m2": {2, 3}},
+		},
+		{
+			name:       "Remainder goes to the first members",
+			partitions: 5,
+			members:    []string{"m1", "m2", "m3"},
+			expect:     map[string][]int{"m1": {0, 1}, "m2": {2, 3}, "m3": {4}},
+		},
+		{
+			name:       "Extra members are left idle",
+			partitions: 1,
+			members:    []string{"m1", "m2"},
+			expect:     map[string][]int{"m1": {0}, "m2": {}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			group := NewConsumerGroup("g1", "topic", tt.partitions)
+			for _, id := range tt.members {
+				group.Members[id] = &GroupMember{ID: id, Consumer: NewConsumer(id, FlowControl{})}
+			}
+
+			group.Rebalance()
+
+			if group.Generation != 1 {
+				t.Fatalf("expected generation 1, got %d", group.Generation)
+			}
+			for id, expected := range tt.expect {
+				if got := group.Members[id].Partitions; !reflect.DeepEqual(got, expected) {
+					t.Fatalf("expected %s to own %v, got %v", id, expected, got)
+				}
+				for _, p := range expected {
+					if owner := group.Owner(p); owner == nil || owner.ID != id {
+						t.Fatalf("expected %s to own partition %d", id, p)
+					}
+				}
+			}
+		})
+	}
+}
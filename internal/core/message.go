@@ -3,13 +3,17 @@ package core
 import "time"
 
 type Message struct {
-	ID          string
-	Body        []byte
-	Timestamp   time.Time
-	ProducerID  string
-	DeliveredTo map[string]bool
-	AckedBy     map[string]bool
-	Metadata    map[string]string
+	ID               string
+	Key              string
+	Partition        int
+	Offset           int
+	Body             []byte
+	Timestamp        time.Time
+	ProducerID       string
+	DeliveryAttempts int
+	DeliveredTo      map[string]bool
+	AckedBy          map[string]bool
+	Metadata         map[string]string
 }
 
 func NewMessage(body []byte, producerID string) *Message {
@@ -21,3 +25,10 @@ func NewMessage(body []byte, producerID string) *Message {
 		Metadata:    make(map[string]string),
 	}
 }
+
+// Clone returns a shallow copy, so per delivery fields such as DeliveryAttempts can
+// differ between consumers while the tracking maps stay shared.
+func (m *Message) Clone() *Message {
+	clone := *m
+	return &clone
+}
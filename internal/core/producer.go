@@ -2,11 +2,18 @@ package core
 
 import "time"
 
+// Producer is a registered producer session. Epoch grows each time the producer
+// registers, which fences off the sessions before it. Sequence is the last sequence
+// number appended in the current session, -1 before the first, and Recent holds the
+// messages of the latest sequences so a retried publish gets its original result.
 type Producer struct {
 	ID         string
 	Metadata   map[string]string
 	LastActive time.Time
 	MessageIDs []string
+	Epoch      int
+	Sequence   int64
+	Recent     []*Message
 }
 
 func NewProducer(id string) *Producer {
@@ -15,5 +22,6 @@ func NewProducer(id string) *Producer {
 		Metadata:   make(map[string]string),
 		LastActive: time.Now(),
 		MessageIDs: []string{},
+		Sequence:   -1,
 	}
 }
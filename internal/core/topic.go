@@ -2,7 +2,9 @@ package core
 
 import (
 	"fmt"
+	"hash/fnv"
 	"sync"
+	"sync/atomic"
 )
 
 type Topic struct {
@@ -10,6 +12,8 @@ type Topic struct {
 	Messages  []*Message
 	Consumers map[string]*Consumer
 	Mu        sync.RWMutex
+
+	roundRobin atomic.Uint64
 }
 
 func NewTopic(name string) *Topic {
@@ -39,16 +43,30 @@ func (t *Topic) RemoveConsumer(consumerID string) {
 	delete(t.Consumers, consumerID)
 }
 
+// Broadcast offers msg to every consumer, applying each one's slow consumer policy
+// when its inbox is full. Disconnecting consumers is left to the broker.
 func (t *Topic) Broadcast(msg *Message) {
 	t.Mu.RLock()
 	defer t.Mu.RUnlock()
 
 	for _, consumer := range t.Consumers {
-		select {
-		case consumer.Inbox <- msg:
-			// means it was sent successfully
-		default:
-			// else inbox is full and we will skip
-		}
+		consumer.Offer(msg)
+	}
+}
+
+// PartitionFor picks the partition a message is appended to. Messages with the same
+// key always land on the same partition so they stay ordered, unkeyed messages are
+// spread round robin.
+func (t *Topic) PartitionFor(key string, partitions int) int {
+	if partitions <= 1 {
+		return 0
 	}
+
+	if key == "" {
+		return int((t.roundRobin.Add(1) - 1) % uint64(partitions))
+	}
+
+	h := fnv.New32a()
+	h.Write([]byte(key))
+	return int(h.Sum32() % uint32(partitions))
 }
@@ -0,0 +1,55 @@
+package core
+
+import "time"
+
+// RetentionPolicy bounds how much of each partition of a topic is kept. A zero
+// field means that dimension is unlimited. MaxBytes counts message bodies only,
+// not keys, metadata or the storage overhead of a repository, so a policy keeps
+// the same messages whichever repository holds them.
+type RetentionPolicy struct {
+	MaxAge      time.Duration `json:"max_age"`
+	MaxBytes    int64         `json:"max_bytes"`
+	MaxMessages int           `json:"max_messages"`
+}
+
+// DeadLetterPolicy moves a message to another topic once it has been delivered
+// MaxDeliveryAttempts times without being acknowledged.
+type DeadLetterPolicy struct {
+	MaxDeliveryAttempts int    `json:"max_delivery_attempts"`
+	Topic               string `json:"topic"`
+}
+
+// DedupPolicy remembers the IDs of recently published messages so a repeat publish
+// is recognised as a duplicate. IDs are forgotten once they are older than Window
+// or more than MaxIDs newer ones have been seen, a zero field leaves that bound off.
+type DedupPolicy struct {
+	Window time.Duration `json:"window"`
+	MaxIDs int           `json:"max_ids"`
+}
+
+type TopicConfig struct {
+	Partitions int              `json:"partitions"`
+	Retention  RetentionPolicy  `json:"retention"`
+	DeadLetter DeadLetterPolicy `json:"dead_letter"`
+	Dedup      DedupPolicy      `json:"dedup"`
+}
+
+func (r RetentionPolicy) Unlimited() bool {
+	return r.MaxAge <= 0 && r.MaxBytes <= 0 && r.MaxMessages <= 0
+}
+
+func (d DeadLetterPolicy) Enabled() bool {
+	return d.MaxDeliveryAttempts > 0 && d.Topic != ""
+}
+
+func (d DedupPolicy) Enabled() bool {
+	return d.Window > 0 || d.MaxIDs > 0
+}
+
+// PartitionCount treats an unset partition count as a single partition.
+func (c TopicConfig) PartitionCount() int {
+	if c.Partitions < 1 {
+		return 1
+	}
+	return c.Partitions
+}
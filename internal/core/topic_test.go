@@ -7,8 +7,8 @@ import (
 func TestTopic(t *testing.T) {
 	topic := NewTopic("test-topic")
 
-	consumer1 := NewConsumer("consumer-1")
-	consumer2 := NewConsumer("consumer-2")
+	consumer1 := NewConsumer("consumer-1", FlowControl{})
+	consumer2 := NewConsumer("consumer-2", FlowControl{})
 
 	tests := []struct {
 		name      string
@@ -76,3 +76,61 @@ func TestTopic(t *testing.T) {
 		})
 	}
 }
+
+func TestPartitionFor(t *testing.T) {
+	topic := NewTopic("partitioned")
+
+	tests := []struct {
+		name       string
+		partitions int
+		keys       []string
+		expectSame bool
+		expect     []int
+	}{
+		{
+			name:       "Single partition always picks zero",
+			partitions: 1,
+			keys:       []string{"a", "b", ""},
+			expect:     []int{0, 0, 0},
+		},
+		{
+			name:       "Same key always picks the same partition",
+			partitions: 8,
+			keys:       []string{"order-42", "order-42", "order-42"},
+			expectSame: true,
+		},
+		{
+			name:       "Unkeyed messages are spread round robin",
+			partitions: 3,
+			keys:       []string{"", "", "", ""},
+			expect:     []int{0, 1, 2, 0},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []int
+			for _, key := range tt.keys {
+				partition := topic.PartitionFor(key, tt.partitions)
+				if partition < 0 || partition >= tt.partitions {
+					t.Fatalf("partition %d out of range for %d partitions", partition, tt.partitions)
+				}
+				got = append(got, partition)
+			}
+
+			if tt.expectSame {
+				for _, partition := range got {
+					if partition != got[0] {
+						t.Fatalf("expected every message on partition %d, got %v", got[0], got)
+					}
+				}
+			}
+
+			for i, expected := range tt.expect {
+				if got[i] != expected {
+					t.Fatalf("expected partitions %v, got %v", tt.expect, got)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,40 @@
+package core
+
+import "time"
+
+type TransactionState string
+
+const (
+	TransactionOpen       TransactionState = "open"
+	TransactionCommitting TransactionState = "committing"
+	TransactionAborted    TransactionState = "aborted"
+)
+
+// Transaction groups publishes to several topics with consumer offset commits so
+// they take effect together. Its messages are appended as they are published and
+// stay hidden from read-committed consumers until the transaction commits, its
+// offsets are only committed with it.
+type Transaction struct {
+	ID         string                 `json:"id"`
+	State      TransactionState       `json:"state"`
+	Deadline   time.Time              `json:"deadline"`
+	Partitions []TransactionPartition `json:"partitions"`
+	Offsets    []TransactionOffset    `json:"offsets"`
+}
+
+// TransactionPartition is a partition a transaction wrote to. Its messages there lie
+// between FirstOffset and LastOffset.
+type TransactionPartition struct {
+	Topic       string `json:"topic"`
+	Partition   int    `json:"partition"`
+	FirstOffset int    `json:"first_offset"`
+	LastOffset  int    `json:"last_offset"`
+}
+
+// TransactionOffset is a consumer or group offset committed with a transaction.
+type TransactionOffset struct {
+	Topic      string `json:"topic"`
+	Partition  int    `json:"partition"`
+	ConsumerID string `json:"consumer_id"`
+	Offset     int    `json:"offset"`
+}
@@ -0,0 +1,304 @@
+// Package filter parses and evaluates the expressions a subscription selects
+// messages with, such as `region == "eu" && priority > 3`.
+//
+// A field is looked up in the message's metadata first and otherwise in its body,
+// when the body is a JSON object. Dots walk into nested objects, so customer.tier
+// reads {"customer":{"tier":"gold"}}. Metadata values are strings, but compare as
+// numbers or booleans when the literal they are compared with is one.
+//
+// Comparisons are ==, !=, <, <=, > and >= between a field and a string, number,
+// true, false or null literal. A field that is missing, or holds a value of
+// another type, fails every comparison except == null. A field on its own is true
+// when it holds true. Comparisons combine with &&, || and !, and group with
+// parentheses.
+package filter
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/codytheroux96/go-mq/internal/core"
+)
+
+// Filter is a parsed filter expression. It is safe for concurrent use.
+type Filter struct {
+	expr string
+	root node
+}
+
+// Parse parses a filter expression.
+func Parse(expr string) (*Filter, error) {
+	tokens, err := tokenize(expr)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &parser{tokens: tokens}
+	root, err := p.or()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind != tokenEOF {
+		return nil, fmt.Errorf("invalid filter at position %d: unexpected %q", t.pos, t.text)
+	}
+
+	return &Filter{expr: expr, root: root}, nil
+}
+
+// String returns the expression the filter was parsed from.
+func (f *Filter) String() string {
+	return f.expr
+}
+
+// Match reports whether msg passes the filter.
+func (f *Filter) Match(msg *core.Message) bool {
+	return f.root.eval(&fields{msg: msg})
+}
+
+// fields looks up field values on one message, decoding its body at most once.
+type fields struct {
+	msg     *core.Message
+	body    map[string]any
+	decoded bool
+}
+
+func (f *fields) lookup(name string) (any, bool) {
+	if value, ok := f.msg.Metadata[name]; ok {
+		return value, true
+	}
+
+	if !f.decoded {
+		f.decoded = true
+		// a body that is not a JSON object has no fields
+		_ = json.Unmarshal(f.msg.Body, &f.body)
+	}
+
+	var value any = f.body
+	for _, part := range strings.Split(name, ".") {
+		object, ok := value.(map[string]any)
+		if !ok {
+			return nil, false
+		}
+		if value, ok = object[part]; !ok {
+			return nil, false
+		}
+	}
+	return value, true
+}
+
+type node interface {
+	eval(f *fields) bool
+}
+
+type andNode struct{ left, right node }
+
+func (n andNode) eval(f *fields) bool { return n.left.eval(f) && n.right.eval(f) }
+
+type orNode struct{ left, right node }
+
+func (n orNode) eval(f *fields) bool { return n.left.eval(f) || n.right.eval(f) }
+
+type notNode struct{ operand node }
+
+func (n notNode) eval(f *fields) bool { return !n.operand.eval(f) }
+
+// fieldNode is a field on its own, true when it holds true.
+type fieldNode struct{ name string }
+
+func (n fieldNode) eval(f *fields) bool {
+	value, _ := f.lookup(n.name)
+	b, ok := toBool(value)
+	return ok && b
+}
+
+type compareNode struct {
+	field   string
+	op      string
+	literal any // string, float64, bool or nil
+}
+
+func (n compareNode) eval(f *fields) bool {
+	value, ok := f.lookup(n.field)
+
+	var cmp int
+	switch literal := n.literal.(type) {
+	case nil:
+		isNull := !ok || value == nil
+		return isNull == (n.op == "==")
+	case bool:
+		b, ok := toBool(value)
+		if !ok {
+			return false
+		}
+		return (b == literal) == (n.op == "==")
+	case float64:
+		number, ok := toNumber(value)
+		if !ok {
+			return false
+		}
+		switch {
+		case number < literal:
+			cmp = -1
+		case number > literal:
+			cmp = 1
+		}
+	case string:
+		s, ok := value.(string)
+		if !ok {
+			return false
+		}
+		cmp = strings.Compare(s, literal)
+	}
+
+	switch n.op {
+	case "==":
+		return cmp == 0
+	case "!=":
+		return cmp != 0
+	case "<":
+		return cmp < 0
+	case "<=":
+		return cmp <= 0
+	case ">":
+		return cmp > 0
+	default:
+		return cmp >= 0
+	}
+}
+
+func toBool(value any) (bool, bool) {
+	switch v := value.(type) {
+	case bool:
+		return v, true
+	case string:
+		b, err := strconv.ParseBool(v)
+		return b, err == nil
+	}
+	return false, false
+}
+
+func toNumber(value any) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case string:
+		n, err := strconv.ParseFloat(v, 64)
+		return n, err == nil
+	}
+	return 0, false
+}
+
+type parser struct {
+	tokens []token
+	next   int
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.next]
+}
+
+func (p *parser) take() token {
+	t := p.tokens[p.next]
+	if t.kind != tokenEOF {
+		p.next++
+	}
+	return t
+}
+
+func (p *parser) or() (node, error) {
+	left, err := p.and()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().text == "||" {
+		p.take()
+		right, err := p.and()
+		if err != nil {
+			return nil, err
+		}
+		left = orNode{left, right}
+	}
+	return left, nil
+}
+
+func (p *parser) and() (node, error) {
+	left, err := p.unary()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().text == "&&" {
+		p.take()
+		right, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		left = andNode{left, right}
+	}
+	return left, nil
+}
+
+func (p *parser) unary() (node, error) {
+	t := p.take()
+	switch {
+	case t.text == "!" && t.kind == tokenOperator:
+		operand, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		return notNode{operand}, nil
+	case t.text == "(" && t.kind == tokenOperator:
+		inner, err := p.or()
+		if err != nil {
+			return nil, err
+		}
+		if closing := p.take(); closing.text != ")" {
+			return nil, fmt.Errorf("invalid filter at position %d: expected ) to close the ( at position %d", closing.pos, t.pos)
+		}
+		return inner, nil
+	case t.kind == tokenIdent:
+		return p.comparison(t)
+	case t.kind == tokenEOF:
+		return nil, fmt.Errorf("invalid filter at position %d: expected a field", t.pos)
+	}
+	return nil, fmt.Errorf("invalid filter at position %d: expected a field, got %q", t.pos, t.text)
+}
+
+func (p *parser) comparison(field token) (node, error) {
+	op := p.peek()
+	switch op.text {
+	case "==", "!=", "<", "<=", ">", ">=":
+	default:
+		return fieldNode{name: field.text}, nil
+	}
+	p.take()
+
+	t := p.take()
+	var literal any
+	switch t.kind {
+	case tokenString:
+		literal = t.value
+	case tokenNumber:
+		literal = t.value
+	case tokenIdent:
+		switch t.text {
+		case "true", "false":
+			literal = t.text == "true"
+		case "null":
+		default:
+			return nil, fmt.Errorf("invalid filter at position %d: %s can only be compared with a literal, not the field %q", t.pos, field.text, t.text)
+		}
+	default:
+		return nil, fmt.Errorf("invalid filter at position %d: expected a value to compare %s with", t.pos, field.text)
+	}
+
+	switch literal.(type) {
+	case nil, bool:
+		if op.text != "==" && op.text != "!=" {
+			return nil, fmt.Errorf("invalid filter at position %d: %s only compares with == and !=", op.pos, t.text)
+		}
+	}
+
+	return compareNode{field: field.text, op: op.text, literal: literal}, nil
+}
@@ -0,0 +1,97 @@
+package filter
+
+import (
+	"testing"
+
+	"github.com/codytheroux96/go-mq/internal/core"
+)
+
+func TestMatch(t *testing.T) {
+	msg := core.NewMessage([]byte(`{"priority":5,"status":"paid","express":true,"note":null,"customer":{"tier":"gold","orders":12}}`), "p1")
+	msg.Metadata["region"] = "eu"
+	msg.Metadata["attempt"] = "2"
+	msg.Metadata["retry"] = "false"
+
+	tests := []struct {
+		name   string
+		expr   string
+		expect bool
+	}{
+		{"Metadata equals", `region == "eu"`, true},
+		{"Metadata not equals", `region != "eu"`, false},
+		{"Single quoted string", `region == 'eu'`, true},
+		{"Body number", `priority > 3`, true},
+		{"Body number at the bound", `priority >= 5 && priority <= 5`, true},
+		{"Metadata as a number", `attempt < 3`, true},
+		{"Metadata as a boolean", `retry == false`, true},
+		{"Body string", `status == "paid"`, true},
+		{"String ordering", `status < "pending"`, true},
+		{"Nested body field", `customer.tier == "gold" && customer.orders > 10`, true},
+		{"Field on its own", `express`, true},
+		{"Negated field", `!express`, false},
+		{"Example from the docs", `region == "eu" && priority > 3`, true},
+		{"Or", `region == "us" || priority > 3`, true},
+		{"Parentheses", `!(region == "us" || priority < 3) && status == "paid"`, true},
+		{"Missing field", `warehouse == "north"`, false},
+		{"Missing field not equals", `warehouse != "north"`, false},
+		{"Missing field is null", `warehouse == null`, true},
+		{"JSON null", `note == null`, true},
+		{"Present field is not null", `status != null`, true},
+		{"Type mismatch", `status > 3`, false},
+		{"Negative number", `priority > -1`, true},
+		{"Escaped quote", `status != "pa\"id"`, true},
+		{"Escaped backslash at the end", `status != "a\\" && status == "paid"`, true},
+		{"Escaped backslash before a quote", `note == "\\\""`, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := Parse(tt.expr)
+			if err != nil {
+				t.Fatalf("failed to parse %s: %v", tt.expr, err)
+			}
+			if got := f.Match(msg); got != tt.expect {
+				t.Fatalf("expected %s to be %v, got %v", tt.expr, tt.expect, got)
+			}
+		})
+	}
+}
+
+func TestMatchBodyThatIsNotJSON(t *testing.T) {
+	msg := core.NewMessage([]byte("plain text"), "p1")
+	msg.Metadata["region"] = "eu"
+
+	f, err := Parse(`region == "eu" || priority > 3`)
+	if err != nil {
+		t.Fatalf("failed to parse: %v", err)
+	}
+	if !f.Match(msg) {
+		t.Fatalf("expected metadata to match on a message without a JSON body")
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		expr string
+	}{
+		{"Empty", ``},
+		{"Missing value", `region ==`},
+		{"Field compared with a field", `region == country`},
+		{"Ordering a boolean", `express > true`},
+		{"Unclosed parenthesis", `(region == "eu"`},
+		{"Unterminated string", `region == "eu`},
+		{"Unterminated string ending in an escape", `region == "eu\"`},
+		{"Dangling operator", `region == "eu" &&`},
+		{"Trailing token", `region == "eu" "us"`},
+		{"Unknown character", `region = "eu"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Parse(tt.expr); err == nil {
+				t.Fatalf("expected an error parsing %q", tt.expr)
+			}
+		})
+	}
+}
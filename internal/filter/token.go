@@ -0,0 +1,102 @@
+package filter
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenIdent
+	tokenString
+	tokenNumber
+	tokenOperator
+)
+
+type token struct {
+	kind  tokenKind
+	text  string
+	value any // the string or float64 of a literal
+	pos   int
+}
+
+// tokenize splits expr into tokens, ending with a tokenEOF.
+func tokenize(expr string) ([]token, error) {
+	var tokens []token
+	for i := 0; i < len(expr); {
+		c := expr[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+
+		case isIdentStart(c):
+			start := i
+			for i < len(expr) && (isIdentStart(expr[i]) || isDigit(expr[i]) || expr[i] == '.') {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokenIdent, text: expr[start:i], pos: start})
+
+		case isDigit(c) || (c == '-' && i+1 < len(expr) && isDigit(expr[i+1])):
+			start := i
+			i++
+			for i < len(expr) && (isDigit(expr[i]) || expr[i] == '.') {
+				i++
+			}
+			number, err := strconv.ParseFloat(expr[start:i], 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid filter at position %d: bad number %q", start, expr[start:i])
+			}
+			tokens = append(tokens, token{kind: tokenNumber, text: expr[start:i], value: number, pos: start})
+
+		case c == '"' || c == '\'':
+			start := i
+			for i++; i < len(expr) && expr[i] != c; i++ {
+				// a double quoted string may escape its quotes and backslashes
+				if c == '"' && expr[i] == '\\' {
+					i++
+				}
+			}
+			if i >= len(expr) {
+				return nil, fmt.Errorf("invalid filter at position %d: unterminated string", start)
+			}
+			i++
+
+			text := expr[start:i]
+			value := text[1 : len(text)-1]
+			if c == '"' {
+				var err error
+				if value, err = strconv.Unquote(text); err != nil {
+					return nil, fmt.Errorf("invalid filter at position %d: bad string %s", start, text)
+				}
+			}
+			tokens = append(tokens, token{kind: tokenString, text: text, value: value, pos: start})
+
+		default:
+			op := ""
+			for _, candidate := range []string{"==", "!=", "<=", ">=", "&&", "||", "<", ">", "!", "(", ")"} {
+				if strings.HasPrefix(expr[i:], candidate) {
+					op = candidate
+					break
+				}
+			}
+			if op == "" {
+				return nil, fmt.Errorf("invalid filter at position %d: unexpected %q", i, c)
+			}
+			tokens = append(tokens, token{kind: tokenOperator, text: op, pos: i})
+			i += len(op)
+		}
+	}
+
+	return append(tokens, token{kind: tokenEOF, pos: len(expr)}), nil
+}
+
+func isIdentStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
@@ -0,0 +1,242 @@
+// Package metrics keeps counters and histograms and writes them, along with values
+// collected at scrape time, in the Prometheus text exposition format.
+package metrics
+
+import (
+	"bufio"
+	"io"
+	"maps"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+type Type string
+
+const (
+	Counter   Type = "counter"
+	Gauge     Type = "gauge"
+	Histogram Type = "histogram"
+)
+
+// DefaultBuckets are the upper bounds, in seconds, of a latency histogram's buckets.
+var DefaultBuckets = []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5}
+
+// Family is one metric and its samples. A sample's Suffix is appended to the
+// family name, as with the _bucket, _sum and _count samples of a histogram.
+type Family struct {
+	Name    string
+	Help    string
+	Type    Type
+	Samples []Sample
+}
+
+type Sample struct {
+	Suffix string
+	Labels []Label
+	Value  float64
+}
+
+type Label struct {
+	Name  string
+	Value string
+}
+
+// Collector returns the current value of its metrics each time they are scraped.
+type Collector interface {
+	Collect() []Family
+}
+
+// Registry writes the metrics of every registered collector.
+type Registry struct {
+	collectors []Collector
+	mu         sync.Mutex
+}
+
+func NewRegistry(collectors ...Collector) *Registry {
+	return &Registry{collectors: collectors}
+}
+
+func (r *Registry) Register(c Collector) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.collectors = append(r.collectors, c)
+}
+
+// WriteText writes every family in the order its collector was registered.
+func (r *Registry) WriteText(w io.Writer) error {
+	r.mu.Lock()
+	collectors := slices.Clone(r.collectors)
+	r.mu.Unlock()
+
+	bw := bufio.NewWriter(w)
+	for _, c := range collectors {
+		for _, family := range c.Collect() {
+			writeFamily(bw, family)
+		}
+	}
+	return bw.Flush()
+}
+
+func writeFamily(w *bufio.Writer, family Family) {
+	w.WriteString("# HELP " + family.Name + " " + helpEscaper.Replace(family.Help) + "\n")
+	w.WriteString("# TYPE " + family.Name + " " + string(family.Type) + "\n")
+	for _, sample := range family.Samples {
+		w.WriteString(family.Name + sample.Suffix)
+		if len(sample.Labels) > 0 {
+			w.WriteByte('{')
+			for i, label := range sample.Labels {
+				if i > 0 {
+					w.WriteByte(',')
+				}
+				w.WriteString(label.Name + `="` + labelEscaper.Replace(label.Value) + `"`)
+			}
+			w.WriteByte('}')
+		}
+		w.WriteString(" " + formatValue(sample.Value) + "\n")
+	}
+}
+
+var (
+	helpEscaper  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+	labelEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+)
+
+func formatValue(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+// labels pairs label names with one set of values.
+func labels(names, values []string) []Label {
+	out := make([]Label, len(names), len(names)+1)
+	for i, name := range names {
+		out[i] = Label{Name: name, Value: values[i]}
+	}
+	return out
+}
+
+// key identifies one set of label values within a vec.
+func key(values []string) string {
+	return strings.Join(values, "\xff")
+}
+
+// CounterVec is a counter with one value per set of label values.
+type CounterVec struct {
+	name       string
+	help       string
+	labelNames []string
+	values     map[string]*counterValue
+	mu         sync.Mutex
+}
+
+type counterValue struct {
+	labels []string
+	value  float64
+}
+
+func NewCounterVec(name, help string, labelNames ...string) *CounterVec {
+	return &CounterVec{name: name, help: help, labelNames: labelNames, values: make(map[string]*counterValue)}
+}
+
+// Add adds v to the counter for the label values, given in the order of the label names.
+func (c *CounterVec) Add(v float64, labelValues ...string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	k := key(labelValues)
+	value, ok := c.values[k]
+	if !ok {
+		value = &counterValue{labels: slices.Clone(labelValues)}
+		c.values[k] = value
+	}
+	value.value += v
+}
+
+func (c *CounterVec) Inc(labelValues ...string) {
+	c.Add(1, labelValues...)
+}
+
+func (c *CounterVec) Collect() []Family {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	family := Family{Name: c.name, Help: c.help, Type: Counter}
+	for _, k := range slices.Sorted(maps.Keys(c.values)) {
+		value := c.values[k]
+		family.Samples = append(family.Samples, Sample{Labels: labels(c.labelNames, value.labels), Value: value.value})
+	}
+	return []Family{family}
+}
+
+// HistogramVec is a histogram with one set of buckets per set of label values.
+type HistogramVec struct {
+	name       string
+	help       string
+	labelNames []string
+	buckets    []float64
+	values     map[string]*histogramValue
+	mu         sync.Mutex
+}
+
+type histogramValue struct {
+	labels []string
+	counts []uint64 // per bucket, not cumulative
+	count  uint64
+	sum    float64
+}
+
+func NewHistogramVec(name, help string, buckets []float64, labelNames ...string) *HistogramVec {
+	return &HistogramVec{name: name, help: help, labelNames: labelNames, buckets: buckets, values: make(map[string]*histogramValue)}
+}
+
+// Observe records v for the label values, given in the order of the label names.
+func (h *HistogramVec) Observe(v float64, labelValues ...string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	k := key(labelValues)
+	value, ok := h.values[k]
+	if !ok {
+		value = &histogramValue{labels: slices.Clone(labelValues), counts: make([]uint64, len(h.buckets))}
+		h.values[k] = value
+	}
+	if i, _ := slices.BinarySearch(h.buckets, v); i < len(h.buckets) {
+		value.counts[i]++
+	}
+	value.count++
+	value.sum += v
+}
+
+func (h *HistogramVec) Collect() []Family {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	family := Family{Name: h.name, Help: h.help, Type: Histogram}
+	for _, k := range slices.Sorted(maps.Keys(h.values)) {
+		value := h.values[k]
+		var cumulative uint64
+		for i, bound := range h.buckets {
+			cumulative += value.counts[i]
+			le := append(labels(h.labelNames, value.labels), Label{Name: "le", Value: formatValue(bound)})
+			family.Samples = append(family.Samples, Sample{Suffix: "_bucket", Labels: le, Value: float64(cumulative)})
+		}
+		inf := append(labels(h.labelNames, value.labels), Label{Name: "le", Value: "+Inf"})
+		family.Samples = append(family.Samples,
+			Sample{Suffix: "_bucket", Labels: inf, Value: float64(value.count)},
+			Sample{Suffix: "_sum", Labels: labels(h.labelNames, value.labels), Value: value.sum},
+			Sample{Suffix: "_count", Labels: labels(h.labelNames, value.labels), Value: float64(value.count)},
+		)
+	}
+	return []Family{family}
+}
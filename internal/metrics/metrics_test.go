@@ -0,0 +1,49 @@
+package metrics
+
+import (
+	"strings"
+	"testing"
+)
+
+type gauges []Family
+
+func (g gauges) Collect() []Family { return g }
+
+func TestWriteText(t *testing.T) {
+	published := NewCounterVec("gomq_published_total", "Messages published.", "topic")
+	published.Inc("orders")
+	published.Add(2, "orders")
+	published.Inc(`we"ird`)
+
+	latency := NewHistogramVec("gomq_publish_seconds", "Publish latency.", []float64{0.1, 1}, "topic")
+	latency.Observe(0.05, "orders")
+	latency.Observe(0.1, "orders")
+	latency.Observe(3, "orders")
+
+	registry := NewRegistry(published, latency)
+	registry.Register(gauges{{Name: "gomq_up", Help: "Always 1.\nReally.", Type: Gauge, Samples: []Sample{{Value: 1}}}})
+
+	var out strings.Builder
+	if err := registry.WriteText(&out); err != nil {
+		t.Fatalf("failed to write metrics: %v", err)
+	}
+
+	expect := `# HELP gomq_published_total Messages published.
+# TYPE gomq_published_total counter
+gomq_published_total{topic="orders"} 3
+gomq_published_total{topic="we\"ird"} 1
+# HELP gomq_publish_seconds Publish latency.
+# TYPE gomq_publish_seconds histogram
+gomq_publish_seconds_bucket{topic="orders",le="0.1"} 2
+gomq_publish_seconds_bucket{topic="orders",le="1"} 2
+gomq_publish_seconds_bucket{topic="orders",le="+Inf"} 3
+gomq_publish_seconds_sum{topic="orders"} 3.15
+gomq_publish_seconds_count{topic="orders"} 3
+# HELP gomq_up Always 1.\nReally.
+# TYPE gomq_up gauge
+gomq_up 1
+`
+	if out.String() != expect {
+		t.Fatalf("expected:\n%s\ngot:\n%s", expect, out.String())
+	}
+}
@@ -0,0 +1,131 @@
+package repository
+
+import (
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/codytheroux96/go-mq/internal/core"
+)
+
+func TestPublishBatch(t *testing.T) {
+	repos := map[string]func(t *testing.T) Repository{
+		"memory": func(t *testing.T) Repository {
+			return NewInMemoryRepo()
+		},
+		"file": func(t *testing.T) Repository {
+			repo, err := NewFileRepo(t.TempDir())
+			if err != nil {
+				t.Fatalf("failed to open file repo: %v", err)
+			}
+			repo.SegmentBytes = 256
+			t.Cleanup(func() { repo.Close() })
+			return repo
+		},
+	}
+
+	tests := []struct {
+		name          string
+		partitions    []int
+		expectErr     bool
+		expectOffsets []int
+	}{
+		{"Messages get consecutive offsets per partition", []int{0, 1, 0, 0, 1}, false, []int{0, 0, 1, 2, 1}},
+		{"Unknown partition appends nothing", []int{0, 1, 2}, true, nil},
+	}
+
+	for repoName, newRepo := range repos {
+		for _, tt := range tests {
+			t.Run(repoName+"/"+tt.name, func(t *testing.T) {
+				repo := newRepo(t)
+				if err := repo.CreateTopic("batched", core.TopicConfig{Partitions: 2}); err != nil {
+					t.Fatalf("failed to create topic: %v", err)
+				}
+
+				msgs := make([]*core.Message, len(tt.partitions))
+				for i, partition := range tt.partitions {
+					msgs[i] = core.NewMessage([]byte(fmt.Sprintf("message %d", i)), "p1")
+					msgs[i].Partition = partition
+				}
+
+				err := repo.PublishBatch("batched", msgs)
+				if tt.expectErr {
+					if err == nil {
+						t.Fatalf("expected error, got none")
+					}
+					for partition := 0; partition < 2; partition++ {
+						if latest, _ := repo.GetLatestOffset("batched", partition); latest != 0 {
+							t.Fatalf("expected partition %d to stay empty, got latest offset %d", partition, latest)
+						}
+					}
+					return
+				}
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+
+				for i, msg := range msgs {
+					if msg.Offset != tt.expectOffsets[i] {
+						t.Fatalf("expected message %d at offset %d, got %d", i, tt.expectOffsets[i], msg.Offset)
+					}
+				}
+				fetched, err := repo.Fetch("batched", 0, "c1", 10)
+				if err != nil || len(fetched) != 3 || string(fetched[2].Body) != "message 3" {
+					t.Fatalf("expected the batch's 3 partition 0 messages in order, got %d %v", len(fetched), err)
+				}
+			})
+		}
+	}
+}
+
+func TestFileRepoPublishBatchRollsBack(t *testing.T) {
+	dir := t.TempDir()
+
+	repo, err := NewFileRepo(dir)
+	if err != nil {
+		t.Fatalf("failed to open file repo: %v", err)
+	}
+	if err := repo.CreateTopic("batched", core.TopicConfig{Partitions: 2}); err != nil {
+		t.Fatalf("failed to create topic: %v", err)
+	}
+	if err := repo.Publish("batched", 0, core.NewMessage([]byte("before"), "p1")); err != nil {
+		t.Fatalf("failed to publish: %v", err)
+	}
+
+	// appends to partition 1 fail once its log can no longer be written
+	partition := repo.Topics["batched"].Partitions[1]
+	active := partition.Segments[len(partition.Segments)-1]
+	writable := active.Log
+	readOnly, err := os.Open(writable.Name())
+	if err != nil {
+		t.Fatalf("failed to reopen segment log: %v", err)
+	}
+	active.Log = readOnly
+
+	first, second := core.NewMessage([]byte("first"), "p1"), core.NewMessage([]byte("second"), "p1")
+	second.Partition = 1
+	if err := repo.PublishBatch("batched", []*core.Message{first, second}); err == nil {
+		t.Fatalf("expected the batch to fail")
+	}
+	active.Log = writable
+	readOnly.Close()
+
+	if latest, _ := repo.GetLatestOffset("batched", 0); latest != 1 {
+		t.Fatalf("expected partition 0 to be rolled back to offset 1, got %d", latest)
+	}
+	if err := repo.Publish("batched", 0, core.NewMessage([]byte("after"), "p1")); err != nil {
+		t.Fatalf("failed to publish after the rollback: %v", err)
+	}
+	repo.Close()
+
+	repo, err = NewFileRepo(dir)
+	if err != nil {
+		t.Fatalf("failed to reopen file repo: %v", err)
+	}
+	defer repo.Close()
+
+	messages, err := repo.Fetch("batched", 0, "c1", 10)
+	if err != nil || len(messages) != 2 || string(messages[0].Body) != "before" || string(messages[1].Body) != "after" || messages[1].Offset != 1 {
+		t.Fatalf("expected only the messages around the failed batch, got %d %v", len(messages), err)
+	}
+}
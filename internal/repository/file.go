@@ -0,0 +1,1364 @@
+package repository
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"maps"
+	"math"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/codytheroux96/go-mq/internal/core"
+)
+
+const (
+	DefaultSegmentBytes = 16 << 20
+
+	// MaxSegmentBytes is the largest SegmentBytes takes effect, index entries hold
+	// 32 bit log positions.
+	MaxSegmentBytes = math.MaxUint32
+
+	// maxRecordBytes bounds a record's payload, a larger length in a record header
+	// can only come from a corrupt or torn write
+	maxRecordBytes = 64 << 20
+
+	// a sparse index entry is written every indexIntervalBytes of log data
+	indexIntervalBytes = 4 << 10
+
+	// every record on disk is [4 byte payload length][4 byte crc32][payload]
+	recordHeaderSize = 8
+	indexEntrySize   = 8
+
+	logSuffix        = ".log"
+	indexSuffix      = ".index"
+	offsetsFile      = "offsets.json"
+	metaFile         = "meta.json"
+	scheduledFile    = "scheduled.json"
+	transactionsFile = "transactions.json"
+)
+
+// FileRepo is a durable Repository. Each topic is a directory holding the topic's
+// metadata and one directory per partition. A partition is an append-only commit
+// log split into segment files, a sparse offset index per segment and the
+// committed consumer offsets. Messages scheduled for later delivery and the state
+// of unfinished transactions are each kept in a single file next to the topic
+// directories.
+//
+// Appends are written to the segment files before a publish returns, so they
+// survive the process crashing. They are only fsynced when a segment rolls over
+// and on Close, unless SyncWrites is set, so a crash of the host can lose the
+// latest acknowledged messages.
+type FileRepo struct {
+	Dir          string
+	SegmentBytes int64
+	// SyncWrites fsyncs the segment after every Publish and PublishBatch.
+	SyncWrites   bool
+	Topics       map[string]*fileTopic
+	Scheduled    map[string]scheduledRecord // messageID -> scheduled message
+	Transactions map[string]core.Transaction
+	Mu           sync.RWMutex
+}
+
+type fileTopic struct {
+	Dir        string
+	Config     core.TopicConfig
+	Partitions []*filePartition
+}
+
+type filePartition struct {
+	Dir         string
+	Index       int
+	StartOffset int // earliest offset still retained
+	Segments    []*segment
+	Offsets     map[string]int // consumerID -> offset
+}
+
+type topicMeta struct {
+	Config       core.TopicConfig `json:"config"`
+	StartOffsets []int            `json:"start_offsets"`
+}
+
+type segment struct {
+	BaseOffset int
+	NextOffset int
+	Size       int64
+	Bytes      int64 // body bytes of the records in the log, what retention counts
+	Log        *os.File
+	IndexFile  *os.File
+	Index      []indexEntry
+}
+
+type indexEntry struct {
+	RelOffset uint32
+	Position  uint32
+}
+
+type record struct {
+	Offset     int               `json:"offset"`
+	ID         string            `json:"id"`
+	Key        string            `json:"key,omitempty"`
+	Body       []byte            `json:"body"`
+	Timestamp  time.Time         `json:"timestamp"`
+	ProducerID string            `json:"producer_id,omitempty"`
+	Metadata   map[string]string `json:"metadata,omitempty"`
+}
+
+type scheduledRecord struct {
+	Topic     string    `json:"topic"`
+	DeliverAt time.Time `json:"deliver_at"`
+	Record    record    `json:"record"`
+}
+
+func NewFileRepo(dir string) (*FileRepo, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create data directory %q: %w", dir, err)
+	}
+
+	repo := &FileRepo{
+		Dir:          dir,
+		SegmentBytes: DefaultSegmentBytes,
+		Topics:       make(map[string]*fileTopic),
+		Scheduled:    make(map[string]scheduledRecord),
+		Transactions: make(map[string]core.Transaction),
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read data directory %q: %w", dir, err)
+	}
+
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+
+		name, err := url.PathUnescape(entry.Name())
+		if err != nil {
+			continue
+		}
+
+		topic, err := loadFileTopic(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			repo.Close()
+			return nil, fmt.Errorf("failed to load topic %q: %w", name, err)
+		}
+		repo.Topics[name] = topic
+	}
+
+	if err := repo.loadScheduled(); err != nil {
+		repo.Close()
+		return nil, err
+	}
+	if err := repo.loadTransactions(); err != nil {
+		repo.Close()
+		return nil, err
+	}
+
+	return repo, nil
+}
+
+func (f *FileRepo) CreateTopic(name string, cfg core.TopicConfig) error {
+	f.Mu.Lock()
+	defer f.Mu.Unlock()
+
+	if _, exists := f.Topics[name]; exists {
+		return fmt.Errorf("topic %q already exists", name)
+	}
+
+	dir := filepath.Join(f.Dir, url.PathEscape(name))
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create topic directory: %w", err)
+	}
+
+	topic := &fileTopic{
+		Dir:    dir,
+		Config: cfg,
+	}
+
+	for i := 0; i < cfg.PartitionCount(); i++ {
+		partition, err := openFilePartition(topic.partitionDir(i), i, 0)
+		if err != nil {
+			topic.close()
+			os.RemoveAll(dir)
+			return err
+		}
+		topic.Partitions = append(topic.Partitions, partition)
+	}
+
+	if err := topic.saveMeta(); err != nil {
+		topic.close()
+		os.RemoveAll(dir)
+		return err
+	}
+
+	f.Topics[name] = topic
+	return nil
+}
+
+func (f *FileRepo) ListTopics() ([]string, error) {
+	f.Mu.RLock()
+	defer f.Mu.RUnlock()
+
+	topics := make([]string, 0, len(f.Topics))
+	for name := range f.Topics {
+		topics = append(topics, name)
+	}
+
+	return topics, nil
+}
+
+func (f *FileRepo) DeleteTopic(name string) error {
+	f.Mu.Lock()
+	defer f.Mu.Unlock()
+
+	topic, exists := f.Topics[name]
+	if !exists {
+		return fmt.Errorf("topic %q does not exist", name)
+	}
+
+	topic.close()
+	delete(f.Topics, name)
+
+	if err := os.RemoveAll(topic.Dir); err != nil {
+		return fmt.Errorf("failed to remove topic directory: %w", err)
+	}
+
+	return nil
+}
+
+func (f *FileRepo) GetTopicConfig(name string) (core.TopicConfig, error) {
+	f.Mu.RLock()
+	defer f.Mu.RUnlock()
+
+	fileTopic, exists := f.Topics[name]
+	if !exists {
+		return core.TopicConfig{}, fmt.Errorf("topic %q does not exist", name)
+	}
+
+	return fileTopic.Config, nil
+}
+
+func (f *FileRepo) UpdateTopicConfig(name string, cfg core.TopicConfig) error {
+	f.Mu.Lock()
+	defer f.Mu.Unlock()
+
+	fileTopic, exists := f.Topics[name]
+	if !exists {
+		return fmt.Errorf("topic %q does not exist", name)
+	}
+
+	if err := checkPartitionChange(name, fileTopic.Config, cfg); err != nil {
+		return err
+	}
+
+	previous := fileTopic.Config
+	fileTopic.Config = cfg
+	if err := fileTopic.saveMeta(); err != nil {
+		fileTopic.Config = previous
+		return err
+	}
+
+	return nil
+}
+
+func (f *FileRepo) Fetch(topic string, partition int, consumerID string, limit int) ([]*core.Message, error) {
+	f.Mu.RLock()
+	defer f.Mu.RUnlock()
+
+	filePartition, err := f.partition(topic, partition)
+	if err != nil {
+		return nil, err
+	}
+
+	return filePartition.readFrom(topic, partition, filePartition.Offsets[consumerID], limit)
+}
+
+// Read returns up to limit messages starting at offset, without a consumer.
+func (f *FileRepo) Read(topic string, partition int, offset int, limit int) ([]*core.Message, error) {
+	f.Mu.RLock()
+	defer f.Mu.RUnlock()
+
+	filePartition, err := f.partition(topic, partition)
+	if err != nil {
+		return nil, err
+	}
+
+	return filePartition.readFrom(topic, partition, offset, limit)
+}
+
+// readFrom reads like read but rejects an offset retention has already dropped.
+func (p *filePartition) readFrom(topic string, partition int, offset int, limit int) ([]*core.Message, error) {
+	if offset < p.StartOffset {
+		return nil, &OffsetOutOfRangeError{Topic: topic, Partition: partition, Offset: offset, LowWatermark: p.StartOffset}
+	}
+
+	return p.read(offset, limit)
+}
+
+func (f *FileRepo) CommitOffset(topic string, partition int, consumerID string, offset int) error {
+	f.Mu.Lock()
+	defer f.Mu.Unlock()
+
+	filePartition, err := f.partition(topic, partition)
+	if err != nil {
+		return err
+	}
+
+	if end := filePartition.nextOffset(); offset > end {
+		return fmt.Errorf("cannot commit offset %d beyond the partition length %d", offset, end)
+	}
+	if offset < filePartition.StartOffset {
+		return &OffsetOutOfRangeError{Topic: topic, Partition: partition, Offset: offset, LowWatermark: filePartition.StartOffset}
+	}
+
+	filePartition.Offsets[consumerID] = offset
+	return filePartition.saveOffsets()
+}
+
+func (f *FileRepo) GetOffset(topic string, partition int, consumerID string) (int, error) {
+	f.Mu.RLock()
+	defer f.Mu.RUnlock()
+
+	filePartition, err := f.partition(topic, partition)
+	if err != nil {
+		return 0, err
+	}
+
+	offset, ok := filePartition.Offsets[consumerID]
+	if !ok {
+		return 0, nil
+	}
+
+	return offset, nil
+}
+
+func (f *FileRepo) Publish(topic string, partition int, msg *core.Message) error {
+	f.Mu.Lock()
+	defer f.Mu.Unlock()
+
+	filePartition, err := f.partition(topic, partition)
+	if err != nil {
+		return err
+	}
+
+	active, err := f.activeSegment(filePartition)
+	if err != nil {
+		return err
+	}
+
+	offset, err := appendMessage(active, msg)
+	if err != nil {
+		return fmt.Errorf("failed to append message to topic %q partition %d: %w", topic, partition, err)
+	}
+	if f.SyncWrites {
+		if err := active.sync(); err != nil {
+			return fmt.Errorf("failed to sync topic %q partition %d: %w", topic, partition, err)
+		}
+	}
+
+	msg.Partition = partition
+	msg.Offset = offset
+	return nil
+}
+
+// PublishBatch appends every message to the partition in its Partition field,
+// either all of them or none. A partition's share of the batch goes into a single
+// segment, so a failed append is undone by truncating the segments back to where
+// the batch started.
+func (f *FileRepo) PublishBatch(topic string, msgs []*core.Message) error {
+	f.Mu.Lock()
+	defer f.Mu.Unlock()
+
+	type mark struct {
+		seg        *segment
+		size       int64
+		nextOffset int
+		bytes      int64
+		indexLen   int
+	}
+
+	segments := make(map[int]*segment)
+	var marks []mark
+	for _, msg := range msgs {
+		if _, ok := segments[msg.Partition]; ok {
+			continue
+		}
+		filePartition, err := f.partition(topic, msg.Partition)
+		if err != nil {
+			return err
+		}
+		active, err := f.activeSegment(filePartition)
+		if err != nil {
+			return err
+		}
+		segments[msg.Partition] = active
+		marks = append(marks, mark{seg: active, size: active.Size, nextOffset: active.NextOffset, bytes: active.Bytes, indexLen: len(active.Index)})
+	}
+
+	offsets := make([]int, len(msgs))
+	for i, msg := range msgs {
+		offset, err := appendMessage(segments[msg.Partition], msg)
+		if err != nil {
+			for _, m := range marks {
+				if rollbackErr := m.seg.truncate(m.size, m.nextOffset, m.bytes, m.indexLen); rollbackErr != nil {
+					err = errors.Join(err, rollbackErr)
+				}
+			}
+			return fmt.Errorf("failed to append batch to topic %q partition %d: %w", topic, msg.Partition, err)
+		}
+		offsets[i] = offset
+	}
+
+	if f.SyncWrites {
+		for _, m := range marks {
+			if err := m.seg.sync(); err != nil {
+				return fmt.Errorf("failed to sync batch to topic %q: %w", topic, err)
+			}
+		}
+	}
+
+	for i, msg := range msgs {
+		msg.Offset = offsets[i]
+	}
+	return nil
+}
+
+// activeSegment returns the segment new messages go to, rolling over to a new one
+// once the current segment is full. The caller must hold f.Mu.
+func (f *FileRepo) activeSegment(filePartition *filePartition) (*segment, error) {
+	active := filePartition.Segments[len(filePartition.Segments)-1]
+	if active.Size < min(f.SegmentBytes, MaxSegmentBytes) || active.NextOffset == active.BaseOffset {
+		return active, nil
+	}
+
+	next, err := openSegment(filePartition.Dir, active.NextOffset)
+	if err != nil {
+		return nil, err
+	}
+	if err := active.sync(); err != nil {
+		next.close()
+		return nil, err
+	}
+	filePartition.Segments = append(filePartition.Segments, next)
+	return next, nil
+}
+
+func appendMessage(active *segment, msg *core.Message) (int, error) {
+	if msg.Timestamp.IsZero() {
+		msg.Timestamp = time.Now()
+	}
+
+	offset := active.NextOffset
+	if err := active.append(newRecord(offset, msg)); err != nil {
+		return 0, err
+	}
+	return offset, nil
+}
+
+func (f *FileRepo) GetEarliestOffset(topic string, partition int) (int, error) {
+	f.Mu.RLock()
+	defer f.Mu.RUnlock()
+
+	filePartition, err := f.partition(topic, partition)
+	if err != nil {
+		return 0, err
+	}
+
+	return filePartition.StartOffset, nil
+}
+
+// GetLatestOffset returns the log end offset, the offset the next message
+// published to the partition will get.
+func (f *FileRepo) GetLatestOffset(topic string, partition int) (int, error) {
+	f.Mu.RLock()
+	defer f.Mu.RUnlock()
+
+	filePartition, err := f.partition(topic, partition)
+	if err != nil {
+		return 0, err
+	}
+
+	return filePartition.nextOffset(), nil
+}
+
+// GetOffsetForTimestamp returns the first retained offset whose message was
+// appended at or after timestamp, or the log end offset when none was.
+func (f *FileRepo) GetOffsetForTimestamp(topic string, partition int, timestamp time.Time) (int, error) {
+	f.Mu.RLock()
+	defer f.Mu.RUnlock()
+
+	filePartition, err := f.partition(topic, partition)
+	if err != nil {
+		return 0, err
+	}
+
+	return filePartition.offsetForTimestamp(timestamp)
+}
+
+// GetPartitionBytes returns the total body size of the messages still retained.
+func (f *FileRepo) GetPartitionBytes(topic string, partition int) (int64, error) {
+	f.Mu.RLock()
+	defer f.Mu.RUnlock()
+
+	filePartition, err := f.partition(topic, partition)
+	if err != nil {
+		return 0, err
+	}
+
+	return filePartition.bytesFrom(filePartition.StartOffset)
+}
+
+// ListOffsets returns the committed offset of every consumer and group on the partition.
+func (f *FileRepo) ListOffsets(topic string, partition int) (map[string]int, error) {
+	f.Mu.RLock()
+	defer f.Mu.RUnlock()
+
+	filePartition, err := f.partition(topic, partition)
+	if err != nil {
+		return nil, err
+	}
+
+	return maps.Clone(filePartition.Offsets), nil
+}
+
+// DeleteOffsets forgets the committed offset of a consumer or group on every
+// partition of the topic.
+func (f *FileRepo) DeleteOffsets(topic string, consumerID string) error {
+	f.Mu.Lock()
+	defer f.Mu.Unlock()
+
+	fileTopic, exists := f.Topics[topic]
+	if !exists {
+		return fmt.Errorf("topic %q does not exist", topic)
+	}
+
+	for _, filePartition := range fileTopic.Partitions {
+		if _, ok := filePartition.Offsets[consumerID]; !ok {
+			continue
+		}
+		delete(filePartition.Offsets, consumerID)
+		if err := filePartition.saveOffsets(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ApplyRetention moves each partition's start offset past every message the
+// retention policy no longer allows and deletes segments that fall entirely before it.
+func (f *FileRepo) ApplyRetention(topic string, now time.Time) (int, error) {
+	f.Mu.Lock()
+	defer f.Mu.Unlock()
+
+	fileTopic, exists := f.Topics[topic]
+	if !exists {
+		return 0, fmt.Errorf("topic %q does not exist", topic)
+	}
+
+	policy := fileTopic.Config.Retention
+	if policy.Unlimited() {
+		return 0, nil
+	}
+
+	total := 0
+	for _, filePartition := range fileTopic.Partitions {
+		start, err := filePartition.retainedStart(policy, now)
+		if err != nil {
+			return total, err
+		}
+
+		dropped := start - filePartition.StartOffset
+		if dropped == 0 {
+			continue
+		}
+
+		filePartition.StartOffset = start
+		if err := fileTopic.saveMeta(); err != nil {
+			return total, err
+		}
+		total += dropped
+
+		// the active segment is kept even when it is empty so publishing can continue
+		for len(filePartition.Segments) > 1 && filePartition.Segments[0].NextOffset <= start {
+			if err := filePartition.Segments[0].remove(); err != nil {
+				return total, err
+			}
+			filePartition.Segments = filePartition.Segments[1:]
+		}
+	}
+
+	return total, nil
+}
+
+func (f *FileRepo) SaveScheduled(scheduled ScheduledMessage) error {
+	f.Mu.Lock()
+	defer f.Mu.Unlock()
+
+	f.Scheduled[scheduled.Message.ID] = scheduledRecord{
+		Topic:     scheduled.Topic,
+		DeliverAt: scheduled.DeliverAt,
+		Record:    newRecord(0, scheduled.Message),
+	}
+	if err := f.saveScheduled(); err != nil {
+		delete(f.Scheduled, scheduled.Message.ID)
+		return err
+	}
+
+	return nil
+}
+
+func (f *FileRepo) DeleteScheduled(messageID string) error {
+	f.Mu.Lock()
+	defer f.Mu.Unlock()
+
+	if _, ok := f.Scheduled[messageID]; !ok {
+		return nil
+	}
+
+	delete(f.Scheduled, messageID)
+	return f.saveScheduled()
+}
+
+func (f *FileRepo) ListScheduled() ([]ScheduledMessage, error) {
+	f.Mu.RLock()
+	defer f.Mu.RUnlock()
+
+	scheduled := make([]ScheduledMessage, 0, len(f.Scheduled))
+	for _, s := range f.Scheduled {
+		scheduled = append(scheduled, ScheduledMessage{
+			Topic:     s.Topic,
+			DeliverAt: s.DeliverAt,
+			Message:   s.Record.message(0),
+		})
+	}
+
+	return scheduled, nil
+}
+
+// Close flushes and closes every open segment. The repo must not be used afterwards.
+func (f *FileRepo) Close() error {
+	f.Mu.Lock()
+	defer f.Mu.Unlock()
+
+	var errs []error
+	for _, topic := range f.Topics {
+		errs = append(errs, topic.close())
+	}
+
+	return errors.Join(errs...)
+}
+
+// partition looks up a single partition of a topic, the caller must hold f.Mu.
+func (f *FileRepo) partition(topic string, partition int) (*filePartition, error) {
+	fileTopic, exists := f.Topics[topic]
+	if !exists {
+		return nil, fmt.Errorf("topic %q does not exist", topic)
+	}
+
+	if partition < 0 || partition >= len(fileTopic.Partitions) {
+		return nil, partitionNotFound(topic, partition)
+	}
+
+	return fileTopic.Partitions[partition], nil
+}
+
+func (f *FileRepo) loadScheduled() error {
+	data, err := os.ReadFile(filepath.Join(f.Dir, scheduledFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read scheduled messages: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &f.Scheduled); err != nil {
+		return fmt.Errorf("failed to decode scheduled messages: %w", err)
+	}
+	return nil
+}
+
+// saveScheduled rewrites the scheduled messages file, the caller must hold f.Mu.
+func (f *FileRepo) saveScheduled() error {
+	data, err := json.Marshal(f.Scheduled)
+	if err != nil {
+		return err
+	}
+
+	return writeFileAtomic(filepath.Join(f.Dir, scheduledFile), data)
+}
+
+func (f *FileRepo) SaveTransaction(txn core.Transaction) error {
+	f.Mu.Lock()
+	defer f.Mu.Unlock()
+
+	previous, existed := f.Transactions[txn.ID]
+	f.Transactions[txn.ID] = txn
+	if err := f.saveTransactions(); err != nil {
+		if existed {
+			f.Transactions[txn.ID] = previous
+		} else {
+			delete(f.Transactions, txn.ID)
+		}
+		return err
+	}
+
+	return nil
+}
+
+func (f *FileRepo) DeleteTransaction(id string) error {
+	f.Mu.Lock()
+	defer f.Mu.Unlock()
+
+	if _, ok := f.Transactions[id]; !ok {
+		return nil
+	}
+
+	delete(f.Transactions, id)
+	return f.saveTransactions()
+}
+
+func (f *FileRepo) ListTransactions() ([]core.Transaction, error) {
+	f.Mu.RLock()
+	defer f.Mu.RUnlock()
+
+	transactions := make([]core.Transaction, 0, len(f.Transactions))
+	for _, txn := range f.Transactions {
+		transactions = append(transactions, txn)
+	}
+
+	return transactions, nil
+}
+
+func (f *FileRepo) loadTransactions() error {
+	data, err := os.ReadFile(filepath.Join(f.Dir, transactionsFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read transactions: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &f.Transactions); err != nil {
+		return fmt.Errorf("failed to decode transactions: %w", err)
+	}
+	return nil
+}
+
+// saveTransactions rewrites the transactions file, the caller must hold f.Mu.
+func (f *FileRepo) saveTransactions() error {
+	data, err := json.Marshal(f.Transactions)
+	if err != nil {
+		return err
+	}
+
+	return writeFileAtomic(filepath.Join(f.Dir, transactionsFile), data)
+}
+
+func loadFileTopic(dir string) (*fileTopic, error) {
+	data, err := os.ReadFile(filepath.Join(dir, metaFile))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read topic metadata: %w", err)
+	}
+
+	var meta topicMeta
+	if err := json.Unmarshal(data, &meta); err != nil {
+		return nil, fmt.Errorf("failed to decode topic metadata: %w", err)
+	}
+
+	topic := &fileTopic{
+		Dir:    dir,
+		Config: meta.Config,
+	}
+
+	for i := 0; i < meta.Config.PartitionCount(); i++ {
+		startOffset := 0
+		if i < len(meta.StartOffsets) {
+			startOffset = meta.StartOffsets[i]
+		}
+
+		partition, err := openFilePartition(topic.partitionDir(i), i, startOffset)
+		if err != nil {
+			topic.close()
+			return nil, fmt.Errorf("failed to load partition %d: %w", i, err)
+		}
+		topic.Partitions = append(topic.Partitions, partition)
+	}
+
+	return topic, nil
+}
+
+func (t *fileTopic) partitionDir(partition int) string {
+	return filepath.Join(t.Dir, strconv.Itoa(partition))
+}
+
+func (t *fileTopic) saveMeta() error {
+	meta := topicMeta{
+		Config:       t.Config,
+		StartOffsets: make([]int, len(t.Partitions)),
+	}
+	for i, partition := range t.Partitions {
+		meta.StartOffsets[i] = partition.StartOffset
+	}
+
+	data, err := json.Marshal(meta)
+	if err != nil {
+		return err
+	}
+
+	return writeFileAtomic(filepath.Join(t.Dir, metaFile), data)
+}
+
+func (t *fileTopic) close() error {
+	var errs []error
+	for _, partition := range t.Partitions {
+		errs = append(errs, partition.close())
+	}
+
+	return errors.Join(errs...)
+}
+
+func openFilePartition(dir string, index, startOffset int) (*filePartition, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create partition directory: %w", err)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	partition := &filePartition{
+		Dir:         dir,
+		Index:       index,
+		StartOffset: startOffset,
+		Offsets:     map[string]int{},
+	}
+
+	var bases []int
+	for _, entry := range entries {
+		if !strings.HasSuffix(entry.Name(), logSuffix) {
+			continue
+		}
+		base, err := strconv.Atoi(strings.TrimSuffix(entry.Name(), logSuffix))
+		if err != nil {
+			continue
+		}
+		bases = append(bases, base)
+	}
+	sort.Ints(bases)
+	if len(bases) == 0 {
+		bases = []int{startOffset}
+	}
+
+	for i, base := range bases {
+		seg, err := openSegment(dir, base)
+		if err != nil {
+			partition.close()
+			return nil, err
+		}
+		// a segment that stops short of the next one means the log lost data in the
+		// middle, which we cannot serve around
+		if i > 0 && partition.Segments[i-1].NextOffset != base {
+			seg.close()
+			partition.close()
+			return nil, fmt.Errorf("segment %d does not follow on from the previous segment", base)
+		}
+		partition.Segments = append(partition.Segments, seg)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, offsetsFile))
+	if err != nil && !os.IsNotExist(err) {
+		partition.close()
+		return nil, err
+	}
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &partition.Offsets); err != nil {
+			partition.close()
+			return nil, fmt.Errorf("failed to decode committed offsets: %w", err)
+		}
+	}
+
+	return partition, nil
+}
+
+func (p *filePartition) nextOffset() int {
+	return p.Segments[len(p.Segments)-1].NextOffset
+}
+
+func (p *filePartition) read(offset, limit int) ([]*core.Message, error) {
+	messages := []*core.Message{}
+	if offset >= p.nextOffset() || limit <= 0 {
+		return messages, nil
+	}
+
+	err := p.scan(offset, func(rec record, _, _ int64) bool {
+		messages = append(messages, rec.message(p.Index))
+		return len(messages) < limit
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return messages, nil
+}
+
+// retainedStart works out the first offset the retention policy still allows.
+func (p *filePartition) retainedStart(policy core.RetentionPolicy, now time.Time) (int, error) {
+	end := p.nextOffset()
+	start := p.StartOffset
+	if policy.MaxMessages > 0 && end-start > policy.MaxMessages {
+		start = end - policy.MaxMessages
+	}
+
+	if (policy.MaxAge <= 0 && policy.MaxBytes <= 0) || start >= end {
+		return start, nil
+	}
+
+	bytes, err := p.bytesFrom(start)
+	if err != nil {
+		return 0, err
+	}
+
+	err = p.scan(start, func(rec record, _, _ int64) bool {
+		expired := policy.MaxAge > 0 && now.Sub(rec.Timestamp) > policy.MaxAge
+		overBytes := policy.MaxBytes > 0 && bytes > policy.MaxBytes
+		if !expired && !overBytes {
+			return false
+		}
+		bytes -= int64(len(rec.Body))
+		start = rec.Offset + 1
+		return true
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return start, nil
+}
+
+// segmentFor returns the index of the segment holding offset.
+func (p *filePartition) segmentFor(offset int) int {
+	i := sort.Search(len(p.Segments), func(i int) bool {
+		return p.Segments[i].BaseOffset > offset
+	}) - 1
+	if i < 0 {
+		i = 0
+	}
+
+	return i
+}
+
+// offsetForTimestamp finds the last segment whose first retained record is older
+// than timestamp and scans on from there, timestamps only go up along the log.
+func (p *filePartition) offsetForTimestamp(timestamp time.Time) (int, error) {
+	start := p.StartOffset
+	for i := len(p.Segments) - 1; i >= 0; i-- {
+		from := max(p.StartOffset, p.Segments[i].BaseOffset)
+		var first *record
+		if _, err := p.Segments[i].scan(from, func(rec record, _, _ int64) bool {
+			first = &rec
+			return false
+		}); err != nil {
+			return 0, err
+		}
+		if first != nil && first.Timestamp.Before(timestamp) {
+			start = from
+			break
+		}
+	}
+
+	offset := p.nextOffset()
+	err := p.scan(start, func(rec record, _, _ int64) bool {
+		if rec.Timestamp.Before(timestamp) {
+			return true
+		}
+		offset = rec.Offset
+		return false
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return offset, nil
+}
+
+// scan calls fn for every record from offset onwards, in order, until fn returns false.
+func (p *filePartition) scan(offset int, fn func(rec record, pos, size int64) bool) error {
+	for i := p.segmentFor(offset); i < len(p.Segments); i++ {
+		stopped, err := p.Segments[i].scan(offset, fn)
+		if err != nil {
+			return err
+		}
+		if stopped {
+			return nil
+		}
+	}
+
+	return nil
+}
+
+// bytesFrom returns the body size of every record from offset to the end of the log.
+func (p *filePartition) bytesFrom(offset int) (int64, error) {
+	i := p.segmentFor(offset)
+
+	var total int64
+	for _, seg := range p.Segments[i:] {
+		total += seg.Bytes
+	}
+
+	// the records before offset in its segment are not counted
+	first := p.Segments[i]
+	if _, err := first.scan(first.BaseOffset, func(rec record, _, _ int64) bool {
+		if rec.Offset >= offset {
+			return false
+		}
+		total -= int64(len(rec.Body))
+		return true
+	}); err != nil {
+		return 0, err
+	}
+
+	return total, nil
+}
+
+func (p *filePartition) saveOffsets() error {
+	data, err := json.Marshal(p.Offsets)
+	if err != nil {
+		return err
+	}
+
+	return writeFileAtomic(filepath.Join(p.Dir, offsetsFile), data)
+}
+
+func (p *filePartition) close() error {
+	var errs []error
+	for _, seg := range p.Segments {
+		errs = append(errs, seg.close())
+	}
+
+	return errors.Join(errs...)
+}
+
+// writeFileAtomic writes to a temp file first so a crash never leaves a half written file behind.
+func writeFileAtomic(path string, data []byte) error {
+	if err := os.WriteFile(path+".tmp", data, 0o644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", filepath.Base(path), err)
+	}
+
+	return os.Rename(path+".tmp", path)
+}
+
+func segmentPath(dir string, base int, suffix string) string {
+	return filepath.Join(dir, fmt.Sprintf("%020d%s", base, suffix))
+}
+
+// openSegment opens or creates the segment starting at base. Any torn or corrupt
+// records at the tail of the log, left behind by a crash mid write, are truncated.
+func openSegment(dir string, base int) (*segment, error) {
+	log, err := os.OpenFile(segmentPath(dir, base, logSuffix), os.O_RDWR|os.O_CREATE|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open segment log: %w", err)
+	}
+
+	indexFile, err := os.OpenFile(segmentPath(dir, base, indexSuffix), os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		log.Close()
+		return nil, fmt.Errorf("failed to open segment index: %w", err)
+	}
+
+	seg := &segment{
+		BaseOffset: base,
+		NextOffset: base,
+		Log:        log,
+		IndexFile:  indexFile,
+	}
+
+	if err := seg.recover(); err != nil {
+		seg.close()
+		return nil, err
+	}
+	if _, err := seg.scan(base, func(rec record, _, _ int64) bool {
+		seg.Bytes += int64(len(rec.Body))
+		return true
+	}); err != nil {
+		seg.close()
+		return nil, err
+	}
+
+	return seg, nil
+}
+
+func (s *segment) recover() error {
+	info, err := s.Log.Stat()
+	if err != nil {
+		return err
+	}
+	logSize := info.Size()
+
+	data, err := io.ReadAll(s.IndexFile)
+	if err != nil {
+		return fmt.Errorf("failed to read segment index: %w", err)
+	}
+	for i := 0; i+indexEntrySize <= len(data); i += indexEntrySize {
+		entry := indexEntry{
+			RelOffset: binary.BigEndian.Uint32(data[i:]),
+			Position:  binary.BigEndian.Uint32(data[i+4:]),
+		}
+		if int64(entry.Position) >= logSize {
+			break
+		}
+		s.Index = append(s.Index, entry)
+	}
+
+	// replay everything after the last indexed position to find the end of the log
+	var pos int64
+	if len(s.Index) > 0 {
+		last := s.Index[len(s.Index)-1]
+		pos = int64(last.Position)
+		s.NextOffset = s.BaseOffset + int(last.RelOffset)
+		s.Index = s.Index[:len(s.Index)-1]
+	}
+
+	reader := bufio.NewReader(io.NewSectionReader(s.Log, pos, logSize-pos))
+	for {
+		rec, n, err := readRecord(reader)
+		if err != nil {
+			break
+		}
+		s.maybeIndex(rec.Offset, pos)
+		pos += n
+		s.NextOffset = rec.Offset + 1
+	}
+	s.Size = pos
+
+	if pos < logSize {
+		if err := s.Log.Truncate(pos); err != nil {
+			return fmt.Errorf("failed to truncate corrupt segment tail: %w", err)
+		}
+	}
+
+	if len(s.Index)*indexEntrySize != len(data) {
+		return s.rewriteIndex()
+	}
+
+	return nil
+}
+
+func (s *segment) maybeIndex(offset int, pos int64) bool {
+	if len(s.Index) > 0 && pos-int64(s.Index[len(s.Index)-1].Position) < indexIntervalBytes {
+		return false
+	}
+
+	s.Index = append(s.Index, indexEntry{
+		RelOffset: uint32(offset - s.BaseOffset),
+		Position:  uint32(pos),
+	})
+	return true
+}
+
+func (s *segment) rewriteIndex() error {
+	buf := make([]byte, 0, len(s.Index)*indexEntrySize)
+	for _, entry := range s.Index {
+		buf = binary.BigEndian.AppendUint32(buf, entry.RelOffset)
+		buf = binary.BigEndian.AppendUint32(buf, entry.Position)
+	}
+
+	if err := s.IndexFile.Truncate(0); err != nil {
+		return fmt.Errorf("failed to rewrite segment index: %w", err)
+	}
+	if _, err := s.IndexFile.WriteAt(buf, 0); err != nil {
+		return fmt.Errorf("failed to rewrite segment index: %w", err)
+	}
+
+	return nil
+}
+
+// append writes rec to the end of the log. On failure the log and index are cut
+// back to where they were, so the next append does not land after a partial
+// record.
+func (s *segment) append(rec record) error {
+	payload, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	if len(payload) > maxRecordBytes {
+		return fmt.Errorf("record of %d bytes exceeds the maximum of %d", len(payload), maxRecordBytes)
+	}
+
+	buf := make([]byte, recordHeaderSize, recordHeaderSize+len(payload))
+	binary.BigEndian.PutUint32(buf[0:], uint32(len(payload)))
+	binary.BigEndian.PutUint32(buf[4:], crc32.ChecksumIEEE(payload))
+	buf = append(buf, payload...)
+
+	indexLen := len(s.Index)
+	undo := func(err error) error {
+		s.Index = s.Index[:indexLen]
+		return errors.Join(err, s.Log.Truncate(s.Size), s.IndexFile.Truncate(int64(indexLen*indexEntrySize)))
+	}
+
+	if _, err := s.Log.Write(buf); err != nil {
+		return undo(err)
+	}
+
+	if s.maybeIndex(rec.Offset, s.Size) {
+		entry := s.Index[len(s.Index)-1]
+		var raw [indexEntrySize]byte
+		binary.BigEndian.PutUint32(raw[0:], entry.RelOffset)
+		binary.BigEndian.PutUint32(raw[4:], entry.Position)
+		if _, err := s.IndexFile.WriteAt(raw[:], int64((len(s.Index)-1)*indexEntrySize)); err != nil {
+			return undo(err)
+		}
+	}
+
+	s.Size += int64(len(buf))
+	s.Bytes += int64(len(rec.Body))
+	s.NextOffset = rec.Offset + 1
+	return nil
+}
+
+// scan calls fn for every record in the segment from offset onwards and reports
+// whether fn asked to stop.
+func (s *segment) scan(offset int, fn func(rec record, pos, size int64) bool) (bool, error) {
+	if offset < s.BaseOffset {
+		offset = s.BaseOffset
+	}
+	if offset >= s.NextOffset {
+		return false, nil
+	}
+
+	// start from the closest indexed position at or before the requested offset
+	rel := uint32(offset - s.BaseOffset)
+	i := sort.Search(len(s.Index), func(i int) bool {
+		return s.Index[i].RelOffset > rel
+	}) - 1
+
+	var pos int64
+	if i >= 0 {
+		pos = int64(s.Index[i].Position)
+	}
+
+	reader := bufio.NewReader(io.NewSectionReader(s.Log, pos, s.Size-pos))
+	for {
+		rec, n, err := readRecord(reader)
+		if err == io.EOF {
+			return false, nil
+		}
+		if err != nil {
+			return false, fmt.Errorf("failed to read segment %d: %w", s.BaseOffset, err)
+		}
+		if rec.Offset >= offset && !fn(rec, pos, n) {
+			return true, nil
+		}
+		pos += n
+	}
+}
+
+// truncate drops everything appended after the segment was size bytes long with
+// nextOffset as its next offset, bytes of record bodies and indexLen index entries.
+func (s *segment) truncate(size int64, nextOffset int, bytes int64, indexLen int) error {
+	if err := s.Log.Truncate(size); err != nil {
+		return fmt.Errorf("failed to truncate segment log: %w", err)
+	}
+	if err := s.IndexFile.Truncate(int64(indexLen * indexEntrySize)); err != nil {
+		return fmt.Errorf("failed to truncate segment index: %w", err)
+	}
+
+	s.Size = size
+	s.NextOffset = nextOffset
+	s.Bytes = bytes
+	s.Index = s.Index[:indexLen]
+	return nil
+}
+
+func (s *segment) sync() error {
+	if err := s.Log.Sync(); err != nil {
+		return err
+	}
+	return s.IndexFile.Sync()
+}
+
+func (s *segment) close() error {
+	return errors.Join(s.sync(), s.Log.Close(), s.IndexFile.Close())
+}
+
+func (s *segment) remove() error {
+	logPath, indexPath := s.Log.Name(), s.IndexFile.Name()
+	s.Log.Close()
+	s.IndexFile.Close()
+
+	return errors.Join(os.Remove(logPath), os.Remove(indexPath))
+}
+
+func readRecord(r io.Reader) (record, int64, error) {
+	var header [recordHeaderSize]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		if err == io.ErrUnexpectedEOF {
+			return record{}, 0, fmt.Errorf("torn record header: %w", err)
+		}
+		return record{}, 0, err
+	}
+
+	length := binary.BigEndian.Uint32(header[0:])
+	checksum := binary.BigEndian.Uint32(header[4:])
+	if length > maxRecordBytes {
+		return record{}, 0, fmt.Errorf("record length %d exceeds the maximum of %d", length, maxRecordBytes)
+	}
+
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		return record{}, 0, fmt.Errorf("torn record payload: %w", err)
+	}
+	if crc32.ChecksumIEEE(payload) != checksum {
+		return record{}, 0, errors.New("record checksum mismatch")
+	}
+
+	var rec record
+	if err := json.Unmarshal(payload, &rec); err != nil {
+		return record{}, 0, fmt.Errorf("failed to decode record: %w", err)
+	}
+
+	return rec, int64(recordHeaderSize + len(payload)), nil
+}
+
+func newRecord(offset int, msg *core.Message) record {
+	return record{
+		Offset:     offset,
+		ID:         msg.ID,
+		Key:        msg.Key,
+		Body:       msg.Body,
+		Timestamp:  msg.Timestamp,
+		ProducerID: msg.ProducerID,
+		Metadata:   msg.Metadata,
+	}
+}
+
+func (r record) message(partition int) *core.Message {
+	metadata := r.Metadata
+	if metadata == nil {
+		metadata = make(map[string]string)
+	}
+
+	return &core.Message{
+		ID:          r.ID,
+		Key:         r.Key,
+		Partition:   partition,
+		Offset:      r.Offset,
+		Body:        r.Body,
+		Timestamp:   r.Timestamp,
+		ProducerID:  r.ProducerID,
+		DeliveredTo: make(map[string]bool),
+		AckedBy:     make(map[string]bool),
+		Metadata:    metadata,
+	}
+}
@@ -0,0 +1,415 @@
+package repository
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/codytheroux96/go-mq/internal/core"
+)
+
+func TestFileRepo(t *testing.T) {
+	dir := t.TempDir()
+
+	repo, err := NewFileRepo(dir)
+	if err != nil {
+		t.Fatalf("failed to open file repo: %v", err)
+	}
+	// small segments so the tests below cross segment boundaries
+	repo.SegmentBytes = 256
+
+	tests := []struct {
+		name      string
+		action    func() (any, error)
+		expectErr bool
+		expectVal any
+	}{
+		{
+			name: "Create new topic",
+			action: func() (any, error) {
+				return nil, repo.CreateTopic("test-topic", core.TopicConfig{})
+			},
+			expectErr: false,
+		},
+		{
+			name: "Create duplicate topic",
+			action: func() (any, error) {
+				return nil, repo.CreateTopic("test-topic", core.TopicConfig{})
+			},
+			expectErr: true,
+		},
+		{
+			name: "Publish messages across several segments",
+			action: func() (any, error) {
+				for i := 0; i < 20; i++ {
+					msg := core.NewMessage([]byte(fmt.Sprintf("Test message %d", i)), "p1")
+					msg.ID = fmt.Sprintf("msg-%d", i)
+					if err := repo.Publish("test-topic", 0, msg); err != nil {
+						return nil, err
+					}
+				}
+				return len(repo.Topics["test-topic"].Partitions[0].Segments) > 1, nil
+			},
+			expectErr: false,
+			expectVal: true,
+		},
+		{
+			name: "Publish to missing topic",
+			action: func() (any, error) {
+				return nil, repo.Publish("missing-topic", 0, core.NewMessage([]byte("x"), "p1"))
+			},
+			expectErr: true,
+		},
+		{
+			name: "Fetch first message batch",
+			action: func() (any, error) {
+				return repo.Fetch("test-topic", 0, "consumer-1", 5)
+			},
+			expectErr: false,
+			expectVal: 5,
+		},
+		{
+			name: "Commit offset in a later segment",
+			action: func() (any, error) {
+				return nil, repo.CommitOffset("test-topic", 0, "consumer-1", 17)
+			},
+			expectErr: false,
+		},
+		{
+			name: "Commit offset beyond end of topic",
+			action: func() (any, error) {
+				return nil, repo.CommitOffset("test-topic", 0, "consumer-1", 21)
+			},
+			expectErr: true,
+		},
+		{
+			name: "Fetch remaining messages",
+			action: func() (any, error) {
+				return repo.Fetch("test-topic", 0, "consumer-1", 10)
+			},
+			expectErr: false,
+			expectVal: 3,
+		},
+		{
+			name: "Reopen and read committed offset",
+			action: func() (any, error) {
+				if err := repo.Close(); err != nil {
+					return nil, err
+				}
+				reopened, err := NewFileRepo(dir)
+				if err != nil {
+					return nil, err
+				}
+				repo = reopened
+				return repo.GetOffset("test-topic", 0, "consumer-1")
+			},
+			expectErr: false,
+			expectVal: 17,
+		},
+		{
+			name: "Fetch after reopen returns the same messages",
+			action: func() (any, error) {
+				messages, err := repo.Fetch("test-topic", 0, "consumer-1", 1)
+				if err != nil {
+					return nil, err
+				}
+				if messages[0].ID != "msg-17" || messages[0].Offset != 17 || string(messages[0].Body) != "Test message 17" {
+					return nil, fmt.Errorf("unexpected message after reopen: %+v", messages[0])
+				}
+				return messages, nil
+			},
+			expectErr: false,
+			expectVal: 1,
+		},
+		{
+			name: "Publish after reopen continues the offset sequence",
+			action: func() (any, error) {
+				msg := core.NewMessage([]byte("after reopen"), "p1")
+				if err := repo.Publish("test-topic", 0, msg); err != nil {
+					return nil, err
+				}
+				return msg.Offset, nil
+			},
+			expectErr: false,
+			expectVal: 20,
+		},
+		{
+			name: "Deleted offsets stay deleted after reopen",
+			action: func() (any, error) {
+				if err := repo.DeleteOffsets("test-topic", "consumer-1"); err != nil {
+					return nil, err
+				}
+				if err := repo.Close(); err != nil {
+					return nil, err
+				}
+				reopened, err := NewFileRepo(dir)
+				if err != nil {
+					return nil, err
+				}
+				repo = reopened
+				offsets, err := repo.ListOffsets("test-topic", 0)
+				return len(offsets), err
+			},
+			expectErr: false,
+			expectVal: 0,
+		},
+		{
+			name: "Delete topic removes its directory",
+			action: func() (any, error) {
+				if err := repo.DeleteTopic("test-topic"); err != nil {
+					return nil, err
+				}
+				_, err := os.Stat(filepath.Join(dir, "test-topic"))
+				return os.IsNotExist(err), nil
+			},
+			expectErr: false,
+			expectVal: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.action()
+
+			if tt.expectErr && err == nil {
+				t.Fatalf("expected error but got nil")
+			}
+			if !tt.expectErr && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if tt.expectVal != nil {
+				switch expected := tt.expectVal.(type) {
+				case int:
+					switch v := got.(type) {
+					case int:
+						if v != expected {
+							t.Fatalf("expected offset %d, got %d", expected, v)
+						}
+					case []*core.Message:
+						if len(v) != expected {
+							t.Fatalf("expected %d messages, got %d", expected, len(v))
+						}
+					default:
+						t.Fatalf("unexpected return type: %T", got)
+					}
+				case bool:
+					if got != expected {
+						t.Fatalf("expected %v, got %v", expected, got)
+					}
+				default:
+					t.Fatalf("unsupported expectVal type: %T", tt.expectVal)
+				}
+			}
+		})
+	}
+
+	repo.Close()
+}
+
+func TestFileRepoRecoversTornWrite(t *testing.T) {
+	tests := []struct {
+		name string
+		tail []byte
+	}{
+		{"Torn payload", []byte{0, 0, 0, 42, 1, 2}},
+		{"Torn header", []byte{0, 0, 0}},
+		{"Corrupt length", []byte{0xff, 0xff, 0xff, 0xff, 0, 0, 0, 0, 1, 2}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+
+			repo, err := NewFileRepo(dir)
+			if err != nil {
+				t.Fatalf("failed to open file repo: %v", err)
+			}
+			if err := repo.CreateTopic("torn", core.TopicConfig{}); err != nil {
+				t.Fatalf("failed to create topic: %v", err)
+			}
+			for i := 0; i < 3; i++ {
+				if err := repo.Publish("torn", 0, core.NewMessage([]byte("ok"), "p1")); err != nil {
+					t.Fatalf("failed to publish: %v", err)
+				}
+			}
+			repo.Close()
+
+			// simulate a crash half way through writing a fourth record
+			log, err := os.OpenFile(segmentPath(filepath.Join(dir, "torn", "0"), 0, logSuffix), os.O_WRONLY|os.O_APPEND, 0o644)
+			if err != nil {
+				t.Fatalf("failed to open segment: %v", err)
+			}
+			log.Write(tt.tail)
+			log.Close()
+
+			repo, err = NewFileRepo(dir)
+			if err != nil {
+				t.Fatalf("failed to reopen file repo: %v", err)
+			}
+			defer repo.Close()
+
+			messages, err := repo.Fetch("torn", 0, "c1", 10)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(messages) != 3 {
+				t.Fatalf("expected 3 messages, got %d", len(messages))
+			}
+
+			msg := core.NewMessage([]byte("next"), "p1")
+			if err := repo.Publish("torn", 0, msg); err != nil {
+				t.Fatalf("failed to publish after recovery: %v", err)
+			}
+			if msg.Offset != 3 {
+				t.Fatalf("expected offset 3 after recovery, got %d", msg.Offset)
+			}
+		})
+	}
+}
+
+func TestFileRepoUndoesFailedAppend(t *testing.T) {
+	repo, err := NewFileRepo(t.TempDir())
+	if err != nil {
+		t.Fatalf("failed to open file repo: %v", err)
+	}
+	defer repo.Close()
+	if err := repo.CreateTopic("events", core.TopicConfig{}); err != nil {
+		t.Fatalf("failed to create topic: %v", err)
+	}
+
+	// the first record of a segment is always indexed, so a broken index fails it
+	// after the log write went through
+	seg := repo.Topics["events"].Partitions[0].Segments[0]
+	indexPath := seg.IndexFile.Name()
+	seg.IndexFile.Close()
+	if err := repo.Publish("events", 0, core.NewMessage([]byte("lost"), "p1")); err == nil {
+		t.Fatalf("expected the publish to fail")
+	}
+
+	info, err := seg.Log.Stat()
+	if err != nil {
+		t.Fatalf("failed to stat segment: %v", err)
+	}
+	if info.Size() != 0 || seg.Size != 0 || len(seg.Index) != 0 || seg.NextOffset != 0 {
+		t.Fatalf("expected the failed append to be undone, got %d bytes on disk, size %d, %d index entries", info.Size(), seg.Size, len(seg.Index))
+	}
+
+	if seg.IndexFile, err = os.OpenFile(indexPath, os.O_RDWR, 0o644); err != nil {
+		t.Fatalf("failed to reopen index: %v", err)
+	}
+	msg := core.NewMessage([]byte("kept"), "p1")
+	if err := repo.Publish("events", 0, msg); err != nil {
+		t.Fatalf("failed to publish: %v", err)
+	}
+	messages, err := repo.Read("events", 0, 0, 10)
+	if err != nil || len(messages) != 1 || string(messages[0].Body) != "kept" {
+		t.Fatalf("expected only the kept message at offset 0, got %v %v", messages, err)
+	}
+}
+
+func TestFileRepoPartitions(t *testing.T) {
+	dir := t.TempDir()
+
+	repo, err := NewFileRepo(dir)
+	if err != nil {
+		t.Fatalf("failed to open file repo: %v", err)
+	}
+	if err := repo.CreateTopic("partitioned", core.TopicConfig{Partitions: 2}); err != nil {
+		t.Fatalf("failed to create topic: %v", err)
+	}
+
+	for i := 0; i < 4; i++ {
+		msg := core.NewMessage([]byte(fmt.Sprintf("message %d", i)), "p1")
+		msg.Key = fmt.Sprintf("key-%d", i)
+		if err := repo.Publish("partitioned", i%2, msg); err != nil {
+			t.Fatalf("failed to publish: %v", err)
+		}
+		if msg.Offset != i/2 {
+			t.Fatalf("expected offset %d within partition %d, got %d", i/2, i%2, msg.Offset)
+		}
+	}
+	if err := repo.CommitOffset("partitioned", 1, "c1", 1); err != nil {
+		t.Fatalf("failed to commit offset: %v", err)
+	}
+	repo.Close()
+
+	repo, err = NewFileRepo(dir)
+	if err != nil {
+		t.Fatalf("failed to reopen file repo: %v", err)
+	}
+	defer repo.Close()
+
+	messages, err := repo.Fetch("partitioned", 1, "c1", 10)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(messages) != 1 || messages[0].Key != "key-3" || messages[0].Partition != 1 {
+		t.Fatalf("expected key-3 from partition 1, got %+v", messages)
+	}
+
+	if offset, _ := repo.GetOffset("partitioned", 0, "c1"); offset != 0 {
+		t.Fatalf("expected partition 0 offset to be untouched, got %d", offset)
+	}
+
+	if _, err := repo.Fetch("partitioned", 2, "c1", 10); err == nil {
+		t.Fatalf("expected error fetching a partition that does not exist")
+	}
+}
+
+func TestFileRepoTransactions(t *testing.T) {
+	dir := t.TempDir()
+
+	repo, err := NewFileRepo(dir)
+	if err != nil {
+		t.Fatalf("failed to open file repo: %v", err)
+	}
+	if err := repo.CreateTopic("orders", core.TopicConfig{}); err != nil {
+		t.Fatalf("failed to create topic: %v", err)
+	}
+	for i := 0; i < 5; i++ {
+		if err := repo.Publish("orders", 0, core.NewMessage([]byte("order"), "p1")); err != nil {
+			t.Fatalf("failed to publish: %v", err)
+		}
+	}
+
+	// Read does not move any consumer's offset
+	messages, err := repo.Read("orders", 0, 2, 2)
+	if err != nil {
+		t.Fatalf("failed to read: %v", err)
+	}
+	if len(messages) != 2 || messages[0].Offset != 2 {
+		t.Fatalf("expected 2 messages from offset 2, got %d", len(messages))
+	}
+	if messages, err := repo.Read("orders", 0, 5, 1); err != nil || len(messages) != 0 {
+		t.Fatalf("expected nothing at the end of the partition, got %d messages and %v", len(messages), err)
+	}
+
+	for _, txn := range []core.Transaction{
+		{ID: "t1", State: core.TransactionAborted, Partitions: []core.TransactionPartition{{Topic: "orders", FirstOffset: 1, LastOffset: 3}}},
+		{ID: "t2", State: core.TransactionOpen},
+	} {
+		if err := repo.SaveTransaction(txn); err != nil {
+			t.Fatalf("failed to save transaction: %v", err)
+		}
+	}
+	if err := repo.DeleteTransaction("t2"); err != nil {
+		t.Fatalf("failed to delete transaction: %v", err)
+	}
+	repo.Close()
+
+	repo, err = NewFileRepo(dir)
+	if err != nil {
+		t.Fatalf("failed to reopen file repo: %v", err)
+	}
+	defer repo.Close()
+
+	txns, err := repo.ListTransactions()
+	if err != nil {
+		t.Fatalf("failed to list transactions: %v", err)
+	}
+	if len(txns) != 1 || txns[0].ID != "t1" || txns[0].Partitions[0].LastOffset != 3 {
+		t.Fatalf("expected the aborted transaction to survive a restart, got %+v", txns)
+	}
+}
@@ -2,29 +2,43 @@ package repository
 
 import (
 	"fmt"
+	"maps"
+	"sort"
 	"sync"
+	"time"
 
 	"github.com/codytheroux96/go-mq/internal/core"
 )
 
 type InMemoryRepo struct {
-	Topics map[string]*topicEntry
-	Mu     sync.RWMutex
+	Topics       map[string]*topicEntry
+	Scheduled    map[string]ScheduledMessage // messageID -> scheduled message
+	Transactions map[string]core.Transaction
+	Mu           sync.RWMutex
 }
 
 type topicEntry struct {
-	Messages    []*core.Message
-	Offsets     map[string]int // consumerID -> offset
+	Config      core.TopicConfig
+	Partitions  []*partitionEntry
 	Subscribers map[string]*core.Consumer
 }
 
+type partitionEntry struct {
+	Messages   []*core.Message
+	BaseOffset int // offset of Messages[0], moves forward as retention drops messages
+	Bytes      int64
+	Offsets    map[string]int // consumerID -> offset
+}
+
 func NewInMemoryRepo() *InMemoryRepo {
 	return &InMemoryRepo{
-		Topics: make(map[string]*topicEntry),
+		Topics:       make(map[string]*topicEntry),
+		Scheduled:    make(map[string]ScheduledMessage),
+		Transactions: make(map[string]core.Transaction),
 	}
 }
 
-func (m *InMemoryRepo) CreateTopic(name string) error {
+func (m *InMemoryRepo) CreateTopic(name string, cfg core.TopicConfig) error {
 	m.Mu.Lock()
 	defer m.Mu.Unlock()
 
@@ -32,9 +46,17 @@ func (m *InMemoryRepo) CreateTopic(name string) error {
 		return fmt.Errorf("topic %q already exists", name)
 	}
 
+	partitions := make([]*partitionEntry, cfg.PartitionCount())
+	for i := range partitions {
+		partitions[i] = &partitionEntry{
+			Messages: []*core.Message{},
+			Offsets:  map[string]int{},
+		}
+	}
+
 	m.Topics[name] = &topicEntry{
-		Messages:    []*core.Message{},
-		Offsets:     map[string]int{},
+		Config:      cfg,
+		Partitions:  partitions,
 		Subscribers: map[string]*core.Consumer{},
 	}
 
@@ -65,56 +87,108 @@ func (m *InMemoryRepo) DeleteTopic(name string) error {
 	return nil
 }
 
-func (m *InMemoryRepo) Fetch(topic, consumerID string, limit int) ([]*core.Message, error) {
+func (m *InMemoryRepo) GetTopicConfig(name string) (core.TopicConfig, error) {
 	m.Mu.RLock()
 	defer m.Mu.RUnlock()
 
-	topicEntry, exists := m.Topics[topic]
+	topicEntry, exists := m.Topics[name]
 	if !exists {
-		return nil, fmt.Errorf("topic %q does not exist", topic)
+		return core.TopicConfig{}, fmt.Errorf("topic %q does not exist", name)
+	}
+
+	return topicEntry.Config, nil
+}
+
+func (m *InMemoryRepo) UpdateTopicConfig(name string, cfg core.TopicConfig) error {
+	m.Mu.Lock()
+	defer m.Mu.Unlock()
+
+	topicEntry, exists := m.Topics[name]
+	if !exists {
+		return fmt.Errorf("topic %q does not exist", name)
+	}
+
+	if err := checkPartitionChange(name, topicEntry.Config, cfg); err != nil {
+		return err
+	}
+
+	topicEntry.Config = cfg
+	return nil
+}
+
+func (m *InMemoryRepo) Fetch(topic string, partition int, consumerID string, limit int) ([]*core.Message, error) {
+	m.Mu.RLock()
+	defer m.Mu.RUnlock()
+
+	partitionEntry, err := m.partition(topic, partition)
+	if err != nil {
+		return nil, err
 	}
 
-	offset := topicEntry.Offsets[consumerID]
+	return partitionEntry.read(topic, partition, partitionEntry.Offsets[consumerID], limit)
+}
 
-	end := offset + limit
-	if end > len(topicEntry.Messages) {
-		end = len(topicEntry.Messages)
+// Read returns up to limit messages starting at offset, without a consumer.
+func (m *InMemoryRepo) Read(topic string, partition int, offset int, limit int) ([]*core.Message, error) {
+	m.Mu.RLock()
+	defer m.Mu.RUnlock()
+
+	partitionEntry, err := m.partition(topic, partition)
+	if err != nil {
+		return nil, err
 	}
 
-	if offset >= len(topicEntry.Messages) {
+	return partitionEntry.read(topic, partition, offset, limit)
+}
+
+func (p *partitionEntry) read(topic string, partition int, offset int, limit int) ([]*core.Message, error) {
+	if offset < p.BaseOffset {
+		return nil, &OffsetOutOfRangeError{Topic: topic, Partition: partition, Offset: offset, LowWatermark: p.BaseOffset}
+	}
+
+	start := offset - p.BaseOffset
+	end := start + limit
+	if end > len(p.Messages) {
+		end = len(p.Messages)
+	}
+
+	if start >= len(p.Messages) {
 		return []*core.Message{}, nil
 	}
 
-	return topicEntry.Messages[offset:end], nil
+	return p.Messages[start:end], nil
 }
 
-func (m *InMemoryRepo) CommitOffset(topic, consumerID string, offset int) error {
+func (m *InMemoryRepo) CommitOffset(topic string, partition int, consumerID string, offset int) error {
 	m.Mu.Lock()
 	defer m.Mu.Unlock()
 
-	topicEntry, exists := m.Topics[topic]
-	if !exists {
-		return fmt.Errorf("topic %q does not exist", topic)
+	partitionEntry, err := m.partition(topic, partition)
+	if err != nil {
+		return err
 	}
 
-	if offset > len(topicEntry.Messages) {
-		return fmt.Errorf("cannot commit offset %d beyond the topic length %d", offset, len(topicEntry.Messages))
+	if end := partitionEntry.BaseOffset + len(partitionEntry.Messages); offset > end {
+		return fmt.Errorf("cannot commit offset %d beyond the partition length %d", offset, end)
+	}
+	if offset < partitionEntry.BaseOffset {
+		return &OffsetOutOfRangeError{Topic: topic, Partition: partition, Offset: offset, LowWatermark: partitionEntry.BaseOffset}
 	}
 
-	topicEntry.Offsets[consumerID] = offset
+	partitionEntry.Offsets[consumerID] = offset
 	return nil
 }
 
-func (m *InMemoryRepo) GetOffset(topic, consumerID string) (int, error) {
+func (m *InMemoryRepo) GetOffset(topic string, partition int, consumerID string) (int, error) {
 	m.Mu.RLock()
 	defer m.Mu.RUnlock()
 
-	topicEntry, exists := m.Topics[topic]
-	if !exists {
-		return 0, fmt.Errorf("topic %q does not exist", topic)
+	partitionEntry, err := m.partition(topic, partition)
+	if err != nil {
+		return 0, err
 	}
 
-	offset, ok := topicEntry.Offsets[consumerID]
+	offset, ok := partitionEntry.Offsets[consumerID]
 	if !ok {
 		return 0, nil
 	}
@@ -122,7 +196,123 @@ func (m *InMemoryRepo) GetOffset(topic, consumerID string) (int, error) {
 	return offset, nil
 }
 
-func (m *InMemoryRepo) Publish(topic string, msg *core.Message) error {
+func (m *InMemoryRepo) Publish(topic string, partition int, msg *core.Message) error {
+	m.Mu.Lock()
+	defer m.Mu.Unlock()
+
+	partitionEntry, err := m.partition(topic, partition)
+	if err != nil {
+		return err
+	}
+
+	partitionEntry.append(partition, msg)
+	return nil
+}
+
+// PublishBatch appends every message to the partition in its Partition field. The
+// partitions are all checked first, so either every message is appended or none is.
+func (m *InMemoryRepo) PublishBatch(topic string, msgs []*core.Message) error {
+	m.Mu.Lock()
+	defer m.Mu.Unlock()
+
+	partitions := make([]*partitionEntry, len(msgs))
+	for i, msg := range msgs {
+		partitionEntry, err := m.partition(topic, msg.Partition)
+		if err != nil {
+			return err
+		}
+		partitions[i] = partitionEntry
+	}
+
+	for i, msg := range msgs {
+		partitions[i].append(msg.Partition, msg)
+	}
+	return nil
+}
+
+func (p *partitionEntry) append(partition int, msg *core.Message) {
+	if msg.Timestamp.IsZero() {
+		msg.Timestamp = time.Now()
+	}
+	msg.Partition = partition
+	msg.Offset = p.BaseOffset + len(p.Messages)
+	p.Messages = append(p.Messages, msg)
+	p.Bytes += int64(len(msg.Body))
+}
+
+func (m *InMemoryRepo) GetEarliestOffset(topic string, partition int) (int, error) {
+	m.Mu.RLock()
+	defer m.Mu.RUnlock()
+
+	partitionEntry, err := m.partition(topic, partition)
+	if err != nil {
+		return 0, err
+	}
+
+	return partitionEntry.BaseOffset, nil
+}
+
+// GetLatestOffset returns the log end offset, the offset the next message
+// published to the partition will get.
+func (m *InMemoryRepo) GetLatestOffset(topic string, partition int) (int, error) {
+	m.Mu.RLock()
+	defer m.Mu.RUnlock()
+
+	partitionEntry, err := m.partition(topic, partition)
+	if err != nil {
+		return 0, err
+	}
+
+	return partitionEntry.BaseOffset + len(partitionEntry.Messages), nil
+}
+
+// GetOffsetForTimestamp returns the first retained offset whose message was
+// appended at or after timestamp, or the log end offset when none was. Timestamps
+// are given in append order, so they only go up within a partition.
+func (m *InMemoryRepo) GetOffsetForTimestamp(topic string, partition int, timestamp time.Time) (int, error) {
+	m.Mu.RLock()
+	defer m.Mu.RUnlock()
+
+	partitionEntry, err := m.partition(topic, partition)
+	if err != nil {
+		return 0, err
+	}
+
+	i := sort.Search(len(partitionEntry.Messages), func(i int) bool {
+		return !partitionEntry.Messages[i].Timestamp.Before(timestamp)
+	})
+	return partitionEntry.BaseOffset + i, nil
+}
+
+// GetPartitionBytes returns the total body size of the messages still retained.
+func (m *InMemoryRepo) GetPartitionBytes(topic string, partition int) (int64, error) {
+	m.Mu.RLock()
+	defer m.Mu.RUnlock()
+
+	partitionEntry, err := m.partition(topic, partition)
+	if err != nil {
+		return 0, err
+	}
+
+	return partitionEntry.Bytes, nil
+}
+
+// ListOffsets returns the committed offset of every consumer and group on the partition.
+func (m *InMemoryRepo) ListOffsets(topic string, partition int) (map[string]int, error) {
+	m.Mu.RLock()
+	defer m.Mu.RUnlock()
+
+	partitionEntry, err := m.partition(topic, partition)
+	if err != nil {
+		return nil, err
+	}
+
+	return maps.Clone(partitionEntry.Offsets), nil
+}
+
+// DeleteOffsets forgets the committed offset of a consumer or group on every
+// partition of the topic.
+func (m *InMemoryRepo) DeleteOffsets(topic string, consumerID string) error {
 	m.Mu.Lock()
 	defer m.Mu.Unlock()
 
@@ -131,6 +321,126 @@ func (m *InMemoryRepo) Publish(topic string, msg *core.Message) error {
 		return fmt.Errorf("topic %q does not exist", topic)
 	}
 
-	topicEntry.Messages = append(topicEntry.Messages, msg)
+	for _, partitionEntry := range topicEntry.Partitions {
+		delete(partitionEntry.Offsets, consumerID)
+	}
+	return nil
+}
+
+func (m *InMemoryRepo) ApplyRetention(topic string, now time.Time) (int, error) {
+	m.Mu.Lock()
+	defer m.Mu.Unlock()
+
+	topicEntry, exists := m.Topics[topic]
+	if !exists {
+		return 0, fmt.Errorf("topic %q does not exist", topic)
+	}
+
+	policy := topicEntry.Config.Retention
+	if policy.Unlimited() {
+		return 0, nil
+	}
+
+	total := 0
+	for _, partitionEntry := range topicEntry.Partitions {
+		total += partitionEntry.applyRetention(policy, now)
+	}
+
+	return total, nil
+}
+
+func (m *InMemoryRepo) SaveScheduled(scheduled ScheduledMessage) error {
+	m.Mu.Lock()
+	defer m.Mu.Unlock()
+
+	m.Scheduled[scheduled.Message.ID] = scheduled
+	return nil
+}
+
+func (m *InMemoryRepo) DeleteScheduled(messageID string) error {
+	m.Mu.Lock()
+	defer m.Mu.Unlock()
+
+	delete(m.Scheduled, messageID)
 	return nil
 }
+
+func (m *InMemoryRepo) ListScheduled() ([]ScheduledMessage, error) {
+	m.Mu.RLock()
+	defer m.Mu.RUnlock()
+
+	scheduled := make([]ScheduledMessage, 0, len(m.Scheduled))
+	for _, s := range m.Scheduled {
+		scheduled = append(scheduled, s)
+	}
+
+	return scheduled, nil
+}
+
+func (m *InMemoryRepo) SaveTransaction(txn core.Transaction) error {
+	m.Mu.Lock()
+	defer m.Mu.Unlock()
+
+	m.Transactions[txn.ID] = txn
+	return nil
+}
+
+func (m *InMemoryRepo) DeleteTransaction(id string) error {
+	m.Mu.Lock()
+	defer m.Mu.Unlock()
+
+	delete(m.Transactions, id)
+	return nil
+}
+
+func (m *InMemoryRepo) ListTransactions() ([]core.Transaction, error) {
+	m.Mu.RLock()
+	defer m.Mu.RUnlock()
+
+	transactions := make([]core.Transaction, 0, len(m.Transactions))
+	for _, txn := range m.Transactions {
+		transactions = append(transactions, txn)
+	}
+
+	return transactions, nil
+}
+
+func (p *partitionEntry) applyRetention(policy core.RetentionPolicy, now time.Time) int {
+	dropped := 0
+	bytes := p.Bytes
+	for _, msg := range p.Messages {
+		expired := policy.MaxAge > 0 && now.Sub(msg.Timestamp) > policy.MaxAge
+		overBytes := policy.MaxBytes > 0 && bytes > policy.MaxBytes
+		overCount := policy.MaxMessages > 0 && len(p.Messages)-dropped > policy.MaxMessages
+		if !expired && !overBytes && !overCount {
+			break
+		}
+		bytes -= int64(len(msg.Body))
+		dropped++
+	}
+
+	if dropped == 0 {
+		return 0
+	}
+
+	// copy the survivors so the dropped messages can be garbage collected
+	p.Messages = append([]*core.Message(nil), p.Messages[dropped:]...)
+	p.BaseOffset += dropped
+	p.Bytes = bytes
+
+	return dropped
+}
+
+// partition looks up a single partition of a topic, the caller must hold m.Mu.
+func (m *InMemoryRepo) partition(topic string, partition int) (*partitionEntry, error) {
+	topicEntry, exists := m.Topics[topic]
+	if !exists {
+		return nil, fmt.Errorf("topic %q does not exist", topic)
+	}
+
+	if partition < 0 || partition >= len(topicEntry.Partitions) {
+		return nil, partitionNotFound(topic, partition)
+	}
+
+	return topicEntry.Partitions[partition], nil
+}
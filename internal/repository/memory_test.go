@@ -18,14 +18,14 @@ func TestInMemoryRepo(t *testing.T) {
 		{
 			name: "Create new topic",
 			action: func() (any, error) {
-				return nil, repo.CreateTopic("test-topic")
+				return nil, repo.CreateTopic("test-topic", core.TopicConfig{})
 			},
 			expectErr: false,
 		},
 		{
 			name: "Create duplicate topic",
 			action: func() (any, error) {
-				return nil, repo.CreateTopic("test-topic")
+				return nil, repo.CreateTopic("test-topic", core.TopicConfig{})
 			},
 			expectErr: true,
 		},
@@ -33,7 +33,7 @@ func TestInMemoryRepo(t *testing.T) {
 			name: "Publish message to topic",
 			action: func() (any, error) {
 				msg := &core.Message{ID: "msg-1", Body: []byte("Test message 1")}
-				return nil, repo.Publish("test-topic", msg)
+				return nil, repo.Publish("test-topic", 0, msg)
 			},
 			expectErr: false,
 		},
@@ -41,14 +41,14 @@ func TestInMemoryRepo(t *testing.T) {
 			name: "Publish another message",
 			action: func() (any, error) {
 				msg := &core.Message{ID: "msg-2", Body: []byte("Test message 2")}
-				return nil, repo.Publish("test-topic", msg)
+				return nil, repo.Publish("test-topic", 0, msg)
 			},
 			expectErr: false,
 		},
 		{
 			name: "Fetch first message batch",
 			action: func() (any, error) {
-				return repo.Fetch("test-topic", "consumer-1", 1)
+				return repo.Fetch("test-topic", 0, "consumer-1", 1)
 			},
 			expectErr: false,
 			expectVal: 1, // expecting 1 message fetched
@@ -56,7 +56,7 @@ func TestInMemoryRepo(t *testing.T) {
 		{
 			name: "Get initial offset",
 			action: func() (any, error) {
-				return repo.GetOffset("test-topic", "consumer-1")
+				return repo.GetOffset("test-topic", 0, "consumer-1")
 			},
 			expectErr: false,
 			expectVal: 0, // offset defaults to 0
@@ -64,22 +64,59 @@ func TestInMemoryRepo(t *testing.T) {
 		{
 			name: "Commit offset after processing",
 			action: func() (any, error) {
-				return nil, repo.CommitOffset("test-topic", "consumer-1", 1)
+				return nil, repo.CommitOffset("test-topic", 0, "consumer-1", 1)
 			},
 			expectErr: false,
 		},
 		{
 			name: "Get updated offset",
 			action: func() (any, error) {
-				return repo.GetOffset("test-topic", "consumer-1")
+				return repo.GetOffset("test-topic", 0, "consumer-1")
 			},
 			expectErr: false,
 			expectVal: 1,
 		},
+		{
+			name: "Create partitioned topic",
+			action: func() (any, error) {
+				return nil, repo.CreateTopic("partitioned-topic", core.TopicConfig{Partitions: 3})
+			},
+			expectErr: false,
+		},
+		{
+			name: "Publish to second partition starts its own offsets",
+			action: func() (any, error) {
+				msg := &core.Message{ID: "msg-3", Body: []byte("Test message 3")}
+				if err := repo.Publish("partitioned-topic", 2, msg); err != nil {
+					return nil, err
+				}
+				return msg.Offset, nil
+			},
+			expectErr: false,
+			expectVal: 0,
+		},
+		{
+			name: "Publish to partition that does not exist",
+			action: func() (any, error) {
+				return nil, repo.Publish("partitioned-topic", 3, &core.Message{ID: "msg-4"})
+			},
+			expectErr: true,
+		},
+		{
+			name: "Offsets are tracked per partition",
+			action: func() (any, error) {
+				if err := repo.CommitOffset("partitioned-topic", 2, "consumer-1", 1); err != nil {
+					return nil, err
+				}
+				return repo.GetOffset("partitioned-topic", 1, "consumer-1")
+			},
+			expectErr: false,
+			expectVal: 0,
+		},
 		{
 			name: "Fetch second message batch",
 			action: func() (any, error) {
-				return repo.Fetch("test-topic", "consumer-1", 1)
+				return repo.Fetch("test-topic", 0, "consumer-1", 1)
 			},
 			expectErr: false,
 			expectVal: 1,
@@ -1,15 +1,68 @@
 package repository
 
 import (
+	"fmt"
+	"time"
+
 	"github.com/codytheroux96/go-mq/internal/core"
 )
 
 type Repository interface {
-	CreateTopic(name string) error
+	CreateTopic(name string, cfg core.TopicConfig) error
 	ListTopics() ([]string, error)
 	DeleteTopic(name string) error
-	Fetch(topic, consumerID string, limit int) ([]*core.Message, error)
-	CommitOffset(topic, consumerID string, offset int) error
-	GetOffset(topic, consumerID string) (int, error)
-	Publish(topic string, msg *core.Message) error
+	GetTopicConfig(name string) (core.TopicConfig, error)
+	UpdateTopicConfig(name string, cfg core.TopicConfig) error
+	Fetch(topic string, partition int, consumerID string, limit int) ([]*core.Message, error)
+	Read(topic string, partition int, offset int, limit int) ([]*core.Message, error)
+	CommitOffset(topic string, partition int, consumerID string, offset int) error
+	GetOffset(topic string, partition int, consumerID string) (int, error)
+	GetEarliestOffset(topic string, partition int) (int, error)
+	GetLatestOffset(topic string, partition int) (int, error)
+	GetOffsetForTimestamp(topic string, partition int, timestamp time.Time) (int, error)
+	GetPartitionBytes(topic string, partition int) (int64, error)
+	ListOffsets(topic string, partition int) (map[string]int, error)
+	DeleteOffsets(topic string, consumerID string) error
+	Publish(topic string, partition int, msg *core.Message) error
+	PublishBatch(topic string, msgs []*core.Message) error
+	ApplyRetention(topic string, now time.Time) (int, error)
+	SaveScheduled(scheduled ScheduledMessage) error
+	DeleteScheduled(messageID string) error
+	ListScheduled() ([]ScheduledMessage, error)
+	SaveTransaction(txn core.Transaction) error
+	DeleteTransaction(id string) error
+	ListTransactions() ([]core.Transaction, error)
+}
+
+// ScheduledMessage is a message held back from its topic until DeliverAt.
+type ScheduledMessage struct {
+	Topic     string
+	DeliverAt time.Time
+	Message   *core.Message
+}
+
+// OffsetOutOfRangeError is returned when an offset points before the earliest
+// message still retained by the topic.
+type OffsetOutOfRangeError struct {
+	Topic        string
+	Partition    int
+	Offset       int
+	LowWatermark int
+}
+
+func (e *OffsetOutOfRangeError) Error() string {
+	return fmt.Sprintf("offset %d is out of range for topic %q partition %d, earliest available offset is %d", e.Offset, e.Topic, e.Partition, e.LowWatermark)
+}
+
+func partitionNotFound(topic string, partition int) error {
+	return fmt.Errorf("partition %d does not exist for topic %q", partition, topic)
+}
+
+// checkPartitionChange rejects config updates that would change a topic's partition
+// count, existing keys would silently start landing on different partitions.
+func checkPartitionChange(topic string, current, next core.TopicConfig) error {
+	if next.PartitionCount() != current.PartitionCount() {
+		return fmt.Errorf("cannot change partition count of topic %q from %d to %d", topic, current.PartitionCount(), next.PartitionCount())
+	}
+	return nil
 }
@@ -0,0 +1,37 @@
+package repository
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// RunJanitor applies every topic's retention policy once per interval until ctx is cancelled.
+func RunJanitor(ctx context.Context, repo Repository, interval time.Duration, logger *slog.Logger) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			topics, err := repo.ListTopics()
+			if err != nil {
+				logger.Error("retention janitor failed to list topics", "error", err)
+				continue
+			}
+
+			for _, topic := range topics {
+				dropped, err := repo.ApplyRetention(topic, now)
+				if err != nil {
+					logger.Error("retention janitor failed to apply retention", "topic", topic, "error", err)
+					continue
+				}
+				if dropped > 0 {
+					logger.Info("retention janitor dropped messages", "topic", topic, "count", dropped)
+				}
+			}
+		}
+	}
+}
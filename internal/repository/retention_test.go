@@ -0,0 +1,192 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/codytheroux96/go-mq/internal/core"
+)
+
+func TestApplyRetention(t *testing.T) {
+	now := time.Now()
+
+	repos := map[string]func(t *testing.T) Repository{
+		"memory": func(t *testing.T) Repository {
+			return NewInMemoryRepo()
+		},
+		"file": func(t *testing.T) Repository {
+			repo, err := NewFileRepo(t.TempDir())
+			if err != nil {
+				t.Fatalf("failed to open file repo: %v", err)
+			}
+			repo.SegmentBytes = 256
+			t.Cleanup(func() { repo.Close() })
+			return repo
+		},
+	}
+
+	tests := []struct {
+		name          string
+		policy        core.RetentionPolicy
+		expectDropped int
+	}{
+		{
+			name:          "Unlimited retention keeps everything",
+			policy:        core.RetentionPolicy{},
+			expectDropped: 0,
+		},
+		{
+			name:          "Max messages keeps the newest messages",
+			policy:        core.RetentionPolicy{MaxMessages: 4},
+			expectDropped: 6,
+		},
+		{
+			// message i is published 10-i minutes ago
+			name:          "Max age drops expired messages",
+			policy:        core.RetentionPolicy{MaxAge: 5*time.Minute + 30*time.Second},
+			expectDropped: 5,
+		},
+		{
+			// every body is 9 bytes, whatever the repository stores around it
+			name:          "Max bytes counts message bodies",
+			policy:        core.RetentionPolicy{MaxBytes: 40},
+			expectDropped: 6,
+		},
+		{
+			name:          "Tightest policy wins",
+			policy:        core.RetentionPolicy{MaxAge: time.Hour, MaxMessages: 8},
+			expectDropped: 2,
+		},
+	}
+
+	for repoName, newRepo := range repos {
+		for _, tt := range tests {
+			t.Run(repoName+"/"+tt.name, func(t *testing.T) {
+				repo := newRepo(t)
+				if err := repo.CreateTopic("retained", core.TopicConfig{Retention: tt.policy}); err != nil {
+					t.Fatalf("failed to create topic: %v", err)
+				}
+
+				for i := 0; i < 10; i++ {
+					msg := core.NewMessage([]byte(fmt.Sprintf("message %d", i)), "p1")
+					msg.Timestamp = now.Add(-time.Duration(10-i) * time.Minute)
+					if err := repo.Publish("retained", 0, msg); err != nil {
+						t.Fatalf("failed to publish: %v", err)
+					}
+				}
+
+				before, err := repo.GetPartitionBytes("retained", 0)
+				if err != nil || before != 90 {
+					t.Fatalf("expected 90 bytes of message bodies, got %d %v", before, err)
+				}
+
+				dropped, err := repo.ApplyRetention("retained", now)
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if dropped != tt.expectDropped {
+					t.Fatalf("expected %d dropped, got %d", tt.expectDropped, dropped)
+				}
+
+				after, err := repo.GetPartitionBytes("retained", 0)
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if expect := int64(9 * (10 - tt.expectDropped)); after != expect {
+					t.Fatalf("expected %d bytes of message bodies to be retained, got %d", expect, after)
+				}
+
+				lowWatermark, err := repo.GetEarliestOffset("retained", 0)
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if lowWatermark != tt.expectDropped {
+					t.Fatalf("expected low watermark %d, got %d", tt.expectDropped, lowWatermark)
+				}
+
+				// retention only moves the start of the log
+				if latest, err := repo.GetLatestOffset("retained", 0); err != nil || latest != 10 {
+					t.Fatalf("expected latest offset 10, got %d %v", latest, err)
+				}
+
+				if tt.expectDropped == 0 {
+					return
+				}
+
+				var rangeErr *OffsetOutOfRangeError
+				if _, err := repo.Fetch("retained", 0, "c1", 10); !errors.As(err, &rangeErr) {
+					t.Fatalf("expected offset out of range error, got %v", err)
+				}
+				if rangeErr.LowWatermark != lowWatermark {
+					t.Fatalf("expected reported low watermark %d, got %d", lowWatermark, rangeErr.LowWatermark)
+				}
+				if err := repo.CommitOffset("retained", 0, "c1", lowWatermark-1); !errors.As(err, &rangeErr) {
+					t.Fatalf("expected offset out of range error on commit, got %v", err)
+				}
+
+				if err := repo.CommitOffset("retained", 0, "c1", lowWatermark); err != nil {
+					t.Fatalf("failed to commit low watermark: %v", err)
+				}
+				if offsets, err := repo.ListOffsets("retained", 0); err != nil || len(offsets) != 1 || offsets["c1"] != lowWatermark {
+					t.Fatalf("expected c1 at %d, got %v %v", lowWatermark, offsets, err)
+				}
+				messages, err := repo.Fetch("retained", 0, "c1", 10)
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if len(messages) != 10-tt.expectDropped || messages[0].Offset != lowWatermark {
+					t.Fatalf("expected %d messages starting at %d, got %d", 10-tt.expectDropped, lowWatermark, len(messages))
+				}
+			})
+		}
+	}
+}
+
+func TestFileRepoRetentionSurvivesRestart(t *testing.T) {
+	dir := t.TempDir()
+
+	repo, err := NewFileRepo(dir)
+	if err != nil {
+		t.Fatalf("failed to open file repo: %v", err)
+	}
+	repo.SegmentBytes = 128
+
+	if err := repo.CreateTopic("retained", core.TopicConfig{Retention: core.RetentionPolicy{MaxMessages: 3}}); err != nil {
+		t.Fatalf("failed to create topic: %v", err)
+	}
+	for i := 0; i < 12; i++ {
+		if err := repo.Publish("retained", 0, core.NewMessage([]byte(fmt.Sprintf("message %d", i)), "p1")); err != nil {
+			t.Fatalf("failed to publish: %v", err)
+		}
+	}
+	segments := len(repo.Topics["retained"].Partitions[0].Segments)
+
+	if _, err := repo.ApplyRetention("retained", time.Now()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(repo.Topics["retained"].Partitions[0].Segments) >= segments {
+		t.Fatalf("expected old segments to be deleted, still have %d of %d", len(repo.Topics["retained"].Partitions[0].Segments), segments)
+	}
+	repo.Close()
+
+	repo, err = NewFileRepo(dir)
+	if err != nil {
+		t.Fatalf("failed to reopen file repo: %v", err)
+	}
+	defer repo.Close()
+
+	lowWatermark, err := repo.GetEarliestOffset("retained", 0)
+	if err != nil || lowWatermark != 9 {
+		t.Fatalf("expected low watermark 9 after restart, got %d (err: %v)", lowWatermark, err)
+	}
+	if bytes, err := repo.GetPartitionBytes("retained", 0); err != nil || bytes != 29 {
+		t.Fatalf("expected the bodies of the 3 retained messages after restart, got %d (err: %v)", bytes, err)
+	}
+
+	cfg, err := repo.GetTopicConfig("retained")
+	if err != nil || cfg.Retention.MaxMessages != 3 {
+		t.Fatalf("expected retention config to survive restart, got %+v (err: %v)", cfg, err)
+	}
+}
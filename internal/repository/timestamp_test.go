@@ -0,0 +1,74 @@
+package repository
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/codytheroux96/go-mq/internal/core"
+)
+
+func TestGetOffsetForTimestamp(t *testing.T) {
+	start := time.Date(2026, 1, 1, 2, 0, 0, 0, time.UTC)
+
+	repos := map[string]func(t *testing.T) Repository{
+		"memory": func(t *testing.T) Repository {
+			return NewInMemoryRepo()
+		},
+		"file": func(t *testing.T) Repository {
+			repo, err := NewFileRepo(t.TempDir())
+			if err != nil {
+				t.Fatalf("failed to open file repo: %v", err)
+			}
+			repo.SegmentBytes = 256
+			t.Cleanup(func() { repo.Close() })
+			return repo
+		},
+	}
+
+	tests := []struct {
+		name      string
+		timestamp time.Time
+		expect    int
+	}{
+		{"Before every message", start.Add(-time.Hour), 2},
+		{"Exactly a message's timestamp", start.Add(5 * time.Minute), 5},
+		{"Between two messages", start.Add(5*time.Minute + 30*time.Second), 6},
+		{"Last message", start.Add(9 * time.Minute), 9},
+		{"After every message", start.Add(time.Hour), 10},
+	}
+
+	for repoName, newRepo := range repos {
+		repo := newRepo(t)
+		if err := repo.CreateTopic("events", core.TopicConfig{Retention: core.RetentionPolicy{MaxMessages: 8}}); err != nil {
+			t.Fatalf("failed to create topic: %v", err)
+		}
+		// message i is published i minutes after start, retention then drops the first two
+		for i := 0; i < 10; i++ {
+			msg := core.NewMessage([]byte(fmt.Sprintf("message %d", i)), "p1")
+			msg.Timestamp = start.Add(time.Duration(i) * time.Minute)
+			if err := repo.Publish("events", 0, msg); err != nil {
+				t.Fatalf("failed to publish: %v", err)
+			}
+		}
+		if _, err := repo.ApplyRetention("events", start); err != nil {
+			t.Fatalf("failed to apply retention: %v", err)
+		}
+
+		for _, tt := range tests {
+			t.Run(repoName+"/"+tt.name, func(t *testing.T) {
+				offset, err := repo.GetOffsetForTimestamp("events", 0, tt.timestamp)
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if offset != tt.expect {
+					t.Fatalf("expected offset %d, got %d", tt.expect, offset)
+				}
+			})
+		}
+
+		if _, err := repo.GetOffsetForTimestamp("events", 3, start); err == nil {
+			t.Fatalf("%s: expected an error for a missing partition", repoName)
+		}
+	}
+}
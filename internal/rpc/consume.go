@@ -0,0 +1,172 @@
+package rpc
+
+import (
+	"io"
+	"time"
+
+	"github.com/codytheroux96/go-mq/internal/core"
+	"github.com/codytheroux96/go-mq/internal/rpc/gomqpb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// defaultConsumeCredit applies when the subscribe frame does not grant any,
+	// matching the capacity of a consumer inbox.
+	defaultConsumeCredit = 10
+
+	consumeHeartbeatInterval = 10 * time.Second
+)
+
+// Consume is the gRPC counterpart of the WebSocket stream: messages from the
+// consumer's inbox are pushed while the client has credit, and acks, nacks and
+// credit grants arrive on the same stream.
+func (s *Server) Consume(stream grpc.BidiStreamingServer[gomqpb.ConsumeRequest, gomqpb.ConsumeResponse]) error {
+	first, err := stream.Recv()
+	if err != nil {
+		return err
+	}
+	sub := first.GetSubscribe()
+	if sub == nil || sub.GetTopic() == "" || sub.GetConsumerId() == "" {
+		return status.Error(codes.InvalidArgument, "the first frame must subscribe with a topic and consumer_id")
+	}
+	if sub.GetCredit() < 0 {
+		return status.Error(codes.InvalidArgument, "credit cannot be negative")
+	}
+
+	topicName, consumerID, groupID := sub.GetTopic(), sub.GetConsumerId(), sub.GetGroupId()
+
+	var inbox <-chan *core.Message
+	if groupID != "" {
+		inbox, _, err = s.App.Broker.JoinGroup(groupID, topicName, consumerID, core.FlowControl{})
+	} else {
+		inbox, err = s.App.Broker.Subscribe(topicName, consumerID, core.FlowControl{})
+	}
+	if err != nil {
+		s.App.Logger.Warn("grpc: failed to subscribe", "topic", topicName, "consumer", consumerID, "error", err)
+		return statusFor(err)
+	}
+	defer func() {
+		if groupID != "" {
+			s.App.Broker.LeaveGroup(groupID, consumerID)
+			return
+		}
+		s.App.Broker.Unsubscribe(topicName, consumerID, inbox)
+	}()
+
+	credit := int(sub.GetCredit())
+	if credit == 0 {
+		credit = defaultConsumeCredit
+	}
+
+	s.App.Logger.Info("grpc: consume stream opened", "topic", topicName, "consumer", consumerID, "group", groupID, "credit", credit)
+
+	credits := make(chan int)
+	replies := make(chan *gomqpb.ConsumeResponse)
+	done := make(chan error, 1)
+	quit := make(chan struct{})
+	defer close(quit)
+	go s.readConsume(stream, topicName, consumerID, credits, replies, done, quit)
+
+	// group members keep their partitions by heartbeating from the stream
+	interval := consumeHeartbeatInterval
+	if s.App.Broker.SessionTimeout/3 < interval {
+		interval = s.App.Broker.SessionTimeout / 3
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		var next <-chan *core.Message
+		if credit > 0 {
+			next = inbox
+		}
+
+		select {
+		case <-stream.Context().Done():
+			return stream.Context().Err()
+		case err := <-done:
+			s.App.Logger.Info("grpc: consume stream closed", "topic", topicName, "consumer", consumerID)
+			return err
+		case n := <-credits:
+			credit += n
+		case reply := <-replies:
+			if err := stream.Send(reply); err != nil {
+				return err
+			}
+		case msg, ok := <-next:
+			if !ok {
+				s.App.Logger.Warn("grpc: slow consumer disconnected", "topic", topicName, "consumer", consumerID)
+				return status.Error(codes.ResourceExhausted, "consumer was disconnected for falling behind")
+			}
+			if err := stream.Send(&gomqpb.ConsumeResponse{Frame: &gomqpb.ConsumeResponse_Message{Message: toProto(msg)}}); err != nil {
+				return err
+			}
+			credit--
+		case <-ticker.C:
+			if groupID == "" {
+				continue
+			}
+			if _, err := s.App.Broker.Heartbeat(groupID, consumerID); err != nil {
+				s.App.Logger.Warn("grpc: consume stream member lost its group", "group", groupID, "consumer", consumerID, "error", err)
+				return statusFor(err)
+			}
+		}
+	}
+}
+
+// readConsume applies the client's frames until the stream ends, then reports why
+// on done. A clean close by the client reports nil.
+func (s *Server) readConsume(stream grpc.BidiStreamingServer[gomqpb.ConsumeRequest, gomqpb.ConsumeResponse], topicName, consumerID string, credits chan<- int, replies chan<- *gomqpb.ConsumeResponse, done chan<- error, quit <-chan struct{}) {
+	for {
+		req, err := stream.Recv()
+		if err != nil {
+			if err == io.EOF || status.Code(err) == codes.Canceled {
+				err = nil
+			}
+			done <- err
+			return
+		}
+
+		var messageID, failure string
+		switch frame := req.GetFrame().(type) {
+		case *gomqpb.ConsumeRequest_Credit_:
+			if frame.Credit.GetCredit() <= 0 {
+				failure = "credit must be positive"
+				break
+			}
+			select {
+			case credits <- int(frame.Credit.GetCredit()):
+			case <-quit:
+				return
+			}
+		case *gomqpb.ConsumeRequest_Ack_:
+			messageID = frame.Ack.GetMessageId()
+			if _, err := s.App.Broker.Ack(topicName, consumerID, messageID); err != nil {
+				failure = err.Error()
+			}
+		case *gomqpb.ConsumeRequest_Nack_:
+			messageID = frame.Nack.GetMessageId()
+			if frame.Nack.GetDelayMs() < 0 {
+				failure = "delay_ms cannot be negative"
+				break
+			}
+			delay := time.Duration(frame.Nack.GetDelayMs()) * time.Millisecond
+			if _, err := s.App.Broker.Nack(topicName, consumerID, messageID, frame.Nack.GetRequeue(), delay, frame.Nack.GetReason()); err != nil {
+				failure = err.Error()
+			}
+		default:
+			failure = "expected an ack, nack or credit frame"
+		}
+
+		if failure != "" {
+			reply := &gomqpb.ConsumeResponse{Frame: &gomqpb.ConsumeResponse_Error_{Error: &gomqpb.ConsumeResponse_Error{MessageId: messageID, Error: failure}}}
+			select {
+			case replies <- reply:
+			case <-quit:
+				return
+			}
+		}
+	}
+}
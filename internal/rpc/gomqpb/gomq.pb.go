@@ -0,0 +1,1768 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.9
+// 	protoc        (unknown)
+// source: gomq/v1/gomq.proto
+
+package gomqpb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type Message struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Key           string                 `protobuf:"bytes,2,opt,name=key,proto3" json:"key,omitempty"`
+	Partition     int32                  `protobuf:"varint,3,opt,name=partition,proto3" json:"partition,omitempty"`
+	Offset        int64                  `protobuf:"varint,4,opt,name=offset,proto3" json:"offset,omitempty"`
+	Body          []byte                 `protobuf:"bytes,5,opt,name=body,proto3" json:"body,omitempty"`
+	Timestamp     *timestamppb.Timestamp `protobuf:"bytes,6,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	ProducerId    string                 `protobuf:"bytes,7,opt,name=producer_id,json=producerId,proto3" json:"producer_id,omitempty"`
+	Attempts      int32                  `protobuf:"varint,8,opt,name=attempts,proto3" json:"attempts,omitempty"`
+	Metadata      map[string]string      `protobuf:"bytes,9,rep,name=metadata,proto3" json:"metadata,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Message) Reset() {
+	*x = Message{}
+	mi := &file_gomq_v1_gomq_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Message) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Message) ProtoMessage() {}
+
+func (x *Message) ProtoReflect() protoreflect.Message {
+	mi := &file_gomq_v1_gomq_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Message.ProtoReflect.Descriptor instead.
+func (*Message) Descriptor() ([]byte, []int) {
+	return file_gomq_v1_gomq_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Message) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Message) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *Message) GetPartition() int32 {
+	if x != nil {
+		return x.Partition
+	}
+	return 0
+}
+
+func (x *Message) GetOffset() int64 {
+	if x != nil {
+		return x.Offset
+	}
+	return 0
+}
+
+func (x *Message) GetBody() []byte {
+	if x != nil {
+		return x.Body
+	}
+	return nil
+}
+
+func (x *Message) GetTimestamp() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Timestamp
+	}
+	return nil
+}
+
+func (x *Message) GetProducerId() string {
+	if x != nil {
+		return x.ProducerId
+	}
+	return ""
+}
+
+func (x *Message) GetAttempts() int32 {
+	if x != nil {
+		return x.Attempts
+	}
+	return 0
+}
+
+func (x *Message) GetMetadata() map[string]string {
+	if x != nil {
+		return x.Metadata
+	}
+	return nil
+}
+
+type RetentionPolicy struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	MaxAgeMs      int64                  `protobuf:"varint,1,opt,name=max_age_ms,json=maxAgeMs,proto3" json:"max_age_ms,omitempty"`
+	MaxBytes      int64                  `protobuf:"varint,2,opt,name=max_bytes,json=maxBytes,proto3" json:"max_bytes,omitempty"`
+	MaxMessages   int64                  `protobuf:"varint,3,opt,name=max_messages,json=maxMessages,proto3" json:"max_messages,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RetentionPolicy) Reset() {
+	*x = RetentionPolicy{}
+	mi := &file_gomq_v1_gomq_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RetentionPolicy) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RetentionPolicy) ProtoMessage() {}
+
+func (x *RetentionPolicy) ProtoReflect() protoreflect.Message {
+	mi := &file_gomq_v1_gomq_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RetentionPolicy.ProtoReflect.Descriptor instead.
+func (*RetentionPolicy) Descriptor() ([]byte, []int) {
+	return file_gomq_v1_gomq_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *RetentionPolicy) GetMaxAgeMs() int64 {
+	if x != nil {
+		return x.MaxAgeMs
+	}
+	return 0
+}
+
+func (x *RetentionPolicy) GetMaxBytes() int64 {
+	if x != nil {
+		return x.MaxBytes
+	}
+	return 0
+}
+
+func (x *RetentionPolicy) GetMaxMessages() int64 {
+	if x != nil {
+		return x.MaxMessages
+	}
+	return 0
+}
+
+type DeadLetterPolicy struct {
+	state               protoimpl.MessageState `protogen:"open.v1"`
+	MaxDeliveryAttempts int32                  `protobuf:"varint,1,opt,name=max_delivery_attempts,json=maxDeliveryAttempts,proto3" json:"max_delivery_attempts,omitempty"`
+	Topic               string                 `protobuf:"bytes,2,opt,name=topic,proto3" json:"topic,omitempty"`
+	unknownFields       protoimpl.UnknownFields
+	sizeCache           protoimpl.SizeCache
+}
+
+func (x *DeadLetterPolicy) Reset() {
+	*x = DeadLetterPolicy{}
+	mi := &file_gomq_v1_gomq_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeadLetterPolicy) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeadLetterPolicy) ProtoMessage() {}
+
+func (x *DeadLetterPolicy) ProtoReflect() protoreflect.Message {
+	mi := &file_gomq_v1_gomq_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeadLetterPolicy.ProtoReflect.Descriptor instead.
+func (*DeadLetterPolicy) Descriptor() ([]byte, []int) {
+	return file_gomq_v1_gomq_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *DeadLetterPolicy) GetMaxDeliveryAttempts() int32 {
+	if x != nil {
+		return x.MaxDeliveryAttempts
+	}
+	return 0
+}
+
+func (x *DeadLetterPolicy) GetTopic() string {
+	if x != nil {
+		return x.Topic
+	}
+	return ""
+}
+
+type CreateTopicRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Partitions    int32                  `protobuf:"varint,2,opt,name=partitions,proto3" json:"partitions,omitempty"`
+	Retention     *RetentionPolicy       `protobuf:"bytes,3,opt,name=retention,proto3" json:"retention,omitempty"`
+	DeadLetter    *DeadLetterPolicy      `protobuf:"bytes,4,opt,name=dead_letter,json=deadLetter,proto3" json:"dead_letter,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CreateTopicRequest) Reset() {
+	*x = CreateTopicRequest{}
+	mi := &file_gomq_v1_gomq_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateTopicRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateTopicRequest) ProtoMessage() {}
+
+func (x *CreateTopicRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_gomq_v1_gomq_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateTopicRequest.ProtoReflect.Descriptor instead.
+func (*CreateTopicRequest) Descriptor() ([]byte, []int) {
+	return file_gomq_v1_gomq_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *CreateTopicRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *CreateTopicRequest) GetPartitions() int32 {
+	if x != nil {
+		return x.Partitions
+	}
+	return 0
+}
+
+func (x *CreateTopicRequest) GetRetention() *RetentionPolicy {
+	if x != nil {
+		return x.Retention
+	}
+	return nil
+}
+
+func (x *CreateTopicRequest) GetDeadLetter() *DeadLetterPolicy {
+	if x != nil {
+		return x.DeadLetter
+	}
+	return nil
+}
+
+type CreateTopicResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CreateTopicResponse) Reset() {
+	*x = CreateTopicResponse{}
+	mi := &file_gomq_v1_gomq_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateTopicResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateTopicResponse) ProtoMessage() {}
+
+func (x *CreateTopicResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_gomq_v1_gomq_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateTopicResponse.ProtoReflect.Descriptor instead.
+func (*CreateTopicResponse) Descriptor() ([]byte, []int) {
+	return file_gomq_v1_gomq_proto_rawDescGZIP(), []int{4}
+}
+
+type ListTopicsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListTopicsRequest) Reset() {
+	*x = ListTopicsRequest{}
+	mi := &file_gomq_v1_gomq_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListTopicsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListTopicsRequest) ProtoMessage() {}
+
+func (x *ListTopicsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_gomq_v1_gomq_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListTopicsRequest.ProtoReflect.Descriptor instead.
+func (*ListTopicsRequest) Descriptor() ([]byte, []int) {
+	return file_gomq_v1_gomq_proto_rawDescGZIP(), []int{5}
+}
+
+type ListTopicsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Topics        []string               `protobuf:"bytes,1,rep,name=topics,proto3" json:"topics,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListTopicsResponse) Reset() {
+	*x = ListTopicsResponse{}
+	mi := &file_gomq_v1_gomq_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListTopicsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListTopicsResponse) ProtoMessage() {}
+
+func (x *ListTopicsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_gomq_v1_gomq_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListTopicsResponse.ProtoReflect.Descriptor instead.
+func (*ListTopicsResponse) Descriptor() ([]byte, []int) {
+	return file_gomq_v1_gomq_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *ListTopicsResponse) GetTopics() []string {
+	if x != nil {
+		return x.Topics
+	}
+	return nil
+}
+
+type DeleteTopicRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteTopicRequest) Reset() {
+	*x = DeleteTopicRequest{}
+	mi := &file_gomq_v1_gomq_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteTopicRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteTopicRequest) ProtoMessage() {}
+
+func (x *DeleteTopicRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_gomq_v1_gomq_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteTopicRequest.ProtoReflect.Descriptor instead.
+func (*DeleteTopicRequest) Descriptor() ([]byte, []int) {
+	return file_gomq_v1_gomq_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *DeleteTopicRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+type DeleteTopicResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteTopicResponse) Reset() {
+	*x = DeleteTopicResponse{}
+	mi := &file_gomq_v1_gomq_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteTopicResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteTopicResponse) ProtoMessage() {}
+
+func (x *DeleteTopicResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_gomq_v1_gomq_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteTopicResponse.ProtoReflect.Descriptor instead.
+func (*DeleteTopicResponse) Descriptor() ([]byte, []int) {
+	return file_gomq_v1_gomq_proto_rawDescGZIP(), []int{8}
+}
+
+type PublishRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Topic         string                 `protobuf:"bytes,1,opt,name=topic,proto3" json:"topic,omitempty"`
+	Body          []byte                 `protobuf:"bytes,2,opt,name=body,proto3" json:"body,omitempty"`
+	ProducerId    string                 `protobuf:"bytes,3,opt,name=producer_id,json=producerId,proto3" json:"producer_id,omitempty"`
+	Key           string                 `protobuf:"bytes,4,opt,name=key,proto3" json:"key,omitempty"`
+	Metadata      map[string]string      `protobuf:"bytes,5,rep,name=metadata,proto3" json:"metadata,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PublishRequest) Reset() {
+	*x = PublishRequest{}
+	mi := &file_gomq_v1_gomq_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PublishRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PublishRequest) ProtoMessage() {}
+
+func (x *PublishRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_gomq_v1_gomq_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PublishRequest.ProtoReflect.Descriptor instead.
+func (*PublishRequest) Descriptor() ([]byte, []int) {
+	return file_gomq_v1_gomq_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *PublishRequest) GetTopic() string {
+	if x != nil {
+		return x.Topic
+	}
+	return ""
+}
+
+func (x *PublishRequest) GetBody() []byte {
+	if x != nil {
+		return x.Body
+	}
+	return nil
+}
+
+func (x *PublishRequest) GetProducerId() string {
+	if x != nil {
+		return x.ProducerId
+	}
+	return ""
+}
+
+func (x *PublishRequest) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *PublishRequest) GetMetadata() map[string]string {
+	if x != nil {
+		return x.Metadata
+	}
+	return nil
+}
+
+type PublishResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	MessageId     string                 `protobuf:"bytes,1,opt,name=message_id,json=messageId,proto3" json:"message_id,omitempty"`
+	Partition     int32                  `protobuf:"varint,2,opt,name=partition,proto3" json:"partition,omitempty"`
+	Offset        int64                  `protobuf:"varint,3,opt,name=offset,proto3" json:"offset,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PublishResponse) Reset() {
+	*x = PublishResponse{}
+	mi := &file_gomq_v1_gomq_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PublishResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PublishResponse) ProtoMessage() {}
+
+func (x *PublishResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_gomq_v1_gomq_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PublishResponse.ProtoReflect.Descriptor instead.
+func (*PublishResponse) Descriptor() ([]byte, []int) {
+	return file_gomq_v1_gomq_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *PublishResponse) GetMessageId() string {
+	if x != nil {
+		return x.MessageId
+	}
+	return ""
+}
+
+func (x *PublishResponse) GetPartition() int32 {
+	if x != nil {
+		return x.Partition
+	}
+	return 0
+}
+
+func (x *PublishResponse) GetOffset() int64 {
+	if x != nil {
+		return x.Offset
+	}
+	return 0
+}
+
+type PublishStreamResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Results       []*PublishResponse     `protobuf:"bytes,1,rep,name=results,proto3" json:"results,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PublishStreamResponse) Reset() {
+	*x = PublishStreamResponse{}
+	mi := &file_gomq_v1_gomq_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PublishStreamResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PublishStreamResponse) ProtoMessage() {}
+
+func (x *PublishStreamResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_gomq_v1_gomq_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PublishStreamResponse.ProtoReflect.Descriptor instead.
+func (*PublishStreamResponse) Descriptor() ([]byte, []int) {
+	return file_gomq_v1_gomq_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *PublishStreamResponse) GetResults() []*PublishResponse {
+	if x != nil {
+		return x.Results
+	}
+	return nil
+}
+
+type FetchRequest struct {
+	state      protoimpl.MessageState `protogen:"open.v1"`
+	Topic      string                 `protobuf:"bytes,1,opt,name=topic,proto3" json:"topic,omitempty"`
+	ConsumerId string                 `protobuf:"bytes,2,opt,name=consumer_id,json=consumerId,proto3" json:"consumer_id,omitempty"`
+	// group_id fetches on behalf of a consumer group, using the group's offsets and
+	// only the partitions assigned to consumer_id.
+	GroupId string `protobuf:"bytes,3,opt,name=group_id,json=groupId,proto3" json:"group_id,omitempty"`
+	// partition defaults to 0, or to every assigned partition for a group member.
+	Partition *int32 `protobuf:"varint,4,opt,name=partition,proto3,oneof" json:"partition,omitempty"`
+	Limit     int32  `protobuf:"varint,5,opt,name=limit,proto3" json:"limit,omitempty"`
+	Commit    bool   `protobuf:"varint,6,opt,name=commit,proto3" json:"commit,omitempty"`
+	// offset moves the committed offset there on every partition fetched before
+	// reading, instead of reading on from where the consumer left off.
+	Offset        *int64 `protobuf:"varint,7,opt,name=offset,proto3,oneof" json:"offset,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *FetchRequest) Reset() {
+	*x = FetchRequest{}
+	mi := &file_gomq_v1_gomq_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FetchRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FetchRequest) ProtoMessage() {}
+
+func (x *FetchRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_gomq_v1_gomq_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FetchRequest.ProtoReflect.Descriptor instead.
+func (*FetchRequest) Descriptor() ([]byte, []int) {
+	return file_gomq_v1_gomq_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *FetchRequest) GetTopic() string {
+	if x != nil {
+		return x.Topic
+	}
+	return ""
+}
+
+func (x *FetchRequest) GetConsumerId() string {
+	if x != nil {
+		return x.ConsumerId
+	}
+	return ""
+}
+
+func (x *FetchRequest) GetGroupId() string {
+	if x != nil {
+		return x.GroupId
+	}
+	return ""
+}
+
+func (x *FetchRequest) GetPartition() int32 {
+	if x != nil && x.Partition != nil {
+		return *x.Partition
+	}
+	return 0
+}
+
+func (x *FetchRequest) GetLimit() int32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+func (x *FetchRequest) GetCommit() bool {
+	if x != nil {
+		return x.Commit
+	}
+	return false
+}
+
+func (x *FetchRequest) GetOffset() int64 {
+	if x != nil && x.Offset != nil {
+		return *x.Offset
+	}
+	return 0
+}
+
+type FetchResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Messages      []*Message             `protobuf:"bytes,1,rep,name=messages,proto3" json:"messages,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *FetchResponse) Reset() {
+	*x = FetchResponse{}
+	mi := &file_gomq_v1_gomq_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FetchResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FetchResponse) ProtoMessage() {}
+
+func (x *FetchResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_gomq_v1_gomq_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FetchResponse.ProtoReflect.Descriptor instead.
+func (*FetchResponse) Descriptor() ([]byte, []int) {
+	return file_gomq_v1_gomq_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *FetchResponse) GetMessages() []*Message {
+	if x != nil {
+		return x.Messages
+	}
+	return nil
+}
+
+type CommitOffsetRequest struct {
+	state      protoimpl.MessageState `protogen:"open.v1"`
+	Topic      string                 `protobuf:"bytes,1,opt,name=topic,proto3" json:"topic,omitempty"`
+	Partition  int32                  `protobuf:"varint,2,opt,name=partition,proto3" json:"partition,omitempty"`
+	ConsumerId string                 `protobuf:"bytes,3,opt,name=consumer_id,json=consumerId,proto3" json:"consumer_id,omitempty"`
+	// group_id commits the group's offset instead of the consumer's.
+	GroupId       string `protobuf:"bytes,4,opt,name=group_id,json=groupId,proto3" json:"group_id,omitempty"`
+	Offset        int64  `protobuf:"varint,5,opt,name=offset,proto3" json:"offset,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CommitOffsetRequest) Reset() {
+	*x = CommitOffsetRequest{}
+	mi := &file_gomq_v1_gomq_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CommitOffsetRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CommitOffsetRequest) ProtoMessage() {}
+
+func (x *CommitOffsetRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_gomq_v1_gomq_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CommitOffsetRequest.ProtoReflect.Descriptor instead.
+func (*CommitOffsetRequest) Descriptor() ([]byte, []int) {
+	return file_gomq_v1_gomq_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *CommitOffsetRequest) GetTopic() string {
+	if x != nil {
+		return x.Topic
+	}
+	return ""
+}
+
+func (x *CommitOffsetRequest) GetPartition() int32 {
+	if x != nil {
+		return x.Partition
+	}
+	return 0
+}
+
+func (x *CommitOffsetRequest) GetConsumerId() string {
+	if x != nil {
+		return x.ConsumerId
+	}
+	return ""
+}
+
+func (x *CommitOffsetRequest) GetGroupId() string {
+	if x != nil {
+		return x.GroupId
+	}
+	return ""
+}
+
+func (x *CommitOffsetRequest) GetOffset() int64 {
+	if x != nil {
+		return x.Offset
+	}
+	return 0
+}
+
+type CommitOffsetResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CommitOffsetResponse) Reset() {
+	*x = CommitOffsetResponse{}
+	mi := &file_gomq_v1_gomq_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CommitOffsetResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CommitOffsetResponse) ProtoMessage() {}
+
+func (x *CommitOffsetResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_gomq_v1_gomq_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CommitOffsetResponse.ProtoReflect.Descriptor instead.
+func (*CommitOffsetResponse) Descriptor() ([]byte, []int) {
+	return file_gomq_v1_gomq_proto_rawDescGZIP(), []int{15}
+}
+
+type AckRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Topic         string                 `protobuf:"bytes,1,opt,name=topic,proto3" json:"topic,omitempty"`
+	ConsumerId    string                 `protobuf:"bytes,2,opt,name=consumer_id,json=consumerId,proto3" json:"consumer_id,omitempty"`
+	MessageId     string                 `protobuf:"bytes,3,opt,name=message_id,json=messageId,proto3" json:"message_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AckRequest) Reset() {
+	*x = AckRequest{}
+	mi := &file_gomq_v1_gomq_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AckRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AckRequest) ProtoMessage() {}
+
+func (x *AckRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_gomq_v1_gomq_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AckRequest.ProtoReflect.Descriptor instead.
+func (*AckRequest) Descriptor() ([]byte, []int) {
+	return file_gomq_v1_gomq_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *AckRequest) GetTopic() string {
+	if x != nil {
+		return x.Topic
+	}
+	return ""
+}
+
+func (x *AckRequest) GetConsumerId() string {
+	if x != nil {
+		return x.ConsumerId
+	}
+	return ""
+}
+
+func (x *AckRequest) GetMessageId() string {
+	if x != nil {
+		return x.MessageId
+	}
+	return ""
+}
+
+type AckResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Duplicate     bool                   `protobuf:"varint,1,opt,name=duplicate,proto3" json:"duplicate,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AckResponse) Reset() {
+	*x = AckResponse{}
+	mi := &file_gomq_v1_gomq_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AckResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AckResponse) ProtoMessage() {}
+
+func (x *AckResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_gomq_v1_gomq_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AckResponse.ProtoReflect.Descriptor instead.
+func (*AckResponse) Descriptor() ([]byte, []int) {
+	return file_gomq_v1_gomq_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *AckResponse) GetDuplicate() bool {
+	if x != nil {
+		return x.Duplicate
+	}
+	return false
+}
+
+type ConsumeRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Types that are valid to be assigned to Frame:
+	//
+	//	*ConsumeRequest_Subscribe_
+	//	*ConsumeRequest_Ack_
+	//	*ConsumeRequest_Nack_
+	//	*ConsumeRequest_Credit_
+	Frame         isConsumeRequest_Frame `protobuf_oneof:"frame"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ConsumeRequest) Reset() {
+	*x = ConsumeRequest{}
+	mi := &file_gomq_v1_gomq_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ConsumeRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ConsumeRequest) ProtoMessage() {}
+
+func (x *ConsumeRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_gomq_v1_gomq_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ConsumeRequest.ProtoReflect.Descriptor instead.
+func (*ConsumeRequest) Descriptor() ([]byte, []int) {
+	return file_gomq_v1_gomq_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *ConsumeRequest) GetFrame() isConsumeRequest_Frame {
+	if x != nil {
+		return x.Frame
+	}
+	return nil
+}
+
+func (x *ConsumeRequest) GetSubscribe() *ConsumeRequest_Subscribe {
+	if x != nil {
+		if x, ok := x.Frame.(*ConsumeRequest_Subscribe_); ok {
+			return x.Subscribe
+		}
+	}
+	return nil
+}
+
+func (x *ConsumeRequest) GetAck() *ConsumeRequest_Ack {
+	if x != nil {
+		if x, ok := x.Frame.(*ConsumeRequest_Ack_); ok {
+			return x.Ack
+		}
+	}
+	return nil
+}
+
+func (x *ConsumeRequest) GetNack() *ConsumeRequest_Nack {
+	if x != nil {
+		if x, ok := x.Frame.(*ConsumeRequest_Nack_); ok {
+			return x.Nack
+		}
+	}
+	return nil
+}
+
+func (x *ConsumeRequest) GetCredit() *ConsumeRequest_Credit {
+	if x != nil {
+		if x, ok := x.Frame.(*ConsumeRequest_Credit_); ok {
+			return x.Credit
+		}
+	}
+	return nil
+}
+
+type isConsumeRequest_Frame interface {
+	isConsumeRequest_Frame()
+}
+
+type ConsumeRequest_Subscribe_ struct {
+	Subscribe *ConsumeRequest_Subscribe `protobuf:"bytes,1,opt,name=subscribe,proto3,oneof"`
+}
+
+type ConsumeRequest_Ack_ struct {
+	Ack *ConsumeRequest_Ack `protobuf:"bytes,2,opt,name=ack,proto3,oneof"`
+}
+
+type ConsumeRequest_Nack_ struct {
+	Nack *ConsumeRequest_Nack `protobuf:"bytes,3,opt,name=nack,proto3,oneof"`
+}
+
+type ConsumeRequest_Credit_ struct {
+	Credit *ConsumeRequest_Credit `protobuf:"bytes,4,opt,name=credit,proto3,oneof"`
+}
+
+func (*ConsumeRequest_Subscribe_) isConsumeRequest_Frame() {}
+
+func (*ConsumeRequest_Ack_) isConsumeRequest_Frame() {}
+
+func (*ConsumeRequest_Nack_) isConsumeRequest_Frame() {}
+
+func (*ConsumeRequest_Credit_) isConsumeRequest_Frame() {}
+
+type ConsumeResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Types that are valid to be assigned to Frame:
+	//
+	//	*ConsumeResponse_Message
+	//	*ConsumeResponse_Error_
+	Frame         isConsumeResponse_Frame `protobuf_oneof:"frame"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ConsumeResponse) Reset() {
+	*x = ConsumeResponse{}
+	mi := &file_gomq_v1_gomq_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ConsumeResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ConsumeResponse) ProtoMessage() {}
+
+func (x *ConsumeResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_gomq_v1_gomq_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ConsumeResponse.ProtoReflect.Descriptor instead.
+func (*ConsumeResponse) Descriptor() ([]byte, []int) {
+	return file_gomq_v1_gomq_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *ConsumeResponse) GetFrame() isConsumeResponse_Frame {
+	if x != nil {
+		return x.Frame
+	}
+	return nil
+}
+
+func (x *ConsumeResponse) GetMessage() *Message {
+	if x != nil {
+		if x, ok := x.Frame.(*ConsumeResponse_Message); ok {
+			return x.Message
+		}
+	}
+	return nil
+}
+
+func (x *ConsumeResponse) GetError() *ConsumeResponse_Error {
+	if x != nil {
+		if x, ok := x.Frame.(*ConsumeResponse_Error_); ok {
+			return x.Error
+		}
+	}
+	return nil
+}
+
+type isConsumeResponse_Frame interface {
+	isConsumeResponse_Frame()
+}
+
+type ConsumeResponse_Message struct {
+	Message *Message `protobuf:"bytes,1,opt,name=message,proto3,oneof"`
+}
+
+type ConsumeResponse_Error_ struct {
+	Error *ConsumeResponse_Error `protobuf:"bytes,2,opt,name=error,proto3,oneof"`
+}
+
+func (*ConsumeResponse_Message) isConsumeResponse_Frame() {}
+
+func (*ConsumeResponse_Error_) isConsumeResponse_Frame() {}
+
+type ConsumeRequest_Subscribe struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Topic         string                 `protobuf:"bytes,1,opt,name=topic,proto3" json:"topic,omitempty"`
+	ConsumerId    string                 `protobuf:"bytes,2,opt,name=consumer_id,json=consumerId,proto3" json:"consumer_id,omitempty"`
+	GroupId       string                 `protobuf:"bytes,3,opt,name=group_id,json=groupId,proto3" json:"group_id,omitempty"`
+	Credit        int32                  `protobuf:"varint,4,opt,name=credit,proto3" json:"credit,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ConsumeRequest_Subscribe) Reset() {
+	*x = ConsumeRequest_Subscribe{}
+	mi := &file_gomq_v1_gomq_proto_msgTypes[22]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ConsumeRequest_Subscribe) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ConsumeRequest_Subscribe) ProtoMessage() {}
+
+func (x *ConsumeRequest_Subscribe) ProtoReflect() protoreflect.Message {
+	mi := &file_gomq_v1_gomq_proto_msgTypes[22]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ConsumeRequest_Subscribe.ProtoReflect.Descriptor instead.
+func (*ConsumeRequest_Subscribe) Descriptor() ([]byte, []int) {
+	return file_gomq_v1_gomq_proto_rawDescGZIP(), []int{18, 0}
+}
+
+func (x *ConsumeRequest_Subscribe) GetTopic() string {
+	if x != nil {
+		return x.Topic
+	}
+	return ""
+}
+
+func (x *ConsumeRequest_Subscribe) GetConsumerId() string {
+	if x != nil {
+		return x.ConsumerId
+	}
+	return ""
+}
+
+func (x *ConsumeRequest_Subscribe) GetGroupId() string {
+	if x != nil {
+		return x.GroupId
+	}
+	return ""
+}
+
+func (x *ConsumeRequest_Subscribe) GetCredit() int32 {
+	if x != nil {
+		return x.Credit
+	}
+	return 0
+}
+
+type ConsumeRequest_Ack struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	MessageId     string                 `protobuf:"bytes,1,opt,name=message_id,json=messageId,proto3" json:"message_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ConsumeRequest_Ack) Reset() {
+	*x = ConsumeRequest_Ack{}
+	mi := &file_gomq_v1_gomq_proto_msgTypes[23]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ConsumeRequest_Ack) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ConsumeRequest_Ack) ProtoMessage() {}
+
+func (x *ConsumeRequest_Ack) ProtoReflect() protoreflect.Message {
+	mi := &file_gomq_v1_gomq_proto_msgTypes[23]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ConsumeRequest_Ack.ProtoReflect.Descriptor instead.
+func (*ConsumeRequest_Ack) Descriptor() ([]byte, []int) {
+	return file_gomq_v1_gomq_proto_rawDescGZIP(), []int{18, 1}
+}
+
+func (x *ConsumeRequest_Ack) GetMessageId() string {
+	if x != nil {
+		return x.MessageId
+	}
+	return ""
+}
+
+type ConsumeRequest_Nack struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	MessageId     string                 `protobuf:"bytes,1,opt,name=message_id,json=messageId,proto3" json:"message_id,omitempty"`
+	Requeue       bool                   `protobuf:"varint,2,opt,name=requeue,proto3" json:"requeue,omitempty"`
+	DelayMs       int64                  `protobuf:"varint,3,opt,name=delay_ms,json=delayMs,proto3" json:"delay_ms,omitempty"`
+	Reason        string                 `protobuf:"bytes,4,opt,name=reason,proto3" json:"reason,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ConsumeRequest_Nack) Reset() {
+	*x = ConsumeRequest_Nack{}
+	mi := &file_gomq_v1_gomq_proto_msgTypes[24]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ConsumeRequest_Nack) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ConsumeRequest_Nack) ProtoMessage() {}
+
+func (x *ConsumeRequest_Nack) ProtoReflect() protoreflect.Message {
+	mi := &file_gomq_v1_gomq_proto_msgTypes[24]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ConsumeRequest_Nack.ProtoReflect.Descriptor instead.
+func (*ConsumeRequest_Nack) Descriptor() ([]byte, []int) {
+	return file_gomq_v1_gomq_proto_rawDescGZIP(), []int{18, 2}
+}
+
+func (x *ConsumeRequest_Nack) GetMessageId() string {
+	if x != nil {
+		return x.MessageId
+	}
+	return ""
+}
+
+func (x *ConsumeRequest_Nack) GetRequeue() bool {
+	if x != nil {
+		return x.Requeue
+	}
+	return false
+}
+
+func (x *ConsumeRequest_Nack) GetDelayMs() int64 {
+	if x != nil {
+		return x.DelayMs
+	}
+	return 0
+}
+
+func (x *ConsumeRequest_Nack) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+type ConsumeRequest_Credit struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Credit        int32                  `protobuf:"varint,1,opt,name=credit,proto3" json:"credit,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ConsumeRequest_Credit) Reset() {
+	*x = ConsumeRequest_Credit{}
+	mi := &file_gomq_v1_gomq_proto_msgTypes[25]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ConsumeRequest_Credit) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ConsumeRequest_Credit) ProtoMessage() {}
+
+func (x *ConsumeRequest_Credit) ProtoReflect() protoreflect.Message {
+	mi := &file_gomq_v1_gomq_proto_msgTypes[25]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ConsumeRequest_Credit.ProtoReflect.Descriptor instead.
+func (*ConsumeRequest_Credit) Descriptor() ([]byte, []int) {
+	return file_gomq_v1_gomq_proto_rawDescGZIP(), []int{18, 3}
+}
+
+func (x *ConsumeRequest_Credit) GetCredit() int32 {
+	if x != nil {
+		return x.Credit
+	}
+	return 0
+}
+
+// Error answers an ack, nack or credit frame that could not be applied.
+type ConsumeResponse_Error struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	MessageId     string                 `protobuf:"bytes,1,opt,name=message_id,json=messageId,proto3" json:"message_id,omitempty"`
+	Error         string                 `protobuf:"bytes,2,opt,name=error,proto3" json:"error,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ConsumeResponse_Error) Reset() {
+	*x = ConsumeResponse_Error{}
+	mi := &file_gomq_v1_gomq_proto_msgTypes[26]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ConsumeResponse_Error) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ConsumeResponse_Error) ProtoMessage() {}
+
+func (x *ConsumeResponse_Error) ProtoReflect() protoreflect.Message {
+	mi := &file_gomq_v1_gomq_proto_msgTypes[26]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ConsumeResponse_Error.ProtoReflect.Descriptor instead.
+func (*ConsumeResponse_Error) Descriptor() ([]byte, []int) {
+	return file_gomq_v1_gomq_proto_rawDescGZIP(), []int{19, 0}
+}
+
+func (x *ConsumeResponse_Error) GetMessageId() string {
+	if x != nil {
+		return x.MessageId
+	}
+	return ""
+}
+
+func (x *ConsumeResponse_Error) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+var File_gomq_v1_gomq_proto protoreflect.FileDescriptor
+
+const file_gomq_v1_gomq_proto_rawDesc = "" +
+	"\n" +
+	"\x12gomq/v1/gomq.proto\x12\agomq.v1\x1a\x1fgoogle/protobuf/timestamp.proto\"\xe5\x02\n" +
+	"\aMessage\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x10\n" +
+	"\x03key\x18\x02 \x01(\tR\x03key\x12\x1c\n" +
+	"\tpartition\x18\x03 \x01(\x05R\tpartition\x12\x16\n" +
+	"\x06offset\x18\x04 \x01(\x03R\x06offset\x12\x12\n" +
+	"\x04body\x18\x05 \x01(\fR\x04body\x128\n" +
+	"\ttimestamp\x18\x06 \x01(\v2\x1a.google.protobuf.TimestampR\ttimestamp\x12\x1f\n" +
+	"\vproducer_id\x18\a \x01(\tR\n" +
+	"producerId\x12\x1a\n" +
+	"\battempts\x18\b \x01(\x05R\battempts\x12:\n" +
+	"\bmetadata\x18\t \x03(\v2\x1e.gomq.v1.Message.MetadataEntryR\bmetadata\x1a;\n" +
+	"\rMetadataEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"o\n" +
+	"\x0fRetentionPolicy\x12\x1c\n" +
+	"\n" +
+	"max_age_ms\x18\x01 \x01(\x03R\bmaxAgeMs\x12\x1b\n" +
+	"\tmax_bytes\x18\x02 \x01(\x03R\bmaxBytes\x12!\n" +
+	"\fmax_messages\x18\x03 \x01(\x03R\vmaxMessages\"\\\n" +
+	"\x10DeadLetterPolicy\x122\n" +
+	"\x15max_delivery_attempts\x18\x01 \x01(\x05R\x13maxDeliveryAttempts\x12\x14\n" +
+	"\x05topic\x18\x02 \x01(\tR\x05topic\"\xbc\x01\n" +
+	"\x12CreateTopicRequest\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x1e\n" +
+	"\n" +
+	"partitions\x18\x02 \x01(\x05R\n" +
+	"partitions\x126\n" +
+	"\tretention\x18\x03 \x01(\v2\x18.gomq.v1.RetentionPolicyR\tretention\x12:\n" +
+	"\vdead_letter\x18\x04 \x01(\v2\x19.gomq.v1.DeadLetterPolicyR\n" +
+	"deadLetter\"\x15\n" +
+	"\x13CreateTopicResponse\"\x13\n" +
+	"\x11ListTopicsRequest\",\n" +
+	"\x12ListTopicsResponse\x12\x16\n" +
+	"\x06topics\x18\x01 \x03(\tR\x06topics\"(\n" +
+	"\x12DeleteTopicRequest\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\"\x15\n" +
+	"\x13DeleteTopicResponse\"\xed\x01\n" +
+	"\x0ePublishRequest\x12\x14\n" +
+	"\x05topic\x18\x01 \x01(\tR\x05topic\x12\x12\n" +
+	"\x04body\x18\x02 \x01(\fR\x04body\x12\x1f\n" +
+	"\vproducer_id\x18\x03 \x01(\tR\n" +
+	"producerId\x12\x10\n" +
+	"\x03key\x18\x04 \x01(\tR\x03key\x12A\n" +
+	"\bmetadata\x18\x05 \x03(\v2%.gomq.v1.PublishRequest.MetadataEntryR\bmetadata\x1a;\n" +
+	"\rMetadataEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"f\n" +
+	"\x0fPublishResponse\x12\x1d\n" +
+	"\n" +
+	"message_id\x18\x01 \x01(\tR\tmessageId\x12\x1c\n" +
+	"\tpartition\x18\x02 \x01(\x05R\tpartition\x12\x16\n" +
+	"\x06offset\x18\x03 \x01(\x03R\x06offset\"K\n" +
+	"\x15PublishStreamResponse\x122\n" +
+	"\aresults\x18\x01 \x03(\v2\x18.gomq.v1.PublishResponseR\aresults\"\xe7\x01\n" +
+	"\fFetchRequest\x12\x14\n" +
+	"\x05topic\x18\x01 \x01(\tR\x05topic\x12\x1f\n" +
+	"\vconsumer_id\x18\x02 \x01(\tR\n" +
+	"consumerId\x12\x19\n" +
+	"\bgroup_id\x18\x03 \x01(\tR\agroupId\x12!\n" +
+	"\tpartition\x18\x04 \x01(\x05H\x00R\tpartition\x88\x01\x01\x12\x14\n" +
+	"\x05limit\x18\x05 \x01(\x05R\x05limit\x12\x16\n" +
+	"\x06commit\x18\x06 \x01(\bR\x06commit\x12\x1b\n" +
+	"\x06offset\x18\a \x01(\x03H\x01R\x06offset\x88\x01\x01B\f\n" +
+	"\n" +
+	"_partitionB\t\n" +
+	"\a_offset\"=\n" +
+	"\rFetchResponse\x12,\n" +
+	"\bmessages\x18\x01 \x03(\v2\x10.gomq.v1.MessageR\bmessages\"\x9d\x01\n" +
+	"\x13CommitOffsetRequest\x12\x14\n" +
+	"\x05topic\x18\x01 \x01(\tR\x05topic\x12\x1c\n" +
+	"\tpartition\x18\x02 \x01(\x05R\tpartition\x12\x1f\n" +
+	"\vconsumer_id\x18\x03 \x01(\tR\n" +
+	"consumerId\x12\x19\n" +
+	"\bgroup_id\x18\x04 \x01(\tR\agroupId\x12\x16\n" +
+	"\x06offset\x18\x05 \x01(\x03R\x06offset\"\x16\n" +
+	"\x14CommitOffsetResponse\"b\n" +
+	"\n" +
+	"AckRequest\x12\x14\n" +
+	"\x05topic\x18\x01 \x01(\tR\x05topic\x12\x1f\n" +
+	"\vconsumer_id\x18\x02 \x01(\tR\n" +
+	"consumerId\x12\x1d\n" +
+	"\n" +
+	"message_id\x18\x03 \x01(\tR\tmessageId\"+\n" +
+	"\vAckResponse\x12\x1c\n" +
+	"\tduplicate\x18\x01 \x01(\bR\tduplicate\"\xae\x04\n" +
+	"\x0eConsumeRequest\x12A\n" +
+	"\tsubscribe\x18\x01 \x01(\v2!.gomq.v1.ConsumeRequest.SubscribeH\x00R\tsubscribe\x12/\n" +
+	"\x03ack\x18\x02 \x01(\v2\x1b.gomq.v1.ConsumeRequest.AckH\x00R\x03ack\x122\n" +
+	"\x04nack\x18\x03 \x01(\v2\x1c.gomq.v1.ConsumeRequest.NackH\x00R\x04nack\x128\n" +
+	"\x06credit\x18\x04 \x01(\v2\x1e.gomq.v1.ConsumeRequest.CreditH\x00R\x06credit\x1au\n" +
+	"\tSubscribe\x12\x14\n" +
+	"\x05topic\x18\x01 \x01(\tR\x05topic\x12\x1f\n" +
+	"\vconsumer_id\x18\x02 \x01(\tR\n" +
+	"consumerId\x12\x19\n" +
+	"\bgroup_id\x18\x03 \x01(\tR\agroupId\x12\x16\n" +
+	"\x06credit\x18\x04 \x01(\x05R\x06credit\x1a$\n" +
+	"\x03Ack\x12\x1d\n" +
+	"\n" +
+	"message_id\x18\x01 \x01(\tR\tmessageId\x1ar\n" +
+	"\x04Nack\x12\x1d\n" +
+	"\n" +
+	"message_id\x18\x01 \x01(\tR\tmessageId\x12\x18\n" +
+	"\arequeue\x18\x02 \x01(\bR\arequeue\x12\x19\n" +
+	"\bdelay_ms\x18\x03 \x01(\x03R\adelayMs\x12\x16\n" +
+	"\x06reason\x18\x04 \x01(\tR\x06reason\x1a \n" +
+	"\x06Credit\x12\x16\n" +
+	"\x06credit\x18\x01 \x01(\x05R\x06creditB\a\n" +
+	"\x05frame\"\xbe\x01\n" +
+	"\x0fConsumeResponse\x12,\n" +
+	"\amessage\x18\x01 \x01(\v2\x10.gomq.v1.MessageH\x00R\amessage\x126\n" +
+	"\x05error\x18\x02 \x01(\v2\x1e.gomq.v1.ConsumeResponse.ErrorH\x00R\x05error\x1a<\n" +
+	"\x05Error\x12\x1d\n" +
+	"\n" +
+	"message_id\x18\x01 \x01(\tR\tmessageId\x12\x14\n" +
+	"\x05error\x18\x02 \x01(\tR\x05errorB\a\n" +
+	"\x05frame2\xe4\x04\n" +
+	"\x04GoMQ\x12H\n" +
+	"\vCreateTopic\x12\x1b.gomq.v1.CreateTopicRequest\x1a\x1c.gomq.v1.CreateTopicResponse\x12E\n" +
+	"\n" +
+	"ListTopics\x12\x1a.gomq.v1.ListTopicsRequest\x1a\x1b.gomq.v1.ListTopicsResponse\x12H\n" +
+	"\vDeleteTopic\x12\x1b.gomq.v1.DeleteTopicRequest\x1a\x1c.gomq.v1.DeleteTopicResponse\x12<\n" +
+	"\aPublish\x12\x17.gomq.v1.PublishRequest\x1a\x18.gomq.v1.PublishResponse\x12J\n" +
+	"\rPublishStream\x12\x17.gomq.v1.PublishRequest\x1a\x1e.gomq.v1.PublishStreamResponse(\x01\x126\n" +
+	"\x05Fetch\x12\x15.gomq.v1.FetchRequest\x1a\x16.gomq.v1.FetchResponse\x12K\n" +
+	"\fCommitOffset\x12\x1c.gomq.v1.CommitOffsetRequest\x1a\x1d.gomq.v1.CommitOffsetResponse\x120\n" +
+	"\x03Ack\x12\x13.gomq.v1.AckRequest\x1a\x14.gomq.v1.AckResponse\x12@\n" +
+	"\aConsume\x12\x17.gomq.v1.ConsumeRequest\x1a\x18.gomq.v1.ConsumeResponse(\x010\x01B;Z9github.com/codytheroux96/go-mq/internal/rpc/gomqpb;gomqpbb\x06proto3"
+
+var (
+	file_gomq_v1_gomq_proto_rawDescOnce sync.Once
+	file_gomq_v1_gomq_proto_rawDescData []byte
+)
+
+func file_gomq_v1_gomq_proto_rawDescGZIP() []byte {
+	file_gomq_v1_gomq_proto_rawDescOnce.Do(func() {
+		file_gomq_v1_gomq_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_gomq_v1_gomq_proto_rawDesc), len(file_gomq_v1_gomq_proto_rawDesc)))
+	})
+	return file_gomq_v1_gomq_proto_rawDescData
+}
+
+var file_gomq_v1_gomq_proto_msgTypes = make([]protoimpl.MessageInfo, 27)
+var file_gomq_v1_gomq_proto_goTypes = []any{
+	(*Message)(nil),                  // 0: gomq.v1.Message
+	(*RetentionPolicy)(nil),          // 1: gomq.v1.RetentionPolicy
+	(*DeadLetterPolicy)(nil),         // 2: gomq.v1.DeadLetterPolicy
+	(*CreateTopicRequest)(nil),       // 3: gomq.v1.CreateTopicRequest
+	(*CreateTopicResponse)(nil),      // 4: gomq.v1.CreateTopicResponse
+	(*ListTopicsRequest)(nil),        // 5: gomq.v1.ListTopicsRequest
+	(*ListTopicsResponse)(nil),       // 6: gomq.v1.ListTopicsResponse
+	(*DeleteTopicRequest)(nil),       // 7: gomq.v1.DeleteTopicRequest
+	(*DeleteTopicResponse)(nil),      // 8: gomq.v1.DeleteTopicResponse
+	(*PublishRequest)(nil),           // 9: gomq.v1.PublishRequest
+	(*PublishResponse)(nil),          // 10: gomq.v1.PublishResponse
+	(*PublishStreamResponse)(nil),    // 11: gomq.v1.PublishStreamResponse
+	(*FetchRequest)(nil),             // 12: gomq.v1.FetchRequest
+	(*FetchResponse)(nil),            // 13: gomq.v1.FetchResponse
+	(*CommitOffsetRequest)(nil),      // 14: gomq.v1.CommitOffsetRequest
+	(*CommitOffsetResponse)(nil),     // 15: gomq.v1.CommitOffsetResponse
+	(*AckRequest)(nil),               // 16: gomq.v1.AckRequest
+	(*AckResponse)(nil),              // 17: gomq.v1.AckResponse
+	(*ConsumeRequest)(nil),           // 18: gomq.v1.ConsumeRequest
+	(*ConsumeResponse)(nil),          // 19: gomq.v1.ConsumeResponse
+	nil,                              // 20: gomq.v1.Message.MetadataEntry
+	nil,                              // 21: gomq.v1.PublishRequest.MetadataEntry
+	(*ConsumeRequest_Subscribe)(nil), // 22: gomq.v1.ConsumeRequest.Subscribe
+	(*ConsumeRequest_Ack)(nil),       // 23: gomq.v1.ConsumeRequest.Ack
+	(*ConsumeRequest_Nack)(nil),      // 24: gomq.v1.ConsumeRequest.Nack
+	(*ConsumeRequest_Credit)(nil),    // 25: gomq.v1.ConsumeRequest.Credit
+	(*ConsumeResponse_Error)(nil),    // 26: gomq.v1.ConsumeResponse.Error
+	(*timestamppb.Timestamp)(nil),    // 27: google.protobuf.Timestamp
+}
+var file_gomq_v1_gomq_proto_depIdxs = []int32{
+	27, // 0: gomq.v1.Message.timestamp:type_name -> google.protobuf.Timestamp
+	20, // 1: gomq.v1.Message.metadata:type_name -> gomq.v1.Message.MetadataEntry
+	1,  // 2: gomq.v1.CreateTopicRequest.retention:type_name -> gomq.v1.RetentionPolicy
+	2,  // 3: gomq.v1.CreateTopicRequest.dead_letter:type_name -> gomq.v1.DeadLetterPolicy
+	21, // 4: gomq.v1.PublishRequest.metadata:type_name -> gomq.v1.PublishRequest.MetadataEntry
+	10, // 5: gomq.v1.PublishStreamResponse.results:type_name -> gomq.v1.PublishResponse
+	0,  // 6: gomq.v1.FetchResponse.messages:type_name -> gomq.v1.Message
+	22, // 7: gomq.v1.ConsumeRequest.subscribe:type_name -> gomq.v1.ConsumeRequest.Subscribe
+	23, // 8: gomq.v1.ConsumeRequest.ack:type_name -> gomq.v1.ConsumeRequest.Ack
+	24, // 9: gomq.v1.ConsumeRequest.nack:type_name -> gomq.v1.ConsumeRequest.Nack
+	25, // 10: gomq.v1.ConsumeRequest.credit:type_name -> gomq.v1.ConsumeRequest.Credit
+	0,  // 11: gomq.v1.ConsumeResponse.message:type_name -> gomq.v1.Message
+	26, // 12: gomq.v1.ConsumeResponse.error:type_name -> gomq.v1.ConsumeResponse.Error
+	3,  // 13: gomq.v1.GoMQ.CreateTopic:input_type -> gomq.v1.CreateTopicRequest
+	5,  // 14: gomq.v1.GoMQ.ListTopics:input_type -> gomq.v1.ListTopicsRequest
+	7,  // 15: gomq.v1.GoMQ.DeleteTopic:input_type -> gomq.v1.DeleteTopicRequest
+	9,  // 16: gomq.v1.GoMQ.Publish:input_type -> gomq.v1.PublishRequest
+	9,  // 17: gomq.v1.GoMQ.PublishStream:input_type -> gomq.v1.PublishRequest
+	12, // 18: gomq.v1.GoMQ.Fetch:input_type -> gomq.v1.FetchRequest
+	14, // 19: gomq.v1.GoMQ.CommitOffset:input_type -> gomq.v1.CommitOffsetRequest
+	16, // 20: gomq.v1.GoMQ.Ack:input_type -> gomq.v1.AckRequest
+	18, // 21: gomq.v1.GoMQ.Consume:input_type -> gomq.v1.ConsumeRequest
+	4,  // 22: gomq.v1.GoMQ.CreateTopic:output_type -> gomq.v1.CreateTopicResponse
+	6,  // 23: gomq.v1.GoMQ.ListTopics:output_type -> gomq.v1.ListTopicsResponse
+	8,  // 24: gomq.v1.GoMQ.DeleteTopic:output_type -> gomq.v1.DeleteTopicResponse
+	10, // 25: gomq.v1.GoMQ.Publish:output_type -> gomq.v1.PublishResponse
+	11, // 26: gomq.v1.GoMQ.PublishStream:output_type -> gomq.v1.PublishStreamResponse
+	13, // 27: gomq.v1.GoMQ.Fetch:output_type -> gomq.v1.FetchResponse
+	15, // 28: gomq.v1.GoMQ.CommitOffset:output_type -> gomq.v1.CommitOffsetResponse
+	17, // 29: gomq.v1.GoMQ.Ack:output_type -> gomq.v1.AckResponse
+	19, // 30: gomq.v1.GoMQ.Consume:output_type -> gomq.v1.ConsumeResponse
+	22, // [22:31] is the sub-list for method output_type
+	13, // [13:22] is the sub-list for method input_type
+	13, // [13:13] is the sub-list for extension type_name
+	13, // [13:13] is the sub-list for extension extendee
+	0,  // [0:13] is the sub-list for field type_name
+}
+
+func init() { file_gomq_v1_gomq_proto_init() }
+func file_gomq_v1_gomq_proto_init() {
+	if File_gomq_v1_gomq_proto != nil {
+		return
+	}
+	file_gomq_v1_gomq_proto_msgTypes[12].OneofWrappers = []any{}
+	file_gomq_v1_gomq_proto_msgTypes[18].OneofWrappers = []any{
+		(*ConsumeRequest_Subscribe_)(nil),
+		(*ConsumeRequest_Ack_)(nil),
+		(*ConsumeRequest_Nack_)(nil),
+		(*ConsumeRequest_Credit_)(nil),
+	}
+	file_gomq_v1_gomq_proto_msgTypes[19].OneofWrappers = []any{
+		(*ConsumeResponse_Message)(nil),
+		(*ConsumeResponse_Error_)(nil),
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_gomq_v1_gomq_proto_rawDesc), len(file_gomq_v1_gomq_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   27,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_gomq_v1_gomq_proto_goTypes,
+		DependencyIndexes: file_gomq_v1_gomq_proto_depIdxs,
+		MessageInfos:      file_gomq_v1_gomq_proto_msgTypes,
+	}.Build()
+	File_gomq_v1_gomq_proto = out.File
+	file_gomq_v1_gomq_proto_goTypes = nil
+	file_gomq_v1_gomq_proto_depIdxs = nil
+}
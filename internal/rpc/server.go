@@ -0,0 +1,293 @@
+// Package rpc serves the broker over gRPC, next to the HTTP API in internal/api.
+package rpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/codytheroux96/go-mq/internal/app"
+	"github.com/codytheroux96/go-mq/internal/broker"
+	"github.com/codytheroux96/go-mq/internal/core"
+	"github.com/codytheroux96/go-mq/internal/repository"
+	"github.com/codytheroux96/go-mq/internal/rpc/gomqpb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const defaultFetchLimit = 10
+
+type Server struct {
+	gomqpb.UnimplementedGoMQServer
+
+	App *app.Application
+}
+
+// NewServer returns a gRPC server with the GoMQ service registered on it.
+func NewServer(app *app.Application) *grpc.Server {
+	server := grpc.NewServer()
+	gomqpb.RegisterGoMQServer(server, &Server{App: app})
+	return server
+}
+
+func (s *Server) CreateTopic(ctx context.Context, req *gomqpb.CreateTopicRequest) (*gomqpb.CreateTopicResponse, error) {
+	if req.GetName() == "" {
+		return nil, status.Error(codes.InvalidArgument, "topic name is required")
+	}
+
+	retention := req.GetRetention()
+	deadLetter := req.GetDeadLetter()
+	if req.GetPartitions() < 0 || retention.GetMaxAgeMs() < 0 || retention.GetMaxBytes() < 0 || retention.GetMaxMessages() < 0 {
+		return nil, status.Error(codes.InvalidArgument, "partitions and retention settings cannot be negative")
+	}
+	if !validDeadLetter(req.GetName(), deadLetter) {
+		return nil, status.Error(codes.InvalidArgument, "dead-letter settings need a positive attempt count and a different topic")
+	}
+
+	cfg := core.TopicConfig{
+		Partitions: int(req.GetPartitions()),
+		Retention: core.RetentionPolicy{
+			MaxAge:      time.Duration(retention.GetMaxAgeMs()) * time.Millisecond,
+			MaxBytes:    retention.GetMaxBytes(),
+			MaxMessages: int(retention.GetMaxMessages()),
+		},
+		DeadLetter: core.DeadLetterPolicy{
+			MaxDeliveryAttempts: int(deadLetter.GetMaxDeliveryAttempts()),
+			Topic:               deadLetter.GetTopic(),
+		},
+	}
+	if err := s.App.Repo.CreateTopic(req.GetName(), cfg); err != nil {
+		s.App.Logger.Warn("grpc: failed to create topic", "topic", req.GetName(), "error", err)
+		return nil, statusFor(err)
+	}
+
+	s.App.Logger.Info("grpc: topic created", "topic", req.GetName(), "partitions", cfg.PartitionCount())
+	return &gomqpb.CreateTopicResponse{}, nil
+}
+
+func (s *Server) ListTopics(ctx context.Context, req *gomqpb.ListTopicsRequest) (*gomqpb.ListTopicsResponse, error) {
+	topics, err := s.App.Repo.ListTopics()
+	if err != nil {
+		s.App.Logger.Error("grpc: failed to list topics", "error", err)
+		return nil, statusFor(err)
+	}
+
+	return &gomqpb.ListTopicsResponse{Topics: topics}, nil
+}
+
+func (s *Server) DeleteTopic(ctx context.Context, req *gomqpb.DeleteTopicRequest) (*gomqpb.DeleteTopicResponse, error) {
+	if req.GetName() == "" {
+		return nil, status.Error(codes.InvalidArgument, "topic name is required")
+	}
+
+	if err := s.App.Broker.DeleteTopic(req.GetName()); err != nil {
+		s.App.Logger.Warn("grpc: failed to delete topic", "topic", req.GetName(), "error", err)
+		return nil, statusFor(err)
+	}
+
+	s.App.Logger.Info("grpc: topic deleted", "topic", req.GetName())
+	return &gomqpb.DeleteTopicResponse{}, nil
+}
+
+func (s *Server) Publish(ctx context.Context, req *gomqpb.PublishRequest) (*gomqpb.PublishResponse, error) {
+	return s.publish(req)
+}
+
+func (s *Server) PublishStream(stream grpc.ClientStreamingServer[gomqpb.PublishRequest, gomqpb.PublishStreamResponse]) error {
+	results := []*gomqpb.PublishResponse{}
+	for {
+		req, err := stream.Recv()
+		if err == io.EOF {
+			s.App.Logger.Info("grpc: publish stream finished", "published", len(results))
+			return stream.SendAndClose(&gomqpb.PublishStreamResponse{Results: results})
+		}
+		if err != nil {
+			return err
+		}
+
+		result, err := s.publish(req)
+		if err != nil {
+			return err
+		}
+		results = append(results, result)
+	}
+}
+
+func (s *Server) publish(req *gomqpb.PublishRequest) (*gomqpb.PublishResponse, error) {
+	if req.GetTopic() == "" || len(req.GetBody()) == 0 || req.GetProducerId() == "" {
+		return nil, status.Error(codes.InvalidArgument, "topic, body and producer_id are required")
+	}
+
+	msg := core.NewMessage(req.GetBody(), req.GetProducerId())
+	msg.Key = req.GetKey()
+	for k, v := range req.GetMetadata() {
+		msg.Metadata[k] = v
+	}
+
+	// a duplicate is answered with where the original was appended
+	if err := s.App.Broker.Publish(req.GetTopic(), msg); err != nil && !errors.Is(err, broker.ErrDuplicateMessage) {
+		s.App.Logger.Warn("grpc: failed to publish", "topic", req.GetTopic(), "error", err)
+		return nil, statusFor(err)
+	}
+
+	return &gomqpb.PublishResponse{
+		MessageId: msg.ID,
+		Partition: int32(msg.Partition),
+		Offset:    int64(msg.Offset),
+	}, nil
+}
+
+// Fetch works like the HTTP fetch: group members read the group's offsets for the
+// partitions assigned to them, and commit moves the offset past the fresh messages.
+func (s *Server) Fetch(ctx context.Context, req *gomqpb.FetchRequest) (*gomqpb.FetchResponse, error) {
+	if req.GetTopic() == "" || req.GetConsumerId() == "" {
+		return nil, status.Error(codes.InvalidArgument, "topic and consumer_id are required")
+	}
+	if req.GetPartition() < 0 {
+		return nil, status.Error(codes.InvalidArgument, "partition cannot be negative")
+	}
+
+	limit := int(req.GetLimit())
+	if limit <= 0 {
+		limit = defaultFetchLimit
+	}
+
+	offsetKey := req.GetConsumerId()
+	partitions := []int{int(req.GetPartition())}
+	if req.GetGroupId() != "" {
+		assignment, err := s.App.Broker.Heartbeat(req.GetGroupId(), req.GetConsumerId())
+		if err != nil {
+			return nil, statusFor(err)
+		}
+		if assignment.Topic != req.GetTopic() {
+			return nil, status.Error(codes.FailedPrecondition, "group does not consume this topic")
+		}
+		if req.Partition == nil {
+			partitions = assignment.Partitions
+		} else if !slices.Contains(assignment.Partitions, int(req.GetPartition())) {
+			return nil, status.Error(codes.FailedPrecondition, "partition is not assigned to this group member")
+		}
+		offsetKey = req.GetGroupId()
+	}
+
+	if req.Offset != nil {
+		if req.GetOffset() < 0 {
+			return nil, status.Error(codes.InvalidArgument, "offset cannot be negative")
+		}
+		for _, partition := range partitions {
+			if err := s.App.Repo.CommitOffset(req.GetTopic(), partition, offsetKey, int(req.GetOffset())); err != nil {
+				s.App.Logger.Warn("grpc: failed to move offset for fetch", "topic", req.GetTopic(), "partition", partition, "consumer", offsetKey, "offset", req.GetOffset(), "error", err)
+				return nil, statusFor(err)
+			}
+		}
+	}
+
+	messages := []*gomqpb.Message{}
+	for _, partition := range partitions {
+		if len(messages) >= limit {
+			break
+		}
+
+		startOffset, err := s.App.Repo.GetOffset(req.GetTopic(), partition, offsetKey)
+		if err != nil {
+			return nil, statusFor(err)
+		}
+
+		batch, fresh, err := s.App.Broker.Fetch(req.GetTopic(), partition, req.GetGroupId(), req.GetConsumerId(), limit-len(messages), broker.ReadUncommitted)
+		if err != nil {
+			s.App.Logger.Warn("grpc: failed to fetch", "topic", req.GetTopic(), "partition", partition, "consumer", offsetKey, "error", err)
+			return nil, statusFor(err)
+		}
+		for _, msg := range batch {
+			messages = append(messages, toProto(msg))
+		}
+
+		if req.GetCommit() {
+			if err := s.App.Repo.CommitOffset(req.GetTopic(), partition, offsetKey, startOffset+fresh); err != nil {
+				s.App.Logger.Warn("grpc: failed to auto-commit offset", "topic", req.GetTopic(), "partition", partition, "consumer", offsetKey, "error", err)
+			}
+		}
+	}
+
+	return &gomqpb.FetchResponse{Messages: messages}, nil
+}
+
+func (s *Server) CommitOffset(ctx context.Context, req *gomqpb.CommitOffsetRequest) (*gomqpb.CommitOffsetResponse, error) {
+	offsetKey := req.GetConsumerId()
+	if req.GetGroupId() != "" {
+		offsetKey = req.GetGroupId()
+	}
+	if req.GetTopic() == "" || offsetKey == "" || req.GetOffset() < 0 {
+		return nil, status.Error(codes.InvalidArgument, "topic, a consumer_id or group_id and a non-negative offset are required")
+	}
+
+	if err := s.App.Repo.CommitOffset(req.GetTopic(), int(req.GetPartition()), offsetKey, int(req.GetOffset())); err != nil {
+		s.App.Logger.Warn("grpc: failed to commit offset", "topic", req.GetTopic(), "partition", req.GetPartition(), "consumer", offsetKey, "error", err)
+		return nil, statusFor(err)
+	}
+
+	return &gomqpb.CommitOffsetResponse{}, nil
+}
+
+func (s *Server) Ack(ctx context.Context, req *gomqpb.AckRequest) (*gomqpb.AckResponse, error) {
+	if req.GetTopic() == "" || req.GetConsumerId() == "" || req.GetMessageId() == "" {
+		return nil, status.Error(codes.InvalidArgument, "topic, consumer_id and message_id are required")
+	}
+
+	duplicate, err := s.App.Broker.Ack(req.GetTopic(), req.GetConsumerId(), req.GetMessageId())
+	if err != nil {
+		return nil, statusFor(err)
+	}
+
+	return &gomqpb.AckResponse{Duplicate: duplicate}, nil
+}
+
+// validDeadLetter accepts no dead-letter settings at all, or an attempt count
+// together with a dead-letter topic other than the topic itself.
+func validDeadLetter(topic string, policy *gomqpb.DeadLetterPolicy) bool {
+	attempts, target := policy.GetMaxDeliveryAttempts(), policy.GetTopic()
+	if attempts == 0 && target == "" {
+		return true
+	}
+	return attempts > 0 && target != "" && target != topic
+}
+
+func toProto(msg *core.Message) *gomqpb.Message {
+	return &gomqpb.Message{
+		Id:         msg.ID,
+		Key:        msg.Key,
+		Partition:  int32(msg.Partition),
+		Offset:     int64(msg.Offset),
+		Body:       msg.Body,
+		Timestamp:  timestamppb.New(msg.Timestamp),
+		ProducerId: msg.ProducerID,
+		Attempts:   int32(msg.DeliveryAttempts),
+		Metadata:   msg.Metadata,
+	}
+}
+
+// statusFor maps broker and repository errors onto gRPC status codes the same way
+// the HTTP handlers map them onto status codes.
+func statusFor(err error) error {
+	var outOfRange *repository.OffsetOutOfRangeError
+	switch {
+	case errors.As(err, &outOfRange):
+		return status.Error(codes.OutOfRange, fmt.Sprintf("%s (low watermark %d)", err, outOfRange.LowWatermark))
+	case strings.Contains(err.Error(), "does not exist"), strings.Contains(err.Error(), "not awaiting acknowledgement"):
+		return status.Error(codes.NotFound, err.Error())
+	case strings.Contains(err.Error(), "already exists"):
+		return status.Error(codes.AlreadyExists, err.Error())
+	case strings.Contains(err.Error(), "beyond the partition length"):
+		return status.Error(codes.OutOfRange, err.Error())
+	case strings.Contains(err.Error(), "already consumes"):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	default:
+		return status.Error(codes.Internal, err.Error())
+	}
+}
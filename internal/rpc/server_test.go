@@ -0,0 +1,228 @@
+package rpc
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/codytheroux96/go-mq/internal/app"
+	"github.com/codytheroux96/go-mq/internal/rpc/gomqpb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+func setupTestClient(t *testing.T) gomqpb.GoMQClient {
+	t.Helper()
+
+	listener := bufconn.Listen(1 << 20)
+	server := NewServer(app.NewApplication())
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatalf("failed to dial: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	return gomqpb.NewGoMQClient(conn)
+}
+
+func TestServer(t *testing.T) {
+	client := setupTestClient(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	tests := []struct {
+		name       string
+		action     func() error
+		expectCode codes.Code
+	}{
+		{
+			name: "Create topic",
+			action: func() error {
+				_, err := client.CreateTopic(ctx, &gomqpb.CreateTopicRequest{Name: "orders", Partitions: 2})
+				return err
+			},
+			expectCode: codes.OK,
+		},
+		{
+			name: "Create duplicate topic",
+			action: func() error {
+				_, err := client.CreateTopic(ctx, &gomqpb.CreateTopicRequest{Name: "orders"})
+				return err
+			},
+			expectCode: codes.AlreadyExists,
+		},
+		{
+			name: "Create topic dead-lettering to itself",
+			action: func() error {
+				_, err := client.CreateTopic(ctx, &gomqpb.CreateTopicRequest{Name: "loop", DeadLetter: &gomqpb.DeadLetterPolicy{MaxDeliveryAttempts: 3, Topic: "loop"}})
+				return err
+			},
+			expectCode: codes.InvalidArgument,
+		},
+		{
+			name: "Publish to missing topic",
+			action: func() error {
+				_, err := client.Publish(ctx, &gomqpb.PublishRequest{Topic: "missing", Body: []byte("x"), ProducerId: "p1"})
+				return err
+			},
+			expectCode: codes.NotFound,
+		},
+		{
+			name: "Commit offset beyond the partition",
+			action: func() error {
+				_, err := client.CommitOffset(ctx, &gomqpb.CommitOffsetRequest{Topic: "orders", ConsumerId: "c1", Offset: 100})
+				return err
+			},
+			expectCode: codes.OutOfRange,
+		},
+		{
+			name: "Ack message that was never delivered",
+			action: func() error {
+				_, err := client.Ack(ctx, &gomqpb.AckRequest{Topic: "orders", ConsumerId: "c1", MessageId: "unknown"})
+				return err
+			},
+			expectCode: codes.NotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if code := status.Code(tt.action()); code != tt.expectCode {
+				t.Fatalf("expected %v, got %v", tt.expectCode, code)
+			}
+		})
+	}
+
+	t.Run("Publish stream then fetch, commit and ack", func(t *testing.T) {
+		stream, err := client.PublishStream(ctx)
+		if err != nil {
+			t.Fatalf("failed to open publish stream: %v", err)
+		}
+		for _, body := range []string{"a", "b", "c"} {
+			if err := stream.Send(&gomqpb.PublishRequest{Topic: "orders", Body: []byte(body), ProducerId: "p1", Key: "same-key"}); err != nil {
+				t.Fatalf("failed to send: %v", err)
+			}
+		}
+		published, err := stream.CloseAndRecv()
+		if err != nil || len(published.GetResults()) != 3 {
+			t.Fatalf("expected 3 results, got %v (err: %v)", published, err)
+		}
+		partition := published.GetResults()[0].GetPartition()
+
+		fetched, err := client.Fetch(ctx, &gomqpb.FetchRequest{Topic: "orders", ConsumerId: "c1", Partition: &partition, Limit: 2, Commit: true})
+		if err != nil || len(fetched.GetMessages()) != 2 || string(fetched.GetMessages()[0].GetBody()) != "a" {
+			t.Fatalf("expected the first 2 messages, got %v (err: %v)", fetched, err)
+		}
+
+		acked, err := client.Ack(ctx, &gomqpb.AckRequest{Topic: "orders", ConsumerId: "c1", MessageId: fetched.GetMessages()[0].GetId()})
+		if err != nil || acked.GetDuplicate() {
+			t.Fatalf("expected a first ack, got %v (err: %v)", acked, err)
+		}
+
+		fetched, err = client.Fetch(ctx, &gomqpb.FetchRequest{Topic: "orders", ConsumerId: "c1", Partition: &partition, Limit: 10})
+		if err != nil || len(fetched.GetMessages()) != 1 || fetched.GetMessages()[0].GetOffset() != 2 {
+			t.Fatalf("expected the remaining message at offset 2, got %v (err: %v)", fetched, err)
+		}
+
+		offset := int64(1)
+		fetched, err = client.Fetch(ctx, &gomqpb.FetchRequest{Topic: "orders", ConsumerId: "c1", Partition: &partition, Limit: 10, Offset: &offset})
+		if err != nil || len(fetched.GetMessages()) != 2 || fetched.GetMessages()[0].GetOffset() != 1 {
+			t.Fatalf("expected the messages from offset 1, got %v (err: %v)", fetched, err)
+		}
+
+		topics, err := client.ListTopics(ctx, &gomqpb.ListTopicsRequest{})
+		if err != nil || len(topics.GetTopics()) != 1 {
+			t.Fatalf("expected 1 topic, got %v (err: %v)", topics, err)
+		}
+	})
+
+	t.Run("Consume stream with credit and acks", func(t *testing.T) {
+		if _, err := client.CreateTopic(ctx, &gomqpb.CreateTopicRequest{Name: "live"}); err != nil {
+			t.Fatalf("failed to create topic: %v", err)
+		}
+
+		stream, err := client.Consume(ctx)
+		if err != nil {
+			t.Fatalf("failed to open consume stream: %v", err)
+		}
+		subscribe := &gomqpb.ConsumeRequest_Subscribe{Topic: "live", ConsumerId: "c1", Credit: 1}
+		if err := stream.Send(&gomqpb.ConsumeRequest{Frame: &gomqpb.ConsumeRequest_Subscribe_{Subscribe: subscribe}}); err != nil {
+			t.Fatalf("failed to subscribe: %v", err)
+		}
+
+		// the subscription is registered asynchronously, publish until it is seen
+		var first *gomqpb.Message
+		received := make(chan *gomqpb.ConsumeResponse)
+		go func() {
+			for {
+				resp, err := stream.Recv()
+				if err != nil {
+					close(received)
+					return
+				}
+				received <- resp
+			}
+		}()
+		for first == nil {
+			if _, err := client.Publish(ctx, &gomqpb.PublishRequest{Topic: "live", Body: []byte("hello"), ProducerId: "p1"}); err != nil {
+				t.Fatalf("failed to publish: %v", err)
+			}
+			select {
+			case resp := <-received:
+				first = resp.GetMessage()
+			case <-time.After(50 * time.Millisecond):
+			}
+		}
+
+		ack := &gomqpb.ConsumeRequest_Ack{MessageId: "unknown"}
+		if err := stream.Send(&gomqpb.ConsumeRequest{Frame: &gomqpb.ConsumeRequest_Ack_{Ack: ack}}); err != nil {
+			t.Fatalf("failed to send ack: %v", err)
+		}
+		select {
+		case resp := <-received:
+			if resp.GetError().GetMessageId() != "unknown" {
+				t.Fatalf("expected an error frame for the unknown ack, got %v", resp)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatalf("expected an error frame")
+		}
+
+		ack = &gomqpb.ConsumeRequest_Ack{MessageId: first.GetId()}
+		if err := stream.Send(&gomqpb.ConsumeRequest{Frame: &gomqpb.ConsumeRequest_Ack_{Ack: ack}}); err != nil {
+			t.Fatalf("failed to send ack: %v", err)
+		}
+		if _, err := client.Publish(ctx, &gomqpb.PublishRequest{Topic: "live", Body: []byte("again"), ProducerId: "p1"}); err != nil {
+			t.Fatalf("failed to publish: %v", err)
+		}
+		// extra copies of the first message may still be queued behind the credit
+		credit := &gomqpb.ConsumeRequest_Credit{Credit: 10}
+		if err := stream.Send(&gomqpb.ConsumeRequest{Frame: &gomqpb.ConsumeRequest_Credit_{Credit: credit}}); err != nil {
+			t.Fatalf("failed to send credit: %v", err)
+		}
+		for again := false; !again; {
+			select {
+			case resp := <-received:
+				again = string(resp.GetMessage().GetBody()) == "again"
+			case <-time.After(2 * time.Second):
+				t.Fatalf("expected a message after granting credit")
+			}
+		}
+
+		acked, err := client.Ack(ctx, &gomqpb.AckRequest{Topic: "live", ConsumerId: "c1", MessageId: first.GetId()})
+		if err != nil || !acked.GetDuplicate() {
+			t.Fatalf("expected the stream ack to have settled the message, got %v (err: %v)", acked, err)
+		}
+	})
+}
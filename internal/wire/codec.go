@@ -0,0 +1,287 @@
+package wire
+
+import (
+	"encoding/binary"
+	"errors"
+	"time"
+
+	"github.com/codytheroux96/go-mq/internal/core"
+)
+
+var errMalformed = errors.New("malformed payload")
+
+// PublishBatch is the payload of OpPublish, answered by OpPublishResult with one
+// result per message in the same order.
+type PublishBatch struct {
+	Topic    string
+	Messages []PublishMessage
+}
+
+type PublishMessage struct {
+	Key        string
+	ProducerID string
+	Body       []byte
+}
+
+type PublishResult struct {
+	MessageID string
+	Partition int
+	Offset    int
+	Err       string
+}
+
+// Subscribe is the payload of OpSubscribe. Credit is how many messages the server
+// may push before the client sends OpCredit.
+type Subscribe struct {
+	Topic      string
+	ConsumerID string
+	GroupID    string
+	Credit     uint32
+}
+
+type Nack struct {
+	MessageID string
+	Requeue   bool
+	Delay     time.Duration
+	Reason    string
+}
+
+func (b PublishBatch) Encode() []byte {
+	var e encoder
+	e.string(b.Topic)
+	e.uint32(uint32(len(b.Messages)))
+	for _, msg := range b.Messages {
+		e.string(msg.Key)
+		e.string(msg.ProducerID)
+		e.bytes(msg.Body)
+	}
+	return e.buf
+}
+
+func DecodePublishBatch(payload []byte) (PublishBatch, error) {
+	d := decoder{buf: payload}
+	batch := PublishBatch{Topic: d.string()}
+	count := d.uint32()
+	for i := uint32(0); i < count && d.err == nil; i++ {
+		batch.Messages = append(batch.Messages, PublishMessage{
+			Key:        d.string(),
+			ProducerID: d.string(),
+			Body:       d.bytes(),
+		})
+	}
+	return batch, d.finish()
+}
+
+func EncodePublishResults(results []PublishResult) []byte {
+	var e encoder
+	e.uint32(uint32(len(results)))
+	for _, result := range results {
+		e.string(result.Err)
+		e.string(result.MessageID)
+		e.uint32(uint32(result.Partition))
+		e.uint64(uint64(result.Offset))
+	}
+	return e.buf
+}
+
+func DecodePublishResults(payload []byte) ([]PublishResult, error) {
+	d := decoder{buf: payload}
+	count := d.uint32()
+	var results []PublishResult
+	for i := uint32(0); i < count && d.err == nil; i++ {
+		results = append(results, PublishResult{
+			Err:       d.string(),
+			MessageID: d.string(),
+			Partition: int(d.uint32()),
+			Offset:    int(d.uint64()),
+		})
+	}
+	return results, d.finish()
+}
+
+func (s Subscribe) Encode() []byte {
+	var e encoder
+	e.string(s.Topic)
+	e.string(s.ConsumerID)
+	e.string(s.GroupID)
+	e.uint32(s.Credit)
+	return e.buf
+}
+
+func DecodeSubscribe(payload []byte) (Subscribe, error) {
+	d := decoder{buf: payload}
+	sub := Subscribe{
+		Topic:      d.string(),
+		ConsumerID: d.string(),
+		GroupID:    d.string(),
+		Credit:     d.uint32(),
+	}
+	return sub, d.finish()
+}
+
+func EncodeCredit(credit uint32) []byte {
+	var e encoder
+	e.uint32(credit)
+	return e.buf
+}
+
+func DecodeCredit(payload []byte) (uint32, error) {
+	d := decoder{buf: payload}
+	credit := d.uint32()
+	return credit, d.finish()
+}
+
+// EncodeString is the payload of OpAck (the message ID) and OpError (the reason).
+func EncodeString(s string) []byte {
+	var e encoder
+	e.string(s)
+	return e.buf
+}
+
+func DecodeString(payload []byte) (string, error) {
+	d := decoder{buf: payload}
+	s := d.string()
+	return s, d.finish()
+}
+
+func (n Nack) Encode() []byte {
+	var e encoder
+	e.string(n.MessageID)
+	if n.Requeue {
+		e.uint8(1)
+	} else {
+		e.uint8(0)
+	}
+	e.uint64(uint64(n.Delay.Milliseconds()))
+	e.string(n.Reason)
+	return e.buf
+}
+
+func DecodeNack(payload []byte) (Nack, error) {
+	d := decoder{buf: payload}
+	nack := Nack{
+		MessageID: d.string(),
+		Requeue:   d.uint8() == 1,
+		Delay:     time.Duration(d.uint64()) * time.Millisecond,
+		Reason:    d.string(),
+	}
+	return nack, d.finish()
+}
+
+// EncodeMessage is the payload of OpDeliver.
+func EncodeMessage(msg *core.Message) []byte {
+	var e encoder
+	e.string(msg.ID)
+	e.string(msg.Key)
+	e.string(msg.ProducerID)
+	e.uint32(uint32(msg.Partition))
+	e.uint64(uint64(msg.Offset))
+	e.uint64(uint64(msg.Timestamp.UnixNano()))
+	e.uint32(uint32(msg.DeliveryAttempts))
+	e.bytes(msg.Body)
+	return e.buf
+}
+
+func DecodeMessage(payload []byte) (*core.Message, error) {
+	d := decoder{buf: payload}
+	msg := core.NewMessage(nil, "")
+	msg.ID = d.string()
+	msg.Key = d.string()
+	msg.ProducerID = d.string()
+	msg.Partition = int(d.uint32())
+	msg.Offset = int(d.uint64())
+	msg.Timestamp = time.Unix(0, int64(d.uint64()))
+	msg.DeliveryAttempts = int(d.uint32())
+	msg.Body = d.bytes()
+	return msg, d.finish()
+}
+
+// encoder appends big-endian integers, strings with a 2 byte length and byte
+// slices with a 4 byte length. Strings longer than 64KiB are truncated.
+type encoder struct {
+	buf []byte
+}
+
+func (e *encoder) uint8(v uint8) {
+	e.buf = append(e.buf, v)
+}
+
+func (e *encoder) uint32(v uint32) {
+	e.buf = binary.BigEndian.AppendUint32(e.buf, v)
+}
+
+func (e *encoder) uint64(v uint64) {
+	e.buf = binary.BigEndian.AppendUint64(e.buf, v)
+}
+
+func (e *encoder) string(s string) {
+	if len(s) > 0xffff {
+		s = s[:0xffff]
+	}
+	e.buf = binary.BigEndian.AppendUint16(e.buf, uint16(len(s)))
+	e.buf = append(e.buf, s...)
+}
+
+func (e *encoder) bytes(b []byte) {
+	e.uint32(uint32(len(b)))
+	e.buf = append(e.buf, b...)
+}
+
+// decoder reads what encoder writes. The first short read marks the payload as
+// malformed and every read after it returns zero values.
+type decoder struct {
+	buf []byte
+	err error
+}
+
+func (d *decoder) take(n int) []byte {
+	if d.err != nil || len(d.buf) < n {
+		d.err = errMalformed
+		return nil
+	}
+	b := d.buf[:n]
+	d.buf = d.buf[n:]
+	return b
+}
+
+func (d *decoder) uint8() uint8 {
+	if b := d.take(1); b != nil {
+		return b[0]
+	}
+	return 0
+}
+
+func (d *decoder) uint32() uint32 {
+	if b := d.take(4); b != nil {
+		return binary.BigEndian.Uint32(b)
+	}
+	return 0
+}
+
+func (d *decoder) uint64() uint64 {
+	if b := d.take(8); b != nil {
+		return binary.BigEndian.Uint64(b)
+	}
+	return 0
+}
+
+func (d *decoder) string() string {
+	var n int
+	if b := d.take(2); b != nil {
+		n = int(binary.BigEndian.Uint16(b))
+	}
+	return string(d.take(n))
+}
+
+func (d *decoder) bytes() []byte {
+	n := int(d.uint32())
+	return append([]byte(nil), d.take(n)...)
+}
+
+// finish reports a short read or bytes left over after the last field.
+func (d *decoder) finish() error {
+	if d.err == nil && len(d.buf) > 0 {
+		return errMalformed
+	}
+	return d.err
+}
@@ -0,0 +1,100 @@
+package wire
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/codytheroux96/go-mq/internal/core"
+)
+
+func TestFrameRoundTrip(t *testing.T) {
+	var buf bytes.Buffer
+	sent := Frame{Op: OpPublish, CorrelationID: 42, Payload: []byte("payload")}
+	if err := WriteFrame(&buf, sent); err != nil {
+		t.Fatalf("failed to write frame: %v", err)
+	}
+
+	got, err := ReadFrame(&buf)
+	if err != nil {
+		t.Fatalf("failed to read frame: %v", err)
+	}
+	if !reflect.DeepEqual(got, sent) {
+		t.Fatalf("expected %+v, got %+v", sent, got)
+	}
+
+	if _, err := ReadFrame(bytes.NewReader([]byte{0xff, 0xff, 0xff, 0xff, OpPing, 0, 0, 0, 1})); err == nil {
+		t.Fatalf("expected error for an oversized frame length")
+	}
+}
+
+func TestCodec(t *testing.T) {
+	msg := core.NewMessage([]byte("body"), "p1")
+	msg.ID, msg.Key, msg.Partition, msg.Offset, msg.DeliveryAttempts = "m1", "k1", 2, 7, 3
+	msg.Timestamp = time.Unix(0, 1700000000123456789)
+
+	tests := []struct {
+		name   string
+		value  any
+		encode func() []byte
+		decode func([]byte) (any, error)
+	}{
+		{
+			name:  "Publish batch",
+			value: PublishBatch{Topic: "orders", Messages: []PublishMessage{{Key: "k", ProducerID: "p1", Body: []byte("a")}, {ProducerID: "p2", Body: []byte("b")}}},
+			encode: func() []byte {
+				return PublishBatch{Topic: "orders", Messages: []PublishMessage{{Key: "k", ProducerID: "p1", Body: []byte("a")}, {ProducerID: "p2", Body: []byte("b")}}}.Encode()
+			},
+			decode: func(p []byte) (any, error) { return DecodePublishBatch(p) },
+		},
+		{
+			name:  "Publish results",
+			value: []PublishResult{{MessageID: "m1", Partition: 1, Offset: 9}, {Err: "topic does not exist"}},
+			encode: func() []byte {
+				return EncodePublishResults([]PublishResult{{MessageID: "m1", Partition: 1, Offset: 9}, {Err: "topic does not exist"}})
+			},
+			decode: func(p []byte) (any, error) { return DecodePublishResults(p) },
+		},
+		{
+			name:   "Subscribe",
+			value:  Subscribe{Topic: "orders", ConsumerID: "c1", GroupID: "g1", Credit: 5},
+			encode: func() []byte { return Subscribe{Topic: "orders", ConsumerID: "c1", GroupID: "g1", Credit: 5}.Encode() },
+			decode: func(p []byte) (any, error) { return DecodeSubscribe(p) },
+		},
+		{
+			name:  "Nack",
+			value: Nack{MessageID: "m1", Requeue: true, Delay: 1500 * time.Millisecond, Reason: "boom"},
+			encode: func() []byte {
+				return Nack{MessageID: "m1", Requeue: true, Delay: 1500 * time.Millisecond, Reason: "boom"}.Encode()
+			},
+			decode: func(p []byte) (any, error) { return DecodeNack(p) },
+		},
+		{
+			name:   "Message",
+			value:  msg,
+			encode: func() []byte { return EncodeMessage(msg) },
+			decode: func(p []byte) (any, error) { return DecodeMessage(p) },
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			payload := tt.encode()
+			got, err := tt.decode(payload)
+			if err != nil {
+				t.Fatalf("failed to decode: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.value) {
+				t.Fatalf("expected %+v, got %+v", tt.value, got)
+			}
+
+			if _, err := tt.decode(payload[:len(payload)-1]); err == nil {
+				t.Fatalf("expected error decoding a truncated payload")
+			}
+			if _, err := tt.decode(append(payload, 0)); err == nil {
+				t.Fatalf("expected error decoding a payload with trailing bytes")
+			}
+		})
+	}
+}
@@ -0,0 +1,84 @@
+// Package wire serves the broker over a compact binary protocol on raw TCP.
+//
+// Every frame is a 4 byte big-endian length covering the rest of the frame,
+// followed by a 1 byte op code, a 4 byte correlation ID and the payload. Requests
+// may be pipelined; each response carries the correlation ID of its request and
+// messages pushed to a subscriber carry the correlation ID of the subscribe frame.
+package wire
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+const (
+	// MaxFrameSize bounds a single frame so a bad length prefix cannot make the
+	// server allocate without limit.
+	MaxFrameSize = 16 << 20
+
+	frameHeaderSize = 4 + 1 + 4
+)
+
+const (
+	// requests
+	OpPublish   byte = 0x01
+	OpSubscribe byte = 0x02
+	OpCredit    byte = 0x03
+	OpAck       byte = 0x04
+	OpNack      byte = 0x05
+	OpPing      byte = 0x06
+
+	// responses and pushes
+	OpOK            byte = 0x80
+	OpPublishResult byte = 0x81
+	OpDeliver       byte = 0x90
+	OpError         byte = 0xff
+)
+
+type Frame struct {
+	Op            byte
+	CorrelationID uint32
+	Payload       []byte
+}
+
+func ReadFrame(r io.Reader) (Frame, error) {
+	var header [frameHeaderSize]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return Frame{}, err
+	}
+
+	length := binary.BigEndian.Uint32(header[0:4])
+	if length < frameHeaderSize-4 || length > MaxFrameSize {
+		return Frame{}, fmt.Errorf("invalid frame length %d", length)
+	}
+
+	frame := Frame{
+		Op:            header[4],
+		CorrelationID: binary.BigEndian.Uint32(header[5:9]),
+		Payload:       make([]byte, length-(frameHeaderSize-4)),
+	}
+	if _, err := io.ReadFull(r, frame.Payload); err != nil {
+		return Frame{}, fmt.Errorf("failed to read frame payload: %w", err)
+	}
+
+	return frame, nil
+}
+
+func WriteFrame(w io.Writer, frame Frame) error {
+	length := frameHeaderSize - 4 + len(frame.Payload)
+	if length > MaxFrameSize {
+		return fmt.Errorf("frame of %d bytes exceeds the maximum frame size", length)
+	}
+
+	var header [frameHeaderSize]byte
+	binary.BigEndian.PutUint32(header[0:4], uint32(length))
+	header[4] = frame.Op
+	binary.BigEndian.PutUint32(header[5:9], frame.CorrelationID)
+
+	if _, err := w.Write(header[:]); err != nil {
+		return err
+	}
+	_, err := w.Write(frame.Payload)
+	return err
+}
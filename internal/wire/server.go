@@ -0,0 +1,338 @@
+package wire
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"log/slog"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/codytheroux96/go-mq/internal/broker"
+	"github.com/codytheroux96/go-mq/internal/core"
+)
+
+const (
+	// outboxSize is how many frames may wait for the connection's writer before
+	// the reader stops taking new requests.
+	outboxSize = 256
+
+	heartbeatInterval = 10 * time.Second
+)
+
+type Server struct {
+	Broker *broker.Manager
+	Logger *slog.Logger
+
+	mu       sync.Mutex
+	listener net.Listener
+	conns    map[*conn]struct{}
+	wg       sync.WaitGroup
+}
+
+func NewServer(broker *broker.Manager, logger *slog.Logger) *Server {
+	return &Server{
+		Broker: broker,
+		Logger: logger,
+		conns:  make(map[*conn]struct{}),
+	}
+}
+
+// Serve accepts connections until Close is called, after which it returns nil.
+func (s *Server) Serve(listener net.Listener) error {
+	s.mu.Lock()
+	s.listener = listener
+	s.mu.Unlock()
+
+	for {
+		netConn, err := listener.Accept()
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+			return err
+		}
+
+		c := &conn{
+			server:  s,
+			netConn: netConn,
+			out:     make(chan Frame, outboxSize),
+			credits: make(chan uint32),
+			quit:    make(chan struct{}),
+		}
+
+		s.mu.Lock()
+		s.conns[c] = struct{}{}
+		s.mu.Unlock()
+
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			c.serve()
+
+			s.mu.Lock()
+			delete(s.conns, c)
+			s.mu.Unlock()
+		}()
+	}
+}
+
+// Close stops accepting connections, closes the open ones and waits for them to
+// finish.
+func (s *Server) Close() error {
+	s.mu.Lock()
+	var err error
+	if s.listener != nil {
+		err = s.listener.Close()
+	}
+	for c := range s.conns {
+		c.netConn.Close()
+	}
+	s.mu.Unlock()
+
+	s.wg.Wait()
+	return err
+}
+
+// conn is one client connection. Requests are read and handled in order by serve,
+// frames are written by a single writer goroutine fed through out, and pushed
+// deliveries come from a separate goroutine once the client subscribes.
+type conn struct {
+	server  *Server
+	netConn net.Conn
+	out     chan Frame
+	credits chan uint32
+	quit    chan struct{}
+
+	// set by the subscribe frame, only touched by serve
+	topic      string
+	consumerID string
+	groupID    string
+	inbox      <-chan *core.Message
+}
+
+func (c *conn) serve() {
+	logger := c.server.Logger.With("remote", c.netConn.RemoteAddr().String())
+	logger.Info("wire connection opened")
+
+	var workers sync.WaitGroup
+	workers.Add(1)
+	go func() {
+		defer workers.Done()
+		c.writeLoop()
+	}()
+
+	reader := bufio.NewReader(c.netConn)
+	for {
+		frame, err := ReadFrame(reader)
+		if err != nil {
+			if !errors.Is(err, net.ErrClosed) && !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
+				logger.Warn("wire connection read failed", "error", err)
+			}
+			break
+		}
+
+		if c.handle(frame, &workers) != nil {
+			break
+		}
+	}
+
+	close(c.quit)
+	c.netConn.Close()
+	workers.Wait()
+	c.unsubscribe()
+
+	logger.Info("wire connection closed", "consumer", c.consumerID)
+}
+
+// handle answers a single request. It only returns an error when the connection
+// is shutting down.
+func (c *conn) handle(frame Frame, workers *sync.WaitGroup) error {
+	switch frame.Op {
+	case OpPublish:
+		batch, err := DecodePublishBatch(frame.Payload)
+		if err != nil {
+			return c.fail(frame, err.Error())
+		}
+		return c.send(Frame{Op: OpPublishResult, CorrelationID: frame.CorrelationID, Payload: EncodePublishResults(c.publish(batch))})
+
+	case OpSubscribe:
+		sub, err := DecodeSubscribe(frame.Payload)
+		if err != nil {
+			return c.fail(frame, err.Error())
+		}
+		if c.inbox != nil {
+			return c.fail(frame, "connection is already subscribed")
+		}
+		if sub.Topic == "" || sub.ConsumerID == "" {
+			return c.fail(frame, "topic and consumer id are required")
+		}
+
+		var inbox <-chan *core.Message
+		if sub.GroupID != "" {
+			inbox, _, err = c.server.Broker.JoinGroup(sub.GroupID, sub.Topic, sub.ConsumerID, core.FlowControl{})
+		} else {
+			inbox, err = c.server.Broker.Subscribe(sub.Topic, sub.ConsumerID, core.FlowControl{})
+		}
+		if err != nil {
+			return c.fail(frame, err.Error())
+		}
+		c.topic, c.consumerID, c.groupID, c.inbox = sub.Topic, sub.ConsumerID, sub.GroupID, inbox
+
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			c.deliverLoop(frame.CorrelationID, sub.Credit)
+		}()
+		return c.send(Frame{Op: OpOK, CorrelationID: frame.CorrelationID})
+
+	case OpCredit:
+		credit, err := DecodeCredit(frame.Payload)
+		if err != nil {
+			return c.fail(frame, err.Error())
+		}
+		if c.inbox == nil {
+			return c.fail(frame, "credit requires a subscription")
+		}
+		select {
+		case c.credits <- credit:
+			return nil
+		case <-c.quit:
+			return net.ErrClosed
+		}
+
+	case OpAck:
+		messageID, err := DecodeString(frame.Payload)
+		if err != nil {
+			return c.fail(frame, err.Error())
+		}
+		if c.inbox == nil {
+			return c.fail(frame, "ack requires a subscription")
+		}
+		if _, err := c.server.Broker.Ack(c.topic, c.consumerID, messageID); err != nil {
+			return c.fail(frame, err.Error())
+		}
+		return c.send(Frame{Op: OpOK, CorrelationID: frame.CorrelationID})
+
+	case OpNack:
+		nack, err := DecodeNack(frame.Payload)
+		if err != nil {
+			return c.fail(frame, err.Error())
+		}
+		if c.inbox == nil {
+			return c.fail(frame, "nack requires a subscription")
+		}
+		if _, err := c.server.Broker.Nack(c.topic, c.consumerID, nack.MessageID, nack.Requeue, nack.Delay, nack.Reason); err != nil {
+			return c.fail(frame, err.Error())
+		}
+		return c.send(Frame{Op: OpOK, CorrelationID: frame.CorrelationID})
+
+	case OpPing:
+		return c.send(Frame{Op: OpOK, CorrelationID: frame.CorrelationID})
+
+	default:
+		return c.fail(frame, "unknown op code")
+	}
+}
+
+func (c *conn) publish(batch PublishBatch) []PublishResult {
+	results := make([]PublishResult, len(batch.Messages))
+	for i, m := range batch.Messages {
+		msg := core.NewMessage(m.Body, m.ProducerID)
+		msg.Key = m.Key
+		if err := c.server.Broker.Publish(batch.Topic, msg); err != nil {
+			results[i].Err = err.Error()
+			continue
+		}
+		results[i] = PublishResult{MessageID: msg.ID, Partition: msg.Partition, Offset: msg.Offset}
+	}
+	return results
+}
+
+// deliverLoop pushes inbox messages while the client has credit. Group members
+// heartbeat from here to keep their partitions.
+func (c *conn) deliverLoop(correlationID, credit uint32) {
+	ticker := time.NewTicker(min(heartbeatInterval, c.server.Broker.SessionTimeout/3))
+	defer ticker.Stop()
+
+	for {
+		var next <-chan *core.Message
+		if credit > 0 {
+			next = c.inbox
+		}
+
+		select {
+		case <-c.quit:
+			return
+		case n := <-c.credits:
+			credit += n
+		case msg, ok := <-next:
+			if !ok {
+				c.send(Frame{Op: OpError, CorrelationID: correlationID, Payload: EncodeString("consumer was disconnected for falling behind")})
+				c.netConn.Close()
+				return
+			}
+			if c.send(Frame{Op: OpDeliver, CorrelationID: correlationID, Payload: EncodeMessage(msg)}) != nil {
+				return
+			}
+			credit--
+		case <-ticker.C:
+			if c.groupID == "" {
+				continue
+			}
+			if _, err := c.server.Broker.Heartbeat(c.groupID, c.consumerID); err != nil {
+				c.send(Frame{Op: OpError, CorrelationID: correlationID, Payload: EncodeString(err.Error())})
+				c.netConn.Close()
+				return
+			}
+		}
+	}
+}
+
+// writeLoop writes queued frames, flushing whenever the queue runs dry so
+// pipelined responses share a write.
+func (c *conn) writeLoop() {
+	writer := bufio.NewWriter(c.netConn)
+	for {
+		select {
+		case <-c.quit:
+			return
+		case frame := <-c.out:
+			if err := WriteFrame(writer, frame); err != nil {
+				c.netConn.Close()
+				return
+			}
+			if len(c.out) == 0 {
+				if err := writer.Flush(); err != nil {
+					c.netConn.Close()
+					return
+				}
+			}
+		}
+	}
+}
+
+func (c *conn) send(frame Frame) error {
+	select {
+	case c.out <- frame:
+		return nil
+	case <-c.quit:
+		return net.ErrClosed
+	}
+}
+
+func (c *conn) fail(frame Frame, reason string) error {
+	return c.send(Frame{Op: OpError, CorrelationID: frame.CorrelationID, Payload: EncodeString(reason)})
+}
+
+func (c *conn) unsubscribe() {
+	if c.inbox == nil {
+		return
+	}
+	if c.groupID != "" {
+		c.server.Broker.LeaveGroup(c.groupID, c.consumerID)
+		return
+	}
+	c.server.Broker.Unsubscribe(c.topic, c.consumerID, c.inbox)
+}
@@ -0,0 +1,143 @@
+package wire
+
+import (
+	"bufio"
+	"io"
+	"log/slog"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/codytheroux96/go-mq/internal/broker"
+	"github.com/codytheroux96/go-mq/internal/core"
+	"github.com/codytheroux96/go-mq/internal/repository"
+)
+
+func TestServer(t *testing.T) {
+	repo := repository.NewInMemoryRepo()
+	if err := repo.CreateTopic("orders", core.TopicConfig{Partitions: 2}); err != nil {
+		t.Fatalf("failed to create topic: %v", err)
+	}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	server := NewServer(broker.NewManager(repo), slog.New(slog.NewTextHandler(io.Discard, nil)))
+	go server.Serve(listener)
+	defer server.Close()
+
+	conn, err := net.Dial("tcp", listener.Addr().String())
+	if err != nil {
+		t.Fatalf("failed to dial: %v", err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+	reader := bufio.NewReader(conn)
+
+	send := func(op byte, correlationID uint32, payload []byte) {
+		t.Helper()
+		if err := WriteFrame(conn, Frame{Op: op, CorrelationID: correlationID, Payload: payload}); err != nil {
+			t.Fatalf("failed to write frame: %v", err)
+		}
+	}
+	receive := func() Frame {
+		t.Helper()
+		frame, err := ReadFrame(reader)
+		if err != nil {
+			t.Fatalf("failed to read frame: %v", err)
+		}
+		return frame
+	}
+
+	t.Run("Pipelined publish batches", func(t *testing.T) {
+		send(OpPublish, 1, PublishBatch{Topic: "orders", Messages: []PublishMessage{{Key: "a", ProducerID: "p1", Body: []byte("1")}, {Key: "a", ProducerID: "p1", Body: []byte("2")}}}.Encode())
+		send(OpPublish, 2, PublishBatch{Topic: "missing", Messages: []PublishMessage{{ProducerID: "p1", Body: []byte("x")}}}.Encode())
+
+		for _, expected := range []uint32{1, 2} {
+			frame := receive()
+			if frame.Op != OpPublishResult || frame.CorrelationID != expected {
+				t.Fatalf("expected publish result for request %d, got op %x for %d", expected, frame.Op, frame.CorrelationID)
+			}
+			results, err := DecodePublishResults(frame.Payload)
+			if err != nil {
+				t.Fatalf("failed to decode results: %v", err)
+			}
+			switch expected {
+			case 1:
+				if len(results) != 2 || results[0].Err != "" || results[1].Offset != results[0].Offset+1 {
+					t.Fatalf("expected 2 consecutive offsets, got %+v", results)
+				}
+			case 2:
+				if len(results) != 1 || results[0].Err == "" {
+					t.Fatalf("expected a per message error for a missing topic, got %+v", results)
+				}
+			}
+		}
+	})
+
+	t.Run("Push delivery with credit", func(t *testing.T) {
+		send(OpSubscribe, 10, Subscribe{Topic: "orders", ConsumerID: "c1", Credit: 1}.Encode())
+		if frame := receive(); frame.Op != OpOK || frame.CorrelationID != 10 {
+			t.Fatalf("expected subscribe to succeed, got op %x", frame.Op)
+		}
+
+		send(OpPublish, 11, PublishBatch{Topic: "orders", Messages: []PublishMessage{{ProducerID: "p1", Body: []byte("first")}, {ProducerID: "p1", Body: []byte("second")}}}.Encode())
+
+		var first *core.Message
+		for first == nil {
+			frame := receive()
+			if frame.Op == OpDeliver {
+				if frame.CorrelationID != 10 {
+					t.Fatalf("expected delivery to carry the subscribe correlation id, got %d", frame.CorrelationID)
+				}
+				first, _ = DecodeMessage(frame.Payload)
+			}
+		}
+		if string(first.Body) != "first" {
+			t.Fatalf("expected the first message, got %q", first.Body)
+		}
+
+		// out of credit: the ping answer must arrive before any further delivery
+		send(OpPing, 12, nil)
+		if frame := receive(); frame.Op != OpOK || frame.CorrelationID != 12 {
+			t.Fatalf("expected only the ping answer while out of credit, got op %x", frame.Op)
+		}
+
+		send(OpAck, 13, EncodeString(first.ID))
+		if frame := receive(); frame.Op != OpOK || frame.CorrelationID != 13 {
+			t.Fatalf("expected ack to succeed, got op %x", frame.Op)
+		}
+
+		send(OpCredit, 0, EncodeCredit(1))
+		frame := receive()
+		second, _ := DecodeMessage(frame.Payload)
+		if frame.Op != OpDeliver || string(second.Body) != "second" {
+			t.Fatalf("expected the second message after granting credit, got op %x", frame.Op)
+		}
+
+		send(OpNack, 14, Nack{MessageID: second.ID, Requeue: true}.Encode())
+		if frame := receive(); frame.Op != OpOK || frame.CorrelationID != 14 {
+			t.Fatalf("expected nack to succeed, got op %x", frame.Op)
+		}
+	})
+
+	t.Run("Errors answer the failing request", func(t *testing.T) {
+		send(OpAck, 20, EncodeString("unknown"))
+		if frame := receive(); frame.Op != OpError || frame.CorrelationID != 20 {
+			t.Fatalf("expected an error for an unknown ack, got op %x", frame.Op)
+		}
+
+		send(OpSubscribe, 21, Subscribe{Topic: "orders", ConsumerID: "c1"}.Encode())
+		if frame := receive(); frame.Op != OpError || frame.CorrelationID != 21 {
+			t.Fatalf("expected an error subscribing twice, got op %x", frame.Op)
+		}
+
+		send(0x7f, 22, nil)
+		frame := receive()
+		reason, _ := DecodeString(frame.Payload)
+		if frame.Op != OpError || frame.CorrelationID != 22 || reason != "unknown op code" {
+			t.Fatalf("expected an unknown op code error, got op %x %q", frame.Op, reason)
+		}
+	})
+}